@@ -0,0 +1,144 @@
+// Command import loads POIs into locations, or historical sites, from CSV,
+// GeoJSON or Ministry of Culture heritage JSON files. It prints what would
+// change and only writes with -apply; re-running a file is safe.
+//
+//	go run ./cmd/import -kind historical_site heritage.json
+//	go run ./cmd/import -kind location -type museum -map name=館名,address=地址 museums.csv
+//	go run ./cmd/import -kind historical_site -apply heritage.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"intelligent-spatial-platform/internal/coordinate"
+	"intelligent-spatial-platform/internal/geo"
+	"intelligent-spatial-platform/internal/importer"
+	"intelligent-spatial-platform/internal/tiles"
+)
+
+func main() {
+	kindName := flag.String("kind", "historical_site", "table to import into: location or historical_site")
+	formatName := flag.String("format", "", "csv, geojson or heritage (default: from the file extension)")
+	mapping := flag.String("map", "", "column mapping, e.g. name=場所名稱,latitude=緯度")
+	crsName := flag.String("crs", "TWD97", "CRS of x/y columns when there is no latitude/longitude")
+	defaultType := flag.String("type", "", "type for locations that have none")
+	radius := flag.Float64("radius", importer.DefaultMatchRadius, "meters within which records with the same name are one place")
+	apply := flag.Bool("apply", false, "write the changes; without it only the diff is printed")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: import [flags] FILE\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	path := flag.Arg(0)
+
+	kind, err := importer.ParseKind(*kindName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var format importer.Format
+	if *formatName != "" {
+		format, err = importer.ParseFormat(*formatName)
+	} else {
+		format, err = importer.DetectFormat(path)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	options := importer.Options{Type: *defaultType}
+	if options.Mapping, err = importer.ParseMapping(*mapping); err != nil {
+		log.Fatal(err)
+	}
+	if options.CRS, err = coordinate.ParseCRS(*crsName); err != nil {
+		log.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	records, err := importer.Read(file, format, options)
+	file.Close()
+	if err != nil {
+		log.Fatalf("%s: %v", path, err)
+	}
+
+	// Check coordinates against the same boundaries as the server
+	boundaries := geo.BundledBoundaries()
+	if path := os.Getenv("TAIWAN_BOUNDARIES_FILE"); path != "" {
+		if boundaries, err = geo.LoadBoundariesFile(path); err != nil {
+			log.Fatalf("Failed to load boundaries from %s: %v", path, err)
+		}
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := db.AutoMigrate(&geo.Location{}, &geo.HistoricalSite{}); err != nil {
+		log.Fatalf("Failed to migrate: %v", err)
+	}
+	if err := geo.MigratePointColumns(db, "locations", "historical_sites"); err != nil {
+		log.Fatalf("Failed to migrate: %v", err)
+	}
+
+	store := importer.NewStore(db)
+	existing, err := store.Existing(kind)
+	if err != nil {
+		log.Fatalf("Failed to load existing %s rows: %v", kind, err)
+	}
+	plan := importer.BuildPlan(kind, records, existing, boundaries, *radius)
+	if err := plan.WriteReport(os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+
+	switch {
+	case plan.Empty():
+		fmt.Println("Nothing to import.")
+	case !*apply:
+		fmt.Println("Dry run; pass -apply to write these changes.")
+	default:
+		if err := store.Apply(plan); err != nil {
+			log.Fatalf("Import failed, nothing was written: %v", err)
+		}
+		fmt.Printf("Imported %d new and %d updated rows.\n", len(plan.Creates), len(plan.Updates))
+
+		// The server's tile cache cannot see this write; drop the layer so it re-renders
+		if dir := os.Getenv("TILE_CACHE_DIR"); dir != "" {
+			if err := tiles.RemoveLayer(dir, kind.TileLayer()); err != nil {
+				log.Printf("Failed to clear cached %s tiles: %v", kind.TileLayer(), err)
+			}
+		}
+	}
+}
+
+// openDatabase connects with the same DB_* variables as the server, read from
+// the environment or a .env file
+func openDatabase() (*gorm.DB, error) {
+	if os.Getenv("DB_HOST") == "" {
+		godotenv.Load()
+	}
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Taipei",
+		os.Getenv("DB_HOST"),
+		os.Getenv("DB_USER"),
+		os.Getenv("DB_PASSWORD"),
+		os.Getenv("DB_NAME"),
+		os.Getenv("DB_PORT"),
+	)
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Warn)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %v", err)
+	}
+	return db, nil
+}
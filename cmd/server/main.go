@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
 	"log"
 	"net/http"
@@ -18,9 +19,14 @@ import (
 
 	"intelligent-spatial-platform/internal/api"
 	"intelligent-spatial-platform/internal/ai"
+	"intelligent-spatial-platform/internal/cluster"
 	"intelligent-spatial-platform/internal/game"
 	"intelligent-spatial-platform/internal/geo"
+	"intelligent-spatial-platform/internal/media"
 	"intelligent-spatial-platform/internal/middleware"
+	"intelligent-spatial-platform/internal/routing"
+	"intelligent-spatial-platform/internal/segment"
+	"intelligent-spatial-platform/internal/tiles"
 	"intelligent-spatial-platform/internal/voice"
 	"intelligent-spatial-platform/internal/websocket"
 )
@@ -168,13 +174,26 @@ func initDatabase() (*gorm.DB, error) {
 
 func runMigrations(db *gorm.DB) error {
 	// Auto-migrate models
-	return db.AutoMigrate(
+	err := db.AutoMigrate(
 		&game.Player{},
 		&game.Item{},
 		&game.GameSession{},
 		&geo.Location{},
 		&geo.HistoricalSite{},
+		&game.RestrictedArea{},
+		&game.MovementViolation{},
+		&geo.GazetteerPlace{},
+		&geo.GeocodeCacheEntry{},
+		&geo.Route{},
+		&geo.Waypoint{},
+		&game.TrackPoint{},
 	)
+	if err != nil {
+		return err
+	}
+
+	// Generated geography columns and GiST indexes for locations, historical sites and items
+	return geo.MigratePointColumns(db)
 }
 
 type Services struct {
@@ -184,25 +203,147 @@ type Services struct {
 	Geo       *geo.Service
 	Voice     *voice.Service
 	WebSocket *websocket.Hub
+	Resources geo.Resources
 }
 
 func initServices(db *gorm.DB) *Services {
-	// Initialize AI service (automatically detects provider from environment)
-	aiService := ai.NewService()
+	var resources geo.Resources
 
-	// Initialize game service
-	gameService := game.NewService(db, aiService)
-
-	// Initialize geo service
-	geoService := geo.NewService(db)
+	// Cache geocoding answers in Postgres for every service that resolves place names
+	cacheConfig, err := geo.GeocodeCacheConfigFromEnv()
+	if err != nil {
+		logrus.Fatalf("Invalid geocode cache configuration: %v", err)
+	}
+	resources.GeocodeCache = geo.NewGeocodeCache(db, cacheConfig)
 
-	// Initialize voice service
-	voiceService := voice.NewService()
+	// Keep Google Place Details in memory for follow-up questions; 0 turns the cache off
+	detailsTTL := geo.DefaultPlaceDetailsCacheTTL
+	if value := os.Getenv("PLACE_DETAILS_CACHE_TTL"); value != "" {
+		if detailsTTL, err = time.ParseDuration(value); err != nil || detailsTTL < 0 {
+			logrus.Fatalf("Invalid PLACE_DETAILS_CACHE_TTL %q: use a duration such as 1h", value)
+		}
+	}
+	if detailsTTL > 0 {
+		resources.PlaceDetailsCache = geo.NewPlaceDetailsCache(detailsTTL, geo.DefaultPlaceDetailsCacheSize)
+	}
 
 	// Initialize websocket hub
 	wsHub := websocket.NewHub()
 	go wsHub.Run()
 
+	// Replace the bundled simplified boundaries with official data when provided
+	if path := os.Getenv("TAIWAN_BOUNDARIES_FILE"); path != "" {
+		boundaries, err := geo.LoadBoundariesFile(path)
+		if err != nil {
+			logrus.Fatalf("Failed to load boundaries from %s: %v", path, err)
+		}
+		resources.Boundaries = boundaries
+	}
+
+	// Seed the gazetteer table and use it, including rows added since, for place names
+	resources.Gazetteer = geo.BundledGazetteer()
+	if err := geo.SeedGazetteer(db); err != nil {
+		logrus.Warnf("Failed to seed gazetteer, using bundled data: %v", err)
+	} else if gazetteer, err := geo.LoadGazetteer(db); err != nil {
+		logrus.Warnf("Failed to load gazetteer, using bundled data: %v", err)
+	} else {
+		resources.Gazetteer = gazetteer
+		logrus.Infof("Gazetteer loaded with %d places", gazetteer.Len())
+	}
+
+	// Extra words for place-name segmentation (shops and landmarks missing from the gazetteer)
+	var userDict *segment.Dictionary
+	if path := os.Getenv("SEGMENT_USER_DICT"); path != "" {
+		dict, err := loadUserDictionary(path)
+		if err != nil {
+			logrus.Fatalf("Failed to load segmenter dictionary from %s: %v", path, err)
+		}
+		userDict = dict
+		logrus.Infof("Segmenter user dictionary loaded with %d words", dict.Len())
+	}
+	resources.Segmenter = geo.NewPlaceSegmenter(resources.Gazetteer, userDict)
+
+	// Road network for /routes; without it routing answers 503
+	if path := os.Getenv("ROAD_NETWORK_FILE"); path != "" {
+		started := time.Now()
+		graph, err := routing.LoadFile(path)
+		if err != nil {
+			logrus.Fatalf("Failed to load road network from %s: %v", path, err)
+		}
+		resources.Roads = graph
+		stats := graph.Stats()
+		logrus.Infof("Road network loaded with %d vertices and %d segments in %s", stats.Vertices, stats.Segments, time.Since(started).Round(time.Millisecond))
+	}
+
+	// Rendered vector tiles are kept on disk when a cache directory is set
+	if dir := os.Getenv("TILE_CACHE_DIR"); dir != "" {
+		cache, err := tiles.NewCache(dir)
+		if err != nil {
+			logrus.Fatalf("Failed to open tile cache: %v", err)
+		}
+		resources.TileCache = cache
+	}
+
+	// Map points clustered per zoom for /clusters, kept current by writes in this process
+	clusters := cluster.NewIndex(db, cluster.DefaultRebuildDelay)
+	if err := clusters.Load(); err != nil {
+		logrus.Warnf("Failed to load map points, clustering is off: %v", err)
+	} else {
+		resources.Clusters = clusters
+		logrus.Infof("Clustering %d map points", clusters.Len())
+
+		// Rows written elsewhere, such as by cmd/import, show up on a full reload
+		if value := os.Getenv("CLUSTER_RELOAD_INTERVAL"); value != "" {
+			interval, err := time.ParseDuration(value)
+			if err != nil || interval <= 0 {
+				logrus.Fatalf("Invalid CLUSTER_RELOAD_INTERVAL %q: use a duration such as 10m", value)
+			}
+			go func() {
+				for range time.Tick(interval) {
+					if err := clusters.Load(); err != nil {
+						logrus.Warnf("Failed to reload map points: %v", err)
+					}
+				}
+			}()
+		}
+	}
+
+	// Uploaded historical site media; without a directory uploads answer 503
+	if dir := os.Getenv("MEDIA_DIR"); dir != "" {
+		storage, err := media.NewLocalStorage(dir)
+		if err != nil {
+			logrus.Fatalf("Failed to open media storage: %v", err)
+		}
+		secret := []byte(os.Getenv("MEDIA_SIGNING_KEY"))
+		if len(secret) == 0 {
+			secret = make([]byte, 32)
+			if _, err := rand.Read(secret); err != nil {
+				logrus.Fatalf("Failed to generate media signing key: %v", err)
+			}
+			logrus.Warn("MEDIA_SIGNING_KEY is not set; media URLs will stop working on restart")
+		}
+		ttl := media.DefaultURLTTL
+		if value := os.Getenv("MEDIA_URL_TTL"); value != "" {
+			if ttl, err = time.ParseDuration(value); err != nil || ttl <= 0 {
+				logrus.Fatalf("Invalid MEDIA_URL_TTL %q: use a duration such as 1h", value)
+			}
+		}
+		resources.Media = media.NewService(storage, media.NewSigner(secret, ttl))
+	}
+
+	// Initialize AI service (automatically detects provider from environment)
+	aiService := ai.NewService(resources)
+
+	// Initialize geo service
+	geoService := geo.NewService(db, resources)
+
+	// Initialize game service with animated travel published over websocket
+	gameService := game.NewService(db, aiService, resources)
+	gameService.EnableTravelSimulation(wsHub, geoService)
+
+	// Initialize voice service
+	voiceService := voice.NewService(resources)
+
 	return &Services{
 		DB:        db,
 		AI:        aiService,
@@ -210,7 +351,22 @@ func initServices(db *gorm.DB) *Services {
 		Geo:       geoService,
 		Voice:     voiceService,
 		WebSocket: wsHub,
+		Resources: resources,
+	}
+}
+
+func loadUserDictionary(path string) (*segment.Dictionary, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	dict := segment.NewDictionary()
+	if err := dict.LoadUserDictionary(file); err != nil {
+		return nil, err
 	}
+	return dict, nil
 }
 
 func setupRouter(services *Services) *gin.Engine {
@@ -248,18 +404,33 @@ func setupRouter(services *Services) *gin.Engine {
 	})
 
 	// Initialize API routes
-	apiHandler := api.NewHandler(services.DB, services.AI, services.Game, services.Geo, services.Voice)
+	apiHandler := api.NewHandler(services.DB, services.AI, services.Game, services.Geo, services.Voice, services.Resources)
 	apiGroup := router.Group("/api/v1")
 	{
 		// Basic routes (no rate limiting)
 		apiGroup.GET("/locations", apiHandler.GetLocations)
 		apiGroup.POST("/locations", apiHandler.CreateLocation)
 		apiGroup.GET("/historical-sites", apiHandler.GetHistoricalSites)
+		apiGroup.GET("/tiles/:layer/:z/:x/:y", apiHandler.GetTile) // y may end in .mvt
+		apiGroup.GET("/clusters", apiHandler.GetClusters)
+		apiGroup.GET("/media/*key", apiHandler.ServeMedia) // signed URLs only
+		apiGroup.POST("/geo/transform", apiHandler.TransformCoordinates)
+		apiGroup.GET("/geo/admin", apiHandler.LookupAdminArea)
+		apiGroup.GET("/geo/gazetteer", apiHandler.SearchGazetteer)
+		apiGroup.POST("/routes", apiHandler.CreateRoute)
+		apiGroup.POST("/routes/import", apiHandler.ImportRoute)
+		apiGroup.GET("/routes/:id", apiHandler.GetRoute)
+		apiGroup.GET("/routes/:id/export", apiHandler.ExportRoute)
+		apiGroup.GET("/isochrones", apiHandler.GetIsochrones)
 		apiGroup.GET("/game/status", apiHandler.GetGameStatus)
 		apiGroup.GET("/game/players", apiHandler.GetPlayers)
+		apiGroup.GET("/game/players/:id/track", apiHandler.ExportPlayerTrack)
+		apiGroup.POST("/game/players/:id/track", apiHandler.ImportPlayerTrack)
 		apiGroup.GET("/game/sessions", apiHandler.GetSessions)
 		apiGroup.POST("/game/sessions", apiHandler.CreateSession)
 		apiGroup.POST("/game/collect", apiHandler.CollectItem)
+		apiGroup.GET("/game/travel", apiHandler.GetTravelStatus)
+		apiGroup.POST("/game/travel/cancel", apiHandler.CancelTravel)
 
 		// Rate limited routes for AI and movement (uses geocoding)
 		aiGroup := apiGroup.Group("/")
@@ -269,7 +440,10 @@ func setupRouter(services *Services) *gin.Engine {
 			aiGroup.POST("/voice/command", apiHandler.ProcessVoiceCommand) // Unified voice command handler
 			aiGroup.POST("/ai/chat", apiHandler.ChatWithAI)
 			aiGroup.POST("/game/move", apiHandler.MovePlayer)
+			aiGroup.POST("/game/move/confirm", apiHandler.ConfirmPendingMove)
 			aiGroup.POST("/places/search", apiHandler.SearchPlace) // Google Places API endpoint
+			aiGroup.GET("/places/:placeId", apiHandler.GetPlaceDetails)
+			aiGroup.GET("/geo/reverse", apiHandler.ReverseGeocode)
 		}
 
 		// Strict rate limiting for debugging endpoints
@@ -278,6 +452,33 @@ func setupRouter(services *Services) *gin.Engine {
 		{
 			debugGroup.POST("/movement", apiHandler.DebugMovement)
 		}
+
+		// Admin endpoints (ADMIN_API_TOKEN)
+		adminGroup := apiGroup.Group("/admin")
+		adminGroup.Use(middleware.AdminAuth())
+		{
+			adminGroup.GET("/restricted-areas", apiHandler.ListRestrictedAreas)
+			adminGroup.POST("/restricted-areas", apiHandler.CreateRestrictedArea)
+			adminGroup.GET("/restricted-areas/:id", apiHandler.GetRestrictedArea)
+			adminGroup.PUT("/restricted-areas/:id", apiHandler.UpdateRestrictedArea)
+			adminGroup.DELETE("/restricted-areas/:id", apiHandler.DeleteRestrictedArea)
+			adminGroup.GET("/historical-sites", apiHandler.ListAdminHistoricalSites)
+			adminGroup.POST("/historical-sites", apiHandler.CreateHistoricalSite)
+			adminGroup.GET("/historical-sites/:id", apiHandler.GetAdminHistoricalSite)
+			adminGroup.PUT("/historical-sites/:id", apiHandler.UpdateHistoricalSite)
+			adminGroup.DELETE("/historical-sites/:id", apiHandler.DeleteHistoricalSite) // deactivates
+			adminGroup.POST("/historical-sites/:id/images", apiHandler.UploadHistoricalSiteImage)
+			adminGroup.DELETE("/historical-sites/:id/images/:index", apiHandler.DeleteHistoricalSiteImage)
+			adminGroup.PUT("/historical-sites/:id/audio", apiHandler.UploadHistoricalSiteAudio)
+			adminGroup.DELETE("/historical-sites/:id/audio", apiHandler.DeleteHistoricalSiteAudio)
+			adminGroup.GET("/players/flagged", apiHandler.ListFlaggedPlayers)
+			adminGroup.GET("/players/:id/violations", apiHandler.ListPlayerViolations)
+			adminGroup.POST("/players/:id/violations/reset", apiHandler.ResetPlayerViolations)
+			adminGroup.GET("/geocode-cache", apiHandler.ListGeocodeCache)
+			adminGroup.GET("/geocode-cache/stats", apiHandler.GetGeocodeCacheStats)
+			adminGroup.DELETE("/geocode-cache", apiHandler.PurgeGeocodeCache)
+			adminGroup.DELETE("/geocode-cache/:id", apiHandler.DeleteGeocodeCacheEntry)
+		}
 	}
 
 	// WebSocket endpoint
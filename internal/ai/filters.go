@@ -0,0 +1,63 @@
+package ai
+
+import (
+	"strings"
+
+	"intelligent-spatial-platform/internal/geo"
+)
+
+// Phrases that ask for a nearby-search filter, in traditional and simplified characters
+var (
+	openNowPhrases    = []string{"現在有開", "现在有开", "有開的", "有开的", "還有開", "还有开", "營業中", "营业中", "正在營業", "正在营业", "現在開著", "现在开着"}
+	highRatingPhrases = []string{"評價高", "评价高", "評價好", "评价好", "評價不錯", "评价不错", "評分高", "评分高", "高評價", "高评价", "好評", "好评", "口碑好", "高分"}
+	cheapPhrases      = []string{"便宜", "平價", "平价", "銅板", "铜板", "不貴", "不贵", "實惠", "实惠", "小資", "小资"}
+)
+
+// ParseNearbyFilters 從語音指令的字面找出搜尋篩選條件，例如「現在有開的」「評價高的」「便宜的」
+func ParseNearbyFilters(command string) geo.NearbyFilters {
+	var filters geo.NearbyFilters
+	if containsAny(command, openNowPhrases) {
+		filters.OpenNow = true
+	}
+	if containsAny(command, highRatingPhrases) {
+		filters.MinRating = geo.HighRating
+	}
+	if containsAny(command, cheapPhrases) {
+		filters.MaxPriceLevel = geo.CheapPriceLevel
+	}
+	return filters
+}
+
+// MergeNearbyFilters 合併兩組篩選條件，取較嚴格者；範圍外的數值視為未指定
+func MergeNearbyFilters(a, b geo.NearbyFilters) geo.NearbyFilters {
+	a, b = validNearbyFilters(a), validNearbyFilters(b)
+	merged := geo.NearbyFilters{
+		OpenNow:       a.OpenNow || b.OpenNow,
+		MinRating:     max(a.MinRating, b.MinRating),
+		MaxPriceLevel: max(a.MaxPriceLevel, b.MaxPriceLevel),
+	}
+	if a.MaxPriceLevel > 0 && b.MaxPriceLevel > 0 {
+		merged.MaxPriceLevel = min(a.MaxPriceLevel, b.MaxPriceLevel)
+	}
+	return merged
+}
+
+// validNearbyFilters 清除 AI 回傳的不合理數值
+func validNearbyFilters(f geo.NearbyFilters) geo.NearbyFilters {
+	if f.MinRating < 0 || f.MinRating > 5 {
+		f.MinRating = 0
+	}
+	if f.MaxPriceLevel < 0 || f.MaxPriceLevel > 4 {
+		f.MaxPriceLevel = 0
+	}
+	return f
+}
+
+func containsAny(text string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if strings.Contains(text, phrase) {
+			return true
+		}
+	}
+	return false
+}
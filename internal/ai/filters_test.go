@@ -0,0 +1,59 @@
+package ai
+
+import (
+	"testing"
+
+	"intelligent-spatial-platform/internal/geo"
+)
+
+func TestParseNearbyFilters(t *testing.T) {
+	tests := []struct {
+		command string
+		want    geo.NearbyFilters
+	}{
+		{"附近有什麼餐廳", geo.NearbyFilters{}},
+		{"附近現在有開的咖啡廳", geo.NearbyFilters{OpenNow: true}},
+		{"附近评价高的餐厅", geo.NearbyFilters{MinRating: geo.HighRating}},
+		{"找便宜的小吃", geo.NearbyFilters{MaxPriceLevel: geo.CheapPriceLevel}},
+		{"有沒有還有開又平價的好評拉麵", geo.NearbyFilters{OpenNow: true, MinRating: geo.HighRating, MaxPriceLevel: geo.CheapPriceLevel}},
+	}
+	for _, tt := range tests {
+		if got := ParseNearbyFilters(tt.command); got != tt.want {
+			t.Errorf("ParseNearbyFilters(%q) = %+v, want %+v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestMergeNearbyFilters(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b geo.NearbyFilters
+		want geo.NearbyFilters
+	}{
+		{"both empty", geo.NearbyFilters{}, geo.NearbyFilters{}, geo.NearbyFilters{}},
+		{"open now from either", geo.NearbyFilters{}, geo.NearbyFilters{OpenNow: true}, geo.NearbyFilters{OpenNow: true}},
+		{"higher rating wins", geo.NearbyFilters{MinRating: 4.5}, geo.NearbyFilters{MinRating: 4}, geo.NearbyFilters{MinRating: 4.5}},
+		{"lower price level wins", geo.NearbyFilters{MaxPriceLevel: 2}, geo.NearbyFilters{MaxPriceLevel: 1}, geo.NearbyFilters{MaxPriceLevel: 1}},
+		{"a price limit beats none", geo.NearbyFilters{}, geo.NearbyFilters{MaxPriceLevel: 3}, geo.NearbyFilters{MaxPriceLevel: 3}},
+		{"rating above 5 is ignored", geo.NearbyFilters{MinRating: 45}, geo.NearbyFilters{MinRating: 4}, geo.NearbyFilters{MinRating: 4}},
+		{"negative rating is ignored", geo.NearbyFilters{MinRating: -1}, geo.NearbyFilters{}, geo.NearbyFilters{}},
+		{"price level above 4 is ignored", geo.NearbyFilters{MaxPriceLevel: 9}, geo.NearbyFilters{MaxPriceLevel: 2}, geo.NearbyFilters{MaxPriceLevel: 2}},
+		{"negative price level is ignored", geo.NearbyFilters{MaxPriceLevel: -1}, geo.NearbyFilters{}, geo.NearbyFilters{}},
+		{
+			"each field merged on its own",
+			geo.NearbyFilters{OpenNow: true, MaxPriceLevel: 2},
+			geo.NearbyFilters{MinRating: geo.HighRating, MaxPriceLevel: 4},
+			geo.NearbyFilters{OpenNow: true, MinRating: geo.HighRating, MaxPriceLevel: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MergeNearbyFilters(tt.a, tt.b); got != tt.want {
+				t.Errorf("MergeNearbyFilters(%+v, %+v) = %+v, want %+v", tt.a, tt.b, got, tt.want)
+			}
+			if got := MergeNearbyFilters(tt.b, tt.a); got != tt.want {
+				t.Errorf("MergeNearbyFilters(%+v, %+v) = %+v, want %+v", tt.b, tt.a, got, tt.want)
+			}
+		})
+	}
+}
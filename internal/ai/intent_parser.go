@@ -12,10 +12,11 @@ import (
 type IntentType string
 
 const (
-	IntentSearch   IntentType = "search"    // 搜尋附近的地點
-	IntentMove     IntentType = "move"      // 移動到某地
-	IntentDescribe IntentType = "describe"  // 描述/介紹
+	IntentSearch    IntentType = "search"    // 搜尋附近的地點
+	IntentMove      IntentType = "move"      // 移動到某地
+	IntentDescribe  IntentType = "describe"  // 描述/介紹
 	IntentRecommend IntentType = "recommend" // 推薦
+	IntentDetails   IntentType = "details"   // 詢問剛才列出地點的細節
 )
 
 // CategoryType 地點類別
@@ -33,12 +34,17 @@ const (
 
 // VoiceIntent 語音意圖解析結果
 type VoiceIntent struct {
-	Type       IntentType   `json:"type"`
-	Category   CategoryType `json:"category"`
-	Keywords   []string     `json:"keywords"`
-	Radius     float64      `json:"radius"`      // 搜尋半徑（米）
-	TargetName string       `json:"targetName"`  // 移動目標名稱
-	Confidence float64      `json:"confidence"`  // 信心度 0-1
+	Type          IntentType        `json:"type"`
+	Category      CategoryType      `json:"category"`
+	Keywords      []string          `json:"keywords"`
+	Radius        float64           `json:"radius"`        // 搜尋半徑（米）
+	TargetName    string            `json:"targetName"`    // 移動目標名稱
+	Confidence    float64           `json:"confidence"`    // 信心度 0-1
+	Filters       geo.NearbyFilters `json:"filters"`       // 搜尋篩選：營業中、評價、價位
+	Detail        DetailField       `json:"detail"`        // details 意圖想知道的項目
+	Reference     string            `json:"reference"`     // details 意圖指的地點，例如「第二家」「鼎泰豐」
+	TravelMode    string            `json:"travelMode"`    // 時間範圍的交通方式：foot、bike、car
+	TravelMinutes int               `json:"travelMinutes"` // 時間範圍（分鐘），例如「走路 15 分鐘內」，0 為依半徑搜尋
 }
 
 // IntentParser 意圖解析器
@@ -89,6 +95,10 @@ func (p *IntentParser) ParseVoiceCommandWithUser(userID, command string, current
 - "recommend": 請求推薦
   關鍵詞：推薦、建議
 
+- "details": 詢問剛才列出的某個地點的細節（營業時間、電話、網站、地址、評價）
+  範例：「這家幾點關門」「第二家電話多少」「鼎泰豐評價怎麼樣」
+  → detail 填 hours|phone|website|address|reviews|general，reference 填用戶指的地點（「這家」「第二家」或店名）
+
 地點類別：
 - "restaurant": 餐廳、美食、吃的、飯店（用餐）
 - "cafe": 咖啡廳、飲料店、茶飲
@@ -98,14 +108,29 @@ func (p *IntentParser) ParseVoiceCommandWithUser(userID, command string, current
 - "museum": 博物館、展覽館
 - "general": 一般（沒有特定類別）
 
+搜尋篩選（僅 search、recommend 意圖，沒提到就省略）：
+- "openNow": true — 現在有開的、營業中
+- "minRating": 4.0 — 評價高的、評價好的、高分的
+- "maxPriceLevel": 1 — 便宜的、平價的（1 便宜至 4 昂貴）
+
+時間範圍（僅 search、recommend 意圖，說了「X 分鐘內」「X 分鐘可以到」才填，沒提到就省略）：
+- "travelMinutes": 15 — 分鐘數（1-60）
+- "travelMode": "foot|bike|car" — 走路、騎車、開車，沒說就填 foot
+- 「X 分鐘內可以到哪裡」是 search，category 為 general
+
 回傳格式：
 {
-  "type": "search|move|describe|recommend",
+  "type": "search|move|describe|recommend|details",
   "category": "restaurant|cafe|attraction|hotel|park|museum|general",
   "keywords": ["關鍵詞1", "關鍵詞2"],
   "radius": 500,
   "targetName": "目標地點名稱（僅 move 意圖需要）",
-  "confidence": 0.0-1.0
+  "confidence": 0.0-1.0,
+  "filters": {"openNow": true, "minRating": 4.0, "maxPriceLevel": 1},
+  "detail": "hours|phone|website|address|reviews|general（僅 details 意圖）",
+  "reference": "指的地點（僅 details 意圖）",
+  "travelMode": "foot|bike|car（僅有時間範圍時）",
+  "travelMinutes": 15
 }
 
 範例：
@@ -136,6 +161,25 @@ func (p *IntentParser) ParseVoiceCommandWithUser(userID, command string, current
 輸入："哪裡有咖啡廳"
 輸出：{"type":"search","category":"cafe","keywords":["咖啡廳"],"radius":500,"targetName":"","confidence":0.90}
 
+輸入："附近有什麼現在有開又便宜的餐廳"
+輸出：{"type":"search","category":"restaurant","keywords":["餐廳"],"radius":500,"targetName":"","confidence":0.93,"filters":{"openNow":true,"maxPriceLevel":1}}
+
+輸入："推薦評價高的咖啡廳"
+輸出：{"type":"recommend","category":"cafe","keywords":["咖啡廳"],"radius":1000,"targetName":"","confidence":0.92,"filters":{"minRating":4.0}}
+
+輸入："走路十五分鐘內可以到哪裡"
+輸出：{"type":"search","category":"general","keywords":[],"radius":0,"targetName":"","confidence":0.93,"travelMode":"foot","travelMinutes":15}
+
+輸入："推薦騎車 20 分鐘可以到的景點"
+輸出：{"type":"recommend","category":"attraction","keywords":["景點"],"radius":0,"targetName":"","confidence":0.92,"travelMode":"bike","travelMinutes":20}
+
+【地點細節範例 - 接在搜尋結果之後】
+輸入："這家幾點關門"
+輸出：{"type":"details","category":"general","keywords":[],"radius":0,"targetName":"","confidence":0.93,"detail":"hours","reference":"這家"}
+
+輸入："第二家電話多少"
+輸出：{"type":"details","category":"general","keywords":[],"radius":0,"targetName":"","confidence":0.94,"detail":"phone","reference":"第二家"}
+
 請只回傳 JSON，不要有其他說明文字。`,
 		command,
 		currentLocation.Latitude,
@@ -161,6 +205,23 @@ func (p *IntentParser) ParseVoiceCommandWithUser(userID, command string, current
 		return nil, fmt.Errorf("解析 JSON 失敗: %v, 原始回應: %s", err, response)
 	}
 
+	// AI 可能漏掉篩選詞，以字面比對補上
+	intent.Filters = MergeNearbyFilters(intent.Filters, ParseNearbyFilters(command))
+	if mode, minutes, ok := ParseTravelBudget(command); ok && intent.TravelMinutes == 0 {
+		intent.TravelMode, intent.TravelMinutes = mode, minutes
+	}
+	if intent.TravelMinutes < 0 || intent.TravelMinutes > geo.MaxIsochroneMinutes {
+		intent.TravelMinutes = 0
+	}
+	if intent.Type == IntentDetails {
+		if intent.Detail == "" {
+			intent.Detail = ParseDetailField(command)
+		}
+		if intent.Reference == "" {
+			intent.Reference = command
+		}
+	}
+
 	// 信心度檢查
 	if intent.Confidence < 0.7 {
 		return nil, fmt.Errorf("信心度過低 (%.2f)", intent.Confidence)
@@ -1,21 +1,27 @@
 package ai
 
 import (
+	"errors"
 	"fmt"
 	"math"
-	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	"intelligent-spatial-platform/internal/coordinate"
 	"intelligent-spatial-platform/internal/geo"
+	"intelligent-spatial-platform/internal/maplink"
+	"intelligent-spatial-platform/internal/segment"
 )
 
 type MovementCommandParser struct {
 	aiService        *Service
 	geocodingService *geo.GeocodingService
-	bounds           *geo.Bounds
+	linkParser       *maplink.Parser
+	boundaries       *geo.Boundaries
+	gazetteer        *geo.Gazetteer
+	segmenter        *segment.Segmenter
 }
 
 type MovementCommand struct {
@@ -25,6 +31,8 @@ type MovementCommand struct {
 	Direction      string                 `json:"direction"`      // north, south, east, west, northeast, etc
 	Distance       float64                `json:"distance"`       // in meters
 	Speed          string                 `json:"speed"`          // slow, normal, fast
+	Mode           TransportMode          `json:"mode"`           // walk, run, bike, scooter, car, bus, mrt, tra, thsr
+	ModeInferred   bool                   `json:"modeInferred"`   // mode chosen by distance, not stated by the user
 	Parameters     map[string]interface{} `json:"parameters"`     // additional parameters
 	OriginalText   string                 `json:"originalText"`   // user input
 	Confidence     float64                `json:"confidence"`     // parsing confidence 0-1
@@ -33,19 +41,15 @@ type MovementCommand struct {
 	RequiresAI     bool                   `json:"requiresAI"`     // needs AI interpretation
 }
 
-// Taiwan boundary for safety checks (擴大範圍以包含墾丁等地點)
-var taiwanBounds = &geo.Bounds{
-	North: 25.5,  // 擴大北邊界
-	South: 21.8,  // 擴大南邊界以包含墾丁 (21.9518)
-	East:  122.2, // 擴大東邊界
-	West:  119.8, // 擴大西邊界
-}
-
-func NewMovementCommandParser(aiService *Service, geocodingService *geo.GeocodingService) *MovementCommandParser {
+func NewMovementCommandParser(aiService *Service, geocodingService *geo.GeocodingService, resources geo.Resources) *MovementCommandParser {
+	resources = resources.WithDefaults()
 	return &MovementCommandParser{
 		aiService:        aiService,
 		geocodingService: geocodingService,
-		bounds:          taiwanBounds,
+		linkParser:       maplink.NewParser(maplink.NewHTTPResolver()),
+		boundaries:       resources.Boundaries,
+		gazetteer:        resources.Gazetteer,
+		segmenter:        resources.Segmenter,
 	}
 }
 
@@ -55,56 +59,83 @@ func (p *MovementCommandParser) ParseMovementCommand(text string, currentLocatio
 	command := &MovementCommand{
 		OriginalText:  text,
 		Parameters:    make(map[string]interface{}),
-		Speed:         "normal",
+		Speed:         detectSpeed(text),
 		SafetyChecked: false,
 		RequiresAI:    false,
 	}
 
+	if mode, ok := DetectTransportMode(text); ok {
+		command.Mode = mode
+	}
+
 	// Detect if this is a movement command
 	if !p.isMovementCommand(text) {
 		return nil, fmt.Errorf("not a movement command")
 	}
 
-	// Parse direct coordinates (highest confidence)
-	if coords := p.parseDirectCoordinates(text); coords != nil {
-		// Check if this is a special marker for place name resolution
-		if coords.Latitude == 999.0 && coords.Longitude == 999.0 {
-			// Extract place name from Google Maps URL and use geocoding to resolve coordinates
-			placeName := p.extractPlaceNameFromURL(text)
-			if placeName != "" {
-				command.RequiresAI = false
-				geoLocation, err := p.resolveLocationWithGeocoding(placeName)
-				if err != nil {
-					return nil, fmt.Errorf("failed to resolve Google Maps place URL location with geocoding: %v", err)
-				}
+	// Parse shared map links (Google Maps, Apple Maps, OpenStreetMap)
+	link, err := p.linkParser.Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse map link: %v", err)
+	}
+	if link != nil {
+		command.Type = "move"
+		command.Action = "absolute_move"
+		command.Parameters["provider"] = string(link.Provider)
+		command.Parameters["linkKind"] = string(link.Kind)
+		command.Parameters["originalURL"] = link.URL
+		if link.ShortURL != "" {
+			command.Parameters["shortURL"] = link.ShortURL
+		}
+		if link.Query != "" {
+			command.Parameters["placeName"] = link.Query
+		}
 
-				command.Type = "move"
-				command.Action = "absolute_move"
-				command.Destination = geoLocation
-				command.Parameters = map[string]interface{}{
-					"placeName": placeName,
-					"originalURL": text,
-				}
-				command.Confidence = 0.8 // Slightly lower confidence as it needs AI resolution
-				return p.validateAndEnrichCommand(command, currentLocation)
+		if link.HasCoordinates {
+			command.Destination = &geo.Location{
+				Name:      link.Query,
+				Latitude:  link.Latitude,
+				Longitude: link.Longitude,
 			}
-		} else {
-			// Regular coordinates
-			command.Type = "move"
-			command.Action = "absolute_move"
-			command.Destination = coords
 			command.Confidence = 0.9
 			return p.validateAndEnrichCommand(command, currentLocation)
 		}
+
+		// Only a place name is known; resolve it with geocoding
+		geoLocation, err := p.resolveLocationWithGeocoding(link.Query)
+		if isAmbiguous(err) {
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s map link location with geocoding: %v", link.Provider, err)
+		}
+		command.Destination = geoLocation
+		command.Confidence = 0.8 // Slightly lower confidence as it needs geocoding
+		return p.validateAndEnrichCommand(command, currentLocation)
+	}
+
+	// Parse direct coordinates (highest confidence)
+	if coords, format := p.parseDirectCoordinates(text); coords != nil {
+		command.Type = "move"
+		command.Action = "absolute_move"
+		command.Destination = coords
+		command.Confidence = 0.9
+		if format != "" {
+			command.Parameters["coordinateFormat"] = string(format)
+		}
+		return p.validateAndEnrichCommand(command, currentLocation)
 	}
 
 	// Parse named locations using geocoding (HIGHEST PRIORITY)
 	if p.containsLocationName(text) {
 		// Extract the actual location name from the movement command
-		locationName := p.extractLocationFromCommand(text)
+		locationName, analysis := p.extractLocationFromCommand(text)
 		if locationName != "" {
 			command.RequiresAI = false
 			geoLocation, err := p.resolveLocationWithGeocoding(locationName)
+			if isAmbiguous(err) {
+				return nil, err
+			}
 			if err != nil {
 				return nil, fmt.Errorf("failed to resolve location with geocoding: %v", err)
 			}
@@ -113,6 +144,7 @@ func (p *MovementCommandParser) ParseMovementCommand(text string, currentLocatio
 			command.Action = "absolute_move"
 			command.Destination = geoLocation
 			command.Confidence = 0.9 // Highest confidence for named locations
+			addSegmentParameters(command, analysis)
 			return p.validateAndEnrichCommand(command, currentLocation)
 		}
 	}
@@ -157,6 +189,12 @@ func (p *MovementCommandParser) isMovementCommand(text string) bool {
 
 		// Location indicators
 		"位置", "地點", "coordinates", "座標", "經緯度", "latitude", "longitude",
+		"geo:", "twd97", "twd67", "北緯", "東經",
+	}
+
+	// A shared map link is a request to go there
+	if maplink.ContainsLink(text) {
+		return true
 	}
 
 	lowerText := strings.ToLower(text)
@@ -168,79 +206,17 @@ func (p *MovementCommandParser) isMovementCommand(text string) bool {
 	return false
 }
 
-func (p *MovementCommandParser) parseDirectCoordinates(text string) *geo.Location {
-	// First try to extract from Google Maps URLs
-	if strings.Contains(text, "maps") && (strings.Contains(text, "google") || strings.Contains(text, "goo.gl")) {
-		if coords := p.parseGoogleMapsURL(text); coords != nil {
-			return coords
-		}
-	}
-
-	// Regex patterns for coordinates
-	patterns := []string{
-		`(\d+\.?\d*)\s*,\s*(\d+\.?\d*)`,           // 25.0330, 121.5654
-		`緯度\s*[：:]\s*(\d+\.?\d*)\s*經度\s*[：:]\s*(\d+\.?\d*)`, // 緯度: 25.0330 經度: 121.5654
-		`lat\s*[：:]\s*(\d+\.?\d*)\s*lng\s*[：:]\s*(\d+\.?\d*)`,   // lat: 25.0330 lng: 121.5654
-	}
-
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		matches := re.FindStringSubmatch(text)
-		if len(matches) >= 3 {
-			lat, err1 := strconv.ParseFloat(matches[1], 64)
-			lng, err2 := strconv.ParseFloat(matches[2], 64)
-			if err1 == nil && err2 == nil {
-				return &geo.Location{
-					Latitude:  lat,
-					Longitude: lng,
-				}
-			}
-		}
-	}
-	return nil
-}
-
-func (p *MovementCommandParser) parseGoogleMapsURL(text string) *geo.Location {
-	// Parse Google Maps URLs like: https://www.google.com/maps/@23.0162277,120.2353557,15z
-	patterns := []string{
-		`@(-?\d+\.?\d*),(-?\d+\.?\d*),\d+z`,                    // @lat,lng,zoom
-		`@(-?\d+\.?\d*),(-?\d+\.?\d*)`,                         // @lat,lng
-		`ll=(-?\d+\.?\d*),(-?\d+\.?\d*)`,                       // ll=lat,lng
-		`center=(-?\d+\.?\d*),(-?\d+\.?\d*)`,                   // center=lat,lng
-		`q=(-?\d+\.?\d*),(-?\d+\.?\d*)`,                        // q=lat,lng
-		`place/[^/]*/@(-?\d+\.?\d*),(-?\d+\.?\d*)`,             // place/name/@lat,lng
-	}
-
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		matches := re.FindStringSubmatch(text)
-		if len(matches) >= 3 {
-			lat, err1 := strconv.ParseFloat(matches[1], 64)
-			lng, err2 := strconv.ParseFloat(matches[2], 64)
-			if err1 == nil && err2 == nil {
-				// Validate coordinates are within reasonable bounds
-				if lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180 {
-					return &geo.Location{
-						Latitude:  lat,
-						Longitude: lng,
-					}
-				}
-			}
-		}
-	}
-
-	// Check if it's a Google Maps place URL like: https://www.google.com/maps/place/{location_name}
-	placeName := p.extractPlaceNameFromURL(text)
-	if placeName != "" {
-		// Return a special marker indicating this is a place name that needs AI resolution
-		// This will be handled by the calling function
-		return &geo.Location{
-			Latitude:  999.0, // Special marker for place name
-			Longitude: 999.0,
-		}
+func (p *MovementCommandParser) parseDirectCoordinates(text string) (*geo.Location, coordinate.Format) {
+	// Decimal (signed), DMS, geo: URI and TWD97/TWD67 grid coordinates
+	result, err := coordinate.Parse(text)
+	if err != nil {
+		return nil, ""
 	}
 
-	return nil
+	return &geo.Location{
+		Latitude:  result.Latitude,
+		Longitude: result.Longitude,
+	}, result.Format
 }
 
 func (p *MovementCommandParser) parseDirectionDistance(text string) (string, float64) {
@@ -307,15 +283,12 @@ func (p *MovementCommandParser) parseRelativeMovement(text string) *geo.Location
 }
 
 func (p *MovementCommandParser) containsLocationName(text string) bool {
-	locationIndicators := []string{
-		// 主要城市
-		"台北", "高雄", "台中", "台南", "桃園", "新竹", "基隆", "嘉義", "彰化", "南投", "雲林", "屏東", "宜蘭", "花蓮", "台東", "澎湖", "金門", "馬祖",
-
-		// 著名景點
-		"中正紀念堂", "台北101", "故宮", "夜市", "火車站", "機場", "總統府", "自由廣場", "龍山寺", "西門町", "九份", "淡水", "北投", "陽明山",
-		"日月潭", "阿里山", "太魯閣", "墾丁", "清境", "合歡山", "玉山", "溪頭", "杉林溪", "集集", "鹿港", "安平", "赤崁樓", "愛河", "旗津",
-		"佛光山", "義大世界", "六合夜市", "逢甲夜市", "一中商圈", "東海大學", "中興大學", "成功大學", "中山大學", "高雄大學",
+	// Cities, landmarks and their romanizations come from the gazetteer
+	if len(p.gazetteer.FindInText(text)) > 0 {
+		return true
+	}
 
+	locationIndicators := []string{
 		// 地標建築
 		"公園", "學校", "醫院", "銀行", "便利商店", "餐廳", "百貨公司", "購物中心", "圖書館", "體育館", "游泳池", "電影院", "咖啡廳",
 		"郵局", "警察局", "消防隊", "市政府", "區公所", "教堂", "廟宇", "博物館", "美術館", "動物園", "植物園", "海洋館",
@@ -329,11 +302,6 @@ func (p *MovementCommandParser) containsLocationName(text string) bool {
 		// 商業區域
 		"商圈", "老街", "市場", "傳統市場", "夜市", "商店街", "購物街", "美食街", "小吃街",
 
-		// 英文地名
-		"taipei", "kaohsiung", "taichung", "tainan", "taoyuan", "hsinchu", "keelung", "chiayi", "changhua", "nantou",
-		"yunlin", "pingtung", "yilan", "hualien", "taitung", "penghu", "kinmen", "matsu",
-		"sun moon lake", "alishan", "taroko", "kenting", "yangmingshan", "jiufen", "tamsui", "beitou",
-
 		// 大學
 		"大學", "學院", "科技大學", "技術學院", "師範大學", "醫學院", "university", "college",
 
@@ -364,31 +332,41 @@ func (p *MovementCommandParser) containsLocationName(text string) bool {
 	return false
 }
 
-func (p *MovementCommandParser) extractLocationFromCommand(text string) string {
-	lowerText := strings.ToLower(text)
+// extractLocationFromCommand returns the first place span found by the segmenter,
+// e.g. 鼎泰豐 in 「我想去那個很有名的鼎泰豐」 and 嘉義市 in 「去嘉義市吃雞肉飯後去阿里山」
+func (p *MovementCommandParser) extractLocationFromCommand(text string) (string, *segment.Analysis) {
+	analysis := p.segmenter.Analyze(text)
+	if len(analysis.Places) > 0 {
+		return analysis.Places[0].Text, analysis
+	}
 
-	// Location keywords priority list (cities and landmarks)
-	locationKeywords := []string{
-		// 主要城市（優先匹配，避免被後面的內容污染）
-		"台北市", "新北市", "桃園市", "台中市", "台南市", "高雄市", "基隆市", "新竹市", "嘉義市", "彰化縣",
-		"台北", "新北", "桃園", "台中", "台南", "高雄", "基隆", "新竹", "嘉義", "彰化",
-		"南投", "雲林", "屏東", "宜蘭", "花蓮", "台東", "澎湖", "金門", "馬祖",
+	return extractLocationPhrase(strings.ToLower(text)), analysis
+}
 
-		// 著名景點
-		"台北101", "中正紀念堂", "故宮", "總統府", "自由廣場", "龍山寺", "西門町", "九份", "淡水", "北投", "陽明山",
-		"日月潭", "阿里山", "太魯閣", "墾丁", "清境", "合歡山", "玉山", "溪頭", "杉林溪", "集集", "鹿港", "安平", "赤崁樓", "愛河", "旗津",
-		"佛光山", "義大世界", "六合夜市", "逢甲夜市", "一中商圈", "東海大學", "中興大學", "成功大學", "中山大學", "高雄大學",
+// addSegmentParameters records the place spans and activities of the command
+func addSegmentParameters(command *MovementCommand, analysis *segment.Analysis) {
+	if analysis == nil || len(analysis.Places) == 0 {
+		return
 	}
-
-	// First, try to find known location keywords in the text
-	// This prevents "去嘉義市吃火雞肉飯" from becoming "嘉義市吃火雞肉飯"
-	for _, keyword := range locationKeywords {
-		if strings.Contains(lowerText, strings.ToLower(keyword)) {
-			return keyword
+	command.Parameters["placeSpan"] = analysis.Places[0]
+	if len(analysis.Places) > 1 {
+		next := make([]string, 0, len(analysis.Places)-1)
+		for _, place := range analysis.Places[1:] {
+			next = append(next, place.Text)
 		}
+		command.Parameters["nextDestinations"] = next
 	}
+	if len(analysis.Actions) > 0 {
+		actions := make([]string, 0, len(analysis.Actions))
+		for _, action := range analysis.Actions {
+			actions = append(actions, action.Text)
+		}
+		command.Parameters["activities"] = actions
+	}
+}
 
-	// If no known location found, try regex patterns
+// extractLocationPhrase takes the words after a movement verb
+func extractLocationPhrase(lowerText string) string {
 	patterns := []struct {
 		regex string
 		group int // which capture group contains the location name
@@ -418,18 +396,51 @@ func (p *MovementCommandParser) extractLocationFromCommand(text string) string {
 	return ""
 }
 
+// resolveLocationWithGeocoding returns the best match, or a *geo.AmbiguousLocationError
+// when several places fit about equally well
 func (p *MovementCommandParser) resolveLocationWithGeocoding(locationName string) (*geo.Location, error) {
 	if p.geocodingService == nil {
 		return nil, fmt.Errorf("geocoding service not available")
 	}
 
-	// Use Nominatim (OpenStreetMap) to resolve location
-	location, err := p.geocodingService.GeocodeLocation(locationName)
+	candidates, err := p.geocodingService.GeocodeCandidates(locationName)
 	if err != nil {
 		return nil, fmt.Errorf("geocoding failed: %v", err)
 	}
 
-	return location, nil
+	if geo.IsAmbiguous(candidates) {
+		return nil, &geo.AmbiguousLocationError{
+			Query:      locationName,
+			Candidates: geo.AmbiguousCandidates(candidates),
+		}
+	}
+
+	location := candidates[0].Location
+	return &location, nil
+}
+
+func isAmbiguous(err error) bool {
+	var ambiguous *geo.AmbiguousLocationError
+	return errors.As(err, &ambiguous)
+}
+
+// CommandForDestination builds a go-to command for a destination the user picked
+// from a clarification list; text is the original command, used for the transport mode
+func (p *MovementCommandParser) CommandForDestination(text string, destination *geo.Location, currentLocation *geo.Location) (*MovementCommand, error) {
+	command := &MovementCommand{
+		Type:          "go_to",
+		Action:        "absolute_move",
+		Destination:   destination,
+		OriginalText:  text,
+		Parameters:    map[string]interface{}{"clarified": true},
+		Speed:         detectSpeed(text),
+		Confidence:    0.9,
+		SafetyChecked: false,
+	}
+	if mode, ok := DetectTransportMode(text); ok {
+		command.Mode = mode
+	}
+	return p.validateAndEnrichCommand(command, currentLocation)
 }
 
 func (p *MovementCommandParser) validateAndEnrichCommand(command *MovementCommand, currentLocation *geo.Location) (*MovementCommand, error) {
@@ -444,9 +455,9 @@ func (p *MovementCommandParser) validateAndEnrichCommand(command *MovementComman
 		return nil, fmt.Errorf("no valid destination")
 	}
 
-	// Check Taiwan boundaries
-	if !p.isWithinTaiwanBounds(command.Destination) {
-		return nil, fmt.Errorf("destination outside Taiwan boundaries")
+	// Destinations must be on land; points just offshore are pulled to the nearest coast
+	if err := p.checkLandDestination(command); err != nil {
+		return nil, err
 	}
 
 	// Calculate distance and time
@@ -459,10 +470,24 @@ func (p *MovementCommandParser) validateAndEnrichCommand(command *MovementComman
 			return nil, fmt.Errorf("movement distance too large: %.2f meters (max: %.0f meters)", distance, maxDistance)
 		}
 
-		// Estimate travel time based on speed
-		speed := p.getSpeedInMPS(command.Speed)
-		command.EstimatedTime = int(distance / speed)
+		// Pick a mode by distance when the user didn't name one
+		if command.Mode == "" {
+			command.Mode = InferTransportMode(distance)
+			command.ModeInferred = true
+		}
+
+		// Each mode has its own reach
+		profile := GetTransportProfile(command.Mode)
+		if err := profile.ValidateDistance(distance); err != nil {
+			return nil, err
+		}
+
+		// Estimate travel time based on the mode's speed profile
+		command.EstimatedTime = profile.EstimateTravelTime(distance, command.Speed)
 		command.Parameters["distance"] = distance
+	} else if command.Mode == "" {
+		command.Mode = ModeWalk
+		command.ModeInferred = true
 	}
 
 	command.SafetyChecked = true
@@ -511,11 +536,33 @@ func (p *MovementCommandParser) calculateDirectionDestination(current *geo.Locat
 	}
 }
 
-func (p *MovementCommandParser) isWithinTaiwanBounds(location *geo.Location) bool {
-	return location.Latitude >= p.bounds.South &&
-		   location.Latitude <= p.bounds.North &&
-		   location.Longitude >= p.bounds.West &&
-		   location.Longitude <= p.bounds.East
+func (p *MovementCommandParser) checkLandDestination(command *MovementCommand) error {
+	dest := command.Destination
+
+	if !p.boundaries.IsOnLand(dest.Latitude, dest.Longitude) {
+		lat, lng, offshore, ok := p.boundaries.SnapToLand(dest.Latitude, dest.Longitude, geo.DefaultLandSnapDistance)
+		if !ok {
+			if p.boundaries.InTerritory(dest.Latitude, dest.Longitude) {
+				return fmt.Errorf("destination is in the sea (%.1f km from the nearest coast)", offshore/1000)
+			}
+			return fmt.Errorf("destination outside Taiwan boundaries")
+		}
+
+		snapped := *dest
+		snapped.Latitude, snapped.Longitude = lat, lng
+		command.Destination = &snapped
+		command.Parameters["snappedToLand"] = true
+		command.Parameters["snapDistance"] = offshore
+	}
+
+	if area := p.boundaries.Lookup(command.Destination.Latitude, command.Destination.Longitude); area != nil {
+		command.Parameters["county"] = area.County
+		if area.District != "" {
+			command.Parameters["district"] = area.District
+		}
+	}
+
+	return nil
 }
 
 func (p *MovementCommandParser) calculateDistance(from, to *geo.Location) float64 {
@@ -535,18 +582,6 @@ func (p *MovementCommandParser) calculateDistance(from, to *geo.Location) float6
 	return earthRadius * c
 }
 
-func (p *MovementCommandParser) getSpeedInMPS(speedStr string) float64 {
-	// Returns speed in meters per second
-	switch speedStr {
-	case "slow":
-		return 1.0   // 1 m/s (walking slowly)
-	case "fast":
-		return 5.0   // 5 m/s (running)
-	default: // "normal"
-		return 2.5   // 2.5 m/s (normal walking)
-	}
-}
-
 // Audit logging structure for movement commands
 type MovementAudit struct {
 	PlayerID       string            `json:"playerId"`
@@ -583,35 +618,3 @@ func (p *MovementCommandParser) LogMovementCommand(playerID, sessionID, ipAddres
 
 	return audit
 }
-
-// extractPlaceNameFromURL extracts place name from Google Maps place URL
-// Example: https://www.google.com/maps/place/台北101 -> "台北101"
-func (p *MovementCommandParser) extractPlaceNameFromURL(text string) string {
-	// Pattern for Google Maps place URLs
-	patterns := []string{
-		`https?://(?:www\.)?google\.com/maps/place/([^/?]+)`,          // place/name
-		`https?://maps\.google\.com/maps/place/([^/?]+)`,              // maps.google.com version
-		`https?://(?:www\.)?google\.com\.tw/maps/place/([^/?]+)`,       // Taiwan Google domain
-	}
-
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		matches := re.FindStringSubmatch(text)
-		if len(matches) >= 2 {
-			// URL decode the place name
-			placeName := matches[1]
-			// Replace URL encoded characters
-			placeName = strings.ReplaceAll(placeName, "%E5%8F%B0%E5%8C%97101", "台北101") // Example: URL encoded 台北101
-			placeName = strings.ReplaceAll(placeName, "+", " ")
-			placeName = strings.ReplaceAll(placeName, "%20", " ")
-
-			// Simple URL decode for common Chinese characters (this could be enhanced)
-			if decoded, err := url.QueryUnescape(placeName); err == nil {
-				return decoded
-			}
-			return placeName
-		}
-	}
-
-	return ""
-}
\ No newline at end of file
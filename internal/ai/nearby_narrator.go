@@ -22,10 +22,15 @@ func (n *NearbyNarrator) GenerateNarration(
 	categoryName string,
 ) (string, error) {
 
+	// 篩選條件併入類別名稱，例如「營業中、平價的餐廳」
+	if results.Filters != nil && !results.Filters.IsZero() {
+		categoryName = results.Filters.Description() + "的" + categoryName
+	}
+
 	// 如果沒有結果
 	if results.Total == 0 {
-		return fmt.Sprintf("😅 抱歉，附近 %.0f 公尺內沒有找到%s",
-			results.Radius, categoryName), nil
+		return fmt.Sprintf("😅 抱歉，%s沒有找到%s",
+			searchScope(results), categoryName), nil
 	}
 
 	// 構建結果列表文字
@@ -38,23 +43,22 @@ func (n *NearbyNarrator) GenerateNarration(
 	for i, loc := range top3 {
 		direction := geo.GetDirectionDescription(loc.Bearing)
 		distance := geo.FormatDistance(loc.Distance)
-		// Rating is optional, not all locations have ratings
-		resultList += fmt.Sprintf("%d. %s（%s方向，距離 %s）\n",
-			i+1, loc.Name, direction, distance)
+		resultList += fmt.Sprintf("%d. %s（%s方向，距離 %s%s）\n",
+			i+1, loc.Name, direction, distance, placeDetails(loc))
 	}
 
 	// 構建 AI Prompt
 	prompt := fmt.Sprintf(`你是友善的 AI 導覽助手。用戶剛才搜尋了「附近的%s」，以下是搜尋結果：
 
 找到數量：%d 個
-搜尋半徑：%.0f 公尺
+搜尋範圍：%s
 
 前 3 個結果：
 %s
 
 請生成一段 50-80 字的輕鬆活潑回應（台灣用語）：
 1. 開頭說找到幾個結果
-2. 重點推薦前 2-3 個（提到名稱、距離、特色）
+2. 重點推薦前 2-3 個（提到名稱、距離、特色；有評分或營業狀態時可以提）
 3. 語氣親切、加上合適的 emoji
 
 範例風格：
@@ -63,7 +67,7 @@ func (n *NearbyNarrator) GenerateNarration(
 請直接回答，不要有「我建議」「我認為」等開頭。`,
 		categoryName,
 		results.Total,
-		results.Radius,
+		searchScope(results),
 		resultList,
 	)
 
@@ -77,13 +81,21 @@ func (n *NearbyNarrator) GenerateNarration(
 	return strings.TrimSpace(response), nil
 }
 
+// searchScope 描述搜尋範圍，例如「附近 500 公尺內」或等時圈的「走路 15 分鐘內」
+func searchScope(results *geo.NearbySearchResult) string {
+	if results.Area != nil && results.Area.Description != "" {
+		return results.Area.Description
+	}
+	return fmt.Sprintf("附近 %.0f 公尺內", results.Radius)
+}
+
 // generateFallbackNarration 降級回應（當 AI 失敗時）
 func (n *NearbyNarrator) generateFallbackNarration(
 	results *geo.NearbySearchResult,
 	categoryName string,
 ) string {
 	if results.Total == 0 {
-		return fmt.Sprintf("😅 附近沒有找到%s", categoryName)
+		return fmt.Sprintf("😅 %s沒有找到%s", searchScope(results), categoryName)
 	}
 
 	response := fmt.Sprintf("找到 %d 個%s！", results.Total, categoryName)
@@ -97,8 +109,71 @@ func (n *NearbyNarrator) generateFallbackNarration(
 	for _, loc := range top3 {
 		emoji := "📍"
 		distance := geo.FormatDistance(loc.Distance)
-		response += fmt.Sprintf("\n%s %s (%s)", emoji, loc.Name, distance)
+		response += fmt.Sprintf("\n%s %s (%s%s)", emoji, loc.Name, distance, placeDetails(loc))
 	}
 
 	return response
 }
+
+// placeDetails 評分、價位與營業狀態的簡短說明，沒有資料時為空字串
+func placeDetails(loc geo.LocationWithDistance) string {
+	var details string
+	if loc.Rating > 0 {
+		details += fmt.Sprintf("，%.1f 星", loc.Rating)
+		if loc.RatingCount > 0 {
+			details += fmt.Sprintf("（%d 則評價）", loc.RatingCount)
+		}
+	}
+	if loc.PriceLevel != nil && *loc.PriceLevel > 0 {
+		details += "，" + strings.Repeat("$", *loc.PriceLevel)
+	}
+	if loc.OpenNow != nil {
+		if *loc.OpenNow {
+			details += "，營業中"
+		} else {
+			details += "，休息中"
+		}
+	}
+	return details
+}
+
+// DescribeLocation 以反查結果回答「我在哪裡」，只使用查得的資料；AI 失敗時回傳摘要
+func (n *NearbyNarrator) DescribeLocation(place *geo.ReverseGeocodeResult) string {
+	response, err := n.ai.Chat(DescribeLocationPrompt(place), "你是友善的旅遊助手，只根據提供的資料回答")
+	if err != nil || strings.TrimSpace(response) == "" {
+		return place.Summary()
+	}
+	return strings.TrimSpace(response)
+}
+
+// DescribeLocationPrompt 將反查結果整理成有依據的 prompt
+func DescribeLocationPrompt(place *geo.ReverseGeocodeResult) string {
+	facts := []string{fmt.Sprintf("行政區：%s%s", place.County, place.District)}
+	if place.Road != "" {
+		facts = append(facts, "最近的道路："+place.Road)
+	}
+	if place.Address != "" && place.Address != place.County+place.District {
+		facts = append(facts, "地址："+place.Address)
+	}
+	if !place.OnLand {
+		facts = append(facts, "位置在海上或岸邊")
+	}
+	if place.Landmark != nil {
+		facts = append(facts, fmt.Sprintf("最近的地標：%s（距離 %s）",
+			place.Landmark.Name, geo.FormatDistance(place.Landmark.Distance)))
+	}
+	if place.HistoricalSite != nil {
+		facts = append(facts, fmt.Sprintf("最近的古蹟：%s（距離 %s）",
+			place.HistoricalSite.Name, geo.FormatDistance(place.HistoricalSite.Distance)))
+	}
+
+	return fmt.Sprintf(`用戶想知道他現在在哪裡。以下是查到的位置資料（座標 %.6f, %.6f）：
+
+%s
+
+請用親切的語氣回應（50 字內，台灣用語）：
+1. 先說在哪個縣市、區
+2. 有地標或古蹟時提一個當參考
+3. 只能使用上面的資料，不要編造其他地名或距離`,
+		place.Latitude, place.Longitude, "- "+strings.Join(facts, "\n- "))
+}
@@ -0,0 +1,158 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"intelligent-spatial-platform/internal/geo"
+)
+
+// DetailField 使用者想知道的地點細節
+type DetailField string
+
+const (
+	DetailHours   DetailField = "hours"   // 營業時間
+	DetailPhone   DetailField = "phone"   // 電話
+	DetailWebsite DetailField = "website" // 網站
+	DetailAddress DetailField = "address" // 地址
+	DetailReviews DetailField = "reviews" // 評價
+	DetailGeneral DetailField = "general" // 概要
+)
+
+// detailPhrases 依序比對，先符合者優先
+var detailPhrases = []struct {
+	field   DetailField
+	phrases []string
+}{
+	{DetailHours, []string{"幾點", "几点", "營業時間", "营业时间", "關門", "关门", "開門", "开门", "打烊", "公休", "有開嗎", "有开吗", "休息"}},
+	{DetailPhone, []string{"電話", "电话", "號碼", "号码", "聯絡", "联络"}},
+	{DetailWebsite, []string{"網站", "网站", "網址", "网址", "官網", "官网"}},
+	{DetailAddress, []string{"地址", "在哪", "怎麼去", "怎么去"}},
+	{DetailReviews, []string{"評價", "评价", "評論", "评论", "評分", "评分", "好吃嗎", "好吃吗", "好不好"}},
+}
+
+// ParseDetailField 從語音指令的字面判斷想知道的細節，例如「幾點關門」為 hours
+func ParseDetailField(command string) DetailField {
+	for _, entry := range detailPhrases {
+		if containsAny(command, entry.phrases) {
+			return entry.field
+		}
+	}
+	return DetailGeneral
+}
+
+// DescribePlaceDetails 以查到的地點細節回答，只使用 details 中的資料
+func DescribePlaceDetails(name string, details *geo.PlaceDetails, field DetailField, now time.Time) string {
+	if details.Name != "" {
+		name = details.Name
+	}
+	if details.BusinessStatus == "CLOSED_PERMANENTLY" {
+		return fmt.Sprintf("%s已經歇業了 😢", name)
+	}
+
+	switch field {
+	case DetailHours:
+		return describeHours(name, details, now)
+	case DetailPhone:
+		if details.Phone == "" {
+			return fmt.Sprintf("抱歉，查不到%s的電話", name)
+		}
+		return fmt.Sprintf("%s的電話是 %s 📞", name, details.Phone)
+	case DetailWebsite:
+		if details.Website == "" {
+			return fmt.Sprintf("抱歉，%s沒有提供網站", name)
+		}
+		return fmt.Sprintf("%s的網站：%s", name, details.Website)
+	case DetailAddress:
+		if details.Address == "" {
+			return fmt.Sprintf("抱歉，查不到%s的地址", name)
+		}
+		return fmt.Sprintf("%s的地址是%s 📍", name, details.Address)
+	case DetailReviews:
+		return describeReviews(name, details)
+	}
+
+	parts := []string{}
+	if details.Address != "" {
+		parts = append(parts, "地址："+details.Address)
+	}
+	if details.Phone != "" {
+		parts = append(parts, "電話："+details.Phone)
+	}
+	if hours := details.OpeningHours.Today(now); hours != "" {
+		parts = append(parts, "今天 "+hours)
+	}
+	if details.Reviews.Rating > 0 {
+		parts = append(parts, fmt.Sprintf("評分 %.1f 星（%d 則評價）", details.Reviews.Rating, details.Reviews.Total))
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("抱歉，查不到%s的詳細資料", name)
+	}
+	return name + "\n" + strings.Join(parts, "\n")
+}
+
+func describeHours(name string, details *geo.PlaceDetails, now time.Time) string {
+	hours := details.OpeningHours
+	if details.BusinessStatus == "CLOSED_TEMPORARILY" {
+		return fmt.Sprintf("%s目前暫停營業", name)
+	}
+	if hours == nil {
+		return fmt.Sprintf("抱歉，查不到%s的營業時間", name)
+	}
+
+	var response string
+	if hours.OpenNow != nil {
+		if *hours.OpenNow {
+			response = fmt.Sprintf("%s現在有營業 ✅", name)
+		} else {
+			response = fmt.Sprintf("%s現在沒有營業 🚪", name)
+		}
+	} else {
+		response = name
+	}
+	if today := hours.Today(now); today != "" {
+		response += "，今天 " + today
+	}
+	return response
+}
+
+func describeReviews(name string, details *geo.PlaceDetails) string {
+	reviews := details.Reviews
+	if reviews.Rating <= 0 {
+		return fmt.Sprintf("%s還沒有評價", name)
+	}
+	response := fmt.Sprintf("%s在 Google 上有 %.1f 星（%d 則評價）⭐", name, reviews.Rating, reviews.Total)
+	if len(reviews.Recent) > 0 {
+		review := reviews.Recent[0]
+		response += fmt.Sprintf("\n有人給 %d 星說：「%s」", review.Rating, review.Text)
+	}
+	return response
+}
+
+// DescribeListedPlace 回答沒有 Google 資料的地點（例如資料庫中的地點或古蹟），只能提供名稱與地址
+func DescribeListedPlace(place *geo.LocationWithDistance, field DetailField) string {
+	switch field {
+	case DetailAddress, DetailGeneral:
+		if place.Address != "" {
+			return fmt.Sprintf("%s的地址是%s，距離 %s 📍", place.Name, place.Address, geo.FormatDistance(place.Distance))
+		}
+	}
+	return fmt.Sprintf("抱歉，%s沒有%s資料", place.Name, detailFieldName(field))
+}
+
+func detailFieldName(field DetailField) string {
+	switch field {
+	case DetailHours:
+		return "營業時間"
+	case DetailPhone:
+		return "電話"
+	case DetailWebsite:
+		return "網站"
+	case DetailAddress:
+		return "地址"
+	case DetailReviews:
+		return "評價"
+	}
+	return "詳細"
+}
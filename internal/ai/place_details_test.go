@@ -0,0 +1,105 @@
+package ai
+
+import (
+	"testing"
+	"time"
+
+	"intelligent-spatial-platform/internal/geo"
+)
+
+func TestParseDetailField(t *testing.T) {
+	tests := []struct {
+		command string
+		want    DetailField
+	}{
+		{"第一家幾點關門", DetailHours},
+		{"那間今天有開嗎", DetailHours},
+		{"第二家的電話是多少", DetailPhone},
+		{"它有官網嗎", DetailWebsite},
+		{"那家在哪裡", DetailAddress},
+		{"第三家評價怎麼樣", DetailReviews},
+		{"告訴我第一家的資訊", DetailGeneral},
+	}
+	for _, tt := range tests {
+		if got := ParseDetailField(tt.command); got != tt.want {
+			t.Errorf("ParseDetailField(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestDescribePlaceDetails(t *testing.T) {
+	open := true
+	details := &geo.PlaceDetails{
+		Name:    "鼎泰豐 信義店",
+		Address: "台北市信義區信義路二段194號",
+		Phone:   "02 2321 8928",
+		OpeningHours: &geo.OpeningHours{
+			OpenNow: &open,
+			WeekdayText: []string{
+				"星期一: 10:00 – 21:00", "星期二: 10:00 – 21:00", "星期三: 10:00 – 21:00", "星期四: 10:00 – 21:00",
+				"星期五: 10:00 – 21:30", "星期六: 09:00 – 21:30", "星期日: 09:00 – 21:00",
+			},
+		},
+		Reviews: geo.ReviewSummary{Rating: 4.5, Total: 1200, Recent: []geo.PlaceReview{{Rating: 5, Text: "小籠包很好吃"}}},
+	}
+	// 2024-06-01 02:00 UTC is Saturday 10:00 in Taiwan
+	now := time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		field DetailField
+		want  string
+	}{
+		{DetailHours, "鼎泰豐 信義店現在有營業 ✅，今天 星期六: 09:00 – 21:30"},
+		{DetailPhone, "鼎泰豐 信義店的電話是 02 2321 8928 📞"},
+		{DetailWebsite, "抱歉，鼎泰豐 信義店沒有提供網站"},
+		{DetailAddress, "鼎泰豐 信義店的地址是台北市信義區信義路二段194號 📍"},
+		{DetailReviews, "鼎泰豐 信義店在 Google 上有 4.5 星（1200 則評價）⭐\n有人給 5 星說：「小籠包很好吃」"},
+		{DetailGeneral, "鼎泰豐 信義店\n地址：台北市信義區信義路二段194號\n電話：02 2321 8928\n今天 星期六: 09:00 – 21:30\n評分 4.5 星（1200 則評價）"},
+	}
+	for _, tt := range tests {
+		if got := DescribePlaceDetails("鼎泰豐", details, tt.field, now); got != tt.want {
+			t.Errorf("DescribePlaceDetails(%s) = %q, want %q", tt.field, got, tt.want)
+		}
+	}
+
+	// Late evening UTC on Saturday is already Sunday in Taiwan
+	sunday := time.Date(2024, 6, 1, 20, 0, 0, 0, time.UTC)
+	if got, want := DescribePlaceDetails("鼎泰豐", details, DetailHours, sunday), "鼎泰豐 信義店現在有營業 ✅，今天 星期日: 09:00 – 21:00"; got != want {
+		t.Errorf("hours early on Sunday in Taiwan = %q, want %q", got, want)
+	}
+
+	closed := &geo.PlaceDetails{BusinessStatus: "CLOSED_PERMANENTLY"}
+	if got := DescribePlaceDetails("老店", closed, DetailHours, now); got != "老店已經歇業了 😢" {
+		t.Errorf("closed place = %q", got)
+	}
+	if got := DescribePlaceDetails("新店", &geo.PlaceDetails{}, DetailGeneral, now); got != "抱歉，查不到新店的詳細資料" {
+		t.Errorf("no details = %q", got)
+	}
+}
+
+func TestDescribeListedPlace(t *testing.T) {
+	// Database locations and historical sites have no place ID, only a name and address
+	place := &geo.LocationWithDistance{
+		Location: geo.Location{Name: "剝皮寮歷史街區", Address: "台北市萬華區康定路173巷"},
+		Distance: 350,
+	}
+	tests := []struct {
+		field DetailField
+		want  string
+	}{
+		{DetailAddress, "剝皮寮歷史街區的地址是台北市萬華區康定路173巷，距離 350公尺 📍"},
+		{DetailGeneral, "剝皮寮歷史街區的地址是台北市萬華區康定路173巷，距離 350公尺 📍"},
+		{DetailHours, "抱歉，剝皮寮歷史街區沒有營業時間資料"},
+		{DetailPhone, "抱歉，剝皮寮歷史街區沒有電話資料"},
+	}
+	for _, tt := range tests {
+		if got := DescribeListedPlace(place, tt.field); got != tt.want {
+			t.Errorf("DescribeListedPlace(%s) = %q, want %q", tt.field, got, tt.want)
+		}
+	}
+
+	noAddress := &geo.LocationWithDistance{Location: geo.Location{Name: "無名土地公廟"}}
+	if got := DescribeListedPlace(noAddress, DetailAddress); got != "抱歉，無名土地公廟沒有地址資料" {
+		t.Errorf("no address = %q", got)
+	}
+}
@@ -0,0 +1,104 @@
+package ai
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// selectionPattern matches replies choosing from a numbered list: 第二個, 第2, 選3, 2號, 2
+var selectionPattern = regexp.MustCompile(`^(?:第|選|选)?\s*([0-9０-９]+|[一二兩两三四五六七八九十])\s*(?:個|个|號|号|項|项)?$`)
+
+var chineseOrdinals = map[string]int{
+	"一": 1, "二": 2, "兩": 2, "两": 2, "三": 3, "四": 4, "五": 5,
+	"六": 6, "七": 7, "八": 8, "九": 9, "十": 10,
+}
+
+// ParseCandidateSelection reads a reply like "第二個" or "2" and returns the 0-based
+// index it selects; "最後一個" selects the last of count options
+func ParseCandidateSelection(text string, count int) (int, bool) {
+	text = strings.TrimSpace(text)
+	text = strings.TrimRight(text, "。.!！ ")
+	text = strings.TrimPrefix(text, "我要")
+	text = strings.TrimSuffix(text, "吧")
+
+	if text == "最後一個" || text == "最后一个" {
+		if count > 0 {
+			return count - 1, true
+		}
+		return 0, false
+	}
+
+	match := selectionPattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0, false
+	}
+
+	return ordinalIndex(match[1], count)
+}
+
+// ordinalInTextPattern finds an ordinal inside a sentence: 第二家, 第3間
+var ordinalInTextPattern = regexp.MustCompile(`第\s*([0-9０-９]+|[一二兩两三四五六七八九十])\s*(?:個|个|家|間|间|號|号|項|项|名)?`)
+
+// FindOrdinalReference finds a reference such as "第二家" or "最後一個" inside a
+// sentence like "第二家幾點關門" and returns the 0-based index it selects of count options
+func FindOrdinalReference(text string, count int) (int, bool) {
+	for _, last := range []string{"最後一", "最后一"} {
+		if strings.Contains(text, last) {
+			if count > 0 {
+				return count - 1, true
+			}
+			return 0, false
+		}
+	}
+
+	match := ordinalInTextPattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0, false
+	}
+	return ordinalIndex(match[1], count)
+}
+
+// ordinalIndex turns "二", "2" or "２" into a 0-based index of count options
+func ordinalIndex(ordinal string, count int) (int, bool) {
+	n, ok := parseChineseNumber(ordinal)
+	if !ok || n < 1 || (count > 0 && n > count) {
+		return 0, false
+	}
+	return n - 1, true
+}
+
+// parseChineseNumber reads a number written in digits ("15", "１５") or in
+// Chinese numerals up to 99 ("五", "十五", "二十", "兩")
+func parseChineseNumber(text string) (int, bool) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '０' && r <= '９' {
+			return r - '０' + '0'
+		}
+		return r
+	}, text)
+	if n, err := strconv.Atoi(digits); err == nil {
+		return n, true
+	}
+
+	total, digit := 0, 0
+	for _, r := range text {
+		if r == '十' {
+			if total > 0 {
+				return 0, false
+			}
+			total = max(digit, 1) * 10
+			digit = 0
+			continue
+		}
+		n, ok := chineseOrdinals[string(r)]
+		if !ok || digit > 0 {
+			return 0, false
+		}
+		digit = n
+	}
+	if total+digit == 0 {
+		return 0, false
+	}
+	return total + digit, true
+}
@@ -29,6 +29,7 @@ type Service struct {
 	geocodingService *geo.GeocodingService
 	client           *http.Client
 	rateLimiter      *AIRateLimiter
+	resources        geo.Resources
 
 	// Ollama specific
 	ollamaURL   string
@@ -205,9 +206,11 @@ func (r *AIRateLimiter) ShouldWarn(userID string) bool {
 	return float64(used)/float64(total) >= r.warningPercent
 }
 
-func NewService() *Service {
+func NewService(resources geo.Resources) *Service {
+	resources = resources.WithDefaults()
+
 	// Initialize geocoding service
-	geocodingService, err := geo.NewGeocodingService()
+	geocodingService, err := geo.NewGeocodingService(resources)
 	if err != nil {
 		// Log error but don't fail service initialization
 		fmt.Printf("Warning: Failed to initialize geocoding service: %v\n", err)
@@ -239,6 +242,7 @@ func NewService() *Service {
 		provider:         provider,
 		geocodingService: geocodingService,
 		rateLimiter:      rateLimiter,
+		resources:        resources,
 		client: &http.Client{
 			Timeout: 30 * time.Second, // Reduced from 60s to fail faster
 			Transport: &http.Transport{
@@ -466,7 +470,7 @@ func (s *Service) GenerateGameResponse(action, result string) (string, error) {
 
 func (s *Service) ProcessMovementCommand(command, playerID string, currentLocation *geo.Location) (string, error) {
 	// Create movement parser with geocoding service
-	parser := NewMovementCommandParser(s, s.geocodingService)
+	parser := NewMovementCommandParser(s, s.geocodingService, s.resources)
 
 	// Parse the movement command
 	moveCmd, err := parser.ParseMovementCommand(command, currentLocation)
@@ -481,16 +485,18 @@ func (s *Service) ProcessMovementCommand(command, playerID string, currentLocati
 - 類型：%s
 - 動作：%s
 - 目標位置：緯度 %.6f，經度 %.6f
-- 預估時間：%d 秒
+- 交通方式：%s
+- 預估時間：%s
 - 信心度：%.1f%%
 
-請生成一個友善的回應，告知玩家移動指令已理解並將執行。用台灣用語，語調親切。`,
+請生成一個友善的回應，告知玩家移動指令已理解並將執行，並提到交通方式和大約需要多久。用台灣用語，語調親切。`,
 		moveCmd.OriginalText,
 		moveCmd.Type,
 		moveCmd.Action,
 		moveCmd.Destination.Latitude,
 		moveCmd.Destination.Longitude,
-		moveCmd.EstimatedTime,
+		GetTransportProfile(moveCmd.Mode).Verb,
+		FormatTravelTime(moveCmd.EstimatedTime),
 		moveCmd.Confidence*100)
 
 	return s.Chat(prompt, "你是智慧空間平台的AI助理，專門幫助使用者控制虛擬兔子移動。請用台灣用語，語調親切友善。")
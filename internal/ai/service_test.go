@@ -6,7 +6,8 @@ import (
 	"os"
 	"strings"
 	"testing"
-	"time"
+
+	"intelligent-spatial-platform/internal/geo"
 )
 
 // TestProviderType tests provider type constants
@@ -39,7 +40,7 @@ func TestNewService(t *testing.T) {
 	t.Setenv("OLLAMA_URL", "http://localhost:11434")
 	t.Setenv("OLLAMA_MODEL", "test-model")
 
-	service := NewService()
+	service := NewService(geo.Resources{})
 
 	if service == nil {
 		t.Fatal("Service should not be nil")
@@ -59,7 +60,7 @@ func TestNewService(t *testing.T) {
 	t.Setenv("OPENROUTER_API_KEY", "test-key")
 	t.Setenv("OPENROUTER_MODEL", "test-model")
 
-	service2 := NewService()
+	service2 := NewService(geo.Resources{})
 
 	if service2.provider != ProviderOpenRouter {
 		t.Errorf("Expected provider %s, got %s", ProviderOpenRouter, service2.provider)
@@ -71,30 +72,29 @@ func TestNewService(t *testing.T) {
 
 // TestRateLimiter tests rate limiting functionality
 func TestRateLimiter(t *testing.T) {
-	// 測試 2 RPM 的限制器
+	// 每日 2 次的限制器
 	limiter := NewAIRateLimiter(2)
 
-	// 第一次請求應該成功
-	allowed, _ := limiter.Allow()
-	if !allowed {
-		t.Error("First request should be allowed")
+	// 前兩次請求應該成功
+	for i := 0; i < 2; i++ {
+		if allowed, _ := limiter.Allow(); !allowed {
+			t.Errorf("Request %d should be allowed", i+1)
+		}
 	}
 
-	// 立即第二次請求應該被限制（因為還沒到 30 秒）
+	// 第三次請求應該被限制，直到午夜重置
 	allowed, waitTime := limiter.Allow()
 	if allowed {
-		t.Error("Second immediate request should be rate limited")
+		t.Error("Third request should be rate limited")
 	}
 
-	if waitTime == 0 {
+	if waitTime <= 0 {
 		t.Error("Wait time should be greater than 0")
 	}
 
-	// 測試高頻率限制器 (60 RPM)
-	fastLimiter := NewAIRateLimiter(60)
-	allowed, _ = fastLimiter.Allow()
-	if !allowed {
-		t.Error("Fast limiter first request should be allowed")
+	// 各用戶分開計算
+	if allowed, remaining, _ := limiter.AllowUser("player-1"); !allowed || remaining != 1 {
+		t.Errorf("AllowUser() = %v, %d remaining", allowed, remaining)
 	}
 }
 
@@ -179,8 +179,8 @@ func TestChatWithOpenRouterMockServer(t *testing.T) {
 func TestRateLimitError(t *testing.T) {
 	limiter := NewAIRateLimiter(1) // 1 request per minute
 
-	// 手動設定 lastRequest 為現在，模擬剛發送過請求
-	limiter.lastRequest = time.Now()
+	// 先用掉今日唯一的一次，模擬剛發送過請求
+	limiter.Allow()
 
 	service := &Service{
 		provider:    ProviderOllama,
@@ -194,7 +194,7 @@ func TestRateLimitError(t *testing.T) {
 		t.Error("Should return rate limit error")
 	}
 
-	if !strings.Contains(err.Error(), "rate limit exceeded") {
+	if !strings.Contains(err.Error(), "已達上限") {
 		t.Errorf("Error should mention rate limit, got: %v", err)
 	}
 }
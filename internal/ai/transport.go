@@ -0,0 +1,213 @@
+package ai
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TransportMode 交通方式
+type TransportMode string
+
+const (
+	ModeWalk    TransportMode = "walk"    // 走路
+	ModeRun     TransportMode = "run"     // 跑步
+	ModeBike    TransportMode = "bike"    // 腳踏車 / YouBike
+	ModeScooter TransportMode = "scooter" // 機車
+	ModeCar     TransportMode = "car"     // 開車 / 計程車
+	ModeBus     TransportMode = "bus"     // 公車 / 客運
+	ModeMRT     TransportMode = "mrt"     // 捷運
+	ModeTRA     TransportMode = "tra"     // 台鐵
+	ModeTHSR    TransportMode = "thsr"    // 高鐵
+)
+
+// TransportProfile describes how fast and how far a transport mode can go
+type TransportProfile struct {
+	Mode        TransportMode `json:"mode"`
+	Name        string        `json:"name"`        // 中文名稱
+	Verb        string        `json:"verb"`        // 回覆用語，例如「搭高鐵」
+	SlowMPS     float64       `json:"slowMps"`     // speed for "slow" in m/s
+	NormalMPS   float64       `json:"normalMps"`   // speed for "normal" in m/s
+	FastMPS     float64       `json:"fastMps"`     // speed for "fast" in m/s
+	MaxDistance float64       `json:"maxDistance"` // longest single trip in meters
+	Overhead    int           `json:"overhead"`    // fixed waiting/boarding time in seconds
+}
+
+// transportProfiles holds the speed and reach of each mode.
+// Transit speeds are door-to-door averages including stops, not top speed.
+var transportProfiles = map[TransportMode]*TransportProfile{
+	ModeWalk:    {Mode: ModeWalk, Name: "走路", Verb: "走路", SlowMPS: 1.0, NormalMPS: 1.4, FastMPS: 1.8, MaxDistance: 30000},
+	ModeRun:     {Mode: ModeRun, Name: "跑步", Verb: "跑步", SlowMPS: 2.5, NormalMPS: 3.0, FastMPS: 4.0, MaxDistance: 50000},
+	ModeBike:    {Mode: ModeBike, Name: "腳踏車", Verb: "騎腳踏車", SlowMPS: 3.0, NormalMPS: 4.5, FastMPS: 6.0, MaxDistance: 200000},
+	ModeScooter: {Mode: ModeScooter, Name: "機車", Verb: "騎機車", SlowMPS: 8.0, NormalMPS: 11.0, FastMPS: 14.0, MaxDistance: 300000},
+	ModeCar:     {Mode: ModeCar, Name: "汽車", Verb: "開車", SlowMPS: 10.0, NormalMPS: 14.0, FastMPS: 20.0, MaxDistance: 500000},
+	ModeBus:     {Mode: ModeBus, Name: "公車", Verb: "搭公車", SlowMPS: 5.0, NormalMPS: 7.0, FastMPS: 9.0, MaxDistance: 400000, Overhead: 300},
+	ModeMRT:     {Mode: ModeMRT, Name: "捷運", Verb: "搭捷運", SlowMPS: 8.0, NormalMPS: 9.0, FastMPS: 10.0, MaxDistance: 60000, Overhead: 180},
+	ModeTRA:     {Mode: ModeTRA, Name: "台鐵", Verb: "搭台鐵", SlowMPS: 15.0, NormalMPS: 18.0, FastMPS: 22.0, MaxDistance: 500000, Overhead: 600},
+	ModeTHSR:    {Mode: ModeTHSR, Name: "高鐵", Verb: "搭高鐵", SlowMPS: 45.0, NormalMPS: 55.0, FastMPS: 65.0, MaxDistance: 400000, Overhead: 900},
+}
+
+// transportKeywords is checked in order, so more specific phrases come first.
+// Phrases are verb phrases that name a mode on their own. Nouns only count
+// after a travel verb, so 「去台中高鐵站」 is not a trip by high speed rail.
+// Words are English and must match whole words, so "business" is not a bus.
+var transportKeywords = []struct {
+	mode    TransportMode
+	phrases []string
+	nouns   []string
+	words   []string
+}{
+	{ModeTHSR, nil, []string{"高鐵"}, []string{"thsr", "high speed rail", "bullet train"}},
+	{ModeTRA, nil, []string{"台鐵", "臺鐵", "火車", "區間車", "自強號", "莒光號", "普悠瑪", "太魯閣號"}, []string{"train"}},
+	{ModeMRT, nil, []string{"捷運", "地鐵", "輕軌"}, []string{"mrt", "metro", "subway"}},
+	{ModeBus, nil, []string{"公車", "客運", "巴士"}, []string{"bus"}},
+	{ModeBike, nil, []string{"ubike", "youbike", "腳踏車", "自行車", "單車"}, []string{"ubike", "youbike", "bicycle", "bike", "cycling"}},
+	{ModeScooter, []string{"騎車"}, []string{"機車", "摩托車"}, []string{"scooter", "motorcycle"}},
+	{ModeCar, []string{"開車"}, []string{"計程車", "小黃", "uber", "汽車"}, []string{"drive", "driving", "taxi", "car", "uber"}},
+	{ModeRun, []string{"跑步", "慢跑", "跑去", "跑到"}, nil, []string{"jog", "jogging", "run to", "running"}},
+	{ModeWalk, []string{"走路", "步行", "散步", "走去", "走過去"}, nil, []string{"walk", "walking", "on foot"}},
+}
+
+// transportWordPatterns matches each entry's English words, built from transportKeywords
+var transportWordPatterns = func() []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, len(transportKeywords))
+	for i, entry := range transportKeywords {
+		quoted := make([]string, len(entry.words))
+		for j, word := range entry.words {
+			quoted[j] = regexp.QuoteMeta(word)
+		}
+		patterns[i] = regexp.MustCompile(`\b(?:` + strings.Join(quoted, "|") + `)\b`)
+	}
+	return patterns
+}()
+
+// travelVerbs are what a transport noun follows when it is how to go: 搭高鐵, 坐捷運, 騎Ubike
+var travelVerbs = []string{"搭", "坐", "乘", "騎", "開", "叫", "用", "轉"}
+
+// transportMeasures may come between a travel verb and its noun: 搭個公車, 叫一台小黃
+var transportMeasures = []string{" ", "個", "一台", "一輛", "一班", "台", "輛", "班"}
+
+// speedKeywords maps pace modifiers to MovementCommand.Speed values
+var speedKeywords = []struct {
+	speed    string
+	keywords []string
+}{
+	{"slow", []string{"慢慢", "悠閒", "慢一點", "slowly", "leisurely"}},
+	{"fast", []string{"趕快", "快點", "快速", "盡快", "hurry", "quickly"}},
+}
+
+// GetTransportProfile returns the profile for a mode, falling back to walking
+func GetTransportProfile(mode TransportMode) *TransportProfile {
+	if profile, ok := transportProfiles[mode]; ok {
+		return profile
+	}
+	return transportProfiles[ModeWalk]
+}
+
+// DetectTransportMode finds an explicit transport mode in the text ("騎Ubike", "開車", "搭捷運")
+func DetectTransportMode(text string) (TransportMode, bool) {
+	lowerText := strings.ToLower(text)
+	for i, entry := range transportKeywords {
+		if containsAny(lowerText, entry.phrases) || transportWordPatterns[i].MatchString(lowerText) {
+			return entry.mode, true
+		}
+		for _, noun := range entry.nouns {
+			if followsTravelVerb(lowerText, noun) {
+				return entry.mode, true
+			}
+		}
+	}
+	return "", false
+}
+
+// followsTravelVerb reports whether noun appears right after a travel verb,
+// allowing a measure word in between
+func followsTravelVerb(text, noun string) bool {
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], noun)
+		if i < 0 {
+			return false
+		}
+		before := text[:offset+i]
+		for trimmed := true; trimmed; {
+			trimmed = false
+			for _, measure := range transportMeasures {
+				if strings.HasSuffix(before, measure) {
+					before = strings.TrimSuffix(before, measure)
+					trimmed = true
+				}
+			}
+		}
+		for _, verb := range travelVerbs {
+			if strings.HasSuffix(before, verb) {
+				return true
+			}
+		}
+		offset += i + len(noun)
+	}
+}
+
+// InferTransportMode picks a sensible mode by distance when the user did not name one
+func InferTransportMode(distance float64) TransportMode {
+	switch {
+	case distance <= 2000:
+		return ModeWalk
+	case distance <= 30000:
+		return ModeScooter
+	default:
+		return ModeCar
+	}
+}
+
+// detectSpeed finds a pace modifier in the text, defaulting to "normal"
+func detectSpeed(text string) string {
+	lowerText := strings.ToLower(text)
+	for _, entry := range speedKeywords {
+		for _, keyword := range entry.keywords {
+			if strings.Contains(lowerText, keyword) {
+				return entry.speed
+			}
+		}
+	}
+	return "normal"
+}
+
+// SpeedMPS returns the speed in meters per second for slow, normal or fast
+func (p *TransportProfile) SpeedMPS(speed string) float64 {
+	switch speed {
+	case "slow":
+		return p.SlowMPS
+	case "fast":
+		return p.FastMPS
+	default:
+		return p.NormalMPS
+	}
+}
+
+// EstimateTravelTime returns the travel time in seconds for the given distance
+func (p *TransportProfile) EstimateTravelTime(distance float64, speed string) int {
+	return p.Overhead + int(distance/p.SpeedMPS(speed))
+}
+
+// ValidateDistance checks the trip against the mode's reach
+func (p *TransportProfile) ValidateDistance(distance float64) error {
+	if distance > p.MaxDistance {
+		return fmt.Errorf("%s無法到達：距離 %.1f 公里超過上限 %.0f 公里", p.Name, distance/1000, p.MaxDistance/1000)
+	}
+	return nil
+}
+
+// FormatTravelTime formats seconds as a short Chinese duration, e.g. "1 小時 20 分鐘"
+func FormatTravelTime(seconds int) string {
+	if seconds < 60 {
+		return fmt.Sprintf("%d 秒", seconds)
+	}
+	minutes := seconds / 60
+	if minutes < 60 {
+		return fmt.Sprintf("%d 分鐘", minutes)
+	}
+	if minutes%60 == 0 {
+		return fmt.Sprintf("%d 小時", minutes/60)
+	}
+	return fmt.Sprintf("%d 小時 %d 分鐘", minutes/60, minutes%60)
+}
@@ -0,0 +1,100 @@
+package ai
+
+import "testing"
+
+func TestDetectTransportMode(t *testing.T) {
+	tests := []struct {
+		text string
+		want TransportMode
+	}{
+		{"搭高鐵去台中", ModeTHSR},
+		{"坐火車到花蓮", ModeTRA},
+		{"搭乘捷運去台北101", ModeMRT},
+		{"搭個公車去台北火車站", ModeBus},
+		{"叫一台小黃去機場", ModeCar},
+		{"騎Ubike去河濱公園", ModeBike},
+		{"騎 YouBike 去大安森林公園", ModeBike},
+		{"騎車去高鐵站", ModeScooter},
+		{"開車去墾丁", ModeCar},
+		{"慢跑到國父紀念館", ModeRun},
+		{"走路去便利商店", ModeWalk},
+		{"take the bus to Taipei 101", ModeBus},
+		{"go by car to Tainan", ModeCar},
+		{"walk to the park", ModeWalk},
+
+		// Mode words inside destination names
+		{"去台中高鐵站", ""},
+		{"去台北火車站", ""},
+		{"到捷運站", ""},
+		{"移動到公車總站", ""},
+		{"go to the business district", ""},
+		{"go to the carnival", ""},
+		{"show me my card", ""},
+	}
+	for _, tt := range tests {
+		mode, ok := DetectTransportMode(tt.text)
+		if mode != tt.want || ok != (tt.want != "") {
+			t.Errorf("DetectTransportMode(%q) = %q, %v, want %q", tt.text, mode, ok, tt.want)
+		}
+	}
+}
+
+func TestInferTransportMode(t *testing.T) {
+	tests := []struct {
+		distance float64
+		want     TransportMode
+	}{
+		{0, ModeWalk},
+		{2000, ModeWalk},
+		{2001, ModeScooter},
+		{30000, ModeScooter},
+		{30001, ModeCar},
+		{300000, ModeCar},
+	}
+	for _, tt := range tests {
+		if got := InferTransportMode(tt.distance); got != tt.want {
+			t.Errorf("InferTransportMode(%v) = %q, want %q", tt.distance, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateTravelTime(t *testing.T) {
+	tests := []struct {
+		mode     TransportMode
+		distance float64
+		speed    string
+		want     int
+	}{
+		{ModeWalk, 1400, "normal", 1000},
+		{ModeWalk, 1800, "fast", 1000},
+		{ModeWalk, 1000, "slow", 1000},
+		{ModeMRT, 9000, "normal", 180 + 1000},
+		{ModeTHSR, 55000, "", 900 + 1000},
+		{"unknown", 1400, "normal", 1000}, // falls back to walking
+	}
+	for _, tt := range tests {
+		if got := GetTransportProfile(tt.mode).EstimateTravelTime(tt.distance, tt.speed); got != tt.want {
+			t.Errorf("%s %v m %q: EstimateTravelTime() = %d, want %d", tt.mode, tt.distance, tt.speed, got, tt.want)
+		}
+	}
+}
+
+func TestFormatTravelTime(t *testing.T) {
+	tests := []struct {
+		seconds int
+		want    string
+	}{
+		{0, "0 秒"},
+		{59, "59 秒"},
+		{60, "1 分鐘"},
+		{3599, "59 分鐘"},
+		{3600, "1 小時"},
+		{4800, "1 小時 20 分鐘"},
+		{7200, "2 小時"},
+	}
+	for _, tt := range tests {
+		if got := FormatTravelTime(tt.seconds); got != tt.want {
+			t.Errorf("FormatTravelTime(%d) = %q, want %q", tt.seconds, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package ai
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Travel modes of a time-limited search, named as routing profiles
+const (
+	TravelFoot = "foot"
+	TravelBike = "bike"
+	TravelCar  = "car"
+)
+
+// travelTimePattern finds a time budget such as 十五分鐘內, 20分鐘可以到, 半小時內
+// or 一個半小時內: a number, a half, or both, then the unit
+var travelTimePattern = regexp.MustCompile(`(?:([0-9０-９]+|[一二兩两三四五六七八九十]+)\s*(?:個|个)?)?\s*(半)?\s*(?:個|个)?\s*(分鐘|分钟|分|小時|小时|鐘頭|钟头)\s*(?:以內|以内|之內|之内|內|内|可以到|可到|能到|到得了)`)
+
+// travelProfiles is the routing profile each transport mode's reach is
+// measured with. Scooters, buses and rail have no network of their own, so
+// they use the roads by car.
+var travelProfiles = map[TransportMode]string{
+	ModeWalk:    TravelFoot,
+	ModeRun:     TravelFoot,
+	ModeBike:    TravelBike,
+	ModeScooter: TravelCar,
+	ModeCar:     TravelCar,
+	ModeBus:     TravelCar,
+	ModeMRT:     TravelCar,
+	ModeTRA:     TravelCar,
+	ModeTHSR:    TravelCar,
+}
+
+// RoutingProfile names the routing profile trips by mode are routed with
+func RoutingProfile(mode TransportMode) string {
+	if profile, ok := travelProfiles[mode]; ok {
+		return profile
+	}
+	return TravelFoot
+}
+
+// clauseBreaks end the clause a time budget is in
+const clauseBreaks = "，,。；;！!？?"
+
+// ParseTravelBudget 從語音指令的字面找出時間範圍，例如「走路十五分鐘內」為 foot 15 分鐘
+func ParseTravelBudget(command string) (string, int, bool) {
+	loc := travelTimePattern.FindStringSubmatchIndex(command)
+	if loc == nil {
+		return "", 0, false
+	}
+	group := func(i int) string {
+		if loc[2*i] < 0 {
+			return ""
+		}
+		return command[loc[2*i]:loc[2*i+1]]
+	}
+
+	n := 0
+	if group(1) != "" {
+		parsed, ok := parseChineseNumber(group(1))
+		if !ok {
+			return "", 0, false
+		}
+		n = parsed
+	}
+	half := group(2) != ""
+
+	minutes := n * 60
+	if strings.HasPrefix(group(3), "分") {
+		if half {
+			return "", 0, false
+		}
+		minutes = n
+	} else if half {
+		minutes += 30
+	}
+	if minutes == 0 {
+		return "", 0, false
+	}
+
+	return travelProfile(command, loc[0], loc[1]), minutes, true
+}
+
+// travelProfile is the profile of the transport mode said in the same clause
+// as the budget at command[start:end], so 「搭車去台北，走路十分鐘內」 is on
+// foot; walking is assumed when none is said.
+func travelProfile(command string, start, end int) string {
+	before := command[:start]
+	if i := strings.LastIndexAny(before, clauseBreaks); i >= 0 {
+		_, size := utf8.DecodeRuneInString(before[i:])
+		before = before[i+size:]
+	}
+	after := command[end:]
+	if i := strings.IndexAny(after, clauseBreaks); i >= 0 {
+		after = after[:i]
+	}
+	if mode, ok := DetectTransportMode(before + command[start:end] + after); ok {
+		return RoutingProfile(mode)
+	}
+	return TravelFoot
+}
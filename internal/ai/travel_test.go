@@ -0,0 +1,49 @@
+package ai
+
+import "testing"
+
+func TestParseTravelBudget(t *testing.T) {
+	tests := []struct {
+		command string
+		mode    string
+		minutes int
+		ok      bool
+	}{
+		{"附近十五分鐘內的咖啡廳", TravelFoot, 15, true},
+		{"走路十五分鐘內有什麼好吃的", TravelFoot, 15, true},
+		{"半小時內可以到的公園", TravelFoot, 30, true},
+		{"開車一個半小時可以到的景點", TravelCar, 90, true},
+		{"２０分鐘內的便利商店", TravelFoot, 20, true},
+		{"騎車大概十分鐘內的早餐店", TravelCar, 10, true}, // 騎車 is a scooter
+		{"騎 YouBike 25分鐘內", TravelBike, 25, true},
+		{"騎腳踏車二十分鐘內", TravelBike, 20, true},
+		{"坐計程車兩小時內的溫泉", TravelCar, 120, true},
+		{"十分鐘內騎車可以到的夜市", TravelCar, 10, true},
+		{"搭捷運半小時內可以到的景點", TravelCar, 30, true},
+		{"搭公車二十分鐘內", TravelCar, 20, true},
+		{"慢跑十分鐘內的公園", TravelFoot, 10, true},
+
+		// A mode elsewhere in the sentence is not how the budget is traveled
+		{"我剛搭車到台北，走路十分鐘內有什麼", TravelFoot, 10, true},
+		{"搭車去之前，十分鐘內的早餐店", TravelFoot, 10, true},
+
+		{"半分鐘內", "", 0, false},
+		{"零分鐘內", "", 0, false},
+		{"附近有什麼好吃的", "", 0, false},
+		{"搭車去台北車站", "", 0, false},
+	}
+	for _, tt := range tests {
+		mode, minutes, ok := ParseTravelBudget(tt.command)
+		if mode != tt.mode || minutes != tt.minutes || ok != tt.ok {
+			t.Errorf("ParseTravelBudget(%q) = %q, %d, %v, want %q, %d, %v", tt.command, mode, minutes, ok, tt.mode, tt.minutes, tt.ok)
+		}
+	}
+}
+
+func TestTravelProfilesCoverTransportModes(t *testing.T) {
+	for mode := range transportProfiles {
+		if _, ok := travelProfiles[mode]; !ok {
+			t.Errorf("transport mode %q has no travel profile", mode)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"intelligent-spatial-platform/internal/ai"
 	"intelligent-spatial-platform/internal/game"
 	"intelligent-spatial-platform/internal/geo"
+	"intelligent-spatial-platform/internal/tiles"
 	"intelligent-spatial-platform/internal/voice"
 )
 
@@ -16,15 +17,21 @@ type Handler struct {
 	game  *game.Service
 	geo   *geo.Service
 	voice *voice.Service
+	tiles *tiles.Service
+
+	resources geo.Resources
 }
 
 // NewHandler creates a new handler with all service dependencies
-func NewHandler(db *gorm.DB, ai *ai.Service, game *game.Service, geo *geo.Service, voice *voice.Service) *Handler {
+func NewHandler(db *gorm.DB, ai *ai.Service, game *game.Service, geoService *geo.Service, voice *voice.Service, resources geo.Resources) *Handler {
 	return &Handler{
 		db:    db,
 		ai:    ai,
 		game:  game,
-		geo:   geo,
+		geo:   geoService,
 		voice: voice,
+		tiles: tiles.NewService(db, resources.TileCache),
+
+		resources: resources.WithDefaults(),
 	}
 }
\ No newline at end of file
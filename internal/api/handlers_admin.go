@@ -0,0 +1,277 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"intelligent-spatial-platform/internal/game"
+	"intelligent-spatial-platform/internal/geo"
+)
+
+// restrictedAreaRequest is the admin payload; geometry is a GeoJSON Polygon,
+// MultiPolygon or a Feature wrapping one
+type restrictedAreaRequest struct {
+	Name     string            `json:"name" binding:"required"`
+	Reason   string            `json:"reason"`
+	Severity string            `json:"severity"` // warn, block (default)
+	Schedule string            `json:"schedule"` // always (default), windows
+	Windows  []game.TimeWindow `json:"windows"`
+	IsActive *bool             `json:"isActive"`
+	Geometry json.RawMessage   `json:"geometry"`
+}
+
+func (r *restrictedAreaRequest) toArea() *game.RestrictedArea {
+	area := &game.RestrictedArea{
+		Name:     r.Name,
+		Reason:   r.Reason,
+		Severity: r.Severity,
+		Schedule: r.Schedule,
+		Windows:  r.Windows,
+		IsActive: true,
+	}
+	if r.IsActive != nil {
+		area.IsActive = *r.IsActive
+	}
+	return area
+}
+
+// ListRestrictedAreas returns all restricted-area geofences
+func (h *Handler) ListRestrictedAreas(c *gin.Context) {
+	areas, err := h.game.ListRestrictedAreas()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": areas})
+}
+
+// GetRestrictedArea returns one restricted area
+func (h *Handler) GetRestrictedArea(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	area, err := h.game.GetRestrictedArea(id)
+	if err != nil {
+		respondRecordError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": area})
+}
+
+// CreateRestrictedArea adds a geofence from GeoJSON
+func (h *Handler) CreateRestrictedArea(c *gin.Context) {
+	var request restrictedAreaRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	geometry, err := geo.ParsePolygonGeoJSON(request.Geometry)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid geometry: " + err.Error()})
+		return
+	}
+
+	area, err := h.game.CreateRestrictedArea(request.toArea(), geometry)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": area})
+}
+
+// UpdateRestrictedArea replaces a geofence; geometry is optional
+func (h *Handler) UpdateRestrictedArea(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var request restrictedAreaRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var geometry geo.GeoJSON
+	if len(request.Geometry) > 0 && string(request.Geometry) != "null" {
+		var err error
+		if geometry, err = geo.ParsePolygonGeoJSON(request.Geometry); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid geometry: " + err.Error()})
+			return
+		}
+	}
+
+	area, err := h.game.UpdateRestrictedArea(id, request.toArea(), geometry)
+	if err != nil {
+		respondRecordError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": area})
+}
+
+// DeleteRestrictedArea removes a geofence
+func (h *Handler) DeleteRestrictedArea(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.game.DeleteRestrictedArea(id); err != nil {
+		respondRecordError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// ListFlaggedPlayers returns players whose movement violation score reached the review threshold
+func (h *Handler) ListFlaggedPlayers(c *gin.Context) {
+	players, err := h.game.ListFlaggedPlayers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": players, "threshold": game.ViolationFlagThreshold})
+}
+
+// ListPlayerViolations returns a player's recent movement violations (?limit=, default 50)
+func (h *Handler) ListPlayerViolations(c *gin.Context) {
+	limit := 50
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+			return
+		}
+		limit = parsed
+	}
+
+	violations, err := h.game.ListMovementViolations(c.Param("id"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": violations})
+}
+
+// ResetPlayerViolations clears a player's violation score and flag after review
+func (h *Handler) ResetPlayerViolations(c *gin.Context) {
+	if err := h.game.ResetViolationScore(c.Param("id")); err != nil {
+		respondRecordError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// geocodeCache returns the cache, or responds 503 when caching is off
+func (h *Handler) geocodeCache(c *gin.Context) (*geo.GeocodeCache, bool) {
+	cache := h.geo.GeocodeCache()
+	if cache == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "geocode cache is not enabled"})
+		return nil, false
+	}
+	return cache, true
+}
+
+// ListGeocodeCache returns cached geocoding answers (?q= query prefix, ?limit= default 50) with hit statistics
+func (h *Handler) ListGeocodeCache(c *gin.Context) {
+	cache, ok := h.geocodeCache(c)
+	if !ok {
+		return
+	}
+
+	limit := 50
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+			return
+		}
+		limit = parsed
+	}
+
+	entries, err := cache.ListEntries(c.Query("q"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": entries, "stats": cache.Stats(), "config": cache.Config()})
+}
+
+// GetGeocodeCacheStats returns hit, miss and coalescing counts since startup
+func (h *Handler) GetGeocodeCacheStats(c *gin.Context) {
+	cache, ok := h.geocodeCache(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": cache.Stats()})
+}
+
+// DeleteGeocodeCacheEntry removes one cached answer
+func (h *Handler) DeleteGeocodeCacheEntry(c *gin.Context) {
+	cache, ok := h.geocodeCache(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := cache.DeleteEntry(id); err != nil {
+		respondRecordError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// PurgeGeocodeCache deletes the entries for ?q=, or every entry; ?expired=true keeps live ones
+func (h *Handler) PurgeGeocodeCache(c *gin.Context) {
+	cache, ok := h.geocodeCache(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := cache.Purge(c.Query("q"), c.Query("expired") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "deleted": deleted})
+}
+
+func parseIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondRecordError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -49,6 +49,16 @@ func (h *Handler) ChatWithAI(c *gin.Context) {
 			return
 		}
 
+		// Several places match: ask the player to choose
+		if err == nil && movementResult.Clarification != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"type":     "clarification",
+				"data":     movementResult,
+				"response": movementResult.Message,
+			})
+			return
+		}
+
 		// If rate limited, return error
 		if err == nil && movementResult.RateLimited {
 			c.JSON(http.StatusTooManyRequests, gin.H{
@@ -2,12 +2,15 @@ package api
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"intelligent-spatial-platform/internal/game"
 	"intelligent-spatial-platform/internal/geo"
+	"intelligent-spatial-platform/internal/geofile"
 )
 
 // GetGameStatus retrieves player game status
@@ -105,23 +108,122 @@ func (h *Handler) MovePlayer(c *gin.Context) {
 		return
 	}
 
-	if err := h.game.MovePlayer(request.PlayerID, request.Lat, request.Lng); err != nil {
+	result, err := h.game.MovePlayer(request.PlayerID, request.Lat, request.Lng, c.ClientIP())
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	nearbyHistoricalSite, err := h.geo.GetNearbyHistoricalSite(request.Lat, request.Lng, 100.0)
+	if !result.Success {
+		status := http.StatusUnprocessableEntity
+		if result.Violation.Code == game.ViolationRateLimited {
+			status = http.StatusTooManyRequests
+		}
+		c.JSON(status, gin.H{
+			"success":   false,
+			"error":     result.Violation.Message,
+			"errorCode": result.Violation.Code,
+			"data":      result,
+		})
+		return
+	}
+
+	position := result.Position
+	nearbyHistoricalSite, err := h.geo.GetNearbyHistoricalSite(position.Latitude, position.Longitude, 100.0)
 	if err == nil && nearbyHistoricalSite != nil {
 		introduction, _ := h.ai.GenerateHistoricalSiteIntroduction(nearbyHistoricalSite)
 		c.JSON(http.StatusOK, gin.H{
 			"success":        true,
-			"historicalSite": nearbyHistoricalSite,
+			"data":           result,
+			"historicalSite": nearbyHistoricalSite.WithMediaURLs(h.resources.Media),
 			"aiIntroduction": introduction,
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true})
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": result})
+}
+
+// ConfirmPendingMove answers a clarification question: the token from the clarification
+// payload and either a candidate ID or a choice such as "2" or "第二個"
+func (h *Handler) ConfirmPendingMove(c *gin.Context) {
+	var request struct {
+		PlayerID    string `json:"playerId" binding:"required"`
+		Token       string `json:"token" binding:"required"`
+		CandidateID string `json:"candidateId"`
+		Choice      string `json:"choice"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	choice := request.CandidateID
+	if choice == "" {
+		choice = request.Choice
+	}
+	if choice == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "candidateId or choice is required"})
+		return
+	}
+
+	sessionID := c.GetHeader("X-Session-ID")
+	if sessionID == "" {
+		sessionID = "web_session_" + request.PlayerID
+	}
+
+	result, err := h.game.ConfirmPendingMove(request.PlayerID, request.Token, choice, sessionID, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var status int
+	switch {
+	case result.Success:
+		status = http.StatusOK
+	case result.RateLimited:
+		status = http.StatusTooManyRequests
+	case result.ErrorCode == "CLARIFICATION_EXPIRED":
+		status = http.StatusGone
+	default:
+		status = http.StatusBadRequest
+	}
+
+	c.JSON(status, gin.H{"data": result})
+}
+
+// GetTravelStatus retrieves the player's in-progress trip
+func (h *Handler) GetTravelStatus(c *gin.Context) {
+	playerID := c.Query("playerId")
+	if playerID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "playerId is required"})
+		return
+	}
+
+	status := h.game.GetTravelStatus(playerID)
+	c.JSON(http.StatusOK, gin.H{"data": status, "travelling": status != nil})
+}
+
+// CancelTravel stops the player's trip at their current position
+func (h *Handler) CancelTravel(c *gin.Context) {
+	var request struct {
+		PlayerID string `json:"playerId" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := h.game.CancelTravel(request.PlayerID)
+	if status == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "player is not travelling"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": status})
 }
 
 // AIMovement handles AI-controlled movement
@@ -158,7 +260,7 @@ func (h *Handler) AIMovement(c *gin.Context) {
 
 	// Return different HTTP status based on result
 	var status int
-	if result.Success {
+	if result.Success || result.Clarification != nil {
 		status = http.StatusOK
 	} else if result.RateLimited {
 		status = http.StatusTooManyRequests
@@ -233,4 +335,91 @@ func (h *Handler) DebugMovement(c *gin.Context) {
 // generateSessionID generates a unique session ID
 func (h *Handler) generateSessionID() string {
 	return fmt.Sprintf("session_%d", time.Now().UnixNano())
-}
\ No newline at end of file
+}
+// ExportPlayerTrack downloads a player's movement history as GPX, KML or
+// GeoJSON, optionally limited to ?from= and ?to= (RFC 3339)
+func (h *Handler) ExportPlayerTrack(c *gin.Context) {
+	playerID := c.Param("id")
+	format, err := geofile.ParseFormat(c.DefaultQuery("format", "gpx"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	from, ok := parseTimeQuery(c, "from")
+	if !ok {
+		return
+	}
+	to, ok := parseTimeQuery(c, "to")
+	if !ok {
+		return
+	}
+
+	summary, err := h.game.GetTrackSummary(playerID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if summary.Count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no track points for this player and time range"})
+		return
+	}
+
+	name := fmt.Sprintf("Player %s track", playerID)
+	w, ok := startGeoFileDownload(c, format, "track-"+playerID, geofile.Metadata{Name: name, Time: summary.End})
+	if !ok {
+		return
+	}
+	if err := w.BeginTrack(&geofile.Line{Name: name, Start: summary.Start, End: summary.End}); err == nil {
+		err = h.game.StreamTrack(playerID, from, to, func(point game.TrackPoint) error {
+			return w.WriteTrackPoint(geofile.Point{Latitude: point.Latitude, Longitude: point.Longitude, Time: point.RecordedAt})
+		})
+		if err != nil {
+			log.Printf("Failed to export track of player %s: %v", playerID, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		log.Printf("Failed to export track of player %s: %v", playerID, err)
+	}
+}
+
+// ImportPlayerTrack adds the tracks of an uploaded GPX, KML or GeoJSON file to
+// a player's movement history
+func (h *Handler) ImportPlayerTrack(c *gin.Context) {
+	doc, ok := h.readGeoFile(c)
+	if !ok {
+		return
+	}
+
+	var points []game.TrackPoint
+	for _, track := range doc.Tracks {
+		for _, point := range track.Points {
+			points = append(points, game.TrackPoint{Latitude: point.Latitude, Longitude: point.Longitude, RecordedAt: point.Time})
+		}
+	}
+	if len(points) == 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "the file has no track"})
+		return
+	}
+
+	imported, err := h.game.ImportTrack(c.Param("id"), points)
+	if err != nil {
+		respondRecordError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": gin.H{"playerId": c.Param("id"), "imported": imported}})
+}
+
+// parseTimeQuery reads an optional RFC 3339 query parameter
+func parseTimeQuery(c *gin.Context, name string) (time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, true
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s must be an RFC 3339 time, e.g. 2024-03-01T08:00:00+08:00", name)})
+		return time.Time{}, false
+	}
+	return t, true
+}
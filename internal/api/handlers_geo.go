@@ -1,23 +1,29 @@
 package api
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"intelligent-spatial-platform/internal/coordinate"
 	"intelligent-spatial-platform/internal/geo"
+	"intelligent-spatial-platform/internal/geofile"
+	"intelligent-spatial-platform/internal/routing"
 )
 
-// GetLocations retrieves all locations
-func (h *Handler) GetLocations(c *gin.Context) {
-	locations, err := h.geo.GetAllLocations()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
+// maxTransformPoints limits the size of a single batch CRS conversion
+const maxTransformPoints = 1000
 
-	c.JSON(http.StatusOK, gin.H{"data": locations})
-}
+// maxGeoFileSize limits an uploaded GPX, KML or GeoJSON file
+const maxGeoFileSize = 10 << 20
 
 // CreateLocation creates a new location
 func (h *Handler) CreateLocation(c *gin.Context) {
@@ -35,17 +41,6 @@ func (h *Handler) CreateLocation(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"data": location})
 }
 
-// GetHistoricalSites retrieves all historical sites
-func (h *Handler) GetHistoricalSites(c *gin.Context) {
-	sites, err := h.geo.GetHistoricalSites()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"data": sites})
-}
-
 // SearchPlace searches for a place using geocoding service
 func (h *Handler) SearchPlace(c *gin.Context) {
 	var request struct {
@@ -57,8 +52,7 @@ func (h *Handler) SearchPlace(c *gin.Context) {
 		return
 	}
 
-	// Use the geocoding service which now has Google Places fallback
-	location, err := h.geo.GeocodeLocation(request.Query)
+	candidates, err := h.geo.GeocodeCandidates(request.Query)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "Location not found",
@@ -67,12 +61,380 @@ func (h *Handler) SearchPlace(c *gin.Context) {
 		return
 	}
 
+	location := candidates[0].Location
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
-			"name":      location.Name,
-			"latitude":  location.Latitude,
-			"longitude": location.Longitude,
+			"name":       location.Name,
+			"address":    location.Address,
+			"latitude":   location.Latitude,
+			"longitude":  location.Longitude,
+			"confidence": candidates[0].Confidence,
+		},
+		"candidates": candidates,
+		"ambiguous":  geo.IsAmbiguous(candidates),
+	})
+}
+
+// TransformCoordinates converts a batch of points between coordinate reference systems
+func (h *Handler) TransformCoordinates(c *gin.Context) {
+	var request struct {
+		From   string             `json:"from" binding:"required"` // e.g. EPSG:3826, TWD97
+		To     string             `json:"to" binding:"required"`   // e.g. EPSG:4326, WGS84
+		Points []coordinate.Point `json:"points" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(request.Points) > maxTransformPoints {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("too many points: max %d per request", maxTransformPoints)})
+		return
+	}
+
+	from, err := coordinate.ParseCRS(request.From)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	to, err := coordinate.ParseCRS(request.To)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	points, err := coordinate.Transform(from, to, request.Points)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"from":   from,
+			"to":     to,
+			"points": points,
 		},
 	})
-}
\ No newline at end of file
+}
+
+// LookupAdminArea returns the county/city and district containing a coordinate
+func (h *Handler) LookupAdminArea(c *gin.Context) {
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.Query("lng"), 64)
+	if errLat != nil || errLng != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lng query parameters are required"})
+		return
+	}
+
+	area := h.resources.Boundaries.Lookup(lat, lng)
+	if area == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "coordinate is outside Taiwan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": area})
+}
+
+// ReverseGeocode describes what is at a coordinate: county, district, nearest road or
+// landmark and nearest historical site
+func (h *Handler) ReverseGeocode(c *gin.Context) {
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.Query("lng"), 64)
+	if errLat != nil || errLng != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lng query parameters are required"})
+		return
+	}
+
+	place, err := h.geo.ReverseGeocode(lat, lng)
+	if errors.Is(err, geo.ErrOutsideTaiwan) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": place, "summary": place.Summary()})
+}
+
+// SearchGazetteer looks up local place names, aliases and romanizations (?q=, limit default 10)
+func (h *Handler) SearchGazetteer(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q query parameter is required"})
+		return
+	}
+
+	limit := 10
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > 50 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 50"})
+			return
+		}
+		limit = parsed
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": h.resources.Gazetteer.Search(query, limit)})
+}
+
+// CreateRoute computes a road route through the given points for a travel profile and saves it
+func (h *Handler) CreateRoute(c *gin.Context) {
+	var request struct {
+		Profile string         `json:"profile"` // foot (default), bike, car
+		Name    string         `json:"name"`
+		Points  []geo.Waypoint `json:"points" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile := routing.Foot
+	if request.Profile != "" {
+		parsed, err := routing.ParseProfile(request.Profile)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		profile = parsed
+	}
+	if len(request.Points) < 2 || len(request.Points) > geo.MaxRouteStops {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("points must have between 2 and %d entries", geo.MaxRouteStops)})
+		return
+	}
+
+	route, err := h.geo.CalculateRoute(profile, request.Points, request.Name)
+	var snapErr *routing.SnapError
+	switch {
+	case errors.Is(err, routing.ErrNoRoadNetwork):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	case errors.As(err, &snapErr), errors.Is(err, routing.ErrNoRoute):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": route})
+}
+
+// GetRoute returns a saved route with its geometry and waypoints
+func (h *Handler) GetRoute(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	route, err := h.geo.GetRoute(id)
+	if err != nil {
+		respondRecordError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": route})
+}
+
+// ImportRoute saves the first route, or else the first track, of an uploaded
+// GPX, KML or GeoJSON file
+func (h *Handler) ImportRoute(c *gin.Context) {
+	profile := routing.Foot
+	if name := c.Query("profile"); name != "" {
+		parsed, err := routing.ParseProfile(name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		profile = parsed
+	}
+
+	doc, ok := h.readGeoFile(c)
+	if !ok {
+		return
+	}
+	if len(doc.Routes) == 0 && len(doc.Tracks) == 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "the file has no route or track"})
+		return
+	}
+
+	route, err := h.geo.ImportRoute(doc, profile)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": route})
+}
+
+// ExportRoute downloads a saved route as GPX, KML or GeoJSON
+func (h *Handler) ExportRoute(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	format, err := geofile.ParseFormat(c.DefaultQuery("format", "gpx"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	route, err := h.geo.GetRoute(id)
+	if err != nil {
+		respondRecordError(c, err)
+		return
+	}
+
+	w, ok := startGeoFileDownload(c, format, fmt.Sprintf("route-%d", route.ID), geofile.Metadata{
+		Name:        route.Name,
+		Description: route.Description,
+		Time:        route.CreatedAt,
+	})
+	if !ok {
+		return
+	}
+	if err := geo.ExportRoute(w, route); err != nil {
+		log.Printf("Failed to export route %d: %v", route.ID, err)
+	}
+	if err := w.Close(); err != nil {
+		log.Printf("Failed to export route %d: %v", route.ID, err)
+	}
+}
+
+// GetIsochrones returns the areas reachable from a point within each travel
+// time as a GeoJSON FeatureCollection of Polygons: ?lat=&lng= or ?playerId=,
+// ?profile= (foot, the default, bike or car) and ?minutes=5,10,15 (default 15)
+func (h *Handler) GetIsochrones(c *gin.Context) {
+	var lat, lng float64
+	if playerID := c.Query("playerId"); playerID != "" && c.Query("lat") == "" && c.Query("lng") == "" {
+		player, err := h.game.GetPlayerStatus(playerID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "player not found"})
+			return
+		}
+		lat, lng = player.Latitude, player.Longitude
+	} else {
+		var errLat, errLng error
+		lat, errLat = strconv.ParseFloat(c.Query("lat"), 64)
+		lng, errLng = strconv.ParseFloat(c.Query("lng"), 64)
+		if errLat != nil || errLng != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lng, or playerId, are required"})
+			return
+		}
+	}
+
+	profile := routing.Foot
+	if name := c.Query("profile"); name != "" {
+		parsed, err := routing.ParseProfile(name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		profile = parsed
+	}
+
+	var minutes []int
+	for _, part := range strings.Split(c.DefaultQuery("minutes", "15"), ",") {
+		value, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || value < 1 || value > geo.MaxIsochroneMinutes {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("minutes must be whole numbers between 1 and %d", geo.MaxIsochroneMinutes)})
+			return
+		}
+		minutes = append(minutes, value)
+	}
+	if len(minutes) > geo.MaxIsochrones {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d travel times may be asked for", geo.MaxIsochrones)})
+		return
+	}
+
+	features, err := h.geo.Isochrones(profile, lat, lng, minutes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", geoJSONContentType)
+	c.JSON(http.StatusOK, gin.H{"type": "FeatureCollection", "features": features})
+}
+
+// readGeoFile reads an uploaded file, sent either as the raw body or as the
+// "file" field of a multipart form, and validates it against Taiwan. The format
+// comes from ?format=, else the file name or content type.
+func (h *Handler) readGeoFile(c *gin.Context) (*geofile.Document, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxGeoFileSize)
+
+	var body io.Reader = c.Request.Body
+	filename, contentType := "", c.ContentType()
+	if contentType == "multipart/form-data" {
+		header, err := c.FormFile("file")
+		if err != nil {
+			respondGeoFileReadError(c, err)
+			return nil, false
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		defer file.Close()
+		body, filename, contentType = file, header.Filename, header.Header.Get("Content-Type")
+	}
+
+	var format geofile.Format
+	var err error
+	if name := c.Query("format"); name != "" {
+		format, err = geofile.ParseFormat(name)
+	} else {
+		format, err = geofile.DetectFormat(filename, contentType)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		respondGeoFileReadError(c, err)
+		return nil, false
+	}
+	doc, err := geofile.Read(bytes.NewReader(data), format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if err := doc.Validate(h.resources.Boundaries.InTerritory); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return doc, true
+}
+
+func respondGeoFileReadError(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("file is larger than %d MB", maxGeoFileSize>>20)})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
+// startGeoFileDownload sends the headers of a file download and starts the
+// document on the response. Errors after this can only be logged.
+func startGeoFileDownload(c *gin.Context, format geofile.Format, basename string, metadata geofile.Metadata) (geofile.Writer, bool) {
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, url.PathEscape(basename), format.Extension()))
+	c.Status(http.StatusOK)
+
+	w, err := geofile.NewWriter(c.Writer, format, metadata)
+	if err != nil {
+		log.Printf("Failed to start %s download: %v", format, err)
+		return nil, false
+	}
+	return w, true
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"intelligent-spatial-platform/internal/media"
+)
+
+// ServeMedia serves an uploaded image, thumbnail or audio guide through a
+// signed URL. Range requests are supported so audio can be seeked.
+func (h *Handler) ServeMedia(c *gin.Context) {
+	store := h.resources.Media
+	if store == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if !media.ValidKey(key) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	expires := c.Query("expires")
+	if err := store.Signer().Verify(key, expires, c.Query("sig")); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	object, err := store.Storage().Open(key)
+	if errors.Is(err, media.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to open media %s: %v", key, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open media"})
+		return
+	}
+	defer object.Close()
+
+	// The content never changes under a key, so it can be cached for as long as the URL is valid
+	maxAge := max(int(time.Until(store.Signer().Expiry(expires)).Seconds()), 0)
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d, immutable", maxAge))
+	c.Header("Content-Type", media.ContentType(key))
+	c.Header("X-Content-Type-Options", "nosniff")
+	http.ServeContent(c.Writer, c.Request, "", object.ModTime(), object)
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"intelligent-spatial-platform/internal/geo"
+)
+
+// geoJSONContentType is the media type of GeoJSON
+const geoJSONContentType = "application/geo+json"
+
+// defaultQueryRadius is the radius around lat/lng when none is given, in meters
+const defaultQueryRadius = 1000.0
+
+// GetLocations lists locations with optional filters and cursor pagination
+func (h *Handler) GetLocations(c *gin.Context) {
+	query, ok := parsePlaceQuery(c)
+	if !ok {
+		return
+	}
+	asGeoJSON, ok := wantsGeoJSON(c)
+	if !ok {
+		return
+	}
+
+	locations, next, err := h.geo.QueryLocations(query)
+	if err != nil {
+		respondPlaceQueryError(c, err)
+		return
+	}
+
+	respondPlaces(c, locations, next, asGeoJSON)
+}
+
+// GetHistoricalSites lists active historical sites with optional filters and
+// cursor pagination; uploaded media come as signed URLs
+func (h *Handler) GetHistoricalSites(c *gin.Context) {
+	query, ok := parsePlaceQuery(c)
+	if !ok {
+		return
+	}
+	asGeoJSON, ok := wantsGeoJSON(c)
+	if !ok {
+		return
+	}
+
+	sites, next, err := h.geo.QueryHistoricalSites(query)
+	if err != nil {
+		respondPlaceQueryError(c, err)
+		return
+	}
+
+	store := h.resources.Media
+	for i := range sites {
+		sites[i].HistoricalSite = *sites[i].HistoricalSite.WithMediaURLs(store)
+	}
+	respondPlaces(c, sites, next, asGeoJSON)
+}
+
+// parsePlaceQuery reads ?bbox=west,south,east,north, ?lat=&lng=&radius=
+// (meters), ?type=, ?era=, ?q=, ?sort=, ?cursor= and ?limit=
+func parsePlaceQuery(c *gin.Context) (geo.PlaceQuery, bool) {
+	query := geo.PlaceQuery{
+		Type:   c.Query("type"),
+		Era:    c.Query("era"),
+		Text:   c.Query("q"),
+		Sort:   c.Query("sort"),
+		Cursor: c.Query("cursor"),
+	}
+
+	if value := c.Query("bbox"); value != "" {
+		box, err := geo.ParseBBox(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return query, false
+		}
+		query.BBox = box
+	}
+
+	if c.Query("lat") != "" || c.Query("lng") != "" {
+		lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+		lng, errLng := strconv.ParseFloat(c.Query("lng"), 64)
+		if errLat != nil || errLng != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lng must be given together as valid coordinates"})
+			return query, false
+		}
+		radius := defaultQueryRadius
+		if value := c.Query("radius"); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "radius must be a number of meters"})
+				return query, false
+			}
+			radius = parsed
+		}
+		query.Near = &geo.Near{Latitude: lat, Longitude: lng, Radius: radius}
+	} else if c.Query("radius") != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "radius needs lat and lng"})
+		return query, false
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > geo.MaxPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", geo.MaxPageSize)})
+			return query, false
+		}
+		query.Limit = limit
+	}
+	return query, true
+}
+
+// wantsGeoJSON picks the response format from ?format=json|geojson, or else
+// from an Accept header naming application/geo+json
+func wantsGeoJSON(c *gin.Context) (bool, bool) {
+	switch strings.ToLower(c.Query("format")) {
+	case "":
+		return strings.Contains(c.GetHeader("Accept"), geoJSONContentType), true
+	case "json":
+		return false, true
+	case "geojson":
+		return true, true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or geojson"})
+	return false, false
+}
+
+func respondPlaceQueryError(c *gin.Context, err error) {
+	var queryErr *geo.QueryError
+	if errors.As(err, &queryErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// respondPlaces sends a page as {"data": [...], "nextCursor": ...} or as a
+// GeoJSON FeatureCollection with nextCursor as a foreign member
+func respondPlaces(c *gin.Context, places interface{}, next string, asGeoJSON bool) {
+	if !asGeoJSON {
+		response := gin.H{"data": places}
+		if next != "" {
+			response["nextCursor"] = next
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	features, err := placeFeatures(places)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	collection := gin.H{"type": "FeatureCollection", "features": features}
+	if next != "" {
+		collection["nextCursor"] = next
+	}
+	c.Header("Content-Type", geoJSONContentType)
+	c.JSON(http.StatusOK, collection)
+}
+
+// placeFeatures turns places into Point features whose properties are the
+// places' JSON fields without latitude and longitude
+func placeFeatures(places interface{}) ([]geo.GeoFeature, error) {
+	data, err := json.Marshal(places)
+	if err != nil {
+		return nil, err
+	}
+	var objects []map[string]interface{}
+	if err := json.Unmarshal(data, &objects); err != nil {
+		return nil, err
+	}
+
+	features := make([]geo.GeoFeature, len(objects))
+	for i, properties := range objects {
+		latitude, _ := properties["latitude"].(float64)
+		longitude, _ := properties["longitude"].(float64)
+		delete(properties, "latitude")
+		delete(properties, "longitude")
+		features[i] = geo.GeoFeature{
+			Type:       "Feature",
+			Properties: properties,
+			Geometry:   geo.Geometry{Type: "Point", Coordinates: []float64{longitude, latitude}},
+		}
+	}
+	return features, nil
+}
+
+// GetPlaceDetails returns Google Place Details for a place ID, such as the
+// placeId of a nearby search result
+func (h *Handler) GetPlaceDetails(c *gin.Context) {
+	details, err := h.geo.GetPlaceDetails(c.Param("placeId"))
+	if err != nil {
+		respondPlaceDetailsError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": details})
+}
+
+func respondPlaceDetailsError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, geo.ErrInvalidPlaceID):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, geo.ErrPlaceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, geo.ErrPlacesUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Place details lookup failed: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "place details lookup failed"})
+	}
+}
@@ -0,0 +1,314 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"intelligent-spatial-platform/internal/geo"
+	"intelligent-spatial-platform/internal/media"
+)
+
+// historicalSiteRequest is the admin payload. Images may list external URLs
+// when creating a site; uploaded images and the audio guide have their own
+// endpoints.
+type historicalSiteRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	Era         string   `json:"era"`
+	Latitude    float64  `json:"latitude" binding:"required"`
+	Longitude   float64  `json:"longitude" binding:"required"`
+	Address     string   `json:"address"`
+	Images      []string `json:"images"`
+	IsActive    *bool    `json:"isActive"`
+}
+
+func (r *historicalSiteRequest) toSite() (*geo.HistoricalSite, error) {
+	for _, image := range r.Images {
+		if !strings.HasPrefix(image, "https://") && !strings.HasPrefix(image, "http://") {
+			return nil, fmt.Errorf("images must be http(s) URLs; upload files to /historical-sites/:id/images")
+		}
+	}
+	site := &geo.HistoricalSite{
+		Name:        r.Name,
+		Description: r.Description,
+		Era:         r.Era,
+		Latitude:    r.Latitude,
+		Longitude:   r.Longitude,
+		Address:     r.Address,
+		Images:      r.Images,
+		IsActive:    true,
+	}
+	if r.IsActive != nil {
+		site.IsActive = *r.IsActive
+	}
+	return site, nil
+}
+
+// ListAdminHistoricalSites returns every historical site, inactive ones included
+func (h *Handler) ListAdminHistoricalSites(c *gin.Context) {
+	sites, err := h.geo.ListHistoricalSites()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": h.withMediaURLs(sites)})
+}
+
+// GetAdminHistoricalSite returns one historical site, active or not
+func (h *Handler) GetAdminHistoricalSite(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	site, err := h.geo.GetHistoricalSite(id)
+	if err != nil {
+		respondRecordError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": site.WithMediaURLs(h.resources.Media)})
+}
+
+// CreateHistoricalSite adds a historical site
+func (h *Handler) CreateHistoricalSite(c *gin.Context) {
+	var request historicalSiteRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	site, err := request.toSite()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.geo.CreateHistoricalSite(site); err != nil {
+		respondSiteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": site.WithMediaURLs(h.resources.Media)})
+}
+
+// UpdateHistoricalSite replaces a site's fields; its images and audio guide are kept
+func (h *Handler) UpdateHistoricalSite(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var request historicalSiteRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(request.Images) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "images cannot be replaced here; use the image endpoints"})
+		return
+	}
+
+	site, err := request.toSite()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	updated, err := h.geo.UpdateHistoricalSite(id, site)
+	if err != nil {
+		respondSiteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": updated.WithMediaURLs(h.resources.Media)})
+}
+
+// respondSiteError reports an invalid site as 400 and a missing one as 404;
+// anything else is a storage failure, logged rather than shown to the admin
+func respondSiteError(c *gin.Context, err error) {
+	var siteErr *geo.SiteError
+	switch {
+	case errors.As(err, &siteErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	default:
+		log.Printf("Failed to save historical site: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save historical site"})
+	}
+}
+
+// DeleteHistoricalSite deactivates a site; it can be restored by updating isActive
+func (h *Handler) DeleteHistoricalSite(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.geo.DeactivateHistoricalSite(id); err != nil {
+		respondRecordError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// UploadHistoricalSiteImage stores the image in the multipart "file" field,
+// generates its thumbnail and appends it to the site
+func (h *Handler) UploadHistoricalSiteImage(c *gin.Context) {
+	h.uploadHistoricalSiteMedia(c, media.MaxImageSize, func(store *media.Service, id uint, header *multipart.FileHeader, file multipart.File) (*geo.HistoricalSite, error) {
+		key, err := store.SaveImage(siteMediaPrefix(id), file)
+		if err != nil {
+			return nil, err
+		}
+		site, err := h.geo.AddHistoricalSiteImage(id, key)
+		if err != nil {
+			deleteMedia(store, key)
+			return nil, err
+		}
+		return site, nil
+	})
+}
+
+// DeleteHistoricalSiteImage removes the image at :index and deletes its files
+func (h *Handler) DeleteHistoricalSiteImage(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid image index"})
+		return
+	}
+
+	site, removed, err := h.geo.RemoveHistoricalSiteImage(id, index)
+	if err != nil {
+		respondRecordError(c, err)
+		return
+	}
+	deleteMedia(h.resources.Media, removed)
+
+	c.JSON(http.StatusOK, gin.H{"data": site.WithMediaURLs(h.resources.Media)})
+}
+
+// UploadHistoricalSiteAudio stores the audio guide in the multipart "file"
+// field, replacing the previous one
+func (h *Handler) UploadHistoricalSiteAudio(c *gin.Context) {
+	h.uploadHistoricalSiteMedia(c, media.MaxAudioSize, func(store *media.Service, id uint, header *multipart.FileHeader, file multipart.File) (*geo.HistoricalSite, error) {
+		key, err := store.SaveAudio(siteMediaPrefix(id), header.Filename, file)
+		if err != nil {
+			return nil, err
+		}
+		site, previous, err := h.geo.SetHistoricalSiteAudio(id, key)
+		if err != nil {
+			deleteMedia(store, key)
+			return nil, err
+		}
+		deleteMedia(store, previous)
+		return site, nil
+	})
+}
+
+// DeleteHistoricalSiteAudio removes a site's audio guide
+func (h *Handler) DeleteHistoricalSiteAudio(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	site, previous, err := h.geo.SetHistoricalSiteAudio(id, "")
+	if err != nil {
+		respondRecordError(c, err)
+		return
+	}
+	deleteMedia(h.resources.Media, previous)
+
+	c.JSON(http.StatusOK, gin.H{"data": site.WithMediaURLs(h.resources.Media)})
+}
+
+// uploadHistoricalSiteMedia reads the "file" field of a multipart upload of at
+// most limit bytes for the site in :id and hands it to save
+func (h *Handler) uploadHistoricalSiteMedia(c *gin.Context, limit int64, save func(*media.Service, uint, *multipart.FileHeader, multipart.File) (*geo.HistoricalSite, error)) {
+	store := h.resources.Media
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "media storage is not enabled"})
+		return
+	}
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	// Check the site first so an upload for a missing site stores nothing
+	if _, err := h.geo.GetHistoricalSite(id); err != nil {
+		respondRecordError(c, err)
+		return
+	}
+
+	// Leave room for the multipart headers around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+1<<20)
+	header, err := c.FormFile("file")
+	if err != nil {
+		respondMediaError(c, err, limit)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	site, err := save(store, id, header, file)
+	if err != nil {
+		respondMediaError(c, err, limit)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": site.WithMediaURLs(store)})
+}
+
+func respondMediaError(c *gin.Context, err error, limit int64) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge), errors.Is(err, media.ErrTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("file is larger than %d MB", limit>>20)})
+	case errors.Is(err, media.ErrUnsupportedType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "unsupported file type; images must be JPEG, PNG or GIF and audio MP3, M4A, OGG, WAV or WebM"})
+	default:
+		respondRecordError(c, err)
+	}
+}
+
+func siteMediaPrefix(id uint) string {
+	return "sites/" + strconv.FormatUint(uint64(id), 10)
+}
+
+// deleteMedia removes a replaced or removed file. A failure only leaves an
+// unreferenced file behind, so it is logged.
+func deleteMedia(store *media.Service, key string) {
+	if store == nil || key == "" {
+		return
+	}
+	if err := store.Delete(key); err != nil {
+		log.Printf("Failed to delete media %s: %v", key, err)
+	}
+}
+
+// withMediaURLs signs the media of each site
+func (h *Handler) withMediaURLs(sites []geo.HistoricalSite) []*geo.HistoricalSite {
+	store := h.resources.Media
+	signed := make([]*geo.HistoricalSite, len(sites))
+	for i := range sites {
+		signed[i] = sites[i].WithMediaURLs(store)
+	}
+	return signed
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"intelligent-spatial-platform/internal/geo"
+	"intelligent-spatial-platform/internal/tiles"
+)
+
+// mvtContentType is the media type of a Mapbox Vector Tile
+const mvtContentType = "application/vnd.mapbox-vector-tile"
+
+// GetTile serves one Mapbox Vector Tile of locations, historical sites or items
+func (h *Handler) GetTile(c *gin.Context) {
+	tile, err := tiles.ParseTile(c.Param("z"), c.Param("x"), c.Param("y"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.tiles.Tile(c.Param("layer"), tile)
+	if errors.Is(err, tiles.ErrUnknownLayer) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown layer, use " + strings.Join(tiles.LayerNames(), ", ")})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("ETag", result.ETag)
+	// Clients revalidate every time; unchanged tiles cost a 304
+	c.Header("Cache-Control", "no-cache")
+	if match := c.GetHeader("If-None-Match"); match != "" && etagMatches(match, result.ETag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	if len(result.Data) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.Data(http.StatusOK, mvtContentType, result.Data)
+}
+
+// GetClusters returns the locations, historical sites and items in ?bbox= as
+// clusters for ?zoom=, with how many points of each layer they hold and the
+// zoom at which they split up. Single points carry their layer, id and name.
+func (h *Handler) GetClusters(c *gin.Context) {
+	box, err := geo.ParseBBox(c.Query("bbox"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	zoom, err := strconv.Atoi(c.Query("zoom"))
+	if err != nil || zoom < 0 || zoom > tiles.MaxZoom {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("zoom must be between 0 and %d", tiles.MaxZoom)})
+		return
+	}
+	asGeoJSON, ok := wantsGeoJSON(c)
+	if !ok {
+		return
+	}
+
+	index := h.resources.Clusters
+	if index == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "clustering is not enabled"})
+		return
+	}
+	respondPlaces(c, index.Clusters(box.West, box.South, box.East, box.North, zoom), "", asGeoJSON)
+}
+
+// etagMatches checks an If-None-Match header, which may list several tags or be *
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -5,11 +5,14 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"intelligent-spatial-platform/internal/ai"
+	"intelligent-spatial-platform/internal/game"
 	"intelligent-spatial-platform/internal/geo"
+	"intelligent-spatial-platform/internal/routing"
 )
 
 // ProcessVoice processes voice audio input
@@ -67,6 +70,14 @@ func (h *Handler) ProcessVoiceCommand(c *gin.Context) {
 		}
 	}
 
+	// "第二個" answers an open clarification without another AI call
+	if pending := h.game.GetPendingMove(request.PlayerID); pending != nil {
+		if _, ok := ai.ParseCandidateSelection(request.Command, len(pending.Candidates)); ok {
+			h.confirmPendingMove(c, request.PlayerID, pending.Token, request.Command)
+			return
+		}
+	}
+
 	// Parse intent using AI (with per-user rate limiting)
 	intentParser := ai.NewIntentParser(h.ai, h.geo.GetGeocoding())
 	intent, err := intentParser.ParseVoiceCommandWithUser(request.PlayerID, request.Command, currentLocation)
@@ -118,7 +129,9 @@ func (h *Handler) ProcessVoiceCommand(c *gin.Context) {
 	case ai.IntentDescribe:
 		h.handleDescribeIntent(c, intent, currentLocation)
 	case ai.IntentRecommend:
-		h.handleRecommendIntent(c, intent, currentLocation)
+		h.handleRecommendIntent(c, intent, currentLocation, request.PlayerID)
+	case ai.IntentDetails:
+		h.handleDetailsIntent(c, intent, request.PlayerID)
 	default:
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown intent type"})
 	}
@@ -133,23 +146,37 @@ func (h *Handler) handleSearchIntent(
 ) {
 	// This handler is only for "nearby list search" (附近有什麼)
 	// Build search query with current location context
-	log.Printf("🔍 User wants nearby list search: category=%s, keywords=%v", intent.Category, intent.Keywords)
+	log.Printf("🔍 User wants nearby list search: category=%s, keywords=%v, filters=%+v", intent.Category, intent.Keywords, intent.Filters)
 
 	// Execute nearby search using PostGIS
-	nearbyService := geo.NewNearbySearchService(h.db, h.geo.GetGeocoding())
+	nearbyService := geo.NewNearbySearchService(h.db, h.geo.GetGeocoding(), h.resources)
 
 	radius := intent.Radius
 	if radius == 0 {
 		radius = 500 // Default 500 meters
 	}
 
-	results, err := nearbyService.SearchNearby(
-		currentLocation.Latitude,
-		currentLocation.Longitude,
-		string(intent.Category),
-		radius,
-		10, // Limit to 10 results
-	)
+	var results *geo.NearbySearchResult
+	var err error
+	if area := h.travelArea(intent, currentLocation); area != nil {
+		results, err = nearbyService.SearchNearbyInArea(
+			currentLocation.Latitude,
+			currentLocation.Longitude,
+			string(intent.Category),
+			area,
+			10,
+			intent.Filters,
+		)
+	} else {
+		results, err = nearbyService.SearchNearbyWithFilters(
+			currentLocation.Latitude,
+			currentLocation.Longitude,
+			string(intent.Category),
+			radius,
+			10, // Limit to 10 results
+			intent.Filters,
+		)
+	}
 	if err != nil {
 		log.Printf("❌ Nearby search failed: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed"})
@@ -157,6 +184,7 @@ func (h *Handler) handleSearchIntent(
 	}
 
 	log.Printf("✅ Found %d nearby locations", results.Total)
+	h.game.RememberNearbyResults(playerID, results.Locations)
 
 	// Generate AI narration
 	narrator := ai.NewNearbyNarrator(h.ai)
@@ -226,6 +254,27 @@ func (h *Handler) handleMoveIntent(
 		return
 	}
 
+	h.respondVoiceMovement(c, movementResult)
+}
+
+// confirmPendingMove executes the candidate chosen by a spoken reply
+func (h *Handler) confirmPendingMove(c *gin.Context, playerID, token, choice string) {
+	sessionID := c.GetHeader("X-Session-ID")
+	if sessionID == "" {
+		sessionID = "web_session_" + playerID
+	}
+
+	movementResult, err := h.game.ConfirmPendingMove(playerID, token, choice, sessionID, c.ClientIP())
+	if err != nil {
+		log.Printf("❌ Pending move confirmation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respondVoiceMovement(c, movementResult)
+}
+
+func (h *Handler) respondVoiceMovement(c *gin.Context, movementResult *game.AIMovementResult) {
 	if movementResult.RateLimited {
 		c.JSON(http.StatusTooManyRequests, gin.H{
 			"success":     false,
@@ -239,6 +288,20 @@ func (h *Handler) handleMoveIntent(
 	// Get usage stats from context
 	usageStats, _ := c.Get("usageStats")
 
+	// Several places match: the client lists the options and replies with the token
+	if movementResult.Clarification != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success":            false,
+			"intentType":         "move",
+			"needsClarification": true,
+			"clarification":      movementResult.Clarification,
+			"movement":           movementResult,
+			"aiResponse":         movementResult.Message,
+			"usageStats":         usageStats,
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success":    true,
 		"intentType": "move",
@@ -254,16 +317,15 @@ func (h *Handler) handleDescribeIntent(
 	intent *ai.VoiceIntent,
 	currentLocation *geo.Location,
 ) {
-	// Simple location description
-	description := fmt.Sprintf("目前位置：緯度 %.6f，經度 %.6f",
-		currentLocation.Latitude, currentLocation.Longitude)
-
-	// Generate AI description
-	prompt := fmt.Sprintf("用戶想知道他現在的位置。座標是緯度 %.6f，經度 %.6f。請用親切的語氣回應（50字內）。",
-		currentLocation.Latitude, currentLocation.Longitude)
-	aiResponse, err := h.ai.Chat(prompt, "你是友善的旅遊助手")
+	// Ground the answer in what is actually at the coordinate
+	place, err := h.geo.ReverseGeocode(currentLocation.Latitude, currentLocation.Longitude)
+	var aiResponse string
 	if err != nil {
-		aiResponse = description
+		// Outside every county: all we know is the coordinate
+		aiResponse = fmt.Sprintf("目前位置：緯度 %.6f，經度 %.6f",
+			currentLocation.Latitude, currentLocation.Longitude)
+	} else {
+		aiResponse = ai.NewNearbyNarrator(h.ai).DescribeLocation(place)
 	}
 
 	// Get usage stats from context
@@ -273,26 +335,61 @@ func (h *Handler) handleDescribeIntent(
 		"success":    true,
 		"intentType": "describe",
 		"location":   currentLocation,
+		"place":      place,
 		"aiResponse": aiResponse,
 		"usageStats": usageStats,
 	})
 }
 
+// travelArea returns the isochrone a command like "走路十五分鐘內" limits the
+// search to, or nil to search a radius
+func (h *Handler) travelArea(intent *ai.VoiceIntent, currentLocation *geo.Location) *geo.Area {
+	if intent.TravelMinutes <= 0 {
+		return nil
+	}
+	profile, err := routing.ParseProfile(intent.TravelMode)
+	if err != nil {
+		profile = routing.Foot
+	}
+
+	area, err := h.geo.ReachableArea(profile, currentLocation.Latitude, currentLocation.Longitude, intent.TravelMinutes)
+	if err != nil {
+		log.Printf("⚠️ Isochrone failed: %v, searching a radius instead", err)
+		return nil
+	}
+	return area
+}
+
 // handleRecommendIntent processes recommendation intent
 func (h *Handler) handleRecommendIntent(
 	c *gin.Context,
 	intent *ai.VoiceIntent,
 	currentLocation *geo.Location,
+	playerID string,
 ) {
 	// Search nearby locations for recommendation
-	nearbyService := geo.NewNearbySearchService(h.db, h.geo.GetGeocoding())
-	results, err := nearbyService.SearchNearby(
-		currentLocation.Latitude,
-		currentLocation.Longitude,
-		string(intent.Category),
-		1000, // 1km radius for recommendations
-		5,    // Top 5
-	)
+	nearbyService := geo.NewNearbySearchService(h.db, h.geo.GetGeocoding(), h.resources)
+	var results *geo.NearbySearchResult
+	var err error
+	if area := h.travelArea(intent, currentLocation); area != nil {
+		results, err = nearbyService.SearchNearbyInArea(
+			currentLocation.Latitude,
+			currentLocation.Longitude,
+			string(intent.Category),
+			area,
+			5,
+			intent.Filters,
+		)
+	} else {
+		results, err = nearbyService.SearchNearbyWithFilters(
+			currentLocation.Latitude,
+			currentLocation.Longitude,
+			string(intent.Category),
+			1000, // 1km radius for recommendations
+			5,    // Top 5
+			intent.Filters,
+		)
+	}
 
 	if err != nil || results.Total == 0 {
 		c.JSON(http.StatusOK, gin.H{
@@ -303,6 +400,9 @@ func (h *Handler) handleRecommendIntent(
 		return
 	}
 
+	recommendations := results.Locations[:min(3, len(results.Locations))]
+	h.game.RememberNearbyResults(playerID, recommendations)
+
 	// Generate recommendation using AI
 	categoryName := ai.CategoryToChineseName(intent.Category)
 	narrator := ai.NewNearbyNarrator(h.ai)
@@ -319,12 +419,57 @@ func (h *Handler) handleRecommendIntent(
 	c.JSON(http.StatusOK, gin.H{
 		"success":         true,
 		"intentType":      "recommend",
-		"recommendations": results.Locations[:min(3, len(results.Locations))],
+		"recommendations": recommendations,
 		"aiResponse":      aiResponse,
 		"usageStats":      usageStats,
 	})
 }
 
+// handleDetailsIntent answers a question about a place from the player's last
+// nearby results, such as "這家幾點關門" or "第二家電話多少"
+func (h *Handler) handleDetailsIntent(c *gin.Context, intent *ai.VoiceIntent, playerID string) {
+	usageStats, _ := c.Get("usageStats")
+
+	place, ok := h.game.ResolveRecentPlace(playerID, intent.Reference)
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{
+			"success":    false,
+			"intentType": "details",
+			"errorCode":  "NO_RECENT_RESULTS",
+			"aiResponse": "請先問我附近有什麼，再告訴我想知道哪一家的資訊 🙂",
+			"usageStats": usageStats,
+		})
+		return
+	}
+
+	log.Printf("🔎 Details about %s (placeId=%q, detail=%s)", place.Name, place.PlaceID, intent.Detail)
+
+	response := gin.H{
+		"success":    true,
+		"intentType": "details",
+		"place":      place,
+		"usageStats": usageStats,
+	}
+	if place.PlaceID == "" {
+		response["aiResponse"] = ai.DescribeListedPlace(place, intent.Detail)
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	details, err := h.geo.GetPlaceDetails(place.PlaceID)
+	if err != nil {
+		log.Printf("❌ Place details lookup failed: %v", err)
+		response["aiResponse"] = ai.DescribeListedPlace(place, intent.Detail)
+		response["warning"] = "暫時查不到這個地點的詳細資料"
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	response["details"] = details
+	response["aiResponse"] = ai.DescribePlaceDetails(place.Name, details, intent.Detail, time.Now())
+	c.JSON(http.StatusOK, response)
+}
+
 // min helper function
 func min(a, b int) int {
 	if a < b {
@@ -0,0 +1,202 @@
+// Package cluster groups map points into clusters for each zoom level, like
+// supercluster: starting from the points, each zoom greedily merges what lies
+// within Radius pixels of one another at the zoom above.
+package cluster
+
+import (
+	"math"
+
+	"intelligent-spatial-platform/internal/tiles"
+)
+
+// Clustering parameters
+const (
+	MaxZoom = 16  // points are shown on their own above this zoom
+	Radius  = 60  // pixels within which points join a cluster
+	Extent  = 512 // pixels across a tile, as Radius is measured
+)
+
+// Layers are the tables clustered, named as the vector tile layers
+var Layers = []string{tiles.LayerLocations, tiles.LayerHistoricalSites, tiles.LayerItems}
+
+const layerCount = 3
+
+// Point is a row shown on the map
+type Point struct {
+	Layer     string
+	ID        string
+	Name      string
+	Latitude  float64
+	Longitude float64
+}
+
+// Cluster is a group of points, or a single point, as shown at one zoom
+type Cluster struct {
+	ClusterID     int            `json:"clusterId,omitempty"` // clusters only; changes when the index is rebuilt
+	Latitude      float64        `json:"latitude"`
+	Longitude     float64        `json:"longitude"`
+	Count         int            `json:"count"`                   // 1 for a single point
+	Counts        map[string]int `json:"counts"`                  // points per layer
+	ExpansionZoom int            `json:"expansionZoom,omitempty"` // zoom at which a cluster splits up
+	Layer         string         `json:"layer,omitempty"`         // single points only
+	ID            string         `json:"id,omitempty"`            // single points only
+	Name          string         `json:"name,omitempty"`          // single points only
+}
+
+// node is a point or cluster at one zoom, in Web Mercator coordinates from 0 to 1
+type node struct {
+	x, y          float64
+	count         int32
+	counts        [layerCount]int32
+	point         int32 // index in hierarchy.points, or -1 for a cluster
+	id            int32 // cluster id
+	expansionZoom int8
+	zoom          int8 // the lowest zoom this node was clustered at while building
+}
+
+type level struct {
+	nodes []node
+	tree  *kdTree
+}
+
+// hierarchy holds the clusters of every zoom; levels[MaxZoom+1] are the points
+type hierarchy struct {
+	points []Point
+	levels [MaxZoom + 2]level
+}
+
+func newLevel(nodes []node) level {
+	return level{nodes: nodes, tree: newKDTree(len(nodes), func(i int) (float64, float64) {
+		return nodes[i].x, nodes[i].y
+	})}
+}
+
+// build clusters points zoom by zoom, from the top down. The result depends on
+// the order of points, so callers pass them in a stable order.
+func build(points []Point) *hierarchy {
+	h := &hierarchy{points: points}
+	nodes := make([]node, 0, len(points))
+	for i, point := range points {
+		layer := layerIndex(point.Layer)
+		if layer < 0 {
+			continue
+		}
+		n := node{x: lngX(point.Longitude), y: latY(point.Latitude), count: 1, point: int32(i), zoom: MaxZoom + 2}
+		n.counts[layer] = 1
+		nodes = append(nodes, n)
+	}
+	h.levels[MaxZoom+1] = newLevel(nodes)
+
+	nextID := int32(1)
+	for z := MaxZoom; z >= 0; z-- {
+		h.levels[z] = newLevel(clusterLevel(&h.levels[z+1], z, &nextID))
+	}
+	return h
+}
+
+// clusterLevel merges each unvisited node of the zoom above with its unvisited
+// neighbors into a cluster at their weighted center; lone nodes carry over
+func clusterLevel(above *level, zoom int, nextID *int32) []node {
+	radius := Radius / (Extent * math.Pow(2, float64(zoom)))
+	z := int8(zoom)
+
+	var nodes []node
+	for i := range above.nodes {
+		p := &above.nodes[i]
+		if p.zoom <= z {
+			continue
+		}
+		p.zoom = z
+
+		merged := *p
+		count := p.count
+		above.tree.within(p.x, p.y, radius, func(id int32) {
+			if above.nodes[id].zoom > z {
+				count += above.nodes[id].count
+			}
+		})
+		if count == p.count {
+			nodes = append(nodes, merged)
+			continue
+		}
+
+		wx, wy := p.x*float64(p.count), p.y*float64(p.count)
+		above.tree.within(p.x, p.y, radius, func(id int32) {
+			neighbor := &above.nodes[id]
+			if neighbor.zoom <= z {
+				return
+			}
+			neighbor.zoom = z
+			wx += neighbor.x * float64(neighbor.count)
+			wy += neighbor.y * float64(neighbor.count)
+			for layer, n := range neighbor.counts {
+				merged.counts[layer] += n
+			}
+		})
+		merged.x, merged.y = wx/float64(count), wy/float64(count)
+		merged.count = count
+		merged.point = -1
+		merged.id = *nextID
+		merged.expansionZoom = z + 1
+		*nextID++
+		nodes = append(nodes, merged)
+	}
+	return nodes
+}
+
+// clusters returns what is shown at zoom inside the box
+func (h *hierarchy) clusters(west, south, east, north float64, zoom int) []Cluster {
+	l := &h.levels[min(max(zoom, 0), MaxZoom+1)]
+	result := []Cluster{}
+	l.tree.inBox(lngX(west), latY(north), lngX(east), latY(south), func(id int32, _, _ float64) {
+		result = append(result, h.cluster(&l.nodes[id]))
+	})
+	return result
+}
+
+func (h *hierarchy) cluster(n *node) Cluster {
+	c := Cluster{Count: int(n.count), Counts: make(map[string]int)}
+	for layer, count := range n.counts {
+		if count > 0 {
+			c.Counts[Layers[layer]] = int(count)
+		}
+	}
+	if n.point >= 0 {
+		point := h.points[n.point]
+		c.Latitude, c.Longitude = point.Latitude, point.Longitude
+		c.Layer, c.ID, c.Name = point.Layer, point.ID, point.Name
+		return c
+	}
+	c.ClusterID = int(n.id)
+	c.Latitude, c.Longitude = yLat(n.y), xLng(n.x)
+	c.ExpansionZoom = int(n.expansionZoom)
+	return c
+}
+
+func layerIndex(name string) int {
+	for i, layer := range Layers {
+		if layer == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Web Mercator, scaled to 0-1 across the world
+func lngX(lng float64) float64 {
+	return lng/360 + 0.5
+}
+
+func latY(lat float64) float64 {
+	sin := math.Sin(lat * math.Pi / 180)
+	y := 0.5 - 0.25*math.Log((1+sin)/(1-sin))/math.Pi
+	return min(max(y, 0), 1)
+}
+
+func xLng(x float64) float64 {
+	return (x - 0.5) * 360
+}
+
+func yLat(y float64) float64 {
+	return 360*math.Atan(math.Exp((180-y*360)*math.Pi/180))/math.Pi - 90
+}
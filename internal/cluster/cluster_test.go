@@ -0,0 +1,149 @@
+package cluster
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"intelligent-spatial-platform/internal/tiles"
+)
+
+func TestKDTree(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	xs, ys := make([]float64, 1000), make([]float64, 1000)
+	for i := range xs {
+		xs[i], ys[i] = r.Float64(), r.Float64()
+	}
+	tree := newKDTree(len(xs), func(i int) (float64, float64) { return xs[i], ys[i] })
+
+	var got, want []int
+	tree.inBox(0.2, 0.3, 0.5, 0.4, func(id int32, _, _ float64) { got = append(got, int(id)) })
+	for i := range xs {
+		if xs[i] >= 0.2 && xs[i] <= 0.5 && ys[i] >= 0.3 && ys[i] <= 0.4 {
+			want = append(want, i)
+		}
+	}
+	sort.Ints(got)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("inBox() = %v, want %v", got, want)
+	}
+
+	got, want = nil, nil
+	tree.within(0.5, 0.5, 0.1, func(id int32) { got = append(got, int(id)) })
+	for i := range xs {
+		if math.Hypot(xs[i]-0.5, ys[i]-0.5) <= 0.1 {
+			want = append(want, i)
+		}
+	}
+	sort.Ints(got)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("within() = %v, want %v", got, want)
+	}
+}
+
+// taipei has two places 30 m apart near Taipei 101 and an item 2 km away
+var taipei = []Point{
+	{Layer: tiles.LayerLocations, ID: "1", Name: "台北101", Latitude: 25.0340, Longitude: 121.5645},
+	{Layer: tiles.LayerHistoricalSites, ID: "2", Name: "四四南村", Latitude: 25.0342, Longitude: 121.5647},
+	{Layer: tiles.LayerItems, ID: "x", Name: "古代銅錢", Latitude: 25.0520, Longitude: 121.5645},
+}
+
+func TestClusters(t *testing.T) {
+	idx := NewIndex(nil, 0)
+	idx.Upsert(taipei...)
+
+	// At zoom 10 all three are within 60 pixels
+	clusters := idx.Clusters(121, 24.5, 122, 25.5, 10)
+	if len(clusters) != 1 {
+		t.Fatalf("zoom 10: %+v", clusters)
+	}
+	c := clusters[0]
+	if c.Count != 3 || c.Counts[tiles.LayerLocations] != 1 || c.Counts[tiles.LayerHistoricalSites] != 1 || c.Counts[tiles.LayerItems] != 1 {
+		t.Errorf("counts = %d %v", c.Count, c.Counts)
+	}
+	if c.ClusterID == 0 || c.ExpansionZoom < 11 {
+		t.Errorf("cluster = %+v", c)
+	}
+	if c.Latitude < 25.0340 || c.Latitude > 25.0520 {
+		t.Errorf("center = %v, %v", c.Latitude, c.Longitude)
+	}
+
+	// At its expansion zoom the cluster splits into more
+	if split := idx.Clusters(121, 24.5, 122, 25.5, c.ExpansionZoom); len(split) < 2 {
+		t.Errorf("zoom %d: %+v", c.ExpansionZoom, split)
+	}
+
+	// Above MaxZoom every point is on its own
+	points := idx.Clusters(121, 24.5, 122, 25.5, 20)
+	if len(points) != 3 {
+		t.Fatalf("zoom 20: %+v", points)
+	}
+	for _, p := range points {
+		if p.Count != 1 || p.ClusterID != 0 || p.ID == "" || p.Layer == "" {
+			t.Errorf("point = %+v", p)
+		}
+	}
+
+	if outside := idx.Clusters(120, 23, 120.5, 23.5, 10); len(outside) != 0 {
+		t.Errorf("outside the box: %+v", outside)
+	}
+}
+
+func TestIndexUpdates(t *testing.T) {
+	idx := NewIndex(nil, 0)
+	idx.Upsert(taipei...)
+
+	idx.Remove(tiles.LayerItems, "x")
+	if idx.Len() != 2 {
+		t.Errorf("Len() = %d", idx.Len())
+	}
+	clusters := idx.Clusters(121, 24.5, 122, 25.5, 10)
+	if len(clusters) != 1 || clusters[0].Count != 2 || clusters[0].Counts[tiles.LayerItems] != 0 {
+		t.Errorf("after remove: %+v", clusters)
+	}
+
+	// Moving a point away takes it out of the cluster
+	moved := taipei[1]
+	moved.Latitude, moved.Longitude = 22.6273, 120.3014
+	idx.Upsert(moved)
+	if clusters := idx.Clusters(121, 24.5, 122, 25.5, 10); len(clusters) != 1 || clusters[0].Count != 1 {
+		t.Errorf("after move: %+v", clusters)
+	}
+	if clusters := idx.Clusters(120, 22, 121, 23, 10); len(clusters) != 1 || clusters[0].Name != "四四南村" {
+		t.Errorf("moved point: %+v", clusters)
+	}
+
+	// Points of unknown layers are left out
+	idx.Upsert(Point{Layer: "players", ID: "p", Latitude: 25.0341, Longitude: 121.5646})
+	if clusters := idx.Clusters(121, 24.5, 122, 25.5, 20); len(clusters) != 1 {
+		t.Errorf("unknown layer: %+v", clusters)
+	}
+	// Writers update the index whether or not clustering is on
+	var off *Index
+	off.Upsert(taipei...)
+	off.Remove(tiles.LayerItems, "x")
+}
+
+func TestClustersAcrossAntimeridian(t *testing.T) {
+	idx := NewIndex(nil, 0)
+	idx.Upsert(
+		Point{Layer: tiles.LayerLocations, ID: "1", Latitude: -17.7, Longitude: 179.9},
+		Point{Layer: tiles.LayerLocations, ID: "2", Latitude: -17.7, Longitude: -179.9},
+	)
+	if clusters := idx.Clusters(179, -18, -179, -17, 20); len(clusters) != 2 {
+		t.Errorf("clusters = %+v", clusters)
+	}
+}
+
+func TestMercator(t *testing.T) {
+	for _, lat := range []float64{-80, -25.5, 0, 25.034, 60} {
+		if got := yLat(latY(lat)); math.Abs(got-lat) > 1e-9 {
+			t.Errorf("yLat(latY(%v)) = %v", lat, got)
+		}
+	}
+	if x := lngX(121.5645); math.Abs(xLng(x)-121.5645) > 1e-9 {
+		t.Errorf("xLng(lngX()) = %v", xLng(x))
+	}
+}
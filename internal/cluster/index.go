@@ -0,0 +1,159 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+
+	"intelligent-spatial-platform/internal/tiles"
+)
+
+// DefaultRebuildDelay gathers the writes of a burst, such as a spawn of items,
+// into one rebuild
+const DefaultRebuildDelay = 500 * time.Millisecond
+
+type pointKey struct{ layer, id string }
+
+// Index keeps the points of every layer and their clusters. Writes change the
+// points at once and rebuild the clusters shortly after; queries read the last
+// build and never wait for one.
+type Index struct {
+	db    *gorm.DB
+	delay time.Duration
+
+	mu     sync.Mutex
+	points map[pointKey]Point
+	timer  *time.Timer
+
+	buildMu sync.Mutex // serializes builds, so a newer one is never replaced by an older one
+	built   atomic.Pointer[hierarchy]
+}
+
+// NewIndex returns an empty index reading from db. Writes rebuild it after
+// delay, or before returning when delay is not positive.
+func NewIndex(db *gorm.DB, delay time.Duration) *Index {
+	idx := &Index{db: db, delay: delay, points: make(map[pointKey]Point)}
+	idx.built.Store(build(nil))
+	return idx
+}
+
+// Load replaces the points with the rows of every layer and rebuilds
+func (idx *Index) Load() error {
+	var points []Point
+	for _, name := range Layers {
+		layer, ok := tiles.LookupLayer(name)
+		if !ok {
+			return fmt.Errorf("unknown layer: %s", name)
+		}
+		var rows []Point
+		query := idx.db.Table(layer.Table).Select("id::text AS id, name, latitude, longitude")
+		if layer.Where != "" {
+			query = query.Where(layer.Where)
+		}
+		if err := query.Scan(&rows).Error; err != nil {
+			return fmt.Errorf("failed to load %s: %v", name, err)
+		}
+		for _, row := range rows {
+			row.Layer = name
+			points = append(points, row)
+		}
+	}
+
+	idx.mu.Lock()
+	idx.points = make(map[pointKey]Point, len(points))
+	for _, point := range points {
+		idx.points[pointKey{point.Layer, point.ID}] = point
+	}
+	idx.mu.Unlock()
+
+	idx.rebuild()
+	return nil
+}
+
+// Upsert adds points, or moves them if already indexed, after their rows were
+// written. It does nothing on a nil index, so writers need not check whether
+// clustering is on.
+func (idx *Index) Upsert(points ...Point) {
+	if idx == nil || len(points) == 0 {
+		return
+	}
+	idx.mu.Lock()
+	for _, point := range points {
+		idx.points[pointKey{point.Layer, point.ID}] = point
+	}
+	idx.mu.Unlock()
+	idx.scheduleRebuild()
+}
+
+// Remove drops the points of layer with ids after their rows were deleted or
+// hidden. It does nothing on a nil index.
+func (idx *Index) Remove(layer string, ids ...string) {
+	if idx == nil || len(ids) == 0 {
+		return
+	}
+	idx.mu.Lock()
+	for _, id := range ids {
+		delete(idx.points, pointKey{layer, id})
+	}
+	idx.mu.Unlock()
+	idx.scheduleRebuild()
+}
+
+// Len returns how many points are indexed
+func (idx *Index) Len() int {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	return len(idx.points)
+}
+
+// Clusters returns the clusters and single points shown at zoom inside the box.
+// Boxes across the antimeridian are split in two.
+func (idx *Index) Clusters(west, south, east, north float64, zoom int) []Cluster {
+	h := idx.built.Load()
+	if west > east {
+		return append(h.clusters(west, south, 180, north, zoom), h.clusters(-180, south, east, north, zoom)...)
+	}
+	return h.clusters(west, south, east, north, zoom)
+}
+
+func (idx *Index) scheduleRebuild() {
+	if idx.delay <= 0 {
+		idx.rebuild()
+		return
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.timer == nil {
+		idx.timer = time.AfterFunc(idx.delay, func() {
+			idx.mu.Lock()
+			idx.timer = nil
+			idx.mu.Unlock()
+			idx.rebuild()
+		})
+	}
+}
+
+// rebuild clusters the current points and swaps the result in
+func (idx *Index) rebuild() {
+	idx.buildMu.Lock()
+	defer idx.buildMu.Unlock()
+
+	idx.mu.Lock()
+	points := make([]Point, 0, len(idx.points))
+	for _, point := range idx.points {
+		points = append(points, point)
+	}
+	idx.mu.Unlock()
+
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].Layer != points[j].Layer {
+			return points[i].Layer < points[j].Layer
+		}
+		return points[i].ID < points[j].ID
+	})
+	idx.built.Store(build(points))
+}
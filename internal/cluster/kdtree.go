@@ -0,0 +1,112 @@
+package cluster
+
+// kdNodeSize is how many points a leaf of the tree holds; leaves are scanned
+const kdNodeSize = 64
+
+// kdTree is a static 2-d tree over points for box and radius queries. The
+// points are kept in one array, ordered so that each range's middle element
+// splits it along alternating axes.
+type kdTree struct {
+	ids    []int32
+	coords []float64 // x, y of ids[i] at 2i and 2i+1
+}
+
+func newKDTree(n int, at func(i int) (x, y float64)) *kdTree {
+	t := &kdTree{ids: make([]int32, n), coords: make([]float64, 2*n)}
+	for i := 0; i < n; i++ {
+		t.ids[i] = int32(i)
+		t.coords[2*i], t.coords[2*i+1] = at(i)
+	}
+	t.sort(0, n-1, 0)
+	return t
+}
+
+func (t *kdTree) sort(left, right, axis int) {
+	if right-left <= kdNodeSize {
+		return
+	}
+	middle := (left + right) / 2
+	t.selectNth(middle, left, right, axis)
+	t.sort(left, middle-1, 1-axis)
+	t.sort(middle+1, right, 1-axis)
+}
+
+// selectNth orders [left, right] so that n holds the element that belongs
+// there along axis, with smaller ones before it and larger ones after
+func (t *kdTree) selectNth(n, left, right, axis int) {
+	for left < right {
+		pivot := t.coords[2*((left+right)/2)+axis]
+		i, j := left, right
+		for i <= j {
+			for t.coords[2*i+axis] < pivot {
+				i++
+			}
+			for t.coords[2*j+axis] > pivot {
+				j--
+			}
+			if i <= j {
+				t.swap(i, j)
+				i++
+				j--
+			}
+		}
+		switch {
+		case n <= j:
+			right = j
+		case n >= i:
+			left = i
+		default:
+			return
+		}
+	}
+}
+
+func (t *kdTree) swap(i, j int) {
+	t.ids[i], t.ids[j] = t.ids[j], t.ids[i]
+	t.coords[2*i], t.coords[2*j] = t.coords[2*j], t.coords[2*i]
+	t.coords[2*i+1], t.coords[2*j+1] = t.coords[2*j+1], t.coords[2*i+1]
+}
+
+// inBox calls visit with every point inside the box, edges included
+func (t *kdTree) inBox(minX, minY, maxX, maxY float64, visit func(id int32, x, y float64)) {
+	type span struct{ left, right, axis int }
+	stack := []span{{0, len(t.ids) - 1, 0}}
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if s.right-s.left <= kdNodeSize {
+			for i := s.left; i <= s.right; i++ {
+				if x, y := t.coords[2*i], t.coords[2*i+1]; x >= minX && x <= maxX && y >= minY && y <= maxY {
+					visit(t.ids[i], x, y)
+				}
+			}
+			continue
+		}
+
+		middle := (s.left + s.right) / 2
+		x, y := t.coords[2*middle], t.coords[2*middle+1]
+		if x >= minX && x <= maxX && y >= minY && y <= maxY {
+			visit(t.ids[middle], x, y)
+		}
+		value, low, high := x, minX, maxX
+		if s.axis == 1 {
+			value, low, high = y, minY, maxY
+		}
+		if low <= value {
+			stack = append(stack, span{s.left, middle - 1, 1 - s.axis})
+		}
+		if high >= value {
+			stack = append(stack, span{middle + 1, s.right, 1 - s.axis})
+		}
+	}
+}
+
+// within calls visit with every point within radius of (x, y)
+func (t *kdTree) within(x, y, radius float64, visit func(id int32)) {
+	t.inBox(x-radius, y-radius, x+radius, y+radius, func(id int32, px, py float64) {
+		if (px-x)*(px-x)+(py-y)*(py-y) <= radius*radius {
+			visit(id)
+		}
+	})
+}
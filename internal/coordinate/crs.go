@@ -0,0 +1,106 @@
+package coordinate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CRS identifies a supported coordinate reference system
+type CRS string
+
+const (
+	WGS84          CRS = "EPSG:4326" // longitude/latitude in degrees
+	TWD97          CRS = "EPSG:3826" // TWD97 / TM2 zone 121
+	TWD97Zone119   CRS = "EPSG:3825" // TWD97 / TM2 zone 119 (澎湖、金門、馬祖)
+	TWD67          CRS = "EPSG:3828" // TWD67 / TM2 zone 121
+	crsAliasPrefix     = "EPSG:"
+)
+
+// crsAliases maps common names to EPSG codes
+var crsAliases = map[string]CRS{
+	"4326":       WGS84,
+	"WGS84":      WGS84,
+	"3826":       TWD97,
+	"TWD97":      TWD97,
+	"TWD97TM2":   TWD97,
+	"3825":       TWD97Zone119,
+	"TWD97TM119": TWD97Zone119,
+	"3828":       TWD67,
+	"TWD67":      TWD67,
+	"TWD67TM2":   TWD67,
+}
+
+// ParseCRS normalizes a CRS name such as "EPSG:3826", "3826" or "TWD97"
+func ParseCRS(name string) (CRS, error) {
+	key := strings.ToUpper(strings.TrimSpace(name))
+	key = strings.TrimPrefix(key, crsAliasPrefix)
+	key = strings.NewReplacer("_", "", "-", "", " ", "").Replace(key)
+
+	if crs, ok := crsAliases[key]; ok {
+		return crs, nil
+	}
+	return "", fmt.Errorf("unsupported CRS: %s", name)
+}
+
+// Point is an x/y pair; for WGS84 x is longitude and y is latitude
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// ToWGS84 converts a point in the given CRS to latitude/longitude
+func ToWGS84(crs CRS, p Point) (lat, lng float64, err error) {
+	switch crs {
+	case WGS84:
+		return p.Y, p.X, nil
+	case TWD97:
+		lat, lng = TWD97TM2.Inverse(p.X, p.Y)
+		return lat, lng, nil
+	case TWD97Zone119:
+		lat, lng = TWD97TM2Zone119.Inverse(p.X, p.Y)
+		return lat, lng, nil
+	case TWD67:
+		x97, y97 := TWD67ToTWD97(p.X, p.Y)
+		lat, lng = TWD97TM2.Inverse(x97, y97)
+		return lat, lng, nil
+	default:
+		return 0, 0, fmt.Errorf("unsupported CRS: %s", crs)
+	}
+}
+
+// FromWGS84 converts latitude/longitude to a point in the given CRS
+func FromWGS84(crs CRS, lat, lng float64) (Point, error) {
+	switch crs {
+	case WGS84:
+		return Point{X: lng, Y: lat}, nil
+	case TWD97:
+		x, y := TWD97TM2.Forward(lat, lng)
+		return Point{X: x, Y: y}, nil
+	case TWD97Zone119:
+		x, y := TWD97TM2Zone119.Forward(lat, lng)
+		return Point{X: x, Y: y}, nil
+	case TWD67:
+		x97, y97 := TWD97TM2.Forward(lat, lng)
+		x, y := TWD97ToTWD67(x97, y97)
+		return Point{X: x, Y: y}, nil
+	default:
+		return Point{}, fmt.Errorf("unsupported CRS: %s", crs)
+	}
+}
+
+// Transform converts points between two supported CRSs
+func Transform(from, to CRS, points []Point) ([]Point, error) {
+	result := make([]Point, len(points))
+	for i, p := range points {
+		lat, lng, err := ToWGS84(from, p)
+		if err != nil {
+			return nil, err
+		}
+		converted, err := FromWGS84(to, lat, lng)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = converted
+	}
+	return result, nil
+}
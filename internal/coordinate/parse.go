@@ -0,0 +1,292 @@
+// Package coordinate detects and parses coordinate text (decimal, DMS, geo: URI,
+// TWD97/TWD67 TM2 grid) and converts it to WGS84.
+package coordinate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Format is the detected input format of a coordinate
+type Format string
+
+const (
+	FormatDecimal Format = "decimal" // 25.0330, 121.5654
+	FormatDMS     Format = "dms"     // 25°02'01"N 121°33'54"E
+	FormatGeoURI  Format = "geo_uri" // geo:25.0330,121.5654 (RFC 5870)
+	FormatTWD97   Format = "twd97"   // 306000, 2769000 (EPSG:3826)
+	FormatTWD67   Format = "twd67"   // EPSG:3828
+)
+
+// ErrNoCoordinate is returned when the text contains no recognizable coordinate
+var ErrNoCoordinate = errors.New("no coordinate found")
+
+// Result is a parsed coordinate converted to WGS84
+type Result struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Format    Format  `json:"format"`
+	CRS       CRS     `json:"crs"`                // CRS of the original input
+	Easting   float64 `json:"easting,omitempty"`  // original grid X for TM2 input
+	Northing  float64 `json:"northing,omitempty"` // original grid Y for TM2 input
+	Altitude  float64 `json:"altitude,omitempty"` // geo: URI third component
+	Accuracy  float64 `json:"accuracy,omitempty"` // geo: URI u= parameter in meters
+	Swapped   bool    `json:"swapped,omitempty"`  // input was in lng,lat order
+	Matched   string  `json:"matched"`            // the substring that was parsed
+}
+
+var (
+	number = `[-+]?\d+(?:\.\d+)?`
+
+	geoURIPattern = regexp.MustCompile(`(?i)geo:(` + number + `),(` + number + `)(?:,(` + number + `))?((?:;[^\s;]+)*)`)
+
+	// 25°02'01"N, 25°02.5'N, 25.0336°N, N25°02'01", 北緯25度2分1秒
+	dmsComponent = `(?:([NSEW北南東西])\s*)?(\d{1,3}(?:\.\d+)?)\s*(?:°|度|º|\s)\s*(?:(\d{1,2}(?:\.\d+)?)\s*(?:'|′|’|分)\s*)?(?:(\d{1,2}(?:\.\d+)?)\s*(?:"|″|”|''|秒)\s*)?([NSEW])?`
+	dmsPattern   = regexp.MustCompile(`(?i)(?:北緯|南緯)?` + dmsComponent + `[\s,，/]*(?:東經|西經)?` + dmsComponent)
+
+	twdLabelPattern = regexp.MustCompile(`(?i)(twd\s*-?\s*(?:97|67)|epsg\s*:\s*(?:3826|3825|3828)|tm2)`)
+	gridPattern     = regexp.MustCompile(`(?i)(?:[xe]\s*[:=：]?\s*)?(\d{5,7}(?:\.\d+)?)\s*[,，\s]\s*(?:[yn]\s*[:=：]?\s*)?(\d{6,7}(?:\.\d+)?)`)
+
+	labeledDecimalPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`緯度\s*[：:]\s*(` + number + `)\s*[,，]?\s*經度\s*[：:]\s*(` + number + `)`),
+		regexp.MustCompile(`(?i)lat(?:itude)?\s*[：:=]\s*(` + number + `)\s*[,，&]?\s*(?:lng|lon|long|longitude)\s*[：:=]\s*(` + number + `)`),
+	}
+	decimalPattern = regexp.MustCompile(`(` + number + `)\s*[,，]\s*(` + number + `)`)
+)
+
+// Parse detects the coordinate format in text and returns the position in WGS84.
+// Formats are tried from most to least specific: geo: URI, TM2 grid, DMS, decimal.
+func Parse(text string) (*Result, error) {
+	text = strings.TrimSpace(text)
+
+	parsers := []func(string) (*Result, error){
+		parseGeoURI,
+		parseGrid,
+		parseDMS,
+		parseDecimal,
+	}
+
+	for _, parse := range parsers {
+		result, err := parse(text)
+		if err != nil {
+			return nil, err
+		}
+		if result != nil {
+			return result, nil
+		}
+	}
+
+	return nil, ErrNoCoordinate
+}
+
+// parseGeoURI parses RFC 5870 geo: URIs; only the default WGS84 CRS is supported
+func parseGeoURI(text string) (*Result, error) {
+	matches := geoURIPattern.FindStringSubmatch(text)
+	if matches == nil {
+		return nil, nil
+	}
+
+	lat, _ := strconv.ParseFloat(matches[1], 64)
+	lng, _ := strconv.ParseFloat(matches[2], 64)
+	result := &Result{
+		Latitude:  lat,
+		Longitude: lng,
+		Format:    FormatGeoURI,
+		CRS:       WGS84,
+		Matched:   matches[0],
+	}
+
+	if matches[3] != "" {
+		result.Altitude, _ = strconv.ParseFloat(matches[3], 64)
+	}
+
+	for _, param := range strings.Split(matches[4], ";") {
+		key, value, found := strings.Cut(param, "=")
+		if !found {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "crs":
+			if !strings.EqualFold(value, "wgs84") {
+				return nil, fmt.Errorf("unsupported geo: URI crs: %s", value)
+			}
+		case "u":
+			result.Accuracy, _ = strconv.ParseFloat(value, 64)
+		}
+	}
+
+	if err := validateLatLng(lat, lng); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// parseGrid parses TM2 easting/northing pairs. Unlabelled pairs are treated as
+// TWD97 when they fall inside Taiwan's grid extent.
+func parseGrid(text string) (*Result, error) {
+	matches := gridPattern.FindStringSubmatch(text)
+	if matches == nil {
+		return nil, nil
+	}
+
+	x, _ := strconv.ParseFloat(matches[1], 64)
+	y, _ := strconv.ParseFloat(matches[2], 64)
+
+	crs := TWD97
+	format := FormatTWD97
+	if label := twdLabelPattern.FindString(text); label != "" {
+		normalized := strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(label))
+		switch {
+		case strings.Contains(normalized, "67"), strings.Contains(normalized, "3828"):
+			crs, format = TWD67, FormatTWD67
+		case strings.Contains(normalized, "3825"):
+			crs = TWD97Zone119
+		}
+	} else if !isTaiwanGrid(x, y) {
+		return nil, nil
+	}
+
+	lat, lng, err := ToWGS84(crs, Point{X: x, Y: y})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Latitude:  lat,
+		Longitude: lng,
+		Format:    format,
+		CRS:       crs,
+		Easting:   x,
+		Northing:  y,
+		Matched:   matches[0],
+	}, nil
+}
+
+// isTaiwanGrid reports whether x/y lie within the TM2 zone 121 extent of Taiwan
+func isTaiwanGrid(x, y float64) bool {
+	return x >= 140000 && x <= 360000 && y >= 2410000 && y <= 2800000
+}
+
+func parseDMS(text string) (*Result, error) {
+	matches := dmsPattern.FindStringSubmatch(text)
+	if matches == nil {
+		return nil, nil
+	}
+
+	// A DMS match needs a degree symbol, minutes or a hemisphere; bare "25 121" is not DMS
+	if !strings.ContainsAny(matches[0], "°度º'′’NSEWnsew北南東西") {
+		return nil, nil
+	}
+
+	first, firstHemi := dmsValue(matches[1:6])
+	second, secondHemi := dmsValue(matches[6:11])
+	if strings.Contains(matches[0], "南緯") {
+		firstHemi = "S"
+	}
+	if strings.Contains(matches[0], "西經") {
+		secondHemi = "W"
+	}
+
+	lat, lng := first, second
+	swapped := false
+	if firstHemi == "E" || firstHemi == "W" || secondHemi == "N" || secondHemi == "S" {
+		lat, lng = second, first
+		firstHemi, secondHemi = secondHemi, firstHemi
+		swapped = true
+	}
+	if firstHemi == "S" {
+		lat = -lat
+	}
+	if secondHemi == "W" {
+		lng = -lng
+	}
+
+	if err := validateLatLng(lat, lng); err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Latitude:  lat,
+		Longitude: lng,
+		Format:    FormatDMS,
+		CRS:       WGS84,
+		Swapped:   swapped,
+		Matched:   strings.TrimSpace(matches[0]),
+	}, nil
+}
+
+// dmsValue converts [prefixHemi, deg, min, sec, suffixHemi] to decimal degrees and a hemisphere letter
+func dmsValue(parts []string) (float64, string) {
+	degrees, _ := strconv.ParseFloat(parts[1], 64)
+	minutes, _ := strconv.ParseFloat(parts[2], 64)
+	seconds, _ := strconv.ParseFloat(parts[3], 64)
+
+	hemi := strings.ToUpper(parts[0])
+	if hemi == "" {
+		hemi = strings.ToUpper(parts[4])
+	}
+	switch hemi {
+	case "北":
+		hemi = "N"
+	case "南":
+		hemi = "S"
+	case "東":
+		hemi = "E"
+	case "西":
+		hemi = "W"
+	}
+
+	return degrees + minutes/60 + seconds/3600, hemi
+}
+
+func parseDecimal(text string) (*Result, error) {
+	for _, pattern := range labeledDecimalPatterns {
+		if matches := pattern.FindStringSubmatch(text); matches != nil {
+			lat, _ := strconv.ParseFloat(matches[1], 64)
+			lng, _ := strconv.ParseFloat(matches[2], 64)
+			if err := validateLatLng(lat, lng); err != nil {
+				return nil, err
+			}
+			return &Result{Latitude: lat, Longitude: lng, Format: FormatDecimal, CRS: WGS84, Matched: matches[0]}, nil
+		}
+	}
+
+	matches := decimalPattern.FindStringSubmatch(text)
+	if matches == nil {
+		return nil, nil
+	}
+
+	lat, _ := strconv.ParseFloat(matches[1], 64)
+	lng, _ := strconv.ParseFloat(matches[2], 64)
+
+	// Accept lng,lat order when the first value can't be a latitude (e.g. "121.56, 25.03")
+	swapped := false
+	if math.Abs(lat) > 90 && math.Abs(lng) <= 90 {
+		lat, lng = lng, lat
+		swapped = true
+	}
+
+	if err := validateLatLng(lat, lng); err != nil {
+		return nil, nil
+	}
+
+	return &Result{
+		Latitude:  lat,
+		Longitude: lng,
+		Format:    FormatDecimal,
+		CRS:       WGS84,
+		Swapped:   swapped,
+		Matched:   matches[0],
+	}, nil
+}
+
+func validateLatLng(lat, lng float64) error {
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return fmt.Errorf("coordinate out of range: %.6f, %.6f", lat, lng)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package coordinate
+
+import (
+	"math"
+	"testing"
+)
+
+func almostEqual(a, b, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
+
+// TestParseFormats tests format detection and conversion for each supported input
+func TestParseFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		format  Format
+		lat     float64
+		lng     float64
+		swapped bool
+	}{
+		{"Decimal pair", "去 25.0330, 121.5654", FormatDecimal, 25.0330, 121.5654, false},
+		{"Negative decimals", "-33.8568, 151.2153", FormatDecimal, -33.8568, 151.2153, false},
+		{"Longitude first", "121.5654, 25.0330", FormatDecimal, 25.0330, 121.5654, true},
+		{"Chinese labels", "緯度: 25.0330 經度: 121.5654", FormatDecimal, 25.0330, 121.5654, false},
+		{"Lat/lng labels", "lat: 25.0330 lng: 121.5654", FormatDecimal, 25.0330, 121.5654, false},
+		{"DMS with hemispheres", `25°02'01"N 121°33'54"E`, FormatDMS, 25.033611, 121.565, false},
+		{"DMS prefix hemispheres", `N25°02'01" E121°33'54"`, FormatDMS, 25.033611, 121.565, false},
+		{"DMS southern/western", `33°51'24"S 151°12'55"E`, FormatDMS, -33.856667, 151.215278, false},
+		{"Decimal degrees with symbols", "25.0336°N, 121.565°E", FormatDMS, 25.0336, 121.565, false},
+		{"DMS Chinese", "北緯25度2分1秒 東經121度33分54秒", FormatDMS, 25.033611, 121.565, false},
+		{"DMS longitude first", `121°33'54"E 25°02'01"N`, FormatDMS, 25.033611, 121.565, true},
+		{"geo URI", "geo:25.0330,121.5654", FormatGeoURI, 25.0330, 121.5654, false},
+		{"geo URI with params", "geo:-33.8568,151.2153,20;crs=wgs84;u=35", FormatGeoURI, -33.8568, 151.2153, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) returned error: %v", tt.input, err)
+			}
+			if result.Format != tt.format {
+				t.Errorf("Expected format %s, got %s", tt.format, result.Format)
+			}
+			if !almostEqual(result.Latitude, tt.lat, 1e-5) || !almostEqual(result.Longitude, tt.lng, 1e-5) {
+				t.Errorf("Expected %.6f,%.6f, got %.6f,%.6f", tt.lat, tt.lng, result.Latitude, result.Longitude)
+			}
+			if result.Swapped != tt.swapped {
+				t.Errorf("Expected swapped=%v, got %v", tt.swapped, result.Swapped)
+			}
+		})
+	}
+}
+
+// TestParseGeoURIParameters tests altitude and uncertainty from RFC 5870 URIs
+func TestParseGeoURIParameters(t *testing.T) {
+	result, err := Parse("geo:25.0330,121.5654,508;u=12.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Altitude != 508 || result.Accuracy != 12.5 {
+		t.Errorf("Expected altitude 508 and u=12.5, got %v and %v", result.Altitude, result.Accuracy)
+	}
+
+	if _, err := Parse("geo:25.0330,121.5654;crs=nad27"); err == nil {
+		t.Error("Expected error for unsupported crs")
+	}
+}
+
+// TestParseTWD97 tests TM2 grid detection and inverse projection
+func TestParseTWD97(t *testing.T) {
+	result, err := Parse("TWD97 302200, 2771000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Format != FormatTWD97 || result.CRS != TWD97 {
+		t.Errorf("Expected TWD97, got %s (%s)", result.Format, result.CRS)
+	}
+	if !almostEqual(result.Latitude, 25.05, 0.02) || !almostEqual(result.Longitude, 121.52, 0.02) {
+		t.Errorf("Expected a point near Taipei Main Station, got %.6f,%.6f", result.Latitude, result.Longitude)
+	}
+
+	unlabelled, err := Parse("302200 2771000")
+	if err != nil || unlabelled.Format != FormatTWD97 {
+		t.Errorf("Expected unlabelled grid pair to be detected as TWD97, got %+v (%v)", unlabelled, err)
+	}
+
+	twd67, err := Parse("TWD67 301369.45, 2771203.71")
+	if err != nil || twd67.Format != FormatTWD67 {
+		t.Fatalf("Expected TWD67, got %+v (%v)", twd67, err)
+	}
+	if !almostEqual(twd67.Latitude, result.Latitude, 0.0002) || !almostEqual(twd67.Longitude, result.Longitude, 0.0002) {
+		t.Errorf("TWD67 point should land near the equivalent TWD97 point, got %.6f,%.6f", twd67.Latitude, twd67.Longitude)
+	}
+}
+
+// TestTM2RoundTrip tests that forward and inverse projection agree
+func TestTM2RoundTrip(t *testing.T) {
+	points := [][2]float64{{25.033964, 121.564468}, {22.0, 120.8}, {23.5, 121.0}, {24.43, 118.32}}
+
+	for _, p := range points {
+		for _, zone := range []TM2{TWD97TM2, TWD97TM2Zone119} {
+			x, y := zone.Forward(p[0], p[1])
+			lat, lng := zone.Inverse(x, y)
+			if !almostEqual(lat, p[0], 1e-7) || !almostEqual(lng, p[1], 1e-7) {
+				t.Errorf("Round trip of %v in zone %v gave %.8f,%.8f", p, zone.CentralMeridian, lat, lng)
+			}
+		}
+	}
+
+	// On the central meridian the easting equals the false easting
+	x, _ := TWD97TM2.Forward(24.0, 121.0)
+	if !almostEqual(x, 250000, 1e-6) {
+		t.Errorf("Expected easting 250000 on the central meridian, got %f", x)
+	}
+}
+
+// TestTransform tests batch conversion between CRSs
+func TestTransform(t *testing.T) {
+	from, err := ParseCRS("wgs84")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := ParseCRS("EPSG:3826")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grid, err := Transform(from, to, []Point{{X: 121.5654, Y: 25.0330}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := Transform(to, from, grid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !almostEqual(back[0].X, 121.5654, 1e-7) || !almostEqual(back[0].Y, 25.0330, 1e-7) {
+		t.Errorf("Round trip through TWD97 gave %+v", back[0])
+	}
+
+	if _, err := ParseCRS("EPSG:9999"); err == nil {
+		t.Error("Expected error for unsupported CRS")
+	}
+}
+
+// TestParseNoCoordinate tests text without coordinates
+func TestParseNoCoordinate(t *testing.T) {
+	for _, input := range []string{"去台北101", "走 100 公尺", "move north 3 km"} {
+		if result, err := Parse(input); err != ErrNoCoordinate {
+			t.Errorf("Parse(%q) expected ErrNoCoordinate, got %+v, %v", input, result, err)
+		}
+	}
+}
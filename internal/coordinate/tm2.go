@@ -0,0 +1,131 @@
+package coordinate
+
+import "math"
+
+// Ellipsoid defines the reference ellipsoid used by a projection
+type Ellipsoid struct {
+	A float64 // semi-major axis in meters
+	F float64 // flattening
+}
+
+// GRS80 is used by TWD97 and is within millimeters of WGS84
+var GRS80 = Ellipsoid{A: 6378137.0, F: 1 / 298.257222101}
+
+// TM2 is a 2-degree-wide Transverse Mercator zone as used by Taiwan's TWD67/TWD97 grids
+type TM2 struct {
+	Ellipsoid       Ellipsoid
+	CentralMeridian float64 // degrees, 121 for Taiwan main island, 119 for Penghu/Kinmen/Matsu
+	ScaleFactor     float64
+	FalseEasting    float64
+	FalseNorthing   float64
+}
+
+var (
+	// TWD97TM2 is EPSG:3826 (TWD97 / TM2 zone 121)
+	TWD97TM2 = TM2{Ellipsoid: GRS80, CentralMeridian: 121, ScaleFactor: 0.9999, FalseEasting: 250000}
+	// TWD97TM2Zone119 is EPSG:3825 (TWD97 / TM2 zone 119)
+	TWD97TM2Zone119 = TM2{Ellipsoid: GRS80, CentralMeridian: 119, ScaleFactor: 0.9999, FalseEasting: 250000}
+)
+
+func (e Ellipsoid) eccentricitySquared() float64 {
+	return e.F * (2 - e.F)
+}
+
+// meridianArc returns the distance along the meridian from the equator to latitude phi (radians)
+func (e Ellipsoid) meridianArc(phi float64) float64 {
+	e2 := e.eccentricitySquared()
+	e4 := e2 * e2
+	e6 := e4 * e2
+
+	return e.A * ((1-e2/4-3*e4/64-5*e6/256)*phi -
+		(3*e2/8+3*e4/32+45*e6/1024)*math.Sin(2*phi) +
+		(15*e4/256+45*e6/1024)*math.Sin(4*phi) -
+		(35*e6/3072)*math.Sin(6*phi))
+}
+
+// Forward projects geographic coordinates (degrees) to grid easting/northing (meters)
+func (p TM2) Forward(lat, lng float64) (easting, northing float64) {
+	e2 := p.Ellipsoid.eccentricitySquared()
+	ep2 := e2 / (1 - e2)
+
+	phi := lat * math.Pi / 180
+	lambda := (lng - p.CentralMeridian) * math.Pi / 180
+
+	sinPhi, cosPhi := math.Sin(phi), math.Cos(phi)
+	tanPhi := math.Tan(phi)
+
+	n := p.Ellipsoid.A / math.Sqrt(1-e2*sinPhi*sinPhi)
+	t := tanPhi * tanPhi
+	c := ep2 * cosPhi * cosPhi
+	a := lambda * cosPhi
+	m := p.Ellipsoid.meridianArc(phi)
+
+	easting = p.FalseEasting + p.ScaleFactor*n*(a+
+		(1-t+c)*math.Pow(a, 3)/6+
+		(5-18*t+t*t+72*c-58*ep2)*math.Pow(a, 5)/120)
+
+	northing = p.FalseNorthing + p.ScaleFactor*(m+n*tanPhi*(a*a/2+
+		(5-t+9*c+4*c*c)*math.Pow(a, 4)/24+
+		(61-58*t+t*t+600*c-330*ep2)*math.Pow(a, 6)/720))
+
+	return easting, northing
+}
+
+// Inverse converts grid easting/northing (meters) back to geographic coordinates (degrees)
+func (p TM2) Inverse(easting, northing float64) (lat, lng float64) {
+	e2 := p.Ellipsoid.eccentricitySquared()
+	ep2 := e2 / (1 - e2)
+	e4 := e2 * e2
+	e6 := e4 * e2
+
+	m := (northing - p.FalseNorthing) / p.ScaleFactor
+	mu := m / (p.Ellipsoid.A * (1 - e2/4 - 3*e4/64 - 5*e6/256))
+
+	e1 := (1 - math.Sqrt(1-e2)) / (1 + math.Sqrt(1-e2))
+	phi1 := mu +
+		(3*e1/2-27*math.Pow(e1, 3)/32)*math.Sin(2*mu) +
+		(21*e1*e1/16-55*math.Pow(e1, 4)/32)*math.Sin(4*mu) +
+		(151*math.Pow(e1, 3)/96)*math.Sin(6*mu) +
+		(1097*math.Pow(e1, 4)/512)*math.Sin(8*mu)
+
+	sinPhi1, cosPhi1 := math.Sin(phi1), math.Cos(phi1)
+	tanPhi1 := math.Tan(phi1)
+
+	c1 := ep2 * cosPhi1 * cosPhi1
+	t1 := tanPhi1 * tanPhi1
+	n1 := p.Ellipsoid.A / math.Sqrt(1-e2*sinPhi1*sinPhi1)
+	r1 := p.Ellipsoid.A * (1 - e2) / math.Pow(1-e2*sinPhi1*sinPhi1, 1.5)
+	d := (easting - p.FalseEasting) / (n1 * p.ScaleFactor)
+
+	phi := phi1 - (n1*tanPhi1/r1)*(d*d/2-
+		(5+3*t1+10*c1-4*c1*c1-9*ep2)*math.Pow(d, 4)/24+
+		(61+90*t1+298*c1+45*t1*t1-252*ep2-3*c1*c1)*math.Pow(d, 6)/720)
+
+	lambda := (d - (1+2*t1+c1)*math.Pow(d, 3)/6 +
+		(5-2*c1+28*t1-3*c1*c1+8*ep2+24*t1*t1)*math.Pow(d, 5)/120) / cosPhi1
+
+	return phi * 180 / math.Pi, p.CentralMeridian + lambda*180/math.Pi
+}
+
+// TWD67 to TWD97 grid shift parameters for the Taiwan main island (內政部公告之近似轉換)
+const (
+	twd67ShiftA  = 0.00001549
+	twd67ShiftB  = 0.000006521
+	twd67ShiftDX = 807.8
+	twd67ShiftDY = -248.6
+)
+
+// TWD67ToTWD97 converts TM2 zone 121 grid coordinates from TWD67 to TWD97.
+// It uses the four-parameter approximation, accurate to about 2 m on the main island.
+func TWD67ToTWD97(x67, y67 float64) (x97, y97 float64) {
+	x97 = x67 + twd67ShiftDX + twd67ShiftA*x67 + twd67ShiftB*y67
+	y97 = y67 + twd67ShiftDY + twd67ShiftA*y67 + twd67ShiftB*x67
+	return x97, y97
+}
+
+// TWD97ToTWD67 converts TM2 zone 121 grid coordinates from TWD97 to TWD67
+func TWD97ToTWD67(x97, y97 float64) (x67, y67 float64) {
+	x67 = x97 - twd67ShiftDX - twd67ShiftA*x97 - twd67ShiftB*y97
+	y67 = y97 - twd67ShiftDY - twd67ShiftA*y97 - twd67ShiftB*x97
+	return x67, y67
+}
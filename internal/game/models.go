@@ -12,6 +12,9 @@ type Player struct {
 	Score     int       `json:"score" gorm:"default:0"`
 	Level     int       `json:"level" gorm:"default:1"`
 	IsActive  bool      `json:"isActive" gorm:"default:true"`
+	ViolationScore int        `json:"violationScore" gorm:"default:0"`
+	FlaggedAt      *time.Time `json:"flaggedAt,omitempty"`   // set once ViolationScore reaches the flag threshold
+	LastMovedAt    *time.Time `json:"lastMovedAt,omitempty"` // set only when the position changes; speed checks measure from it
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
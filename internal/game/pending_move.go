@@ -0,0 +1,182 @@
+package game
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"intelligent-spatial-platform/internal/ai"
+	"intelligent-spatial-platform/internal/geo"
+)
+
+// PendingMoveTTL is how long a clarification token stays valid
+const PendingMoveTTL = 2 * time.Minute
+
+// Clarification asks the player to choose between geocoding candidates.
+// The move runs once they answer with the token and a candidate.
+type Clarification struct {
+	Token      string                 `json:"token"`
+	Question   string                 `json:"question"`
+	Query      string                 `json:"query"`
+	Candidates []geo.GeocodeCandidate `json:"candidates"`
+	ExpiresAt  time.Time              `json:"expiresAt"`
+
+	command string // original movement text, for the transport mode
+}
+
+// pendingMoves holds at most one clarification per player
+type pendingMoves struct {
+	mu       sync.Mutex
+	byPlayer map[string]*Clarification
+}
+
+func newPendingMoves() *pendingMoves {
+	return &pendingMoves{byPlayer: make(map[string]*Clarification)}
+}
+
+func (p *pendingMoves) put(playerID string, clarification *Clarification) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for id, pending := range p.byPlayer {
+		if now.After(pending.ExpiresAt) {
+			delete(p.byPlayer, id)
+		}
+	}
+	p.byPlayer[playerID] = clarification
+}
+
+// get returns the player's unexpired clarification
+func (p *pendingMoves) get(playerID string) *Clarification {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pending, exists := p.byPlayer[playerID]
+	if !exists {
+		return nil
+	}
+	if time.Now().After(pending.ExpiresAt) {
+		delete(p.byPlayer, playerID)
+		return nil
+	}
+	return pending
+}
+
+// take removes the clarification so a token can only be used once
+func (p *pendingMoves) take(playerID string, pending *Clarification) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.byPlayer[playerID] != pending {
+		return false
+	}
+	delete(p.byPlayer, playerID)
+	return true
+}
+
+func newClarification(ambiguous *geo.AmbiguousLocationError, command string) *Clarification {
+	var question strings.Builder
+	fmt.Fprintf(&question, "找到多個「%s」，請問是哪一個？", ambiguous.Query)
+	for i, candidate := range ambiguous.Candidates {
+		fmt.Fprintf(&question, "\n%d. %s", i+1, candidate.Location.Name)
+		if candidate.Location.Address != "" && candidate.Location.Address != candidate.Location.Name {
+			fmt.Fprintf(&question, "（%s）", candidate.Location.Address)
+		}
+	}
+
+	return &Clarification{
+		Token:      newPendingMoveToken(),
+		Question:   question.String(),
+		Query:      ambiguous.Query,
+		Candidates: ambiguous.Candidates,
+		ExpiresAt:  time.Now().Add(PendingMoveTTL),
+		command:    command,
+	}
+}
+
+func newPendingMoveToken() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+// choose resolves a candidate ID, or a reply such as "2" or "第二個"
+func (c *Clarification) choose(choice string) (*geo.GeocodeCandidate, bool) {
+	choice = strings.TrimSpace(choice)
+	for i := range c.Candidates {
+		if c.Candidates[i].ID == choice {
+			return &c.Candidates[i], true
+		}
+	}
+	if index, ok := ai.ParseCandidateSelection(choice, len(c.Candidates)); ok {
+		return &c.Candidates[index], true
+	}
+	return nil, false
+}
+
+// GetPendingMove returns the player's open clarification, or nil
+func (s *Service) GetPendingMove(playerID string) *Clarification {
+	return s.pendingMoves.get(playerID)
+}
+
+// ConfirmPendingMove executes a move the player was asked to clarify. token may be empty
+// to answer the latest question (e.g. a spoken "第二個"); choice is a candidate ID or ordinal.
+func (s *Service) ConfirmPendingMove(playerID, token, choice, sessionID, ipAddress string) (*AIMovementResult, error) {
+	pending := s.pendingMoves.get(playerID)
+	if pending == nil || (token != "" && token != pending.Token) {
+		return &AIMovementResult{
+			Success:   false,
+			Message:   "選項已過期，請重新說一次目的地",
+			ErrorCode: "CLARIFICATION_EXPIRED",
+		}, nil
+	}
+
+	candidate, ok := pending.choose(choice)
+	if !ok {
+		return &AIMovementResult{
+			Success:       false,
+			Message:       fmt.Sprintf("請選擇 1 到 %d 其中一個", len(pending.Candidates)),
+			ErrorCode:     "INVALID_CHOICE",
+			Clarification: pending,
+		}, nil
+	}
+
+	if !s.pendingMoves.take(playerID, pending) {
+		return &AIMovementResult{
+			Success:   false,
+			Message:   "選項已過期，請重新說一次目的地",
+			ErrorCode: "CLARIFICATION_EXPIRED",
+		}, nil
+	}
+
+	player, err := s.GetPlayerStatus(playerID)
+	if err != nil {
+		return &AIMovementResult{
+			Success:   false,
+			Message:   "無法取得玩家狀態",
+			ErrorCode: "PLAYER_NOT_FOUND",
+		}, err
+	}
+
+	currentLocation := &geo.Location{Latitude: player.Latitude, Longitude: player.Longitude}
+	destination := candidate.Location
+	moveCmd, err := s.movementParser.CommandForDestination(pending.command, &destination, currentLocation)
+	if err != nil {
+		audit := s.movementParser.LogMovementCommand(playerID, sessionID, ipAddress, nil, false, err.Error())
+		return &AIMovementResult{
+			Success:   false,
+			Message:   "無法解析移動指令：" + err.Error(),
+			ErrorCode: "PARSE_ERROR",
+			Audit:     audit,
+		}, nil
+	}
+	moveCmd.Parameters["candidateId"] = candidate.ID
+
+	return s.executeAIMovement(player, pending.command, moveCmd, sessionID, ipAddress)
+}
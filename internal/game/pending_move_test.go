@@ -0,0 +1,83 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"intelligent-spatial-platform/internal/geo"
+)
+
+func testClarification() *Clarification {
+	return newClarification(&geo.AmbiguousLocationError{
+		Query: "中山路",
+		Candidates: []geo.GeocodeCandidate{
+			{ID: "taichung", Location: geo.Location{Name: "中山路", Address: "台中市中區中山路"}},
+			{ID: "kaohsiung", Location: geo.Location{Name: "中山路", Address: "高雄市前金區中山路"}},
+			{ID: "tainan", Location: geo.Location{Name: "中山路", Address: "台南市中西區中山路"}},
+		},
+	}, "騎車去中山路")
+}
+
+func TestClarificationChoose(t *testing.T) {
+	clarification := testClarification()
+
+	tests := []struct {
+		choice string
+		want   string
+	}{
+		{"kaohsiung", "kaohsiung"},
+		{"第二個", "kaohsiung"},
+		{"第2個", "kaohsiung"},
+		{"3", "tainan"},
+		{"選一", "taichung"},
+		{"最後一個", "tainan"},
+		{"第四個", ""},
+		{"台北", ""},
+	}
+
+	for _, tt := range tests {
+		candidate, ok := clarification.choose(tt.choice)
+		got := ""
+		if ok {
+			got = candidate.ID
+		}
+		if got != tt.want {
+			t.Errorf("choose(%q) = %q, want %q", tt.choice, got, tt.want)
+		}
+	}
+}
+
+func TestClarificationQuestionListsOptions(t *testing.T) {
+	clarification := testClarification()
+
+	if clarification.Token == "" || clarification.command != "騎車去中山路" {
+		t.Errorf("clarification = %+v", clarification)
+	}
+	want := "找到多個「中山路」，請問是哪一個？\n1. 中山路（台中市中區中山路）\n2. 中山路（高雄市前金區中山路）\n3. 中山路（台南市中西區中山路）"
+	if clarification.Question != want {
+		t.Errorf("Question = %q", clarification.Question)
+	}
+}
+
+func TestPendingMovesExpireAndAreSingleUse(t *testing.T) {
+	pending := newPendingMoves()
+
+	clarification := testClarification()
+	pending.put("p1", clarification)
+	if pending.get("p1") != clarification {
+		t.Fatal("get() should return the open clarification")
+	}
+	if !pending.take("p1", clarification) {
+		t.Fatal("take() should succeed once")
+	}
+	if pending.take("p1", clarification) || pending.get("p1") != nil {
+		t.Error("a clarification must not be usable twice")
+	}
+
+	expired := testClarification()
+	expired.ExpiresAt = time.Now().Add(-time.Second)
+	pending.put("p2", expired)
+	if pending.get("p2") != nil {
+		t.Error("expired clarification should not be returned")
+	}
+}
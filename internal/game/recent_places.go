@@ -0,0 +1,104 @@
+package game
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"intelligent-spatial-platform/internal/ai"
+	"intelligent-spatial-platform/internal/geo"
+)
+
+// RecentPlacesTTL is how long a player can ask about their last nearby results
+const RecentPlacesTTL = 10 * time.Minute
+
+// recentList is a player's last nearby results, in the order they were listed
+type recentList struct {
+	places    []geo.LocationWithDistance
+	expiresAt time.Time
+}
+
+// recentPlaces holds each player's last nearby results, for follow-ups such
+// as "第二家幾點關門"
+type recentPlaces struct {
+	mu       sync.Mutex
+	byPlayer map[string]*recentList
+}
+
+func newRecentPlaces() *recentPlaces {
+	return &recentPlaces{byPlayer: make(map[string]*recentList)}
+}
+
+func (r *recentPlaces) put(playerID string, places []geo.LocationWithDistance) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, list := range r.byPlayer {
+		if now.After(list.expiresAt) {
+			delete(r.byPlayer, id)
+		}
+	}
+	if len(places) == 0 {
+		delete(r.byPlayer, playerID)
+		return
+	}
+	r.byPlayer[playerID] = &recentList{
+		places:    append([]geo.LocationWithDistance(nil), places...),
+		expiresAt: now.Add(RecentPlacesTTL),
+	}
+}
+
+// get returns the player's unexpired results
+func (r *recentPlaces) get(playerID string) []geo.LocationWithDistance {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list, exists := r.byPlayer[playerID]
+	if !exists {
+		return nil
+	}
+	if time.Now().After(list.expiresAt) {
+		delete(r.byPlayer, playerID)
+		return nil
+	}
+	return list.places
+}
+
+// RememberNearbyResults keeps the places just listed to a player, replacing
+// the previous list
+func (s *Service) RememberNearbyResults(playerID string, places []geo.LocationWithDistance) {
+	s.recentPlaces.put(playerID, places)
+}
+
+// ResolveRecentPlace returns the listed place a follow-up refers to: one whose
+// name it mentions, else the one an ordinal like "第二家" picks, else the first
+// ("這家", or no reference at all). It is false when nothing was listed lately.
+func (s *Service) ResolveRecentPlace(playerID, reference string) (*geo.LocationWithDistance, bool) {
+	places := s.recentPlaces.get(playerID)
+	if len(places) == 0 {
+		return nil, false
+	}
+	index := referredPlace(places, reference)
+	place := places[index]
+	return &place, true
+}
+
+func referredPlace(places []geo.LocationWithDistance, reference string) int {
+	text := geo.NormalizePlaceName(reference)
+	best, bestLength := -1, 0
+	for i, place := range places {
+		name := geo.NormalizePlaceName(place.Name)
+		if name != "" && len(name) > bestLength && strings.Contains(text, name) {
+			best, bestLength = i, len(name)
+		}
+	}
+	if best >= 0 {
+		return best
+	}
+
+	if index, ok := ai.FindOrdinalReference(reference, len(places)); ok {
+		return index
+	}
+	return 0
+}
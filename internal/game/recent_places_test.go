@@ -0,0 +1,49 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"intelligent-spatial-platform/internal/geo"
+)
+
+func TestResolveRecentPlace(t *testing.T) {
+	service := &Service{recentPlaces: newRecentPlaces()}
+	if _, ok := service.ResolveRecentPlace("p1", "這家幾點關門"); ok {
+		t.Fatal("nothing was listed yet")
+	}
+
+	service.RememberNearbyResults("p1", []geo.LocationWithDistance{
+		{Location: geo.Location{Name: "鼎泰豐 101店", PlaceID: "ChIJdin"}},
+		{Location: geo.Location{Name: "臺北101"}},
+		{Location: geo.Location{Name: "四四南村"}},
+	})
+
+	tests := []struct {
+		reference string
+		want      string
+	}{
+		{"這家幾點關門", "鼎泰豐 101店"},
+		{"", "鼎泰豐 101店"},
+		{"第二家電話多少", "臺北101"},
+		{"第3個的地址", "四四南村"},
+		{"最後一家有開嗎", "四四南村"},
+		{"台北101 幾點關門", "臺北101"}, // names match across 台/臺
+		{"第五家", "鼎泰豐 101店"},     // out of range: the first
+	}
+	for _, tt := range tests {
+		place, ok := service.ResolveRecentPlace("p1", tt.reference)
+		if !ok || place.Name != tt.want {
+			t.Errorf("ResolveRecentPlace(%q) = %v, want %s", tt.reference, place, tt.want)
+		}
+	}
+
+	if _, ok := service.ResolveRecentPlace("p2", "這家"); ok {
+		t.Error("results are kept per player")
+	}
+
+	service.recentPlaces.byPlayer["p1"].expiresAt = time.Now().Add(-time.Second)
+	if _, ok := service.ResolveRecentPlace("p1", "這家"); ok {
+		t.Error("expired results should be forgotten")
+	}
+}
@@ -0,0 +1,298 @@
+package game
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"intelligent-spatial-platform/internal/geo"
+)
+
+// Restricted area severities
+const (
+	SeverityWarn  = "warn"  // movement allowed, player is warned
+	SeverityBlock = "block" // movement rejected
+)
+
+// Restricted area schedules
+const (
+	ScheduleAlways  = "always"  // enforced at all times
+	ScheduleWindows = "windows" // enforced only during TimeWindows
+)
+
+// restrictedAreaColumns selects the geometry as GeoJSON alongside the plain columns
+const restrictedAreaColumns = "id, name, reason, severity, schedule, windows, is_active, created_at, updated_at, ST_AsGeoJSON(geom) AS geometry"
+
+var clockPattern = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d$`)
+
+// RestrictedArea is a geofence players may not move into or through
+type RestrictedArea struct {
+	ID        uint         `json:"id" gorm:"primaryKey"`
+	Name      string       `json:"name" gorm:"not null"`
+	Reason    string       `json:"reason"`
+	Severity  string       `json:"severity" gorm:"not null;default:block"`  // warn, block
+	Schedule  string       `json:"schedule" gorm:"not null;default:always"` // always, windows
+	Windows   []TimeWindow `json:"windows,omitempty" gorm:"type:jsonb;serializer:json"`
+	IsActive  bool         `json:"isActive" gorm:"default:true"`
+	Geom      string       `json:"-" gorm:"type:geography(MultiPolygon,4326);index:idx_restricted_areas_geom,type:gist"`
+	Geometry  geo.GeoJSON  `json:"geometry" gorm:"->;-:migration"`
+	CreatedAt time.Time    `json:"createdAt"`
+	UpdatedAt time.Time    `json:"updatedAt"`
+}
+
+// TimeWindow is a daily period (Asia/Taipei) when an area is enforced.
+// End before Start wraps past midnight; empty Days means every day (0 = Sunday).
+type TimeWindow struct {
+	Days  []time.Weekday `json:"days,omitempty"`
+	Start string         `json:"start"` // HH:MM
+	End   string         `json:"end"`   // HH:MM
+}
+
+// RestrictedAreaError names the area that blocked a movement
+type RestrictedAreaError struct {
+	Area   *RestrictedArea
+	OnPath bool // the route crosses the area but the destination is outside it
+}
+
+func (e *RestrictedAreaError) Error() string {
+	where := "destination is inside"
+	if e.OnPath {
+		where = "path crosses"
+	}
+	if e.Area.Reason != "" {
+		return fmt.Sprintf("%s restricted area 「%s」: %s", where, e.Area.Name, e.Area.Reason)
+	}
+	return fmt.Sprintf("%s restricted area 「%s」", where, e.Area.Name)
+}
+
+// restrictedAreaHit is a candidate area returned by the path query
+type restrictedAreaHit struct {
+	RestrictedArea
+	ContainsDestination bool
+}
+
+// Validate checks the fields an admin can set
+func (a *RestrictedArea) Validate() error {
+	if a.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if a.Severity == "" {
+		a.Severity = SeverityBlock
+	}
+	if a.Severity != SeverityWarn && a.Severity != SeverityBlock {
+		return fmt.Errorf("severity must be %q or %q", SeverityWarn, SeverityBlock)
+	}
+	if a.Schedule == "" {
+		a.Schedule = ScheduleAlways
+	}
+	switch a.Schedule {
+	case ScheduleAlways:
+	case ScheduleWindows:
+		if len(a.Windows) == 0 {
+			return fmt.Errorf("schedule %q needs at least one time window", ScheduleWindows)
+		}
+		for _, window := range a.Windows {
+			if !clockPattern.MatchString(window.Start) || !clockPattern.MatchString(window.End) {
+				return fmt.Errorf("time windows use HH:MM, got %q-%q", window.Start, window.End)
+			}
+			for _, day := range window.Days {
+				if day < time.Sunday || day > time.Saturday {
+					return fmt.Errorf("invalid weekday %d (0 = Sunday ... 6 = Saturday)", day)
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("schedule must be %q or %q", ScheduleAlways, ScheduleWindows)
+	}
+	return nil
+}
+
+// ActiveAt reports whether the area is enforced at t
+func (a *RestrictedArea) ActiveAt(t time.Time) bool {
+	if !a.IsActive {
+		return false
+	}
+	if a.Schedule != ScheduleWindows {
+		return true
+	}
+
+	local := t.In(geo.TaipeiTime)
+	clock := local.Format("15:04")
+	yesterday := local.Add(-24 * time.Hour).Weekday()
+
+	for _, window := range a.Windows {
+		if window.Start <= window.End {
+			if window.onDay(local.Weekday()) && clock >= window.Start && clock < window.End {
+				return true
+			}
+			continue
+		}
+		// Overnight window: the evening part belongs to today, the early hours to yesterday's window
+		if (window.onDay(local.Weekday()) && clock >= window.Start) || (window.onDay(yesterday) && clock < window.End) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w TimeWindow) onDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// ListRestrictedAreas returns all restricted areas with their geometry
+func (s *Service) ListRestrictedAreas() ([]RestrictedArea, error) {
+	var areas []RestrictedArea
+	err := s.db.Model(&RestrictedArea{}).Select(restrictedAreaColumns).Order("id").Find(&areas).Error
+	return areas, err
+}
+
+// GetRestrictedArea returns a single restricted area
+func (s *Service) GetRestrictedArea(id uint) (*RestrictedArea, error) {
+	var area RestrictedArea
+	if err := s.db.Model(&RestrictedArea{}).Select(restrictedAreaColumns).First(&area, id).Error; err != nil {
+		return nil, err
+	}
+	return &area, nil
+}
+
+// CreateRestrictedArea stores a new area; geometry is a GeoJSON Polygon or MultiPolygon
+func (s *Service) CreateRestrictedArea(area *RestrictedArea, geometry geo.GeoJSON) (*RestrictedArea, error) {
+	if err := area.Validate(); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Select explicitly so IsActive=false is not replaced by the column default
+		fields := []string{"Name", "Reason", "Severity", "Schedule", "Windows", "IsActive", "CreatedAt", "UpdatedAt"}
+		if err := tx.Select(fields).Create(area).Error; err != nil {
+			return err
+		}
+		return setRestrictedAreaGeometry(tx, area.ID, geometry)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetRestrictedArea(area.ID)
+}
+
+// UpdateRestrictedArea replaces an area's fields, and its geometry when one is given
+func (s *Service) UpdateRestrictedArea(id uint, area *RestrictedArea, geometry geo.GeoJSON) (*RestrictedArea, error) {
+	if err := area.Validate(); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&RestrictedArea{ID: id}).
+			Select("Name", "Reason", "Severity", "Schedule", "Windows", "IsActive").
+			Updates(area)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if len(geometry) == 0 {
+			return nil
+		}
+		return setRestrictedAreaGeometry(tx, id, geometry)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetRestrictedArea(id)
+}
+
+// DeleteRestrictedArea removes an area
+func (s *Service) DeleteRestrictedArea(id uint) error {
+	result := s.db.Delete(&RestrictedArea{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func setRestrictedAreaGeometry(tx *gorm.DB, id uint, geometry geo.GeoJSON) error {
+	return tx.Exec(
+		"UPDATE restricted_areas SET geom = ST_Multi(ST_CollectionExtract(ST_MakeValid(ST_SetSRID(ST_GeomFromGeoJSON(?), 4326)), 3))::geography WHERE id = ?",
+		string(geometry), id,
+	).Error
+}
+
+// checkRestrictedAreas rejects a move whose destination or straight-line path
+// touches an enforced block area. Warn areas are returned for the caller to report.
+func (s *Service) checkRestrictedAreas(from, to *geo.Location) ([]*RestrictedArea, error) {
+	path := []geo.Location{*to}
+	if from != nil && (from.Latitude != to.Latitude || from.Longitude != to.Longitude) {
+		path = []geo.Location{*from, *to}
+	}
+	return s.checkRestrictedPath(path)
+}
+
+// checkRestrictedPath is checkRestrictedAreas for a path through any number of
+// points; the last one is the destination
+func (s *Service) checkRestrictedPath(points []geo.Location) ([]*RestrictedArea, error) {
+	if s.db == nil {
+		return nil, nil
+	}
+
+	to := points[len(points)-1]
+	destination := fmt.Sprintf("SRID=4326;POINT(%f %f)", to.Longitude, to.Latitude)
+	path := destination
+	if len(points) > 1 {
+		coordinates := make([]string, len(points))
+		for i, point := range points {
+			coordinates[i] = fmt.Sprintf("%f %f", point.Longitude, point.Latitude)
+		}
+		path = "SRID=4326;LINESTRING(" + strings.Join(coordinates, ", ") + ")"
+	}
+
+	// ST_Intersects on geography uses the GiST index on geom
+	var hits []restrictedAreaHit
+	err := s.db.Model(&RestrictedArea{}).
+		Select(restrictedAreaColumns+", ST_Intersects(geom, ST_GeogFromText(?)) AS contains_destination", destination).
+		Where("is_active = true AND ST_Intersects(geom, ST_GeogFromText(?))", path).
+		Order("id").
+		Find(&hits).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check restricted areas: %v", err)
+	}
+
+	now := time.Now()
+	var warnings []*RestrictedArea
+	var blocked *RestrictedAreaError
+
+	for i := range hits {
+		area := &hits[i].RestrictedArea
+		if !area.ActiveAt(now) {
+			continue
+		}
+		if area.Severity == SeverityWarn {
+			warnings = append(warnings, area)
+			continue
+		}
+		// Prefer reporting the area around the destination over one merely crossed
+		if blocked == nil || (blocked.OnPath && hits[i].ContainsDestination) {
+			blocked = &RestrictedAreaError{Area: area, OnPath: !hits[i].ContainsDestination}
+		}
+	}
+
+	if blocked != nil {
+		return warnings, blocked
+	}
+	return warnings, nil
+}
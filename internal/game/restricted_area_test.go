@@ -0,0 +1,87 @@
+package game
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"intelligent-spatial-platform/internal/geo"
+)
+
+func TestRestrictedAreaValidate(t *testing.T) {
+	area := &RestrictedArea{Name: "總統府"}
+	if err := area.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if area.Severity != SeverityBlock || area.Schedule != ScheduleAlways {
+		t.Errorf("defaults = %s/%s, want block/always", area.Severity, area.Schedule)
+	}
+
+	invalid := []*RestrictedArea{
+		{},
+		{Name: "x", Severity: "critical"},
+		{Name: "x", Schedule: ScheduleWindows},
+		{Name: "x", Schedule: ScheduleWindows, Windows: []TimeWindow{{Start: "9:00", End: "17:00"}}},
+		{Name: "x", Schedule: ScheduleWindows, Windows: []TimeWindow{{Days: []time.Weekday{7}, Start: "09:00", End: "17:00"}}},
+	}
+	for _, area := range invalid {
+		if err := area.Validate(); err == nil {
+			t.Errorf("Validate(%+v) should fail", area)
+		}
+	}
+}
+
+func TestRestrictedAreaActiveAt(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, err := time.ParseInLocation("2006-01-02 15:04", value, geo.TaipeiTime)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	always := &RestrictedArea{Name: "a", Schedule: ScheduleAlways, IsActive: true}
+	if !always.ActiveAt(at("2024-05-01 03:00")) {
+		t.Error("always area should be active")
+	}
+
+	disabled := &RestrictedArea{Name: "a", Schedule: ScheduleAlways}
+	if disabled.ActiveAt(at("2024-05-01 03:00")) {
+		t.Error("inactive area should never be enforced")
+	}
+
+	// Weekdays 09:00-17:00 plus an overnight Friday window 22:00-06:00
+	scheduled := &RestrictedArea{
+		Name:     "b",
+		Schedule: ScheduleWindows,
+		IsActive: true,
+		Windows: []TimeWindow{
+			{Days: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}, Start: "09:00", End: "17:00"},
+			{Days: []time.Weekday{time.Friday}, Start: "22:00", End: "06:00"},
+		},
+	}
+
+	tests := []struct {
+		when   string
+		active bool
+	}{
+		{"2024-05-01 10:30", true},  // Wednesday
+		{"2024-05-01 17:00", false}, // end is exclusive
+		{"2024-05-04 10:30", false}, // Saturday
+		{"2024-05-03 23:00", true},  // Friday night
+		{"2024-05-04 05:59", true},  // early Saturday, still Friday's window
+		{"2024-05-05 05:00", false}, // early Sunday
+	}
+	for _, tt := range tests {
+		if got := scheduled.ActiveAt(at(tt.when)); got != tt.active {
+			t.Errorf("ActiveAt(%s) = %v, want %v", tt.when, got, tt.active)
+		}
+	}
+}
+
+func TestRestrictedAreaErrorNamesArea(t *testing.T) {
+	err := &RestrictedAreaError{Area: &RestrictedArea{Name: "松山機場", Reason: "機場管制區"}, OnPath: true}
+	if !strings.Contains(err.Error(), "松山機場") || !strings.Contains(err.Error(), "path crosses") {
+		t.Errorf("Error() = %q", err.Error())
+	}
+}
@@ -1,15 +1,21 @@
 package game
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"math"
 	"math/rand"
+	"os"
+	"sync"
 	"time"
 
 	"gorm.io/gorm"
 	"intelligent-spatial-platform/internal/ai"
+	"intelligent-spatial-platform/internal/cluster"
 	"intelligent-spatial-platform/internal/geo"
+	"intelligent-spatial-platform/internal/routing"
+	"intelligent-spatial-platform/internal/tiles"
 )
 
 type Service struct {
@@ -17,7 +23,19 @@ type Service struct {
 	aiService        *ai.Service
 	geocodingService *geo.GeocodingService
 	movementParser   *ai.MovementCommandParser
-	rateLimiter      map[string]*RateLimit
+	rateLimiter      map[string]*RateLimit // keyed by source:playerID
+	rateMu           sync.Mutex
+	simulator        *MovementSimulator
+	pendingMoves     *pendingMoves
+	recentPlaces     *recentPlaces
+	siteLocator      HistoricalSiteLocator
+	tracks           *trackRecorder
+	resources        geo.Resources
+}
+
+// HistoricalSiteLocator finds a historical site near a point (implemented by geo.Service)
+type HistoricalSiteLocator interface {
+	GetNearbyHistoricalSite(lat, lng, radiusMeters float64) (*geo.HistoricalSite, error)
 }
 
 type CollectResult struct {
@@ -44,11 +62,26 @@ type AIMovementResult struct {
 	ErrorCode        string                 `json:"errorCode,omitempty"`
 	RateLimited      bool                   `json:"rateLimited,omitempty"`
 	Audit            *ai.MovementAudit      `json:"audit,omitempty"`
+	Travel           *TravelStatus          `json:"travel,omitempty"`
+	RestrictedArea   *RestrictedArea        `json:"restrictedArea,omitempty"`
+	Warnings         []string               `json:"warnings,omitempty"`
+	Clarification    *Clarification         `json:"clarification,omitempty"`
+}
+
+// DirectMoveResult is the outcome of a client-supplied position change
+type DirectMoveResult struct {
+	Success   bool               `json:"success"`
+	Position  *geo.Location      `json:"position,omitempty"`
+	Warnings  []string           `json:"warnings,omitempty"`
+	Violation *MovementViolation `json:"violation,omitempty"`
+	Audit     *ai.MovementAudit  `json:"audit,omitempty"`
 }
 
-func NewService(db *gorm.DB, aiService *ai.Service) *Service {
+func NewService(db *gorm.DB, aiService *ai.Service, resources geo.Resources) *Service {
+	resources = resources.WithDefaults()
+
 	// Initialize geocoding service
-	geocodingService, err := geo.NewGeocodingService()
+	geocodingService, err := geo.NewGeocodingServiceWithDB(db, resources)
 	if err != nil {
 		// Log error but don't fail service initialization
 		fmt.Printf("Warning: Failed to initialize geocoding service in game service: %v\n", err)
@@ -59,23 +92,29 @@ func NewService(db *gorm.DB, aiService *ai.Service) *Service {
 		aiService:        aiService,
 		geocodingService: geocodingService,
 		rateLimiter:      make(map[string]*RateLimit),
+		pendingMoves:     newPendingMoves(),
+		recentPlaces:     newRecentPlaces(),
+		tracks:           newTrackRecorder(),
+		resources:        resources,
 	}
 
 	// Initialize movement parser with geocoding service
-	service.movementParser = ai.NewMovementCommandParser(aiService, geocodingService)
+	service.movementParser = ai.NewMovementCommandParser(aiService, geocodingService, resources)
 
 	return service
 }
 
 func (s *Service) CreatePlayer(id, name string, lat, lng float64) (*Player, error) {
+	now := time.Now()
 	player := &Player{
-		ID:        id,
-		Name:      name,
-		Latitude:  lat,
-		Longitude: lng,
-		Score:     0,
-		Level:     1,
-		IsActive:  true,
+		ID:          id,
+		Name:        name,
+		Latitude:    lat,
+		Longitude:   lng,
+		Score:       0,
+		Level:       1,
+		IsActive:    true,
+		LastMovedAt: &now,
 	}
 
 	if err := s.db.Create(player).Error; err != nil {
@@ -118,11 +157,151 @@ func (s *Service) GetAllSessions() ([]GameSession, error) {
 	return sessions, nil
 }
 
-func (s *Service) MovePlayer(playerID string, lat, lng float64) error {
+// EnableTravelSimulation makes AI movement commands travel over time instead of teleporting.
+// Positions are published through broadcaster and arrival runs the historical-site proximity check.
+func (s *Service) EnableTravelSimulation(broadcaster Broadcaster, sites HistoricalSiteLocator) {
+	tick := DefaultTravelTick
+	if tickStr := os.Getenv("GAME_TRAVEL_TICK"); tickStr != "" {
+		if parsed, err := time.ParseDuration(tickStr); err == nil && parsed > 0 {
+			tick = parsed
+		}
+	}
+
+	s.siteLocator = sites
+	s.simulator = NewMovementSimulator(broadcaster, tick, s.updatePlayerPosition, s.handleArrival)
+}
+
+// GetTravelStatus returns the player's in-progress trip, or nil if they are not travelling
+func (s *Service) GetTravelStatus(playerID string) *TravelStatus {
+	if s.simulator == nil {
+		return nil
+	}
+	return s.simulator.GetTravelStatus(playerID)
+}
+
+// CancelTravel stops the player where they currently are
+func (s *Service) CancelTravel(playerID string) *TravelStatus {
+	if s.simulator == nil {
+		return nil
+	}
+	return s.simulator.CancelTravel(playerID)
+}
+
+// MovePlayer validates a client-supplied position through the movement pipeline
+// and places the player there. A rejected move returns a result with the violation.
+func (s *Service) MovePlayer(playerID string, lat, lng float64, ipAddress string) (*DirectMoveResult, error) {
+	player, err := s.GetPlayerStatus(playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &MoveRequest{
+		PlayerID:   playerID,
+		Source:     MoveSourceDirect,
+		From:       &geo.Location{Latitude: player.Latitude, Longitude: player.Longitude},
+		To:         &geo.Location{Latitude: lat, Longitude: lng},
+		LastMoveAt: player.lastMoved(),
+		IPAddress:  ipAddress,
+	}
+	command := &ai.MovementCommand{
+		Type:          "move",
+		Action:        "direct_move",
+		Destination:   request.To,
+		OriginalText:  fmt.Sprintf("%f,%f", lat, lng),
+		Parameters:    map[string]interface{}{"source": MoveSourceDirect},
+		SafetyChecked: true,
+	}
+
+	validation, err := s.ValidateMove(request)
+	var violation *MovementViolation
+	if errors.As(err, &violation) {
+		return &DirectMoveResult{
+			Success:   false,
+			Violation: violation,
+			Audit:     s.movementParser.LogMovementCommand(playerID, "", ipAddress, command, false, violation.Message),
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	command.Destination = validation.Destination
+	if err := s.placePlayer(playerID, validation.Destination.Latitude, validation.Destination.Longitude); err != nil {
+		return nil, err
+	}
+
+	return &DirectMoveResult{
+		Success:  true,
+		Position: validation.Destination,
+		Warnings: validation.Warnings,
+		Audit:    s.movementParser.LogMovementCommand(playerID, "", ipAddress, command, true, ""),
+	}, nil
+}
+
+// placePlayer puts the player at the given position immediately, cancelling any trip in progress
+func (s *Service) placePlayer(playerID string, lat, lng float64) error {
+	if s.simulator != nil {
+		s.simulator.CancelTravel(playerID)
+	}
+
+	return s.updatePlayerPosition(playerID, lat, lng)
+}
+
+// travelPath is the way a trip by mode goes: along the roads when the network
+// is loaded and has a route, else in a straight line. Roads may cross an
+// enforced restricted area the validated straight line does not, so such a
+// route is not taken.
+func (s *Service) travelPath(from, to *geo.Location, mode ai.TransportMode) []geo.Location {
+	straight := []geo.Location{*from, *to}
+	graph := s.resources.Roads
+	if graph == nil {
+		return straight
+	}
+	profile, err := routing.ParseProfile(ai.RoutingProfile(mode))
+	if err != nil {
+		return straight
+	}
+	route, err := graph.Route(profile, []routing.LatLng{
+		{Latitude: from.Latitude, Longitude: from.Longitude},
+		{Latitude: to.Latitude, Longitude: to.Longitude},
+	})
+	if err != nil {
+		return straight
+	}
+
+	// The route runs between the points snapped onto the roads
+	path := make([]geo.Location, 0, len(route.Geometry)+2)
+	path = append(path, *from)
+	for _, point := range route.Geometry {
+		path = append(path, geo.Location{Latitude: point.Latitude, Longitude: point.Longitude})
+	}
+	path = append(path, *to)
+
+	if _, err := s.checkRestrictedPath(path); err != nil {
+		log.Printf("⚠️ Road route for %s not taken: %v", mode, err)
+		return straight
+	}
+	return path
+}
+
+// lastMoved is when the player's position last changed. Players created before
+// LastMovedAt existed have none; their UpdatedAt is no earlier than their last
+// move, so speed checks still apply to them.
+func (p *Player) lastMoved() time.Time {
+	if p.LastMovedAt == nil {
+		return p.UpdatedAt
+	}
+	return *p.LastMovedAt
+}
+
+// updatePlayerPosition stores a new position; it is the only write of LastMovedAt
+func (s *Service) updatePlayerPosition(playerID string, lat, lng float64) error {
+	now := time.Now()
 	result := s.db.Model(&Player{}).Where("id = ?", playerID).Updates(Player{
-		Latitude:  lat,
-		Longitude: lng,
-		UpdatedAt: time.Now(),
+		Latitude:    lat,
+		Longitude:   lng,
+		LastMovedAt: &now,
+		UpdatedAt:   now,
 	})
 
 	if result.Error != nil {
@@ -133,6 +312,7 @@ func (s *Service) MovePlayer(playerID string, lat, lng float64) error {
 		return fmt.Errorf("player not found")
 	}
 
+	s.recordTrackPoint(playerID, lat, lng)
 	return nil
 }
 
@@ -161,6 +341,8 @@ func (s *Service) CollectItem(playerID, itemID string, playerLat, playerLng floa
 	if err := s.db.Save(&item).Error; err != nil {
 		return nil, err
 	}
+	s.resources.TileCache.Invalidate(tiles.LayerItems)
+	s.resources.Clusters.Remove(tiles.LayerItems, item.ID)
 
 	var player Player
 	if err := s.db.First(&player, "id = ?", playerID).Error; err != nil {
@@ -184,10 +366,14 @@ func (s *Service) CollectItem(playerID, itemID string, playerLat, playerLng floa
 func (s *Service) SpawnRandomItems(count int, bounds map[string]float64) error {
 	items := generateRandomItems(count, bounds)
 
+	// Items created before a failure are already stored, so invalidate either way
+	defer s.resources.TileCache.Invalidate(tiles.LayerItems)
+
 	for _, item := range items {
 		if err := s.db.Create(&item).Error; err != nil {
 			return err
 		}
+		s.resources.Clusters.Upsert(cluster.Point{Layer: tiles.LayerItems, ID: item.ID, Name: item.Name, Latitude: item.Latitude, Longitude: item.Longitude})
 	}
 
 	return nil
@@ -195,8 +381,8 @@ func (s *Service) SpawnRandomItems(count int, bounds map[string]float64) error {
 
 func (s *Service) GetActiveItems(bounds map[string]float64) ([]Item, error) {
 	var items []Item
-	err := s.db.Where("is_collected = false AND latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?",
-		bounds["south"], bounds["north"], bounds["west"], bounds["east"]).Find(&items).Error
+	err := s.db.Where(geo.BoundsCondition, bounds["west"], bounds["south"], bounds["east"], bounds["north"]).
+		Where("is_collected = false").Find(&items).Error
 
 	return items, err
 }
@@ -288,8 +474,10 @@ func generateID() string {
 
 // AI-controlled secure movement system
 func (s *Service) ProcessAIMovementCommand(playerID, command, sessionID, ipAddress string) (*AIMovementResult, error) {
-	// Check rate limiting first
-	if s.isRateLimited(playerID) {
+	// Check rate limiting before spending a geocoder call
+	if s.isRateLimited(playerID, MoveSourceAI) {
+		violation := newViolation(ViolationRateLimited, "too many movement commands, try again later")
+		s.recordViolation(&MoveRequest{PlayerID: playerID, Source: MoveSourceAI, IPAddress: ipAddress}, violation)
 		return &AIMovementResult{
 			Success:     false,
 			Message:     "移動指令頻率過高，請稍後再試",
@@ -313,12 +501,33 @@ func (s *Service) ProcessAIMovementCommand(playerID, command, sessionID, ipAddre
 		Longitude: player.Longitude,
 	}
 
+	// A reply such as "第二個" answers an open clarification
+	if pending := s.pendingMoves.get(playerID); pending != nil {
+		if _, ok := ai.ParseCandidateSelection(command, len(pending.Candidates)); ok {
+			return s.ConfirmPendingMove(playerID, pending.Token, command, sessionID, ipAddress)
+		}
+	}
+
 	// Parse movement command using AI
 	moveCmd, err := s.movementParser.ParseMovementCommand(command, currentLocation)
 	if err != nil {
 		// Log the failed attempt
 		audit := s.movementParser.LogMovementCommand(playerID, sessionID, ipAddress, nil, false, err.Error())
 
+		// Several places fit equally well: ask instead of guessing
+		var ambiguous *geo.AmbiguousLocationError
+		if errors.As(err, &ambiguous) {
+			clarification := newClarification(ambiguous, command)
+			s.pendingMoves.put(playerID, clarification)
+			return &AIMovementResult{
+				Success:       false,
+				Message:       clarification.Question,
+				ErrorCode:     "NEEDS_CLARIFICATION",
+				Clarification: clarification,
+				Audit:         audit,
+			}, nil
+		}
+
 		return &AIMovementResult{
 			Success:   false,
 			Message:   "無法解析移動指令：" + err.Error(),
@@ -327,10 +536,60 @@ func (s *Service) ProcessAIMovementCommand(playerID, command, sessionID, ipAddre
 		}, nil
 	}
 
-	// Additional security validation
-	if err := s.validateMovementSecurity(moveCmd, currentLocation); err != nil {
+	return s.executeAIMovement(player, command, moveCmd, sessionID, ipAddress)
+}
+
+// executeAIMovement validates a parsed command and starts the move
+func (s *Service) executeAIMovement(player *Player, command string, moveCmd *ai.MovementCommand, sessionID, ipAddress string) (*AIMovementResult, error) {
+	playerID := player.ID
+	currentLocation := &geo.Location{
+		Latitude:  player.Latitude,
+		Longitude: player.Longitude,
+	}
+
+	// Additional security validation, then the pipeline shared with direct moves
+	request := &MoveRequest{
+		PlayerID:   playerID,
+		Source:     MoveSourceAI,
+		From:       currentLocation,
+		To:         moveCmd.Destination,
+		LastMoveAt: player.lastMoved(),
+		IPAddress:  ipAddress,
+	}
+	validation, err := s.validateMovementSecurity(moveCmd, request)
+	if err != nil {
 		audit := s.movementParser.LogMovementCommand(playerID, sessionID, ipAddress, moveCmd, false, err.Error())
 
+		var violation *MovementViolation
+		if errors.As(err, &violation) {
+			switch violation.Code {
+			case ViolationRateLimited:
+				return &AIMovementResult{
+					Success:     false,
+					Message:     "移動指令頻率過高，請稍後再試",
+					ErrorCode:   violation.Code,
+					RateLimited: true,
+					Audit:       audit,
+				}, nil
+			case ViolationRestrictedArea:
+				return &AIMovementResult{
+					Success:         false,
+					Message:         fmt.Sprintf("無法前往：目的地或路線經過管制區「%s」", violation.RestrictedArea.Name),
+					ErrorCode:       violation.Code,
+					MovementCommand: moveCmd,
+					RestrictedArea:  violation.RestrictedArea,
+					Audit:           audit,
+				}, nil
+			}
+			return &AIMovementResult{
+				Success:         false,
+				Message:         "移動指令安全驗證失敗：" + violation.Message,
+				ErrorCode:       violation.Code,
+				MovementCommand: moveCmd,
+				Audit:           audit,
+			}, nil
+		}
+
 		return &AIMovementResult{
 			Success:         false,
 			Message:         "移動指令安全驗證失敗：" + err.Error(),
@@ -340,8 +599,14 @@ func (s *Service) ProcessAIMovementCommand(playerID, command, sessionID, ipAddre
 		}, nil
 	}
 
-	// Execute the movement
-	err = s.MovePlayer(playerID, moveCmd.Destination.Latitude, moveCmd.Destination.Longitude)
+	// Execute the movement (animated travel when the simulator is enabled)
+	var travel *TravelStatus
+	if s.simulator != nil {
+		path := s.travelPath(currentLocation, moveCmd.Destination, moveCmd.Mode)
+		travel = s.simulator.StartTravel(playerID, path, time.Duration(moveCmd.EstimatedTime)*time.Second)
+	} else {
+		err = s.placePlayer(playerID, moveCmd.Destination.Latitude, moveCmd.Destination.Longitude)
+	}
 	if err != nil {
 		audit := s.movementParser.LogMovementCommand(playerID, sessionID, ipAddress, moveCmd, false, err.Error())
 
@@ -354,9 +619,6 @@ func (s *Service) ProcessAIMovementCommand(playerID, command, sessionID, ipAddre
 		}, nil
 	}
 
-	// Update rate limiter
-	s.updateRateLimit(playerID)
-
 	// Log successful movement
 	audit := s.movementParser.LogMovementCommand(playerID, sessionID, ipAddress, moveCmd, true, "")
 
@@ -365,16 +627,10 @@ func (s *Service) ProcessAIMovementCommand(playerID, command, sessionID, ipAddre
 	if err != nil {
 		log.Printf("⚠️ AI 生成回應失敗 (使用 fallback): %v", err)
 		// Fallback message if AI service is unavailable or rate limited
-		aiResponse = fmt.Sprintf("✅ 好的！帶你去 %s 😊", moveCmd.Destination.Name)
-		if moveCmd.Destination.Name == "" || moveCmd.Destination.Name == moveCmd.Destination.Address {
-			aiResponse = fmt.Sprintf("✅ 好的！帶你去 %s 😊", moveCmd.Destination.Address)
-		}
+		aiResponse = fallbackMovementMessage(moveCmd)
 	} else if aiResponse == "" {
 		log.Printf("⚠️ AI 返回空回應 (使用 fallback)")
-		aiResponse = fmt.Sprintf("✅ 好的！帶你去 %s 😊", moveCmd.Destination.Name)
-		if moveCmd.Destination.Name == "" || moveCmd.Destination.Name == moveCmd.Destination.Address {
-			aiResponse = fmt.Sprintf("✅ 好的！帶你去 %s 😊", moveCmd.Destination.Address)
-		}
+		aiResponse = fallbackMovementMessage(moveCmd)
 	} else {
 		log.Printf("✅ AI 成功生成回應: %s", aiResponse)
 	}
@@ -386,68 +642,75 @@ func (s *Service) ProcessAIMovementCommand(playerID, command, sessionID, ipAddre
 		NewPosition:     moveCmd.Destination,
 		EstimatedTime:   moveCmd.EstimatedTime,
 		Audit:           audit,
+		Travel:          travel,
+		Warnings:        validation.Warnings,
 	}, nil
 }
 
-func (s *Service) isRateLimited(playerID string) bool {
-	limit, exists := s.rateLimiter[playerID]
-	if !exists {
-		s.rateLimiter[playerID] = &RateLimit{
-			Count:          0,
-			LastReset:      time.Now(),
-			WindowDuration: 1 * time.Minute, // 1-minute window
-			MaxRequests:    10,               // max 10 movement commands per minute
-		}
-		return false
+// handleArrival runs the historical-site proximity check once a trip completes
+func (s *Service) handleArrival(playerID string, location geo.Location) {
+	if s.siteLocator == nil {
+		return
+	}
+
+	site, err := s.siteLocator.GetNearbyHistoricalSite(location.Latitude, location.Longitude, 100.0)
+	if err != nil || site == nil {
+		return
 	}
 
-	// Reset if window expired
-	if time.Since(limit.LastReset) > limit.WindowDuration {
-		limit.Count = 0
-		limit.LastReset = time.Now()
+	introduction, err := s.aiService.GenerateHistoricalSiteIntroduction(site)
+	if err != nil {
+		log.Printf("⚠️ 無法生成歷史景點介紹: %v", err)
 	}
 
-	return limit.Count >= limit.MaxRequests
+	if s.simulator != nil && s.simulator.broadcaster != nil {
+		s.simulator.broadcaster.BroadcastMessage("historical_site_reached", map[string]interface{}{
+			"playerId":       playerID,
+			"historicalSite": site.WithMediaURLs(s.resources.Media),
+			"aiIntroduction": introduction,
+		})
+	}
 }
 
-func (s *Service) updateRateLimit(playerID string) {
-	if limit, exists := s.rateLimiter[playerID]; exists {
-		limit.Count++
+// fallbackMovementMessage builds the reply used when the AI can't generate one
+func fallbackMovementMessage(moveCmd *ai.MovementCommand) string {
+	destination := moveCmd.Destination.Name
+	if destination == "" || destination == moveCmd.Destination.Address {
+		destination = moveCmd.Destination.Address
 	}
+
+	profile := ai.GetTransportProfile(moveCmd.Mode)
+	return fmt.Sprintf("✅ 好的！%s帶你去 %s，大約 %s 😊", profile.Verb, destination, ai.FormatTravelTime(moveCmd.EstimatedTime))
 }
 
-func (s *Service) validateMovementSecurity(moveCmd *ai.MovementCommand, currentLocation *geo.Location) error {
+func (s *Service) validateMovementSecurity(moveCmd *ai.MovementCommand, request *MoveRequest) (*MoveValidation, error) {
 	// Check if basic safety checks already passed
 	if !moveCmd.SafetyChecked {
-		return fmt.Errorf("movement command failed basic safety checks")
+		return nil, fmt.Errorf("movement command failed basic safety checks")
 	}
 
 	// Additional business logic validations
-	distance := calculateDistance(currentLocation.Latitude, currentLocation.Longitude,
+	distance := calculateDistance(request.From.Latitude, request.From.Longitude,
 		moveCmd.Destination.Latitude, moveCmd.Destination.Longitude)
 
 	// Prevent teleportation-like movements (allow Taiwan-wide travel)
 	if distance > 500000 { // 500km max single movement (covers all of Taiwan)
-		return fmt.Errorf("movement distance too large: %.2f meters (max: 500000 meters)", distance)
+		return nil, fmt.Errorf("movement distance too large: %.2f meters (max: 500000 meters)", distance)
 	}
 
 	// Check confidence level
 	if moveCmd.Confidence < 0.3 {
-		return fmt.Errorf("movement command confidence too low: %.1f%% (min: 30%%)", moveCmd.Confidence*100)
+		return nil, fmt.Errorf("movement command confidence too low: %.1f%% (min: 30%%)", moveCmd.Confidence*100)
 	}
 
-	// Validate against historical site boundaries (prevent moving into restricted areas)
-	if s.isInRestrictedArea(moveCmd.Destination) {
-		return fmt.Errorf("destination is in a restricted area")
+	// Bounds, rate limit and restricted areas are shared with direct moves
+	validation, err := s.ValidateMove(request)
+	if err != nil {
+		return nil, err
 	}
+	moveCmd.Destination = validation.Destination
 
-	return nil
-}
-
-func (s *Service) isInRestrictedArea(location *geo.Location) bool {
-	// This could check against a database of restricted areas
-	// For now, just return false (no restrictions)
-	return false
+	return validation, nil
 }
 
 // Get movement statistics for monitoring
@@ -456,11 +719,34 @@ func (s *Service) GetMovementStats(playerID string) map[string]interface{} {
 		"playerID": playerID,
 	}
 
-	if limit, exists := s.rateLimiter[playerID]; exists {
-		stats["rateLimitCount"] = limit.Count
-		stats["rateLimitWindow"] = limit.WindowDuration.String()
-		stats["rateLimitMax"] = limit.MaxRequests
-		stats["lastActivity"] = limit.LastReset
+	s.rateMu.Lock()
+	rateLimits := map[string]interface{}{}
+	for _, source := range []string{MoveSourceAI, MoveSourceDirect} {
+		limit, exists := s.rateLimiter[source+":"+playerID]
+		if !exists {
+			continue
+		}
+		rateLimits[source] = map[string]interface{}{
+			"count":        limit.Count,
+			"window":       limit.WindowDuration.String(),
+			"max":          limit.MaxRequests,
+			"lastActivity": limit.LastReset,
+		}
+		// Top-level fields describe AI commands, as before
+		if source == MoveSourceAI {
+			stats["rateLimitCount"] = limit.Count
+			stats["rateLimitWindow"] = limit.WindowDuration.String()
+			stats["rateLimitMax"] = limit.MaxRequests
+			stats["lastActivity"] = limit.LastReset
+		}
+	}
+	s.rateMu.Unlock()
+	stats["rateLimits"] = rateLimits
+
+	var player Player
+	if s.db != nil && s.db.First(&player, "id = ?", playerID).Error == nil {
+		stats["violationScore"] = player.ViolationScore
+		stats["flagged"] = player.FlaggedAt != nil
 	}
 
 	return stats
@@ -0,0 +1,293 @@
+package game
+
+import (
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"intelligent-spatial-platform/internal/geo"
+)
+
+// DefaultTravelTick is how often an in-transit player's position is advanced and published
+const DefaultTravelTick = 500 * time.Millisecond
+
+// Broadcaster publishes real-time events to connected clients (implemented by websocket.Hub)
+type Broadcaster interface {
+	BroadcastMessage(msgType string, data interface{})
+}
+
+// TravelStatus 移動中玩家的即時狀態
+type TravelStatus struct {
+	PlayerID    string         `json:"playerId"`
+	Origin      geo.Location   `json:"origin"`
+	Destination geo.Location   `json:"destination"`
+	Path        []geo.Location `json:"path"`
+	Position    geo.Location   `json:"position"`
+	Progress    float64        `json:"progress"` // 0-1
+	Distance    float64        `json:"distance"` // total path length in meters
+	StartedAt   time.Time      `json:"startedAt"`
+	ArrivesAt   time.Time      `json:"arrivesAt"`
+}
+
+// trip is a single in-flight movement along a polyline
+type trip struct {
+	playerID  string
+	path      []geo.Location
+	cumDist   []float64 // cumulative distance at each path vertex
+	total     float64
+	startedAt time.Time
+	duration  time.Duration
+	stop      chan struct{}
+
+	mu        sync.Mutex
+	position  geo.Location
+	progress  float64
+	cancelled bool
+}
+
+// MovementSimulator moves players along a path over time instead of teleporting them
+type MovementSimulator struct {
+	mu          sync.Mutex
+	trips       map[string]*trip
+	tick        time.Duration
+	broadcaster Broadcaster
+
+	// persist stores the interpolated position; onArrival runs once the trip completes
+	persist   func(playerID string, lat, lng float64) error
+	onArrival func(playerID string, location geo.Location)
+}
+
+// NewMovementSimulator creates a simulator that publishes positions every tick
+func NewMovementSimulator(broadcaster Broadcaster, tick time.Duration,
+	persist func(playerID string, lat, lng float64) error,
+	onArrival func(playerID string, location geo.Location)) *MovementSimulator {
+	if tick <= 0 {
+		tick = DefaultTravelTick
+	}
+
+	return &MovementSimulator{
+		trips:       make(map[string]*trip),
+		tick:        tick,
+		broadcaster: broadcaster,
+		persist:     persist,
+		onArrival:   onArrival,
+	}
+}
+
+// StartTravel starts moving the player along path over duration.
+// An existing trip for the same player is cancelled and the new one starts from
+// the player's current interpolated position, so a new command redirects travel.
+func (m *MovementSimulator) StartTravel(playerID string, path []geo.Location, duration time.Duration) *TravelStatus {
+	if len(path) == 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	if existing, ok := m.trips[playerID]; ok {
+		current := m.stopTrip(existing)
+		path = append([]geo.Location{current}, path[1:]...)
+	}
+
+	t := newTrip(playerID, path, duration)
+	m.trips[playerID] = t
+	m.mu.Unlock()
+
+	status := t.status()
+	m.publish("player_travel_started", status)
+
+	go m.run(t)
+
+	return status
+}
+
+// CancelTravel stops the player's trip at its current interpolated position.
+// It returns the final status, or nil if the player was not travelling.
+func (m *MovementSimulator) CancelTravel(playerID string) *TravelStatus {
+	m.mu.Lock()
+	t, ok := m.trips[playerID]
+	if !ok {
+		m.mu.Unlock()
+		return nil
+	}
+	m.stopTrip(t)
+	m.mu.Unlock()
+
+	status := t.status()
+	m.publish("player_travel_cancelled", status)
+	return status
+}
+
+// GetTravelStatus returns the current trip status, or nil if the player is not travelling
+func (m *MovementSimulator) GetTravelStatus(playerID string) *TravelStatus {
+	m.mu.Lock()
+	t, ok := m.trips[playerID]
+	m.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return t.status()
+}
+
+// stopTrip cancels the trip and returns the position it stopped at. Caller must hold m.mu.
+// Once it returns, the trip goroutine will not persist any further positions.
+func (m *MovementSimulator) stopTrip(t *trip) geo.Location {
+	t.mu.Lock()
+	t.cancelled = true
+	position := t.position
+	t.mu.Unlock()
+
+	close(t.stop)
+	delete(m.trips, t.playerID)
+	return position
+}
+
+func (m *MovementSimulator) run(t *trip) {
+	ticker := time.NewTicker(m.tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.stop:
+			return
+		case now := <-ticker.C:
+			progress, ok := m.step(t, now)
+			if !ok {
+				return
+			}
+
+			if progress >= 1 {
+				m.finish(t)
+				return
+			}
+
+			m.publish("player_position", t.status())
+		}
+	}
+}
+
+// step advances the trip and stores the new position, unless the trip was cancelled
+func (m *MovementSimulator) step(t *trip, now time.Time) (float64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cancelled {
+		return 0, false
+	}
+
+	t.advance(now)
+	m.store(t.playerID, t.position)
+	return t.progress, true
+}
+
+func (m *MovementSimulator) finish(t *trip) {
+	m.mu.Lock()
+	if m.trips[t.playerID] == t {
+		delete(m.trips, t.playerID)
+	}
+	m.mu.Unlock()
+
+	status := t.status()
+	m.publish("player_arrived", status)
+
+	if m.onArrival != nil {
+		go m.onArrival(t.playerID, status.Position)
+	}
+}
+
+func (m *MovementSimulator) store(playerID string, position geo.Location) {
+	if m.persist == nil {
+		return
+	}
+	if err := m.persist(playerID, position.Latitude, position.Longitude); err != nil {
+		log.Printf("⚠️ 無法更新移動中玩家 %s 的位置: %v", playerID, err)
+	}
+}
+
+func (m *MovementSimulator) publish(msgType string, status *TravelStatus) {
+	if m.broadcaster == nil || status == nil {
+		return
+	}
+	m.broadcaster.BroadcastMessage(msgType, status)
+}
+
+func newTrip(playerID string, path []geo.Location, duration time.Duration) *trip {
+	cumDist := make([]float64, len(path))
+	for i := 1; i < len(path); i++ {
+		cumDist[i] = cumDist[i-1] + calculateDistance(
+			path[i-1].Latitude, path[i-1].Longitude,
+			path[i].Latitude, path[i].Longitude,
+		)
+	}
+
+	return &trip{
+		playerID:  playerID,
+		path:      path,
+		cumDist:   cumDist,
+		total:     cumDist[len(cumDist)-1],
+		startedAt: time.Now(),
+		duration:  duration,
+		stop:      make(chan struct{}),
+		position:  path[0],
+	}
+}
+
+// advance moves the trip to the position for the given wall-clock time. Caller must hold t.mu.
+func (t *trip) advance(now time.Time) {
+	progress := 1.0
+	if t.duration > 0 {
+		progress = math.Min(1, float64(now.Sub(t.startedAt))/float64(t.duration))
+	}
+
+	t.position = t.interpolate(progress)
+	t.progress = progress
+}
+
+// interpolate returns the point at the given fraction of the total path length
+func (t *trip) interpolate(progress float64) geo.Location {
+	if progress <= 0 || len(t.path) == 1 || t.total == 0 {
+		if progress >= 1 {
+			return t.path[len(t.path)-1]
+		}
+		return t.path[0]
+	}
+	if progress >= 1 {
+		return t.path[len(t.path)-1]
+	}
+
+	target := progress * t.total
+	for i := 1; i < len(t.path); i++ {
+		if t.cumDist[i] < target {
+			continue
+		}
+		segment := t.cumDist[i] - t.cumDist[i-1]
+		ratio := 0.0
+		if segment > 0 {
+			ratio = (target - t.cumDist[i-1]) / segment
+		}
+		from, to := t.path[i-1], t.path[i]
+		return geo.Location{
+			Latitude:  from.Latitude + (to.Latitude-from.Latitude)*ratio,
+			Longitude: from.Longitude + (to.Longitude-from.Longitude)*ratio,
+		}
+	}
+
+	return t.path[len(t.path)-1]
+}
+
+func (t *trip) status() *TravelStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return &TravelStatus{
+		PlayerID:    t.playerID,
+		Origin:      t.path[0],
+		Destination: t.path[len(t.path)-1],
+		Path:        t.path,
+		Position:    t.position,
+		Progress:    t.progress,
+		Distance:    t.total,
+		StartedAt:   t.startedAt,
+		ArrivesAt:   t.startedAt.Add(t.duration),
+	}
+}
@@ -0,0 +1,193 @@
+package game
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"intelligent-spatial-platform/internal/ai"
+	"intelligent-spatial-platform/internal/geo"
+	"intelligent-spatial-platform/internal/routing"
+)
+
+type recordingBroadcaster struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (b *recordingBroadcaster) BroadcastMessage(msgType string, data interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.messages = append(b.messages, msgType)
+}
+
+func (b *recordingBroadcaster) count(msgType string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := 0
+	for _, m := range b.messages {
+		if m == msgType {
+			n++
+		}
+	}
+	return n
+}
+
+type positionStore struct {
+	mu   sync.Mutex
+	last geo.Location
+	n    int
+}
+
+func (p *positionStore) persist(playerID string, lat, lng float64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.last = geo.Location{Latitude: lat, Longitude: lng}
+	p.n++
+	return nil
+}
+
+// TestTripInterpolation tests that positions follow the path by distance
+func TestTripInterpolation(t *testing.T) {
+	path := []geo.Location{
+		{Latitude: 25.0, Longitude: 121.0},
+		{Latitude: 25.0, Longitude: 121.1},
+		{Latitude: 25.1, Longitude: 121.1},
+	}
+	tr := newTrip("player1", path, time.Minute)
+
+	start := tr.interpolate(0)
+	if start.Latitude != 25.0 || start.Longitude != 121.0 {
+		t.Errorf("Expected start of path, got %+v", start)
+	}
+
+	end := tr.interpolate(1)
+	if end.Latitude != 25.1 || end.Longitude != 121.1 {
+		t.Errorf("Expected end of path, got %+v", end)
+	}
+
+	// The first leg is slightly shorter than the second, so halfway lies on the second leg
+	mid := tr.interpolate(0.5)
+	if mid.Longitude != 121.1 || mid.Latitude <= 25.0 || mid.Latitude >= 25.1 {
+		t.Errorf("Expected halfway point on second leg, got %+v", mid)
+	}
+}
+
+// TestSimulatorArrival tests that a trip completes, persists positions and fires arrival
+func TestSimulatorArrival(t *testing.T) {
+	broadcaster := &recordingBroadcaster{}
+	store := &positionStore{}
+	arrived := make(chan geo.Location, 1)
+
+	sim := NewMovementSimulator(broadcaster, 5*time.Millisecond, store.persist,
+		func(playerID string, location geo.Location) { arrived <- location })
+
+	path := []geo.Location{{Latitude: 25.0, Longitude: 121.0}, {Latitude: 25.01, Longitude: 121.01}}
+	sim.StartTravel("player1", path, 30*time.Millisecond)
+
+	select {
+	case location := <-arrived:
+		if location.Latitude != 25.01 || location.Longitude != 121.01 {
+			t.Errorf("Expected arrival at destination, got %+v", location)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Trip did not arrive")
+	}
+
+	if sim.GetTravelStatus("player1") != nil {
+		t.Error("Trip should be cleared after arrival")
+	}
+	if broadcaster.count("player_arrived") != 1 {
+		t.Error("Expected one player_arrived message")
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.last.Latitude != 25.01 {
+		t.Errorf("Expected stored position at destination, got %+v", store.last)
+	}
+}
+
+// TestSimulatorCancelAndRedirect tests cancelling and redirecting a trip in progress
+func TestSimulatorCancelAndRedirect(t *testing.T) {
+	broadcaster := &recordingBroadcaster{}
+	store := &positionStore{}
+	sim := NewMovementSimulator(broadcaster, 5*time.Millisecond, store.persist, nil)
+
+	origin := geo.Location{Latitude: 25.0, Longitude: 121.0}
+	sim.StartTravel("player1", []geo.Location{origin, {Latitude: 25.5, Longitude: 121.0}}, time.Hour)
+	time.Sleep(20 * time.Millisecond)
+
+	redirected := sim.StartTravel("player1", []geo.Location{origin, {Latitude: 24.5, Longitude: 121.0}}, time.Hour)
+	if redirected.Origin.Latitude < 25.0 {
+		t.Errorf("Redirected trip should start from the interpolated position, got %+v", redirected.Origin)
+	}
+	if redirected.Destination.Latitude != 24.5 {
+		t.Errorf("Expected new destination, got %+v", redirected.Destination)
+	}
+
+	status := sim.CancelTravel("player1")
+	if status == nil {
+		t.Fatal("Expected cancelled trip status")
+	}
+
+	store.mu.Lock()
+	writes := store.n
+	store.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.n != writes {
+		t.Error("Cancelled trip should not persist further positions")
+	}
+
+	if sim.CancelTravel("player1") != nil {
+		t.Error("Cancelling twice should return nil")
+	}
+	if broadcaster.count("player_travel_cancelled") != 1 {
+		t.Error("Expected one player_travel_cancelled message")
+	}
+}
+
+// TestTravelPathFollowsRoads tests that trips take the road network when it is loaded
+func TestTravelPathFollowsRoads(t *testing.T) {
+	// Two streets meeting at a corner: west to east, then north
+	builder := routing.NewBuilder()
+	builder.SetNode(1, 25.030, 121.560)
+	builder.SetNode(2, 25.030, 121.564)
+	builder.SetNode(3, 25.034, 121.564)
+	builder.AddWay([]int64{1, 2, 3}, map[string]string{"highway": "residential"})
+	graph, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	from := &geo.Location{Latitude: 25.0301, Longitude: 121.560}
+	to := &geo.Location{Latitude: 25.034, Longitude: 121.5639}
+
+	s := &Service{resources: geo.Resources{Roads: graph}}
+	path := s.travelPath(from, to, ai.ModeScooter)
+	if len(path) < 4 || path[0] != *from || path[len(path)-1] != *to {
+		t.Fatalf("travelPath() = %v, want a road path from %v to %v", path, *from, *to)
+	}
+	corner := false
+	for _, point := range path {
+		if point.Latitude == 25.030 && point.Longitude == 121.564 {
+			corner = true
+		}
+	}
+	if !corner {
+		t.Errorf("travelPath() = %v, want it through the corner", path)
+	}
+
+	// Without roads, or with no road nearby, the trip goes straight
+	for name, s := range map[string]*Service{
+		"no network": {},
+		"off road":   s,
+	} {
+		far := &geo.Location{Latitude: 25.1, Longitude: 121.6}
+		if path := s.travelPath(from, far, ai.ModeWalk); len(path) != 2 {
+			t.Errorf("%s: travelPath() = %v, want a straight line", name, path)
+		}
+	}
+}
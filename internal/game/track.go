@@ -0,0 +1,158 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Track point sources
+const (
+	TrackSourceGame   = "game"   // recorded as the player moved
+	TrackSourceImport = "import" // uploaded from a GPX, KML or GeoJSON file
+)
+
+// minTrackSpacing skips recorded points closer than this to the previous one, in meters
+const minTrackSpacing = 10.0
+
+// TrackPoint is one position in a player's movement history
+type TrackPoint struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	PlayerID   string    `json:"playerId" gorm:"not null;index:idx_track_points_player_time,priority:1"`
+	Latitude   float64   `json:"latitude"`
+	Longitude  float64   `json:"longitude"`
+	Source     string    `json:"source" gorm:"not null;default:game"` // game, import
+	RecordedAt time.Time `json:"recordedAt" gorm:"not null;index:idx_track_points_player_time,priority:2"`
+}
+
+// TrackSummary describes the points of a track in a time range
+type TrackSummary struct {
+	Count int64     `json:"count"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// trackRecorder remembers each player's last recorded point so that small moves
+// and repeated positions from travel ticks don't bloat the table
+type trackRecorder struct {
+	mu   sync.Mutex
+	last map[string][2]float64
+}
+
+func newTrackRecorder() *trackRecorder {
+	return &trackRecorder{last: make(map[string][2]float64)}
+}
+
+// shouldRecord reports whether a point is far enough from the player's last one, and remembers it if so
+func (r *trackRecorder) shouldRecord(playerID string, lat, lng float64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if last, ok := r.last[playerID]; ok && calculateDistance(last[0], last[1], lat, lng) < minTrackSpacing {
+		return false
+	}
+	r.last[playerID] = [2]float64{lat, lng}
+	return true
+}
+
+// recordTrackPoint appends a position to the player's track. Failures are only
+// logged: a missing history point must never fail a move.
+func (s *Service) recordTrackPoint(playerID string, lat, lng float64) {
+	if s.tracks == nil || !s.tracks.shouldRecord(playerID, lat, lng) {
+		return
+	}
+
+	point := TrackPoint{
+		PlayerID:   playerID,
+		Latitude:   lat,
+		Longitude:  lng,
+		Source:     TrackSourceGame,
+		RecordedAt: time.Now(),
+	}
+	if err := s.db.Create(&point).Error; err != nil {
+		log.Printf("Failed to record track point for player %s: %v", playerID, err)
+	}
+}
+
+// trackQuery selects a player's points, optionally bounded by time (zero means open)
+func (s *Service) trackQuery(playerID string, from, to time.Time) *gorm.DB {
+	query := s.db.Model(&TrackPoint{}).Where("player_id = ?", playerID)
+	if !from.IsZero() {
+		query = query.Where("recorded_at >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("recorded_at <= ?", to)
+	}
+	return query
+}
+
+// GetTrackSummary counts a player's points in a time range
+func (s *Service) GetTrackSummary(playerID string, from, to time.Time) (*TrackSummary, error) {
+	var row struct {
+		Count int64
+		Start *time.Time
+		End   *time.Time
+	}
+	err := s.trackQuery(playerID, from, to).
+		Select("COUNT(*) AS count, MIN(recorded_at) AS start, MAX(recorded_at) AS \"end\"").
+		Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &TrackSummary{Count: row.Count}
+	if row.Start != nil && row.End != nil {
+		summary.Start, summary.End = *row.Start, *row.End
+	}
+	return summary, nil
+}
+
+// StreamTrack calls fn for each of a player's points in time order, reading rows
+// one at a time so that long tracks are never loaded whole
+func (s *Service) StreamTrack(playerID string, from, to time.Time, fn func(TrackPoint) error) error {
+	rows, err := s.trackQuery(playerID, from, to).Order("recorded_at, id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var point TrackPoint
+		if err := s.db.ScanRows(rows, &point); err != nil {
+			return err
+		}
+		if err := fn(point); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ImportTrack adds uploaded points to an existing player's track. Points without
+// a time are stamped with the import time.
+func (s *Service) ImportTrack(playerID string, points []TrackPoint) (int, error) {
+	var player Player
+	if err := s.db.Select("id").First(&player, "id = ?", playerID).Error; err != nil {
+		return 0, err
+	}
+	if len(points) == 0 {
+		return 0, fmt.Errorf("no track points to import")
+	}
+
+	now := time.Now()
+	for i := range points {
+		points[i].ID = 0
+		points[i].PlayerID = playerID
+		points[i].Source = TrackSourceImport
+		if points[i].RecordedAt.IsZero() {
+			points[i].RecordedAt = now
+		}
+	}
+	if err := s.db.CreateInBatches(points, 1000).Error; err != nil {
+		return 0, err
+	}
+	return len(points), nil
+}
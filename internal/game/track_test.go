@@ -0,0 +1,24 @@
+package game
+
+import "testing"
+
+func TestTrackRecorderSpacing(t *testing.T) {
+	recorder := newTrackRecorder()
+
+	steps := []struct {
+		player   string
+		lat, lng float64
+		want     bool
+	}{
+		{"p1", 25.03300, 121.56540, true},  // first point
+		{"p1", 25.03305, 121.56540, false}, // about 5.6 m away
+		{"p1", 25.03320, 121.56540, true},  // about 22 m from the last recorded point
+		{"p2", 25.03320, 121.56540, true},  // players are tracked separately
+		{"p1", 25.03325, 121.56545, false},
+	}
+	for i, step := range steps {
+		if got := recorder.shouldRecord(step.player, step.lat, step.lng); got != step.want {
+			t.Errorf("step %d: shouldRecord() = %v, want %v", i, got, step.want)
+		}
+	}
+}
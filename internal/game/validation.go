@@ -0,0 +1,303 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"intelligent-spatial-platform/internal/geo"
+)
+
+// Movement sources, each with its own rate limit
+const (
+	MoveSourceDirect = "direct" // client-supplied coordinates on /game/move
+	MoveSourceAI     = "ai"     // parsed natural-language commands
+)
+
+// Violation codes
+const (
+	ViolationRateLimited    = "RATE_LIMITED"
+	ViolationOutOfBounds    = "OUT_OF_BOUNDS"
+	ViolationSpeed          = "SPEED_EXCEEDED"
+	ViolationRestrictedArea = "RESTRICTED_AREA"
+)
+
+// violationScores is how much each violation adds to the player's score
+var violationScores = map[string]int{
+	ViolationRateLimited:    1,
+	ViolationOutOfBounds:    5,
+	ViolationSpeed:          10,
+	ViolationRestrictedArea: 3,
+}
+
+// moveRateLimits are the per-player budgets for each source
+var moveRateLimits = map[string]RateLimit{
+	MoveSourceDirect: {WindowDuration: time.Minute, MaxRequests: 30},
+	MoveSourceAI:     {WindowDuration: time.Minute, MaxRequests: 10},
+}
+
+const (
+	// ViolationFlagThreshold is the score at which a player is flagged for admin review
+	ViolationFlagThreshold = 30
+
+	// MaxDirectMoveSpeed (m/s) is about 360 km/h, faster than the high-speed rail
+	MaxDirectMoveSpeed = 100.0
+
+	// directMoveJitter is how far a direct move may jump regardless of elapsed time (GPS noise)
+	directMoveJitter = 50.0
+)
+
+// MoveRequest is a position change to validate
+type MoveRequest struct {
+	PlayerID   string
+	Source     string
+	From       *geo.Location // current position, nil if unknown
+	To         *geo.Location
+	LastMoveAt time.Time // when the player arrived at From
+	IPAddress  string
+}
+
+// MoveValidation is the outcome of a passed validation
+type MoveValidation struct {
+	Destination *geo.Location `json:"destination"` // may be snapped onto land
+	Warnings    []string      `json:"warnings,omitempty"`
+}
+
+// MovementViolation is a rejected move; it is stored for admin review and doubles as the error
+type MovementViolation struct {
+	ID             uint            `json:"id" gorm:"primaryKey"`
+	PlayerID       string          `json:"playerId" gorm:"not null;index"`
+	Source         string          `json:"source"`
+	Code           string          `json:"code" gorm:"not null"`
+	Message        string          `json:"message"`
+	Score          int             `json:"score"`
+	Latitude       float64         `json:"latitude"`
+	Longitude      float64         `json:"longitude"`
+	IPAddress      string          `json:"ipAddress"`
+	RestrictedArea *RestrictedArea `json:"restrictedArea,omitempty" gorm:"-"`
+	CreatedAt      time.Time       `json:"createdAt"`
+}
+
+func (v *MovementViolation) Error() string {
+	return v.Message
+}
+
+// moveCheck is one step of the validation pipeline; it returns a violation to reject the move
+type moveCheck func(s *Service, req *MoveRequest, validation *MoveValidation) (*MovementViolation, error)
+
+// moveChecks run in order; cheap checks come before the database query
+var moveChecks = []moveCheck{
+	checkMoveRateLimit,
+	checkMoveBounds,
+	checkMoveSpeed,
+	checkMoveRestrictedAreas,
+}
+
+// ValidateMove runs a move through the shared pipeline used by direct and AI movement.
+// A rejected move returns a *MovementViolation, which is recorded against the player.
+func (s *Service) ValidateMove(req *MoveRequest) (*MoveValidation, error) {
+	validation := &MoveValidation{Destination: req.To}
+
+	for _, check := range moveChecks {
+		violation, err := check(s, req, validation)
+		if err != nil {
+			return nil, err
+		}
+		if violation != nil {
+			s.recordViolation(req, violation)
+			return nil, violation
+		}
+	}
+
+	s.countMove(req.PlayerID, req.Source)
+	return validation, nil
+}
+
+func checkMoveRateLimit(s *Service, req *MoveRequest, _ *MoveValidation) (*MovementViolation, error) {
+	if !s.isRateLimited(req.PlayerID, req.Source) {
+		return nil, nil
+	}
+	return newViolation(ViolationRateLimited, "too many movement requests, try again later"), nil
+}
+
+func checkMoveBounds(s *Service, req *MoveRequest, validation *MoveValidation) (*MovementViolation, error) {
+	to := req.To
+	boundaries := s.resources.Boundaries
+	if !boundaries.InTerritory(to.Latitude, to.Longitude) {
+		return newViolation(ViolationOutOfBounds, fmt.Sprintf("destination (%.6f, %.6f) is outside Taiwan", to.Latitude, to.Longitude)), nil
+	}
+
+	if boundaries.IsOnLand(to.Latitude, to.Longitude) {
+		return nil, nil
+	}
+
+	// Coastal points just offshore are moved onto the nearest land
+	lat, lng, distance, ok := boundaries.SnapToLand(to.Latitude, to.Longitude, geo.DefaultLandSnapDistance)
+	if !ok {
+		return newViolation(ViolationOutOfBounds, fmt.Sprintf("destination is in the sea (%.1f km from the nearest coast)", distance/1000)), nil
+	}
+
+	snapped := *to
+	snapped.Latitude = lat
+	snapped.Longitude = lng
+	validation.Destination = &snapped
+	validation.Warnings = append(validation.Warnings, fmt.Sprintf("destination moved %.0f m onto land", distance))
+	return nil, nil
+}
+
+// checkMoveSpeed only applies to direct moves; AI moves travel at their transport mode's speed
+func checkMoveSpeed(_ *Service, req *MoveRequest, validation *MoveValidation) (*MovementViolation, error) {
+	if req.Source != MoveSourceDirect || req.From == nil || req.LastMoveAt.IsZero() {
+		return nil, nil
+	}
+
+	to := validation.Destination
+	distance := calculateDistance(req.From.Latitude, req.From.Longitude, to.Latitude, to.Longitude)
+	if distance <= directMoveJitter {
+		return nil, nil
+	}
+
+	elapsed := time.Since(req.LastMoveAt).Seconds()
+	if elapsed < 1 {
+		elapsed = 1
+	}
+
+	speed := distance / elapsed
+	if speed <= MaxDirectMoveSpeed {
+		return nil, nil
+	}
+
+	return newViolation(ViolationSpeed, fmt.Sprintf("moved %.0f m in %.0f s (%.0f km/h, max %.0f km/h)",
+		distance, elapsed, speed*3.6, MaxDirectMoveSpeed*3.6)), nil
+}
+
+func checkMoveRestrictedAreas(s *Service, req *MoveRequest, validation *MoveValidation) (*MovementViolation, error) {
+	warnings, err := s.checkRestrictedAreas(req.From, validation.Destination)
+
+	var restricted *RestrictedAreaError
+	if errors.As(err, &restricted) {
+		violation := newViolation(ViolationRestrictedArea, restricted.Error())
+		violation.RestrictedArea = restricted.Area
+		return violation, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, area := range warnings {
+		validation.Warnings = append(validation.Warnings, fmt.Sprintf("entering restricted area 「%s」", area.Name))
+	}
+	return nil, nil
+}
+
+func newViolation(code, message string) *MovementViolation {
+	return &MovementViolation{Code: code, Message: message, Score: violationScores[code]}
+}
+
+// recordViolation stores the violation and raises the player's score, flagging them at the threshold
+func (s *Service) recordViolation(req *MoveRequest, violation *MovementViolation) {
+	violation.PlayerID = req.PlayerID
+	violation.Source = req.Source
+	violation.IPAddress = req.IPAddress
+	if req.To != nil {
+		violation.Latitude = req.To.Latitude
+		violation.Longitude = req.To.Longitude
+	}
+
+	log.Printf("🚨 移動違規 player=%s source=%s code=%s: %s", req.PlayerID, req.Source, violation.Code, violation.Message)
+
+	if s.db == nil {
+		return
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(violation).Error; err != nil {
+			return err
+		}
+		return addViolationScore(tx, req.PlayerID, violation.Score).Error
+	})
+	if err != nil {
+		log.Printf("⚠️ 無法記錄移動違規: %v", err)
+	}
+}
+
+// addViolationScore raises a player's score, flagging them at the threshold.
+// UpdateColumns leaves updated_at alone, like every write that is not a move.
+func addViolationScore(db *gorm.DB, playerID string, score int) *gorm.DB {
+	return db.Model(&Player{}).Where("id = ?", playerID).UpdateColumns(map[string]interface{}{
+		"violation_score": gorm.Expr("violation_score + ?", score),
+		"flagged_at":      gorm.Expr("COALESCE(flagged_at, CASE WHEN violation_score + ? >= ? THEN NOW() END)", score, ViolationFlagThreshold),
+	})
+}
+
+// ListFlaggedPlayers returns players whose violation score reached the threshold, worst first
+func (s *Service) ListFlaggedPlayers() ([]Player, error) {
+	var players []Player
+	err := s.db.Where("flagged_at IS NOT NULL").Order("violation_score DESC").Find(&players).Error
+	return players, err
+}
+
+// ListMovementViolations returns a player's most recent violations
+func (s *Service) ListMovementViolations(playerID string, limit int) ([]MovementViolation, error) {
+	var violations []MovementViolation
+	err := s.db.Where("player_id = ?", playerID).Order("created_at DESC").Limit(limit).Find(&violations).Error
+	return violations, err
+}
+
+// ResetViolationScore clears a player's score and flag after review; the history is kept
+func (s *Service) ResetViolationScore(playerID string) error {
+	result := s.db.Model(&Player{}).Where("id = ?", playerID).UpdateColumns(map[string]interface{}{
+		"violation_score": 0,
+		"flagged_at":      nil,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *Service) isRateLimited(playerID, source string) bool {
+	s.rateMu.Lock()
+	defer s.rateMu.Unlock()
+
+	limit := s.rateLimitFor(playerID, source)
+
+	// Reset if window expired
+	if time.Since(limit.LastReset) > limit.WindowDuration {
+		limit.Count = 0
+		limit.LastReset = time.Now()
+	}
+
+	return limit.Count >= limit.MaxRequests
+}
+
+func (s *Service) countMove(playerID, source string) {
+	s.rateMu.Lock()
+	defer s.rateMu.Unlock()
+
+	s.rateLimitFor(playerID, source).Count++
+}
+
+// rateLimitFor returns the player's window for source; callers hold rateMu
+func (s *Service) rateLimitFor(playerID, source string) *RateLimit {
+	key := source + ":" + playerID
+	limit, exists := s.rateLimiter[key]
+	if !exists {
+		budget, ok := moveRateLimits[source]
+		if !ok {
+			budget = moveRateLimits[MoveSourceAI]
+		}
+		limit = &RateLimit{
+			LastReset:      time.Now(),
+			WindowDuration: budget.WindowDuration,
+			MaxRequests:    budget.MaxRequests,
+		}
+		s.rateLimiter[key] = limit
+	}
+	return limit
+}
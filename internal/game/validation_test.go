@@ -0,0 +1,279 @@
+package game
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"intelligent-spatial-platform/internal/geo"
+)
+
+func newValidationService() *Service {
+	return &Service{rateLimiter: make(map[string]*RateLimit), resources: geo.Resources{}.WithDefaults()}
+}
+
+func violationCode(err error) string {
+	var violation *MovementViolation
+	if errors.As(err, &violation) {
+		return violation.Code
+	}
+	return ""
+}
+
+func TestValidateMoveBounds(t *testing.T) {
+	s := newValidationService()
+
+	tests := []struct {
+		name     string
+		lat, lng float64
+		code     string
+	}{
+		{"Taipei", 25.0337, 121.5645, ""},
+		{"Tokyo", 35.6762, 139.6503, ViolationOutOfBounds},
+		{"Taiwan Strait", 24.0, 119.9, ViolationOutOfBounds},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.ValidateMove(&MoveRequest{
+				PlayerID: "p1",
+				Source:   MoveSourceAI,
+				To:       &geo.Location{Latitude: tt.lat, Longitude: tt.lng},
+			})
+			if code := violationCode(err); code != tt.code {
+				t.Errorf("violation = %q (err %v), want %q", code, err, tt.code)
+			}
+		})
+	}
+}
+
+func TestValidateMoveSnapsCoastalPoint(t *testing.T) {
+	s := newValidationService()
+
+	validation, err := s.ValidateMove(&MoveRequest{
+		PlayerID: "p1",
+		Source:   MoveSourceAI,
+		To:       &geo.Location{Latitude: 23.0, Longitude: 120.14},
+	})
+	if err != nil {
+		t.Fatalf("ValidateMove() error = %v", err)
+	}
+	if !geo.BundledBoundaries().IsOnLand(validation.Destination.Latitude, validation.Destination.Longitude) {
+		t.Error("destination should be snapped onto land")
+	}
+	if len(validation.Warnings) == 0 {
+		t.Error("snapping should be reported as a warning")
+	}
+}
+
+func TestValidateMoveSpeed(t *testing.T) {
+	s := newValidationService()
+	taipei := &geo.Location{Latitude: 25.0337, Longitude: 121.5645}
+	kaohsiung := &geo.Location{Latitude: 22.6273, Longitude: 120.3014}
+
+	tests := []struct {
+		name   string
+		source string
+		to     *geo.Location
+		since  time.Duration
+		code   string
+	}{
+		{"Taipei to Kaohsiung in 10 s", MoveSourceDirect, kaohsiung, 10 * time.Second, ViolationSpeed},
+		{"Taipei to Kaohsiung in 2 h", MoveSourceDirect, kaohsiung, 2 * time.Hour, ""},
+		{"GPS jitter right after a move", MoveSourceDirect, &geo.Location{Latitude: 25.0340, Longitude: 121.5645}, 0, ""},
+		{"AI moves are not speed checked", MoveSourceAI, kaohsiung, 10 * time.Second, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.ValidateMove(&MoveRequest{
+				PlayerID:   "p-" + tt.name,
+				Source:     tt.source,
+				From:       taipei,
+				To:         tt.to,
+				LastMoveAt: time.Now().Add(-tt.since),
+			})
+			if code := violationCode(err); code != tt.code {
+				t.Errorf("violation = %q (err %v), want %q", code, err, tt.code)
+			}
+		})
+	}
+}
+
+func TestLastMovedFallsBackToUpdatedAt(t *testing.T) {
+	updated := time.Now().Add(-time.Minute)
+	legacy := &Player{UpdatedAt: updated}
+	if got := legacy.lastMoved(); !got.Equal(updated) {
+		t.Errorf("lastMoved() without LastMovedAt = %v, want UpdatedAt %v", got, updated)
+	}
+
+	moved := time.Now().Add(-time.Hour)
+	player := &Player{UpdatedAt: updated, LastMovedAt: &moved}
+	if got := player.lastMoved(); !got.Equal(moved) {
+		t.Errorf("lastMoved() = %v, want LastMovedAt %v", got, moved)
+	}
+}
+
+func TestValidateMoveRateLimitPerSource(t *testing.T) {
+	s := newValidationService()
+	to := &geo.Location{Latitude: 25.0337, Longitude: 121.5645}
+
+	for i := 0; i < moveRateLimits[MoveSourceAI].MaxRequests; i++ {
+		if _, err := s.ValidateMove(&MoveRequest{PlayerID: "p1", Source: MoveSourceAI, To: to}); err != nil {
+			t.Fatalf("move %d: unexpected error %v", i+1, err)
+		}
+	}
+
+	_, err := s.ValidateMove(&MoveRequest{PlayerID: "p1", Source: MoveSourceAI, To: to})
+	if code := violationCode(err); code != ViolationRateLimited {
+		t.Errorf("violation = %q, want %q", code, ViolationRateLimited)
+	}
+
+	// The direct budget and other players are unaffected
+	if _, err := s.ValidateMove(&MoveRequest{PlayerID: "p1", Source: MoveSourceDirect, To: to}); err != nil {
+		t.Errorf("direct move: unexpected error %v", err)
+	}
+	if _, err := s.ValidateMove(&MoveRequest{PlayerID: "p2", Source: MoveSourceAI, To: to}); err != nil {
+		t.Errorf("other player: unexpected error %v", err)
+	}
+}
+
+func TestViolationScores(t *testing.T) {
+	for _, code := range []string{ViolationRateLimited, ViolationOutOfBounds, ViolationSpeed, ViolationRestrictedArea} {
+		violation := newViolation(code, "message")
+		if violation.Score <= 0 {
+			t.Errorf("%s has no score", code)
+		}
+		if violation.Error() != "message" {
+			t.Errorf("Error() = %q, want the message", violation.Error())
+		}
+	}
+}
+
+func TestViolationScoreLeavesUpdatedAt(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=none"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql := addViolationScore(db, "p1", violationScores[ViolationSpeed]).Statement.SQL.String()
+	if !strings.Contains(sql, "violation_score") || strings.Contains(sql, "updated_at") {
+		t.Errorf("SQL = %s", sql)
+	}
+}
+
+// openMoveTestDB connects to POSTGIS_TEST_DSN and switches to an empty schema:
+//
+//	POSTGIS_TEST_DSN="host=localhost user=postgres dbname=game_test sslmode=disable" go test ./internal/game
+func openMoveTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("POSTGIS_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGIS_TEST_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One connection, so the search path set below applies to every query
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		db.Exec("DROP SCHEMA IF EXISTS move_test CASCADE")
+		sqlDB.Close()
+	})
+
+	for _, statement := range []string{
+		"CREATE EXTENSION IF NOT EXISTS postgis",
+		"DROP SCHEMA IF EXISTS move_test CASCADE",
+		"CREATE SCHEMA move_test",
+		"SET search_path TO move_test, public",
+	} {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.AutoMigrate(&Player{}, &Item{}, &RestrictedArea{}, &MovementViolation{}, &TrackPoint{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// newMovedPlayer creates a player in Taipei who last moved a minute ago
+func newMovedPlayer(t *testing.T, s *Service) *Player {
+	t.Helper()
+	player, err := s.CreatePlayer("mover", "Mover", 25.0337, 121.5645)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.db.Model(player).UpdateColumn("last_moved_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	return player
+}
+
+// About 1.1 km north of the player: 18 m/s over the last minute
+const normalMoveLat, normalMoveLng = 25.0437, 121.5645
+
+func TestMoveAfterCollectingItem(t *testing.T) {
+	s := NewService(openMoveTestDB(t), nil, geo.Resources{})
+	player := newMovedPlayer(t, s)
+
+	item := Item{ID: "coin", Name: "古代銅錢", ItemType: "treasure", Value: 10, Latitude: player.Latitude, Longitude: player.Longitude, SpawnedAt: time.Now()}
+	if err := s.db.Create(&item).Error; err != nil {
+		t.Fatal(err)
+	}
+	collected, err := s.CollectItem(player.ID, item.ID, player.Latitude, player.Longitude)
+	if err != nil || !collected.Success {
+		t.Fatalf("CollectItem() = %+v, %v", collected, err)
+	}
+
+	// Collecting saved the player but did not move them, so the speed check still
+	// measures from a minute ago
+	result, err := s.MovePlayer(player.ID, normalMoveLat, normalMoveLng, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success {
+		t.Errorf("move after collecting was rejected: %+v", result.Violation)
+	}
+}
+
+func TestMoveRetryAfterRejection(t *testing.T) {
+	s := NewService(openMoveTestDB(t), nil, geo.Resources{})
+	player := newMovedPlayer(t, s)
+
+	// 20 km in a minute is too fast
+	result, err := s.MovePlayer(player.ID, 25.2137, 121.5645, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success || result.Violation == nil || result.Violation.Code != ViolationSpeed {
+		t.Fatalf("fast move = %+v", result)
+	}
+
+	// Recording the violation must not reset the clock for the retry
+	result, err = s.MovePlayer(player.ID, normalMoveLat, normalMoveLng, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success {
+		t.Errorf("retry was rejected: %+v", result.Violation)
+	}
+
+	updated, err := s.GetPlayerStatus(player.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.ViolationScore != violationScores[ViolationSpeed] {
+		t.Errorf("ViolationScore = %d, want %d", updated.ViolationScore, violationScores[ViolationSpeed])
+	}
+}
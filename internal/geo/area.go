@@ -0,0 +1,65 @@
+package geo
+
+import (
+	"encoding/json"
+)
+
+// Area is a polygon to search in instead of a circle, such as an isochrone
+type Area struct {
+	Geometry    GeoJSON `json:"geometry"`              // Polygon or MultiPolygon
+	Description string  `json:"description,omitempty"` // how to say it, e.g. 走路 15 分鐘內
+
+	polygons []boundaryPolygon
+	bounds   Bounds
+}
+
+// NewArea validates a Polygon or MultiPolygon, given as a bare geometry or a
+// Feature, as a search area
+func NewArea(geometry []byte, description string) (*Area, error) {
+	parsed, err := ParsePolygonGeoJSON(geometry)
+	if err != nil {
+		return nil, err
+	}
+	var object struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	}
+	if err := json.Unmarshal(parsed, &object); err != nil {
+		return nil, err
+	}
+	polygons, err := decodePolygons(object.Type, object.Coordinates)
+	if err != nil {
+		return nil, err
+	}
+
+	area := &Area{Geometry: parsed, Description: description, polygons: polygons, bounds: polygons[0].bounds}
+	for _, polygon := range polygons[1:] {
+		area.bounds = unionBounds(area.bounds, polygon.bounds)
+	}
+	return area, nil
+}
+
+// Contains reports whether the point lies in the area
+func (a *Area) Contains(latitude, longitude float64) bool {
+	if !a.bounds.contains(latitude, longitude) {
+		return false
+	}
+	for _, polygon := range a.polygons {
+		if polygon.contains(latitude, longitude) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reach returns the distance in meters from a point to the farthest corner of
+// the area, the radius of a circle around the point that covers it
+func (a *Area) Reach(latitude, longitude float64) float64 {
+	reach := 0.0
+	for _, polygon := range a.polygons {
+		for _, point := range polygon.rings[0] {
+			reach = max(reach, calculateDistanceInMeters(latitude, longitude, point[1], point[0]))
+		}
+	}
+	return reach
+}
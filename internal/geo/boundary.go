@@ -0,0 +1,491 @@
+package geo
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Simplified land outlines, county/city boundaries and district seats for Taiwan,
+// Penghu, Kinmen and Matsu. Official NLSC/TGOS boundaries in the same GeoJSON
+// layout can be loaded with LoadBoundariesFile.
+//
+//go:embed data/taiwan_admin.geojson
+var taiwanAdminData []byte
+
+// DefaultLandSnapDistance is how far (meters) an offshore destination may be
+// pulled back to the nearest coast before it is rejected
+const DefaultLandSnapDistance = 2000.0
+
+// boundaryCellSize is the grid index cell size in degrees
+const boundaryCellSize = 0.25
+
+// AdminArea is the result of a reverse administrative lookup
+type AdminArea struct {
+	County     string `json:"county"`
+	CountyEn   string `json:"countyEn"`
+	CountyCode string `json:"countyCode"`
+	District   string `json:"district,omitempty"`
+	OnLand     bool   `json:"onLand"`
+}
+
+// Boundaries answers point-in-polygon, snapping and admin lookups against
+// land and county polygons, using a grid index to pick candidate polygons
+type Boundaries struct {
+	land      []*boundaryFeature
+	counties  []*boundaryFeature // smallest first so enclaved cities win
+	districts []*boundaryFeature
+	seats     map[string][]districtSeat // county -> district seats
+
+	landIndex     *gridIndex
+	countyIndex   *gridIndex
+	districtIndex *gridIndex
+	bounds        Bounds
+}
+
+type boundaryFeature struct {
+	name       string
+	county     string
+	countyEn   string
+	countyCode string
+	district   string
+	polygons   []boundaryPolygon
+	bounds     Bounds
+	area       float64
+}
+
+// boundaryPolygon holds rings of [lng, lat] points; ring 0 is the outer ring, the rest are holes
+type boundaryPolygon struct {
+	rings  [][][2]float64
+	bounds Bounds
+}
+
+// districtSeat is a district office location, used to assign a district when
+// only point data is available
+type districtSeat struct {
+	name      string
+	latitude  float64
+	longitude float64
+}
+
+var (
+	bundledBoundaries     *Boundaries
+	bundledBoundariesOnce sync.Once
+)
+
+// BundledBoundaries returns the embedded simplified boundaries, parsed on first use
+func BundledBoundaries() *Boundaries {
+	bundledBoundariesOnce.Do(func() {
+		boundaries, err := parseBoundaries(taiwanAdminData)
+		if err != nil {
+			panic(fmt.Sprintf("geo: invalid embedded boundary data: %v", err))
+		}
+		bundledBoundaries = boundaries
+	})
+	return bundledBoundaries
+}
+
+// LoadBoundariesFile loads boundaries from a GeoJSON file
+func LoadBoundariesFile(path string) (*Boundaries, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open boundary file: %v", err)
+	}
+	defer file.Close()
+
+	return LoadBoundaries(file)
+}
+
+// LoadBoundaries reads a GeoJSON FeatureCollection whose features carry a "kind"
+// property of "land", "county" or "district". County and district features name
+// their county in "county"; districts may be polygons or Point district seats.
+func LoadBoundaries(r io.Reader) (*Boundaries, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read boundary data: %v", err)
+	}
+	return parseBoundaries(data)
+}
+
+func parseBoundaries(data []byte) (*Boundaries, error) {
+	var collection struct {
+		Features []struct {
+			Properties map[string]interface{} `json:"properties"`
+			Geometry   struct {
+				Type        string          `json:"type"`
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("invalid boundary GeoJSON: %v", err)
+	}
+
+	b := &Boundaries{seats: make(map[string][]districtSeat)}
+
+	for i, f := range collection.Features {
+		kind := stringProperty(f.Properties, "kind")
+		county := stringProperty(f.Properties, "county")
+
+		if f.Geometry.Type == "Point" {
+			if kind != "district" {
+				continue
+			}
+			var point [2]float64
+			if err := json.Unmarshal(f.Geometry.Coordinates, &point); err != nil {
+				return nil, fmt.Errorf("feature %d: invalid point: %v", i, err)
+			}
+			b.seats[county] = append(b.seats[county], districtSeat{
+				name:      stringProperty(f.Properties, "district"),
+				longitude: point[0],
+				latitude:  point[1],
+			})
+			continue
+		}
+
+		polygons, err := decodePolygons(f.Geometry.Type, f.Geometry.Coordinates)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %v", i, err)
+		}
+		if len(polygons) == 0 {
+			continue
+		}
+
+		feature := &boundaryFeature{
+			name:       stringProperty(f.Properties, "name"),
+			county:     county,
+			countyEn:   stringProperty(f.Properties, "countyEn"),
+			countyCode: stringProperty(f.Properties, "code"),
+			district:   stringProperty(f.Properties, "district"),
+			polygons:   polygons,
+		}
+		feature.bounds = polygons[0].bounds
+		for _, polygon := range polygons {
+			feature.bounds = unionBounds(feature.bounds, polygon.bounds)
+			feature.area += math.Abs(ringArea(polygon.rings[0]))
+		}
+
+		switch kind {
+		case "land":
+			b.land = append(b.land, feature)
+		case "county":
+			b.counties = append(b.counties, feature)
+		case "district":
+			b.districts = append(b.districts, feature)
+		}
+	}
+
+	if len(b.land) == 0 || len(b.counties) == 0 {
+		return nil, fmt.Errorf("boundary data needs at least one land and one county feature")
+	}
+
+	sort.SliceStable(b.counties, func(i, j int) bool {
+		return b.counties[i].area < b.counties[j].area
+	})
+	sort.SliceStable(b.districts, func(i, j int) bool {
+		return b.districts[i].area < b.districts[j].area
+	})
+
+	b.landIndex = newGridIndex(b.land)
+	b.countyIndex = newGridIndex(b.counties)
+	b.districtIndex = newGridIndex(b.districts)
+
+	b.bounds = b.land[0].bounds
+	for _, feature := range append(append([]*boundaryFeature{}, b.land...), b.counties...) {
+		b.bounds = unionBounds(b.bounds, feature.bounds)
+	}
+
+	return b, nil
+}
+
+// Bounds returns the bounding box of all land and county polygons
+func (b *Boundaries) Bounds() Bounds {
+	return b.bounds
+}
+
+// IsOnLand reports whether the point lies on one of the land polygons
+func (b *Boundaries) IsOnLand(latitude, longitude float64) bool {
+	return b.findContaining(b.land, b.landIndex, latitude, longitude) != nil
+}
+
+// InTerritory reports whether the point lies within any county or city,
+// including its coastal waters
+func (b *Boundaries) InTerritory(latitude, longitude float64) bool {
+	return b.findContaining(b.counties, b.countyIndex, latitude, longitude) != nil
+}
+
+// Lookup returns the county and district for a point, or nil when it is outside every county
+func (b *Boundaries) Lookup(latitude, longitude float64) *AdminArea {
+	county := b.findContaining(b.counties, b.countyIndex, latitude, longitude)
+	if county == nil {
+		return nil
+	}
+
+	area := &AdminArea{
+		County:     county.county,
+		CountyEn:   county.countyEn,
+		CountyCode: county.countyCode,
+		OnLand:     b.IsOnLand(latitude, longitude),
+	}
+
+	if district := b.findContaining(b.districts, b.districtIndex, latitude, longitude); district != nil && district.county == county.county {
+		area.District = district.district
+		return area
+	}
+
+	// Fall back to the nearest district seat within the county
+	nearest := math.MaxFloat64
+	for _, seat := range b.seats[county.county] {
+		if d := planarDistance(latitude, longitude, seat.latitude, seat.longitude); d < nearest {
+			nearest = d
+			area.District = seat.name
+		}
+	}
+
+	return area
+}
+
+// SnapToLand returns the nearest point on land within maxDistance meters.
+// Points already on land are returned unchanged with a distance of 0.
+func (b *Boundaries) SnapToLand(latitude, longitude, maxDistance float64) (float64, float64, float64, bool) {
+	if b.IsOnLand(latitude, longitude) {
+		return latitude, longitude, 0, true
+	}
+
+	latPad := maxDistance / metersPerDegreeLat
+	lngPad := maxDistance / (metersPerDegreeLat * math.Cos(latitude*math.Pi/180))
+	search := Bounds{
+		North: latitude + latPad,
+		South: latitude - latPad,
+		East:  longitude + lngPad,
+		West:  longitude - lngPad,
+	}
+
+	bestDistance := math.MaxFloat64
+	var bestLat, bestLng float64
+	for _, i := range b.landIndex.query(search) {
+		feature := b.land[i]
+		if !boundsIntersect(feature.bounds, search) {
+			continue
+		}
+		for _, polygon := range feature.polygons {
+			for _, ring := range polygon.rings {
+				for k := 0; k < len(ring)-1; k++ {
+					lat, lng, d := nearestOnSegment(latitude, longitude, ring[k], ring[k+1])
+					if d < bestDistance {
+						bestDistance, bestLat, bestLng = d, lat, lng
+					}
+				}
+			}
+		}
+	}
+
+	if bestDistance > maxDistance {
+		return latitude, longitude, bestDistance, false
+	}
+
+	// Step a few meters past the coastline so the snapped point is inside the polygon
+	if bestDistance > 0 {
+		overshoot := (bestDistance + 5) / bestDistance
+		bestLat = latitude + (bestLat-latitude)*overshoot
+		bestLng = longitude + (bestLng-longitude)*overshoot
+	}
+
+	return bestLat, bestLng, bestDistance, true
+}
+
+func (b *Boundaries) findContaining(features []*boundaryFeature, index *gridIndex, latitude, longitude float64) *boundaryFeature {
+	if index == nil {
+		return nil
+	}
+	for _, i := range index.query(Bounds{North: latitude, South: latitude, East: longitude, West: longitude}) {
+		if features[i].contains(latitude, longitude) {
+			return features[i]
+		}
+	}
+	return nil
+}
+
+func (f *boundaryFeature) contains(latitude, longitude float64) bool {
+	if !f.bounds.contains(latitude, longitude) {
+		return false
+	}
+	for _, polygon := range f.polygons {
+		if polygon.contains(latitude, longitude) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p boundaryPolygon) contains(latitude, longitude float64) bool {
+	if !p.bounds.contains(latitude, longitude) || !ringContains(p.rings[0], latitude, longitude) {
+		return false
+	}
+	for _, hole := range p.rings[1:] {
+		if ringContains(hole, latitude, longitude) {
+			return false
+		}
+	}
+	return true
+}
+
+// ringContains is the even-odd ray casting test
+func ringContains(ring [][2]float64, latitude, longitude float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > latitude) != (yj > latitude) && longitude < (xj-xi)*(latitude-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// ringArea is the shoelace area in square degrees, only used for ordering
+func ringArea(ring [][2]float64) float64 {
+	area := 0.0
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		area += (ring[j][0] + ring[i][0]) * (ring[j][1] - ring[i][1])
+	}
+	return area / 2
+}
+
+const metersPerDegreeLat = 111320.0
+
+// nearestOnSegment returns the closest point on segment a-b ([lng, lat]) and its
+// distance in meters, using a local equirectangular projection
+func nearestOnSegment(latitude, longitude float64, a, b [2]float64) (float64, float64, float64) {
+	kx := metersPerDegreeLat * math.Cos(latitude*math.Pi/180)
+	ky := metersPerDegreeLat
+
+	ax, ay := (a[0]-longitude)*kx, (a[1]-latitude)*ky
+	bx, by := (b[0]-longitude)*kx, (b[1]-latitude)*ky
+	dx, dy := bx-ax, by-ay
+
+	t := 0.0
+	if lengthSq := dx*dx + dy*dy; lengthSq > 0 {
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/lengthSq))
+	}
+	px, py := ax+t*dx, ay+t*dy
+
+	return latitude + py/ky, longitude + px/kx, math.Hypot(px, py)
+}
+
+func planarDistance(lat1, lng1, lat2, lng2 float64) float64 {
+	x := (lng2 - lng1) * math.Cos((lat1+lat2)/2*math.Pi/180)
+	y := lat2 - lat1
+	return math.Hypot(x, y) * metersPerDegreeLat
+}
+
+func decodePolygons(geometryType string, raw json.RawMessage) ([]boundaryPolygon, error) {
+	var multi [][][][2]float64
+	switch geometryType {
+	case "Polygon":
+		var rings [][][2]float64
+		if err := json.Unmarshal(raw, &rings); err != nil {
+			return nil, fmt.Errorf("invalid polygon: %v", err)
+		}
+		multi = [][][][2]float64{rings}
+	case "MultiPolygon":
+		if err := json.Unmarshal(raw, &multi); err != nil {
+			return nil, fmt.Errorf("invalid multipolygon: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported geometry type %q", geometryType)
+	}
+
+	polygons := make([]boundaryPolygon, 0, len(multi))
+	for _, rings := range multi {
+		if len(rings) == 0 || len(rings[0]) < 4 {
+			return nil, fmt.Errorf("polygon ring needs at least 4 points")
+		}
+		polygons = append(polygons, boundaryPolygon{rings: rings, bounds: ringBounds(rings[0])})
+	}
+	return polygons, nil
+}
+
+func ringBounds(ring [][2]float64) Bounds {
+	bounds := Bounds{North: ring[0][1], South: ring[0][1], East: ring[0][0], West: ring[0][0]}
+	for _, point := range ring[1:] {
+		bounds.North = math.Max(bounds.North, point[1])
+		bounds.South = math.Min(bounds.South, point[1])
+		bounds.East = math.Max(bounds.East, point[0])
+		bounds.West = math.Min(bounds.West, point[0])
+	}
+	return bounds
+}
+
+func unionBounds(a, b Bounds) Bounds {
+	return Bounds{
+		North: math.Max(a.North, b.North),
+		South: math.Min(a.South, b.South),
+		East:  math.Max(a.East, b.East),
+		West:  math.Min(a.West, b.West),
+	}
+}
+
+func boundsIntersect(a, b Bounds) bool {
+	return a.West <= b.East && b.West <= a.East && a.South <= b.North && b.South <= a.North
+}
+
+func (b Bounds) contains(latitude, longitude float64) bool {
+	return latitude >= b.South && latitude <= b.North && longitude >= b.West && longitude <= b.East
+}
+
+func stringProperty(properties map[string]interface{}, key string) string {
+	if value, ok := properties[key].(string); ok {
+		return value
+	}
+	return ""
+}
+
+// gridIndex buckets features by the grid cells their bounding boxes cover
+type gridIndex struct {
+	cells map[[2]int][]int
+}
+
+func newGridIndex(features []*boundaryFeature) *gridIndex {
+	index := &gridIndex{cells: make(map[[2]int][]int)}
+	for i, feature := range features {
+		minX, minY := gridCell(feature.bounds.South, feature.bounds.West)
+		maxX, maxY := gridCell(feature.bounds.North, feature.bounds.East)
+		for x := minX; x <= maxX; x++ {
+			for y := minY; y <= maxY; y++ {
+				index.cells[[2]int{x, y}] = append(index.cells[[2]int{x, y}], i)
+			}
+		}
+	}
+	return index
+}
+
+// query returns the indices of features whose cells overlap the box, in ascending order
+func (g *gridIndex) query(box Bounds) []int {
+	minX, minY := gridCell(box.South, box.West)
+	maxX, maxY := gridCell(box.North, box.East)
+
+	seen := make(map[int]bool)
+	var result []int
+	for x := minX; x <= maxX; x++ {
+		for y := minY; y <= maxY; y++ {
+			for _, i := range g.cells[[2]int{x, y}] {
+				if !seen[i] {
+					seen[i] = true
+					result = append(result, i)
+				}
+			}
+		}
+	}
+	sort.Ints(result)
+	return result
+}
+
+func gridCell(latitude, longitude float64) (int, int) {
+	return int(math.Floor(longitude / boundaryCellSize)), int(math.Floor(latitude / boundaryCellSize))
+}
@@ -0,0 +1,196 @@
+package geo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBoundariesLookup(t *testing.T) {
+	b := BundledBoundaries()
+
+	tests := []struct {
+		name     string
+		lat, lng float64
+		county   string
+		district string
+		onLand   bool
+	}{
+		{"Taipei 101", 25.0337, 121.5645, "臺北市", "信義區", true},
+		{"Banqiao", 25.0116, 121.4627, "新北市", "板橋區", true},
+		{"Hsinchu City enclave", 24.8040, 120.9700, "新竹市", "東區", true},
+		{"Chiayi City enclave", 23.4800, 120.4490, "嘉義市", "", true},
+		{"Tainan Anping", 22.9990, 120.1700, "臺南市", "安平區", true},
+		{"Kenting", 21.9518, 120.7970, "屏東縣", "", true},
+		{"Magong", 23.5660, 119.5660, "澎湖縣", "馬公市", true},
+		{"Zhubei is Hsinchu County", 24.8390, 121.0040, "新竹縣", "竹北市", true},
+		{"Alishan", 23.5100, 120.8000, "嘉義縣", "", true},
+		{"Pingtung City", 22.6690, 120.4880, "屏東縣", "屏東市", true},
+		{"Kinmen", 24.4340, 118.3170, "金門縣", "金城鎮", true},
+		{"Nangan", 26.1590, 119.9440, "連江縣", "南竿鄉", true},
+		{"Orchid Island", 22.0440, 121.5480, "臺東縣", "蘭嶼鄉", true},
+		{"Cijin is across the harbor from Yancheng", 22.6130, 120.2660, "高雄市", "旗津區", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			area := b.Lookup(tt.lat, tt.lng)
+			if area == nil {
+				t.Fatal("Lookup() returned nil")
+			}
+			if area.County != tt.county {
+				t.Errorf("County = %s, want %s", area.County, tt.county)
+			}
+			if tt.district != "" && area.District != tt.district {
+				t.Errorf("District = %s, want %s", area.District, tt.district)
+			}
+			if area.District == "" {
+				t.Error("District should be filled from the nearest seat")
+			}
+			if area.OnLand != tt.onLand {
+				t.Errorf("OnLand = %v, want %v", area.OnLand, tt.onLand)
+			}
+		})
+	}
+}
+
+func TestBoundariesRejectStraitAndMainland(t *testing.T) {
+	b := BundledBoundaries()
+
+	for _, p := range []struct {
+		name     string
+		lat, lng float64
+	}{
+		{"Taiwan Strait", 24.0, 120.0},
+		{"Fujian coast near Xiamen", 24.48, 118.08},
+		{"Fuzhou", 26.07, 119.30},
+	} {
+		if b.IsOnLand(p.lat, p.lng) {
+			t.Errorf("%s should not be on land", p.name)
+		}
+		if b.InTerritory(p.lat, p.lng) {
+			t.Errorf("%s should be outside Taiwan", p.name)
+		}
+	}
+}
+
+func TestCoastalLandmarksAreOnLand(t *testing.T) {
+	b := BundledBoundaries()
+
+	for _, p := range []struct {
+		name     string
+		lat, lng float64
+	}{
+		{"富貴角", 25.2970, 121.5360},
+		{"淡水漁人碼頭", 25.1833, 121.4107},
+		{"野柳", 25.2070, 121.6900},
+		{"鼻頭角", 25.1280, 121.9180},
+		{"三貂角", 25.0075, 121.9994},
+		{"南方澳", 24.5850, 121.8680},
+		{"七星潭", 24.0300, 121.6200},
+		{"三仙台", 23.1236, 121.4133},
+		{"鵝鑾鼻", 21.9022, 120.8528},
+		{"貓鼻頭", 21.9200, 120.7400},
+		{"旗津", 22.6130, 120.2660},
+		{"安平古堡", 23.0015, 120.1606},
+		{"王功", 23.9700, 120.3200},
+		{"南寮漁港", 24.8480, 120.9250},
+	} {
+		if !b.IsOnLand(p.lat, p.lng) || !b.InTerritory(p.lat, p.lng) {
+			t.Errorf("%s (%f, %f) should be on land in Taiwan", p.name, p.lat, p.lng)
+		}
+	}
+
+	// A few kilometers offshore of the same coasts
+	for _, p := range []struct {
+		name     string
+		lat, lng float64
+	}{
+		{"north of 富貴角", 25.3300, 121.5400},
+		{"east of 三貂角", 25.0100, 122.0400},
+		{"south of 鵝鑾鼻", 21.8600, 120.8500},
+		{"west of 旗津", 22.6000, 120.2300},
+		{"off 臺中港", 24.2800, 120.4300},
+	} {
+		if b.IsOnLand(p.lat, p.lng) {
+			t.Errorf("%s (%f, %f) should not be on land", p.name, p.lat, p.lng)
+		}
+	}
+}
+
+// TestBundledLandIsInTerritory checks that county outlines leave no coast uncovered
+func TestBundledLandIsInTerritory(t *testing.T) {
+	b := BundledBoundaries()
+
+	for _, land := range b.land {
+		for _, polygon := range land.polygons {
+			ring := polygon.rings[0]
+			for k := 0; k < len(ring)-1; k++ {
+				for step := 0.0; step < 1; step += 0.1 {
+					lng := ring[k][0] + (ring[k+1][0]-ring[k][0])*step
+					lat := ring[k][1] + (ring[k+1][1]-ring[k][1])*step
+					if !b.InTerritory(lat, lng) {
+						t.Errorf("%s coast (%f, %f) is outside every county", land.name, lat, lng)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSnapToLand(t *testing.T) {
+	b := BundledBoundaries()
+
+	// About 1 km off the Tainan coast
+	lat, lng, distance, ok := b.SnapToLand(23.0, 120.14, DefaultLandSnapDistance)
+	if !ok {
+		t.Fatalf("expected snap, nearest land %.0f m away", distance)
+	}
+	if distance <= 0 || distance > DefaultLandSnapDistance {
+		t.Errorf("distance = %.0f, want (0, %.0f]", distance, DefaultLandSnapDistance)
+	}
+	if !b.IsOnLand(lat, lng) {
+		t.Errorf("snapped point (%f, %f) is not on land", lat, lng)
+	}
+
+	// The middle of the strait is too far from any coast
+	if _, _, _, ok := b.SnapToLand(24.0, 119.9, DefaultLandSnapDistance); ok {
+		t.Error("mid-strait point should not snap")
+	}
+
+	// Points on land are unchanged
+	if lat, lng, distance, ok := b.SnapToLand(25.0337, 121.5645, DefaultLandSnapDistance); !ok || distance != 0 || lat != 25.0337 || lng != 121.5645 {
+		t.Error("on-land point should be returned unchanged")
+	}
+}
+
+func TestLoadBoundariesPolygonDistricts(t *testing.T) {
+	data := `{"type":"FeatureCollection","features":[
+{"type":"Feature","properties":{"kind":"land"},"geometry":{"type":"Polygon","coordinates":[[[0,0],[2,0],[2,2],[0,2],[0,0]]]}},
+{"type":"Feature","properties":{"kind":"county","county":"A"},"geometry":{"type":"Polygon","coordinates":[[[0,0],[2,0],[2,2],[0,2],[0,0]]]}},
+{"type":"Feature","properties":{"kind":"district","county":"A","district":"West"},"geometry":{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,2],[0,2],[0,0]]]}},
+{"type":"Feature","properties":{"kind":"district","county":"A","district":"East"},"geometry":{"type":"Polygon","coordinates":[[[1,0],[2,0],[2,2],[1,2],[1,0]]]}}
+]}`
+	b, err := LoadBoundaries(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("LoadBoundaries() error = %v", err)
+	}
+	if area := b.Lookup(1, 1.5); area == nil || area.District != "East" {
+		t.Errorf("Lookup() = %+v, want district East", area)
+	}
+	if _, err := LoadBoundaries(strings.NewReader(`{"features":[]}`)); err == nil {
+		t.Error("expected an error for empty boundary data")
+	}
+}
+
+func TestBundledDistrictSeatsMatchTheirCounty(t *testing.T) {
+	b := BundledBoundaries()
+
+	for county, seats := range b.seats {
+		for _, seat := range seats {
+			area := b.Lookup(seat.latitude, seat.longitude)
+			if area == nil || area.County != county || area.District != seat.name || !area.OnLand {
+				t.Errorf("%s%s (%f, %f) resolved to %+v", county, seat.name, seat.latitude, seat.longitude, area)
+			}
+		}
+	}
+}
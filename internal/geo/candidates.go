@@ -0,0 +1,151 @@
+package geo
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// AmbiguityMargin is how close the top two candidate scores must be for the caller to ask the user
+	AmbiguityMargin = 0.15
+
+	// sameCandidateDistance (m): candidates closer than this are the same place under different names
+	sameCandidateDistance = 2000.0
+
+	// MaxCandidates is how many ranked candidates geocoding returns
+	MaxCandidates = 5
+)
+
+// GeocodeCandidate is one ranked geocoding match
+type GeocodeCandidate struct {
+	ID         string   `json:"id"` // provider place ID, or c1, c2...
+	Location   Location `json:"location"`
+	Confidence float64  `json:"confidence"`          // 0-1
+	Source     string   `json:"source"`              // provider that produced it: gazetteer, database, google_places, nominatim
+	Providers  []string `json:"providers,omitempty"` // every provider that found this place
+}
+
+// AmbiguousLocationError is returned when several candidates fit a query about equally well
+type AmbiguousLocationError struct {
+	Query      string
+	Candidates []GeocodeCandidate
+}
+
+func (e *AmbiguousLocationError) Error() string {
+	return fmt.Sprintf("%q matches %d places, please choose one", e.Query, len(e.Candidates))
+}
+
+// IsAmbiguous reports whether the top candidates are too close in score to pick one.
+// Candidates are expected in ranked order.
+func IsAmbiguous(candidates []GeocodeCandidate) bool {
+	if len(candidates) < 2 {
+		return false
+	}
+	top := candidates[0]
+	for _, other := range candidates[1:] {
+		if top.Confidence-other.Confidence >= AmbiguityMargin {
+			return false
+		}
+		distance := calculateDistanceInMeters(top.Location.Latitude, top.Location.Longitude,
+			other.Location.Latitude, other.Location.Longitude)
+		if distance > sameCandidateDistance {
+			return true
+		}
+	}
+	return false
+}
+
+// AmbiguousCandidates returns the candidates worth offering the user: those within the
+// ambiguity margin of the top score, keeping one per place
+func AmbiguousCandidates(candidates []GeocodeCandidate) []GeocodeCandidate {
+	if len(candidates) == 0 {
+		return nil
+	}
+	options := []GeocodeCandidate{candidates[0]}
+	for _, candidate := range candidates[1:] {
+		if candidates[0].Confidence-candidate.Confidence >= AmbiguityMargin {
+			break
+		}
+		duplicate := false
+		for _, option := range options {
+			if calculateDistanceInMeters(option.Location.Latitude, option.Location.Longitude,
+				candidate.Location.Latitude, candidate.Location.Longitude) <= sameCandidateDistance {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			options = append(options, candidate)
+		}
+	}
+	return options
+}
+
+// normalizeTai folds 臺 into 台 so 臺北 and 台北 compare equal
+func normalizeTai(s string) string {
+	return strings.ReplaceAll(s, "臺", "台")
+}
+
+// candidateScore blends the provider's ranking, how well the name matches the query
+// and whether the address is in the city the query names
+func candidateScore(query, name, address string, rank int) float64 {
+	prominence := 1.0 / (1.0 + 0.3*float64(rank))
+
+	query = strings.ToLower(normalizeTai(strings.TrimSpace(query)))
+	name = strings.ToLower(normalizeTai(name))
+	address = normalizeTai(address)
+
+	var nameMatch float64
+	switch {
+	case name == query:
+		nameMatch = 1.0
+	case strings.Contains(name, query) || (name != "" && strings.Contains(query, name)):
+		nameMatch = 0.8
+	default:
+		nameMatch = bigramOverlap(query, name)
+	}
+
+	cityMatch := 0.5 // no city in the query
+	cityMismatch := false
+	// Counties and cities are the same in every gazetteer, so the bundled one is enough
+	if county := BundledGazetteer().DetectCounty(query); county != nil {
+		cityMatch = 1.0
+		cityMismatch = !mentionsPlace(address, county) && !mentionsPlace(name, county)
+	}
+
+	score := 0.4*prominence + 0.4*nameMatch + 0.2*cityMatch
+	if cityMismatch {
+		// A result in another city than the one named is rarely what the user meant
+		score *= 0.6
+	}
+	return score
+}
+
+// mentionsPlace reports whether text contains one of the place's Chinese names
+func mentionsPlace(text string, place *GazetteerPlace) bool {
+	text = foldText(text)
+	for _, name := range append([]string{place.Name}, place.Aliases...) {
+		if hasHan(name) && strings.Contains(text, foldText(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// bigramOverlap is the share of the query's character bigrams found in s
+func bigramOverlap(query, s string) float64 {
+	runes := []rune(query)
+	if len(runes) < 2 {
+		if len(runes) == 1 && strings.ContainsRune(s, runes[0]) {
+			return 1
+		}
+		return 0
+	}
+	matched := 0
+	for i := 0; i+1 < len(runes); i++ {
+		if strings.Contains(s, string(runes[i:i+2])) {
+			matched++
+		}
+	}
+	return float64(matched) / float64(len(runes)-1)
+}
@@ -0,0 +1,111 @@
+package geo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// textSearchResponse builds a Places text search body from name/address/lat/lng tuples
+func textSearchResponse(places ...[4]interface{}) string {
+	body := `{"status":"OK","results":[`
+	for i, p := range places {
+		if i > 0 {
+			body += ","
+		}
+		body += fmt.Sprintf(`{"name":%q,"place_id":"p%d","formatted_address":%q,"geometry":{"location":{"lat":%v,"lng":%v}}}`,
+			p[0], i+1, p[1], p[2], p[3])
+	}
+	return body + `]}`
+}
+
+func newTestPlacesService(t *testing.T, body string) *GooglePlacesService {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return &GooglePlacesService{client: server.Client(), apiKey: "test", baseURL: server.URL, boundaries: BundledBoundaries()}
+}
+
+var zhongshanRoads = [][4]interface{}{
+	{"中山路", "台灣台中市中區中山路", 24.1410, 120.6780},
+	{"中山路", "台灣高雄市前金區中山路", 22.6270, 120.3010},
+	{"中山路", "台灣台南市中西區中山路", 22.9950, 120.2080},
+	{"中山路", "台灣嘉義市東區中山路", 23.4800, 120.4500},
+}
+
+func TestSearchCandidatesAmbiguousStreet(t *testing.T) {
+	g := newTestPlacesService(t, textSearchResponse(zhongshanRoads...))
+
+	candidates, err := g.SearchCandidates("中山路")
+	if err != nil {
+		t.Fatalf("SearchCandidates() error = %v", err)
+	}
+	if len(candidates) != 4 {
+		t.Fatalf("got %d candidates, want 4", len(candidates))
+	}
+	if candidates[0].ID != "p1" || candidates[0].Source != "google_places" {
+		t.Errorf("top candidate = %+v", candidates[0])
+	}
+	if !IsAmbiguous(candidates) {
+		t.Error("the same street name in several cities should be ambiguous")
+	}
+	if options := AmbiguousCandidates(candidates); len(options) < 2 {
+		t.Errorf("AmbiguousCandidates() = %d options, want at least 2", len(options))
+	}
+}
+
+func TestSearchCandidatesCityInQuery(t *testing.T) {
+	g := newTestPlacesService(t, textSearchResponse(zhongshanRoads...))
+
+	candidates, err := g.SearchCandidates("高雄中山路")
+	if err != nil {
+		t.Fatalf("SearchCandidates() error = %v", err)
+	}
+	if candidates[0].Location.Address != "台灣高雄市前金區中山路" {
+		t.Errorf("top candidate = %s, want the Kaohsiung one", candidates[0].Location.Address)
+	}
+	if IsAmbiguous(candidates) {
+		t.Error("naming the city should settle the choice")
+	}
+}
+
+func TestSearchCandidatesDistinctLandmark(t *testing.T) {
+	g := newTestPlacesService(t, textSearchResponse(
+		[4]interface{}{"台北101", "台灣台北市信義區信義路五段7號", 25.0337, 121.5645},
+		[4]interface{}{"台北101觀景台", "台灣台北市信義區信義路五段7號89樓", 25.0338, 121.5646},
+		[4]interface{}{"101 Coffee", "台灣台中市西區", 24.1400, 120.6600},
+	))
+
+	candidates, err := g.SearchCandidates("台北101")
+	if err != nil {
+		t.Fatalf("SearchCandidates() error = %v", err)
+	}
+	if candidates[0].Location.Name != "台北101" {
+		t.Errorf("top candidate = %s", candidates[0].Location.Name)
+	}
+	if IsAmbiguous(candidates) {
+		t.Error("an exact landmark match should not be ambiguous")
+	}
+}
+
+func TestSearchCandidatesDropsResultsOutsideTaiwan(t *testing.T) {
+	g := newTestPlacesService(t, textSearchResponse(
+		[4]interface{}{"中山路", "中國廈門市思明區中山路", 24.4560, 118.0800},
+	))
+
+	if _, err := g.SearchCandidates("中山路"); err == nil {
+		t.Error("expected an error when every result is outside Taiwan")
+	}
+}
+
+func TestIsAmbiguousIgnoresNearbyDuplicates(t *testing.T) {
+	candidates := []GeocodeCandidate{
+		{ID: "a", Confidence: 0.8, Location: Location{Latitude: 25.0337, Longitude: 121.5645}},
+		{ID: "b", Confidence: 0.78, Location: Location{Latitude: 25.0340, Longitude: 121.5650}},
+	}
+	if IsAmbiguous(candidates) {
+		t.Error("two names for the same place should not be ambiguous")
+	}
+}
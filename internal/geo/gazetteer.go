@@ -0,0 +1,493 @@
+package geo
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Counties, cities and well-known landmarks with aliases and romanizations.
+// It seeds the gazetteer_places table, which admins can extend.
+//
+//go:embed data/gazetteer.json
+var gazetteerSeedData []byte
+
+// Gazetteer place types
+const (
+	PlaceTypeCounty   = "county"
+	PlaceTypeDistrict = "district"
+)
+
+// GazetteerPlace is a named place with its alternative spellings
+type GazetteerPlace struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null;uniqueIndex:idx_gazetteer_name_county"` // canonical name, e.g. 臺北市
+	Aliases   []string  `json:"aliases" gorm:"type:jsonb;serializer:json"`                  // 台北, Taipei
+	Hanyu     string    `json:"hanyu"`                                                      // Hanyu Pinyin: Taibei
+	Tongyong  string    `json:"tongyong"`                                                   // Tongyong Pinyin: Taibei
+	WadeGiles string    `json:"wadeGiles"`                                                  // Wade-Giles: T'ai-pei
+	County    string    `json:"county" gorm:"uniqueIndex:idx_gazetteer_name_county"`
+	District  string    `json:"district"`
+	Type      string    `json:"type" gorm:"index"` // county, district, landmark, station, university...
+	Latitude  float64   `json:"latitude" gorm:"not null"`
+	Longitude float64   `json:"longitude" gorm:"not null"`
+	Rank      int       `json:"rank" gorm:"default:0"` // breaks ties between equal matches
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// TableName keeps the table name explicit
+func (GazetteerPlace) TableName() string {
+	return "gazetteer_places"
+}
+
+// Names returns the canonical name, aliases and romanizations
+func (p *GazetteerPlace) Names() []string {
+	names := append([]string{p.Name}, p.Aliases...)
+	for _, romanized := range []string{p.Hanyu, p.Tongyong, p.WadeGiles} {
+		if romanized != "" {
+			names = append(names, romanized)
+		}
+	}
+	return names
+}
+
+// Location converts the place for geocoding results
+func (p *GazetteerPlace) Location() Location {
+	address := p.County + p.District
+	if address == p.Name {
+		address = p.County
+	}
+	return Location{
+		Name:      p.Name,
+		Latitude:  p.Latitude,
+		Longitude: p.Longitude,
+		Address:   address,
+		Type:      p.Type,
+	}
+}
+
+// GazetteerMatch is a lookup result
+type GazetteerMatch struct {
+	Place     *GazetteerPlace `json:"place"`
+	Score     float64         `json:"score"`     // 1 for exact name or alias matches
+	MatchedOn string          `json:"matchedOn"` // the name, alias or romanization that matched
+}
+
+// TextMatch is a gazetteer name found inside free text; Start and End are byte offsets
+type TextMatch struct {
+	Place *GazetteerPlace
+	Text  string
+	Start int
+	End   int
+}
+
+// Gazetteer answers exact, prefix, fuzzy and in-text lookups over GazetteerPlaces
+type Gazetteer struct {
+	places     []GazetteerPlace
+	keys       map[string][]int // normalized name -> place indexes
+	sortedKeys []string         // for prefix search
+	original   map[string]string
+	textKeys   []textKey // longest first
+}
+
+type textKey struct {
+	text  string // lowercase, 臺 folded to 台
+	latin bool   // needs word boundaries
+	place int
+}
+
+var (
+	bundledGazetteer     *Gazetteer
+	bundledGazetteerOnce sync.Once
+)
+
+// BundledGazetteer returns a gazetteer over the embedded seed, built on first use
+func BundledGazetteer() *Gazetteer {
+	bundledGazetteerOnce.Do(func() {
+		places, err := gazetteerSeed()
+		if err != nil {
+			panic(fmt.Sprintf("geo: invalid embedded gazetteer data: %v", err))
+		}
+		bundledGazetteer = NewGazetteer(places)
+	})
+	return bundledGazetteer
+}
+
+func gazetteerSeed() ([]GazetteerPlace, error) {
+	var places []GazetteerPlace
+	if err := json.Unmarshal(gazetteerSeedData, &places); err != nil {
+		return nil, err
+	}
+	return places, nil
+}
+
+// SeedGazetteer inserts bundled places missing from the table; existing rows are left alone
+func SeedGazetteer(db *gorm.DB) error {
+	places, err := gazetteerSeed()
+	if err != nil {
+		return fmt.Errorf("invalid embedded gazetteer data: %v", err)
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}, {Name: "county"}},
+		DoNothing: true,
+	}).CreateInBatches(places, 100).Error
+}
+
+// LoadGazetteer builds a gazetteer from the gazetteer_places table
+func LoadGazetteer(db *gorm.DB) (*Gazetteer, error) {
+	var places []GazetteerPlace
+	if err := db.Order("id").Find(&places).Error; err != nil {
+		return nil, fmt.Errorf("failed to load gazetteer: %v", err)
+	}
+	if len(places) == 0 {
+		return nil, fmt.Errorf("gazetteer table is empty")
+	}
+	return NewGazetteer(places), nil
+}
+
+// NewGazetteer indexes places by every name, alias and romanization
+func NewGazetteer(places []GazetteerPlace) *Gazetteer {
+	g := &Gazetteer{
+		places:   places,
+		keys:     make(map[string][]int),
+		original: make(map[string]string),
+	}
+
+	type placeText struct {
+		place int
+		text  string
+	}
+	seenText := make(map[placeText]bool)
+	for i := range places {
+		for _, name := range places[i].Names() {
+			key := normalizePlaceName(name)
+			if key == "" {
+				continue
+			}
+			if !containsIndex(g.keys[key], i) {
+				g.keys[key] = append(g.keys[key], i)
+			}
+			if _, exists := g.original[key]; !exists {
+				g.original[key] = name
+			}
+
+			text := foldText(name)
+			if utf8.RuneCountInString(text) < 2 || seenText[placeText{i, text}] {
+				continue
+			}
+			seenText[placeText{i, text}] = true
+			g.textKeys = append(g.textKeys, textKey{text: text, latin: !hasHan(text), place: i})
+		}
+	}
+
+	for key := range g.keys {
+		g.sortedKeys = append(g.sortedKeys, key)
+	}
+	sort.Strings(g.sortedKeys)
+
+	sort.SliceStable(g.textKeys, func(i, j int) bool {
+		return len(g.textKeys[i].text) > len(g.textKeys[j].text)
+	})
+
+	return g
+}
+
+// Len returns the number of places
+func (g *Gazetteer) Len() int {
+	return len(g.places)
+}
+
+// Lookup returns the places whose name, alias or romanization equals name, highest rank first
+func (g *Gazetteer) Lookup(name string) []*GazetteerPlace {
+	indexes := g.keys[normalizePlaceName(name)]
+	places := make([]*GazetteerPlace, 0, len(indexes))
+	for _, i := range indexes {
+		places = append(places, &g.places[i])
+	}
+	sort.SliceStable(places, func(i, j int) bool { return places[i].Rank > places[j].Rank })
+	return places
+}
+
+// Search returns exact, prefix and fuzzy matches for query, best first
+func (g *Gazetteer) Search(query string, limit int) []GazetteerMatch {
+	key := normalizePlaceName(query)
+	if key == "" {
+		return nil
+	}
+
+	best := make(map[int]GazetteerMatch)
+	consider := func(i int, score float64, matchedKey string) {
+		if current, exists := best[i]; exists && current.Score >= score {
+			return
+		}
+		best[i] = GazetteerMatch{Place: &g.places[i], Score: score, MatchedOn: g.original[matchedKey]}
+	}
+
+	for _, i := range g.keys[key] {
+		consider(i, 1.0, key)
+	}
+
+	queryLen := utf8.RuneCountInString(key)
+
+	// Prefix: "taib" finds Taibei, "日月" finds 日月潭
+	if queryLen >= 2 {
+		start := sort.SearchStrings(g.sortedKeys, key)
+		for _, candidate := range g.sortedKeys[start:] {
+			if !strings.HasPrefix(candidate, key) {
+				break
+			}
+			if candidate == key {
+				continue
+			}
+			score := 0.6 + 0.3*float64(queryLen)/float64(utf8.RuneCountInString(candidate))
+			for _, i := range g.keys[candidate] {
+				consider(i, score, candidate)
+			}
+		}
+	}
+
+	// Fuzzy: typos and romanization variants within a small edit distance
+	maxDistance := fuzzyDistance(key)
+	if maxDistance > 0 {
+		for _, candidate := range g.sortedKeys {
+			candidateLen := utf8.RuneCountInString(candidate)
+			if abs(candidateLen-queryLen) > maxDistance {
+				continue
+			}
+			distance := levenshtein(key, candidate)
+			if distance == 0 || distance > maxDistance {
+				continue
+			}
+			score := 0.75 - 0.15*float64(distance)
+			for _, i := range g.keys[candidate] {
+				consider(i, score, candidate)
+			}
+		}
+	}
+
+	matches := make([]GazetteerMatch, 0, len(best))
+	for _, match := range best {
+		matches = append(matches, match)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		if matches[i].Place.Rank != matches[j].Place.Rank {
+			return matches[i].Place.Rank > matches[j].Place.Rank
+		}
+		return matches[i].Place.ID < matches[j].Place.ID
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// FindInText returns the gazetteer names in text, longest first and without overlaps
+func (g *Gazetteer) FindInText(text string) []TextMatch {
+	folded := foldText(text)
+	taken := make([]bool, len(folded))
+
+	var matches []TextMatch
+	for _, key := range g.textKeys {
+		offset := 0
+		for {
+			index := strings.Index(folded[offset:], key.text)
+			if index < 0 {
+				break
+			}
+			start := offset + index
+			end := start + len(key.text)
+			offset = end
+
+			if key.latin && !wordBoundary(folded, start, end) {
+				continue
+			}
+			if overlaps(taken, start, end) {
+				continue
+			}
+			for i := start; i < end; i++ {
+				taken[i] = true
+			}
+			matches = append(matches, TextMatch{Place: &g.places[key.place], Text: text[start:end], Start: start, End: end})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if len(matches[i].Text) != len(matches[j].Text) {
+			return len(matches[i].Text) > len(matches[j].Text)
+		}
+		return matches[i].Start < matches[j].Start
+	})
+	return matches
+}
+
+// Nearest returns the closest landmark (not a county or district) within maxDistance meters
+// and its distance, or nil
+func (g *Gazetteer) Nearest(latitude, longitude, maxDistance float64) (*GazetteerPlace, float64) {
+	var nearest *GazetteerPlace
+	best := maxDistance
+	for i := range g.places {
+		place := &g.places[i]
+		if place.Type == PlaceTypeCounty || place.Type == PlaceTypeDistrict {
+			continue
+		}
+		if distance := calculateDistanceInMeters(latitude, longitude, place.Latitude, place.Longitude); distance <= best {
+			nearest, best = place, distance
+		}
+	}
+	return nearest, best
+}
+
+// DetectCounty returns the county or city named in text, if any
+func (g *Gazetteer) DetectCounty(text string) *GazetteerPlace {
+	for _, match := range g.FindInText(text) {
+		if match.Place.Type == PlaceTypeCounty {
+			return match.Place
+		}
+	}
+	return nil
+}
+
+// normalizePlaceName folds case, 臺/台, spacing, hyphens, apostrophes and umlauts
+// so that "T'ai-pei", "taipei" and "Tai Pei" share a key
+// NormalizePlaceName folds case, 臺/台 and punctuation so that spellings of the
+// same name compare equal
+func NormalizePlaceName(name string) string {
+	return normalizePlaceName(name)
+}
+
+func normalizePlaceName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case r == '臺':
+			b.WriteRune('台')
+		case r == 'ü':
+			b.WriteRune('u')
+		case unicode.IsSpace(r), r == '-', r == '\'', r == '’', r == '‘', r == '·', r == '.':
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// foldText lowercases ASCII and folds 臺 to 台 without changing byte offsets
+func foldText(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	for _, r := range text {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			b.WriteRune(r + ('a' - 'A'))
+		case r == '臺':
+			b.WriteRune('台') // same UTF-8 length
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func hasHan(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// wordBoundary reports whether text[start:end] is not part of a longer Latin word
+func wordBoundary(text string, start, end int) bool {
+	if start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(text[:start])
+		if unicode.IsLetter(r) && r < unicode.MaxASCII {
+			return false
+		}
+	}
+	if end < len(text) {
+		r, _ := utf8.DecodeRuneInString(text[end:])
+		if unicode.IsLetter(r) && r < unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+func overlaps(taken []bool, start, end int) bool {
+	for i := start; i < end; i++ {
+		if taken[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// fuzzyDistance is the edit distance tolerated for a normalized query
+func fuzzyDistance(key string) int {
+	length := utf8.RuneCountInString(key)
+	if hasHan(key) {
+		if length >= 3 {
+			return 1
+		}
+		return 0
+	}
+	switch {
+	case length >= 8:
+		return 2
+	case length >= 4:
+		return 1
+	}
+	return 0
+}
+
+// levenshtein is the rune edit distance between a and b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+func containsIndex(indexes []int, i int) bool {
+	for _, existing := range indexes {
+		if existing == i {
+			return true
+		}
+	}
+	return false
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,168 @@
+package geo
+
+import (
+	"testing"
+)
+
+func TestGazetteerLookupNamesAliasesAndRomanizations(t *testing.T) {
+	g := BundledGazetteer()
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"臺北市", "臺北市"},
+		{"台北", "臺北市"},
+		{"Taipei", "臺北市"},
+		{"T'ai-pei", "臺北市"},
+		{"tai pei", "臺北市"},
+		{"Gaosyong", "高雄市"},
+		{"Kao-hsiung", "高雄市"},
+		{"Sun Moon Lake", "日月潭"},
+		{"Jih-yüeh-t'an", "日月潭"},
+		{"jih yueh tan", "日月潭"},
+		{"故宮", "國立故宮博物院"},
+		{"台北101", "臺北101"},
+		{"新竹", "新竹市"},
+		{"馬祖", "連江縣"},
+	}
+
+	for _, tt := range tests {
+		places := g.Lookup(tt.query)
+		if len(places) == 0 {
+			t.Errorf("Lookup(%q) found nothing, want %s", tt.query, tt.want)
+			continue
+		}
+		if places[0].Name != tt.want {
+			t.Errorf("Lookup(%q) = %s, want %s", tt.query, places[0].Name, tt.want)
+		}
+	}
+}
+
+func TestGazetteerSearchPrefixAndFuzzy(t *testing.T) {
+	g := BundledGazetteer()
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"日月", "日月潭"},        // prefix
+		{"kaohsiu", "高雄市"},   // romanization prefix
+		{"Kaoshiung", "高雄市"}, // transposed letters
+		{"Taroco", "太魯閣"},    // typo
+		{"阿里三", "阿里山"},       // wrong character
+		{"National Palace", "國立故宮博物院"},
+	}
+
+	for _, tt := range tests {
+		matches := g.Search(tt.query, 5)
+		if len(matches) == 0 {
+			t.Errorf("Search(%q) found nothing, want %s", tt.query, tt.want)
+			continue
+		}
+		if matches[0].Place.Name != tt.want {
+			t.Errorf("Search(%q) = %s (%.2f on %q), want %s", tt.query, matches[0].Place.Name, matches[0].Score, matches[0].MatchedOn, tt.want)
+		}
+		if matches[0].Score >= 1 {
+			t.Errorf("Search(%q) should not be an exact match", tt.query)
+		}
+	}
+
+	if matches := g.Search("zzzz", 5); len(matches) != 0 {
+		t.Errorf("Search(zzzz) = %v, want nothing", matches)
+	}
+}
+
+func TestGazetteerFindInText(t *testing.T) {
+	g := BundledGazetteer()
+
+	tests := []struct {
+		text  string
+		want  string
+		start int
+	}{
+		{"去嘉義市吃火雞肉飯", "嘉義市", len("去")},
+		{"帶我去台北車站", "台北車站", len("帶我去")},
+		{"我想去新北市板橋", "新北市", len("我想去")},
+		{"go to Sun Moon Lake by car", "Sun Moon Lake", len("go to ")},
+		{"take me to taipei 101", "taipei 101", len("take me to ")},
+	}
+
+	for _, tt := range tests {
+		matches := g.FindInText(tt.text)
+		if len(matches) == 0 {
+			t.Errorf("FindInText(%q) found nothing", tt.text)
+			continue
+		}
+		if matches[0].Text != tt.want || matches[0].Start != tt.start || tt.text[matches[0].Start:matches[0].End] != tt.want {
+			t.Errorf("FindInText(%q) = %q at %d, want %q at %d", tt.text, matches[0].Text, matches[0].Start, tt.want, tt.start)
+		}
+	}
+
+	// Romanizations only match whole words
+	if matches := g.FindInText("jijiang river"); len(matches) != 0 {
+		t.Errorf("FindInText matched inside a word: %+v", matches[0])
+	}
+}
+
+func TestGazetteerDetectCounty(t *testing.T) {
+	g := BundledGazetteer()
+
+	if county := g.DetectCounty("高雄中山路"); county == nil || county.Name != "高雄市" {
+		t.Errorf("DetectCounty(高雄中山路) = %+v, want 高雄市", county)
+	}
+	if county := g.DetectCounty("中山路"); county != nil {
+		t.Errorf("DetectCounty(中山路) = %s, want nil", county.Name)
+	}
+}
+
+func TestBundledGazetteerPlacesAreInTaiwan(t *testing.T) {
+	places, err := gazetteerSeed()
+	if err != nil {
+		t.Fatalf("gazetteerSeed() error = %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, place := range places {
+		if place.Name == "" || place.Type == "" || place.County == "" {
+			t.Errorf("%+v is missing name, type or county", place)
+		}
+		if place.Hanyu == "" || place.Tongyong == "" || place.WadeGiles == "" {
+			t.Errorf("%s is missing a romanization", place.Name)
+		}
+		if seen[place.Name+place.County] {
+			t.Errorf("%s is listed twice", place.Name)
+		}
+		seen[place.Name+place.County] = true
+
+		if !BundledBoundaries().InTerritory(place.Latitude, place.Longitude) {
+			t.Errorf("%s (%f, %f) is outside Taiwan", place.Name, place.Latitude, place.Longitude)
+			continue
+		}
+		if area := BundledBoundaries().Lookup(place.Latitude, place.Longitude); area == nil || area.County != place.County {
+			t.Errorf("%s (%f, %f) resolves to %+v, want county %s", place.Name, place.Latitude, place.Longitude, area, place.County)
+		}
+	}
+}
+
+func TestGeocodeCandidatesUsesGazetteerBeforeGoogle(t *testing.T) {
+	g := NewGeocodingServiceWithChain(NewGeocoderChain(NewGazetteerGeocoder(nil)), nil) // no Google Places
+
+	candidates, err := g.GeocodeCandidates("日月潭")
+	if err != nil {
+		t.Fatalf("GeocodeCandidates() error = %v", err)
+	}
+	if candidates[0].Source != "gazetteer" || candidates[0].Location.Name != "日月潭" || candidates[0].Confidence != 1 {
+		t.Errorf("top candidate = %+v", candidates[0])
+	}
+
+	// Without Google, fuzzy gazetteer matches are the fallback
+	candidates, err = g.GeocodeCandidates("Taroco")
+	if err != nil || candidates[0].Location.Name != "太魯閣" {
+		t.Errorf("GeocodeCandidates(Taroco) = %+v, %v", candidates, err)
+	}
+
+	if _, err := g.GeocodeCandidates("zzzz"); err == nil {
+		t.Error("expected an error for an unknown place without Google")
+	}
+}
@@ -0,0 +1,315 @@
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// DefaultGeocodeRegion is the region every lookup is biased to
+	DefaultGeocodeRegion = "tw"
+
+	// DefaultGeocodeCacheTTL keeps found places for 30 days, the longest Google allows coordinates to be cached
+	DefaultGeocodeCacheTTL = 30 * 24 * time.Hour
+
+	// DefaultGeocodeNegativeTTL keeps "no results" for an hour so a new place is found soon after it is listed
+	DefaultGeocodeNegativeTTL = time.Hour
+)
+
+// GeocodeCacheEntry is a cached geocoding answer for a normalized query in a region.
+// Negative entries record that no provider found anything.
+type GeocodeCacheEntry struct {
+	ID         uint               `json:"id" gorm:"primaryKey"`
+	QueryKey   string             `json:"queryKey" gorm:"not null;uniqueIndex:idx_geocode_cache_key_region"`
+	Region     string             `json:"region" gorm:"not null;uniqueIndex:idx_geocode_cache_key_region"`
+	Query      string             `json:"query"` // as first asked
+	Candidates []GeocodeCandidate `json:"candidates" gorm:"type:jsonb;serializer:json"`
+	Negative   bool               `json:"negative"`
+	HitCount   int                `json:"hitCount" gorm:"default:0"`
+	LastHitAt  *time.Time         `json:"lastHitAt"`
+	ExpiresAt  time.Time          `json:"expiresAt" gorm:"index"`
+	CreatedAt  time.Time          `json:"createdAt"`
+	UpdatedAt  time.Time          `json:"updatedAt"`
+}
+
+// TableName keeps the table name explicit
+func (GeocodeCacheEntry) TableName() string {
+	return "geocode_cache_entries"
+}
+
+// GeocodeCacheConfig sets how long answers are kept
+type GeocodeCacheConfig struct {
+	TTL         time.Duration `json:"ttl"`
+	NegativeTTL time.Duration `json:"negativeTtl"`
+	Region      string        `json:"region"`
+}
+
+// GeocodeCacheConfigFromEnv reads GEOCODE_CACHE_TTL and GEOCODE_CACHE_NEGATIVE_TTL (Go durations)
+func GeocodeCacheConfigFromEnv() (GeocodeCacheConfig, error) {
+	config := GeocodeCacheConfig{
+		TTL:         DefaultGeocodeCacheTTL,
+		NegativeTTL: DefaultGeocodeNegativeTTL,
+		Region:      DefaultGeocodeRegion,
+	}
+	for name, target := range map[string]*time.Duration{
+		"GEOCODE_CACHE_TTL":          &config.TTL,
+		"GEOCODE_CACHE_NEGATIVE_TTL": &config.NegativeTTL,
+	} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration < 0 {
+			return config, fmt.Errorf("invalid %s: %s", name, value)
+		}
+		*target = duration
+	}
+	return config, nil
+}
+
+// GeocodeCacheStats counts lookups since startup
+type GeocodeCacheStats struct {
+	Hits         int64   `json:"hits"`
+	NegativeHits int64   `json:"negativeHits"`
+	Misses       int64   `json:"misses"`
+	Coalesced    int64   `json:"coalesced"`   // lookups that waited for an identical one in flight
+	StoreErrors  int64   `json:"storeErrors"` // cache reads or writes that failed; the lookup still ran
+	HitRatio     float64 `json:"hitRatio"`    // (hits + negativeHits) / all lookups
+}
+
+// geocodeCacheStore persists entries; GORM in production
+type geocodeCacheStore interface {
+	get(key, region string, now time.Time) (*GeocodeCacheEntry, error) // nil when missing or expired
+	put(entry *GeocodeCacheEntry) error
+	touch(id uint, now time.Time) error
+}
+
+// geocodeCall is a lookup in flight that identical lookups wait for
+type geocodeCall struct {
+	done       chan struct{}
+	candidates []GeocodeCandidate
+	err        error
+}
+
+// GeocodeCache keeps geocoding answers in Postgres so repeated place names do
+// not reach Google Places or Nominatim again
+type GeocodeCache struct {
+	db     *gorm.DB
+	store  geocodeCacheStore
+	config GeocodeCacheConfig
+
+	mu       sync.Mutex
+	inFlight map[string]*geocodeCall
+
+	hits, negativeHits, misses, coalesced, storeErrors atomic.Int64
+}
+
+// NewGeocodeCache returns a cache over the geocode_cache_entries table
+func NewGeocodeCache(db *gorm.DB, config GeocodeCacheConfig) *GeocodeCache {
+	cache := newGeocodeCache(&gormGeocodeCacheStore{db: db}, config)
+	cache.db = db
+	return cache
+}
+
+func newGeocodeCache(store geocodeCacheStore, config GeocodeCacheConfig) *GeocodeCache {
+	if config.Region == "" {
+		config.Region = DefaultGeocodeRegion
+	}
+	return &GeocodeCache{
+		store:    store,
+		config:   config,
+		inFlight: make(map[string]*geocodeCall),
+	}
+}
+
+// Config returns the TTLs and region
+func (c *GeocodeCache) Config() GeocodeCacheConfig {
+	return c.config
+}
+
+// Resolve answers query from the cache, or calls lookup and stores its answer.
+// Concurrent calls for the same normalized query share one lookup. A *NoResultsError
+// is cached for the negative TTL; other errors are not cached.
+func (c *GeocodeCache) Resolve(query string, lookup func(string) ([]GeocodeCandidate, error)) ([]GeocodeCandidate, error) {
+	key := normalizePlaceName(query)
+	if key == "" {
+		return lookup(query)
+	}
+
+	now := time.Now()
+	entry, err := c.store.get(key, c.config.Region, now)
+	if err != nil {
+		c.storeErrors.Add(1)
+	}
+	if entry != nil {
+		if err := c.store.touch(entry.ID, now); err != nil {
+			c.storeErrors.Add(1)
+		}
+		if entry.Negative {
+			c.negativeHits.Add(1)
+			return nil, &NoResultsError{Query: query}
+		}
+		c.hits.Add(1)
+		return entry.Candidates, nil
+	}
+
+	c.mu.Lock()
+	if call, exists := c.inFlight[key]; exists {
+		c.mu.Unlock()
+		c.coalesced.Add(1)
+		<-call.done
+		return call.candidates, call.err
+	}
+	call := &geocodeCall{done: make(chan struct{})}
+	c.inFlight[key] = call
+	c.mu.Unlock()
+
+	// Release waiters even if lookup panics
+	defer func() {
+		c.mu.Lock()
+		delete(c.inFlight, key)
+		c.mu.Unlock()
+		close(call.done)
+	}()
+
+	c.misses.Add(1)
+	call.err = fmt.Errorf("geocoding lookup for %s did not complete", query)
+	call.candidates, call.err = lookup(query)
+	c.save(key, query, call.candidates, call.err)
+
+	return call.candidates, call.err
+}
+
+func (c *GeocodeCache) save(key, query string, candidates []GeocodeCandidate, lookupErr error) {
+	entry := &GeocodeCacheEntry{
+		QueryKey:   key,
+		Region:     c.config.Region,
+		Query:      query,
+		Candidates: candidates,
+	}
+
+	var noResults *NoResultsError
+	switch {
+	case lookupErr == nil && len(candidates) > 0:
+		if c.config.TTL <= 0 {
+			return
+		}
+		entry.ExpiresAt = time.Now().Add(c.config.TTL)
+	case errors.As(lookupErr, &noResults):
+		if c.config.NegativeTTL <= 0 {
+			return
+		}
+		entry.Negative = true
+		entry.Candidates = nil
+		entry.ExpiresAt = time.Now().Add(c.config.NegativeTTL)
+	default:
+		return // a provider failed; try again next time
+	}
+
+	if err := c.store.put(entry); err != nil {
+		c.storeErrors.Add(1)
+		fmt.Printf("Warning: failed to cache geocoding result for %s: %v\n", query, err)
+	}
+}
+
+// Stats returns the counters and hit ratio since startup
+func (c *GeocodeCache) Stats() GeocodeCacheStats {
+	stats := GeocodeCacheStats{
+		Hits:         c.hits.Load(),
+		NegativeHits: c.negativeHits.Load(),
+		Misses:       c.misses.Load(),
+		Coalesced:    c.coalesced.Load(),
+		StoreErrors:  c.storeErrors.Load(),
+	}
+	if total := stats.Hits + stats.NegativeHits + stats.Misses + stats.Coalesced; total > 0 {
+		stats.HitRatio = float64(stats.Hits+stats.NegativeHits) / float64(total)
+	}
+	return stats
+}
+
+// ListEntries returns cached entries whose normalized query starts with prefix, most used first
+func (c *GeocodeCache) ListEntries(prefix string, limit int) ([]GeocodeCacheEntry, error) {
+	if c.db == nil {
+		return nil, fmt.Errorf("geocode cache has no database")
+	}
+	query := c.db.Order("hit_count DESC, updated_at DESC").Limit(limit)
+	if key := normalizePlaceName(prefix); key != "" {
+		query = query.Where("query_key LIKE ?", escapeLike(key)+"%")
+	}
+	var entries []GeocodeCacheEntry
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// DeleteEntry removes one entry
+func (c *GeocodeCache) DeleteEntry(id uint) error {
+	if c.db == nil {
+		return fmt.Errorf("geocode cache has no database")
+	}
+	result := c.db.Delete(&GeocodeCacheEntry{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Purge deletes the entries for query (all entries when empty); expiredOnly keeps live ones
+func (c *GeocodeCache) Purge(query string, expiredOnly bool) (int64, error) {
+	if c.db == nil {
+		return 0, fmt.Errorf("geocode cache has no database")
+	}
+	tx := c.db.Session(&gorm.Session{AllowGlobalUpdate: true})
+	if key := normalizePlaceName(query); key != "" {
+		tx = tx.Where("query_key = ? AND region = ?", key, c.config.Region)
+	}
+	if expiredOnly {
+		tx = tx.Where("expires_at <= ?", time.Now())
+	}
+	result := tx.Delete(&GeocodeCacheEntry{})
+	return result.RowsAffected, result.Error
+}
+
+type gormGeocodeCacheStore struct {
+	db *gorm.DB
+}
+
+func (s *gormGeocodeCacheStore) get(key, region string, now time.Time) (*GeocodeCacheEntry, error) {
+	var entry GeocodeCacheEntry
+	err := s.db.Where("query_key = ? AND region = ? AND expires_at > ?", key, region, now).
+		Take(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (s *gormGeocodeCacheStore) put(entry *GeocodeCacheEntry) error {
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "query_key"}, {Name: "region"}},
+		DoUpdates: clause.AssignmentColumns([]string{"query", "candidates", "negative", "expires_at", "updated_at"}),
+	}).Create(entry).Error
+}
+
+func (s *gormGeocodeCacheStore) touch(id uint, now time.Time) error {
+	return s.db.Model(&GeocodeCacheEntry{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"hit_count":   gorm.Expr("hit_count + 1"),
+			"last_hit_at": now,
+		}).Error
+}
@@ -0,0 +1,198 @@
+package geo
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memoryGeocodeCacheStore keeps entries in a map for tests
+type memoryGeocodeCacheStore struct {
+	mu      sync.Mutex
+	entries map[string]*GeocodeCacheEntry
+	nextID  uint
+}
+
+func newMemoryGeocodeCacheStore() *memoryGeocodeCacheStore {
+	return &memoryGeocodeCacheStore{entries: make(map[string]*GeocodeCacheEntry)}
+}
+
+func (s *memoryGeocodeCacheStore) get(key, region string, now time.Time) (*GeocodeCacheEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[region+"/"+key]
+	if !ok || !entry.ExpiresAt.After(now) {
+		return nil, nil
+	}
+	copied := *entry
+	return &copied, nil
+}
+
+func (s *memoryGeocodeCacheStore) put(entry *GeocodeCacheEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	entry.ID = s.nextID
+	s.entries[entry.Region+"/"+entry.QueryKey] = entry
+	return nil
+}
+
+func (s *memoryGeocodeCacheStore) touch(id uint, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, entry := range s.entries {
+		if entry.ID == id {
+			entry.HitCount++
+			entry.LastHitAt = &now
+		}
+	}
+	return nil
+}
+
+func testGeocodeCache(store *memoryGeocodeCacheStore) *GeocodeCache {
+	return newGeocodeCache(store, GeocodeCacheConfig{TTL: time.Hour, NegativeTTL: time.Minute})
+}
+
+func TestGeocodeCacheHitsNormalizedQuery(t *testing.T) {
+	store := newMemoryGeocodeCacheStore()
+	cache := testGeocodeCache(store)
+
+	calls := 0
+	lookup := func(query string) ([]GeocodeCandidate, error) {
+		calls++
+		return []GeocodeCandidate{stubCandidate(ProviderGoogle, "臺北101", 25.0339, 121.5645, 0.95)}, nil
+	}
+
+	for _, query := range []string{"台北101", "臺北101", " 台北 101 "} {
+		candidates, err := cache.Resolve(query, lookup)
+		if err != nil || len(candidates) != 1 {
+			t.Fatalf("Resolve(%q) = %v, %v", query, candidates, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("lookup called %d times, want 1", calls)
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if stats.HitRatio < 0.66 || stats.HitRatio > 0.67 {
+		t.Errorf("hit ratio = %v, want 2/3", stats.HitRatio)
+	}
+	for _, entry := range store.entries {
+		if entry.HitCount != 2 || entry.Region != DefaultGeocodeRegion {
+			t.Errorf("entry = %+v", entry)
+		}
+	}
+}
+
+func TestGeocodeCacheNegativeAndErrors(t *testing.T) {
+	store := newMemoryGeocodeCacheStore()
+	cache := testGeocodeCache(store)
+
+	calls := 0
+	notFound := func(query string) ([]GeocodeCandidate, error) {
+		calls++
+		return nil, &NoResultsError{Query: query}
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Resolve("zzzz", notFound); err == nil {
+			t.Fatal("expected a no-results error")
+		}
+	}
+	if calls != 1 || cache.Stats().NegativeHits != 1 {
+		t.Errorf("no results should be cached: calls = %d, stats = %+v", calls, cache.Stats())
+	}
+
+	// A provider outage is not an answer; the next lookup tries again
+	calls = 0
+	unavailable := func(query string) ([]GeocodeCandidate, error) {
+		calls++
+		return nil, fmt.Errorf("google_places: connection refused")
+	}
+	cache.Resolve("日月潭", unavailable)
+	cache.Resolve("日月潭", unavailable)
+	if calls != 2 {
+		t.Errorf("errors must not be cached, lookup called %d times", calls)
+	}
+}
+
+func TestGeocodeCacheExpires(t *testing.T) {
+	store := newMemoryGeocodeCacheStore()
+	cache := testGeocodeCache(store)
+
+	lookup := func(query string) ([]GeocodeCandidate, error) {
+		return []GeocodeCandidate{stubCandidate(ProviderGazetteer, query, 23.8667, 120.9167, 1)}, nil
+	}
+	cache.Resolve("日月潭", lookup)
+	for _, entry := range store.entries {
+		entry.ExpiresAt = time.Now().Add(-time.Second)
+	}
+	cache.Resolve("日月潭", lookup)
+
+	if stats := cache.Stats(); stats.Misses != 2 || stats.Hits != 0 {
+		t.Errorf("expired entry should miss: %+v", stats)
+	}
+}
+
+func TestGeocodeCacheCoalescesConcurrentLookups(t *testing.T) {
+	cache := testGeocodeCache(newMemoryGeocodeCacheStore())
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	lookup := func(query string) ([]GeocodeCandidate, error) {
+		calls.Add(1)
+		<-release
+		return []GeocodeCandidate{stubCandidate(ProviderGoogle, "阿里山", 23.5105, 120.8024, 0.9)}, nil
+	}
+
+	const callers = 8
+	var wg sync.WaitGroup
+	results := make(chan int, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			candidates, _ := cache.Resolve("阿里山", lookup)
+			results <- len(candidates)
+		}()
+	}
+
+	// Let every caller either start the lookup or join it
+	deadline := time.Now().Add(time.Second)
+	for cache.Stats().Coalesced+cache.Stats().Misses < callers && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	close(results)
+
+	if calls.Load() != 1 {
+		t.Errorf("lookup called %d times, want 1", calls.Load())
+	}
+	for n := range results {
+		if n != 1 {
+			t.Errorf("a caller got %d candidates", n)
+		}
+	}
+}
+
+func TestGeocodingServiceUsesCache(t *testing.T) {
+	cache := testGeocodeCache(newMemoryGeocodeCacheStore())
+	google := &stubGeocoder{name: ProviderGoogle, candidates: []GeocodeCandidate{
+		stubCandidate(ProviderGoogle, "鼎泰豐 信義店", 25.0334, 121.5300, 0.92),
+	}}
+	g := NewGeocodingServiceWithChain(NewGeocoderChain(google), cache)
+
+	for i := 0; i < 3; i++ {
+		if _, err := g.GeocodeCandidates("鼎泰豐"); err != nil {
+			t.Fatalf("GeocodeCandidates() error = %v", err)
+		}
+	}
+	if google.calls != 1 {
+		t.Errorf("Google called %d times, want 1", google.calls)
+	}
+}
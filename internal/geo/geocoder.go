@@ -0,0 +1,324 @@
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Geocoder resolves a place name to ranked candidates. Candidates carry the
+// provider's name in Source and a 0-1 Confidence comparable across providers.
+type Geocoder interface {
+	Name() string
+	Geocode(query string) ([]GeocodeCandidate, error)
+}
+
+// NoResultsError means the providers answered but found nothing, as opposed to a
+// provider being unreachable; only this outcome is worth caching
+type NoResultsError struct {
+	Query string
+}
+
+func (e *NoResultsError) Error() string {
+	return fmt.Sprintf("no results found for location: %s", e.Query)
+}
+
+// Provider names, as reported in GeocodeCandidate.Source and accepted in GEOCODER_CHAIN
+// ("google" is also accepted for Google Places)
+const (
+	ProviderGazetteer = "gazetteer"
+	ProviderDatabase  = "database"
+	ProviderGoogle    = "google_places"
+	ProviderNominatim = "nominatim"
+)
+
+const (
+	// DefaultGeocoderChain asks the free local sources before the metered and rate-limited ones
+	DefaultGeocoderChain = "gazetteer,database,google,nominatim"
+
+	// ChainStopConfidence ends the chain early once a candidate scores this well
+	ChainStopConfidence = 0.9
+
+	// mergeDistance (m): candidates from different providers this close are the same place
+	mergeDistance = 200.0
+
+	// agreementBonus is added when another provider found the same place
+	agreementBonus = 0.05
+)
+
+var defaultProviderWeights = map[string]float64{
+	ProviderGazetteer: 1.0,
+	ProviderDatabase:  0.95,
+	ProviderGoogle:    1.0,
+	ProviderNominatim: 0.9,
+}
+
+// chainLink is a provider and the weight its confidences are scaled by
+type chainLink struct {
+	geocoder Geocoder
+	weight   float64
+}
+
+// GeocoderChain queries providers in order and merges their candidates
+type GeocoderChain struct {
+	links []chainLink
+}
+
+// NewGeocoderChain returns a chain over geocoders with their default weights
+func NewGeocoderChain(geocoders ...Geocoder) *GeocoderChain {
+	chain := &GeocoderChain{}
+	for _, geocoder := range geocoders {
+		chain.Add(geocoder, 0)
+	}
+	return chain
+}
+
+// Add appends a provider; weight <= 0 uses the provider's default
+func (c *GeocoderChain) Add(geocoder Geocoder, weight float64) {
+	if weight <= 0 {
+		weight = defaultProviderWeights[geocoder.Name()]
+		if weight == 0 {
+			weight = 1
+		}
+	}
+	c.links = append(c.links, chainLink{geocoder: geocoder, weight: weight})
+}
+
+// Providers returns the provider names in query order
+func (c *GeocoderChain) Providers() []string {
+	if c == nil {
+		return nil
+	}
+	names := make([]string, 0, len(c.links))
+	for _, link := range c.links {
+		names = append(names, link.geocoder.Name())
+	}
+	return names
+}
+
+// Geocode asks each provider in turn until one returns a candidate scoring at least
+// ChainStopConfidence, then returns the merged candidates of every provider asked,
+// best first. Results from providers that agree on a place are merged into one
+// candidate that lists all of them in Providers.
+func (c *GeocoderChain) Geocode(query string) ([]GeocodeCandidate, error) {
+	if c == nil || len(c.links) == 0 {
+		return nil, fmt.Errorf("no geocoding providers configured")
+	}
+
+	var merged []GeocodeCandidate
+	var failures []string
+	for _, link := range c.links {
+		candidates, err := link.geocoder.Geocode(query)
+		if err != nil {
+			var noResults *NoResultsError
+			if !errors.As(err, &noResults) {
+				failures = append(failures, fmt.Sprintf("%s: %v", link.geocoder.Name(), err))
+			}
+			continue
+		}
+
+		for _, candidate := range candidates {
+			candidate.Confidence = candidate.Confidence * link.weight
+			if candidate.Source == "" {
+				candidate.Source = link.geocoder.Name()
+			}
+			merged = mergeCandidate(merged, candidate)
+		}
+
+		sortCandidates(merged)
+		if len(merged) > 0 && merged[0].Confidence >= ChainStopConfidence {
+			break
+		}
+	}
+
+	if len(merged) == 0 {
+		if len(failures) > 0 {
+			return nil, fmt.Errorf("failed to find location: %s (%s)", query, strings.Join(failures, "; "))
+		}
+		return nil, &NoResultsError{Query: query}
+	}
+
+	if len(merged) > MaxCandidates {
+		merged = merged[:MaxCandidates]
+	}
+	return merged, nil
+}
+
+// mergeCandidate adds candidate to merged, folding it into a candidate from another
+// provider for the same place
+func mergeCandidate(merged []GeocodeCandidate, candidate GeocodeCandidate) []GeocodeCandidate {
+	if len(candidate.Providers) == 0 {
+		candidate.Providers = []string{candidate.Source}
+	}
+
+	for i := range merged {
+		existing := &merged[i]
+		if containsString(existing.Providers, candidate.Source) {
+			continue // a provider's own results are distinct places
+		}
+		distance := calculateDistanceInMeters(existing.Location.Latitude, existing.Location.Longitude,
+			candidate.Location.Latitude, candidate.Location.Longitude)
+		sameName := normalizePlaceName(existing.Location.Name) == normalizePlaceName(candidate.Location.Name)
+		if distance > mergeDistance && !(sameName && distance <= sameCandidateDistance) {
+			continue
+		}
+
+		providers := append(existing.Providers, candidate.Source)
+		if candidate.Confidence > existing.Confidence {
+			*existing = candidate
+		}
+		existing.Providers = providers
+		existing.Confidence = min(1, existing.Confidence+agreementBonus)
+		return merged
+	}
+
+	return append(merged, candidate)
+}
+
+func sortCandidates(candidates []GeocodeCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+}
+
+// GeocoderChainFromEnv builds the chain named by GEOCODER_CHAIN, e.g.
+// "gazetteer,google,nominatim:0.8"; a provider may carry a weight after a colon.
+// Providers that cannot be configured (Google without an API key, the database
+// without a connection) are left out.
+func GeocoderChainFromEnv(db *gorm.DB, resources Resources) (*GeocoderChain, error) {
+	resources = resources.WithDefaults()
+	spec := os.Getenv("GEOCODER_CHAIN")
+	if spec == "" {
+		spec = DefaultGeocoderChain
+	}
+
+	chain := &GeocoderChain{}
+	for _, item := range strings.Split(spec, ",") {
+		name, weightText, _ := strings.Cut(strings.TrimSpace(item), ":")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		weight := 0.0
+		if weightText != "" {
+			parsed, err := strconv.ParseFloat(weightText, 64)
+			if err != nil || parsed <= 0 {
+				return nil, fmt.Errorf("invalid weight for geocoder %q: %s", name, weightText)
+			}
+			weight = parsed
+		}
+
+		switch name {
+		case ProviderGazetteer:
+			chain.Add(NewGazetteerGeocoder(resources.Gazetteer), weight)
+		case ProviderDatabase:
+			if db == nil {
+				continue
+			}
+			chain.Add(NewDatabaseGeocoder(db), weight)
+		case ProviderGoogle, "google":
+			googlePlaces, err := NewGooglePlacesService(resources.Boundaries)
+			if err != nil {
+				fmt.Printf("Warning: Google Places API not available: %v\n", err)
+				continue
+			}
+			chain.Add(googlePlaces, weight)
+		case ProviderNominatim:
+			chain.Add(NewNominatimGeocoder(os.Getenv("NOMINATIM_URL"), os.Getenv("NOMINATIM_USER_AGENT"), resources.Boundaries), weight)
+		default:
+			return nil, fmt.Errorf("unknown geocoder %q in GEOCODER_CHAIN", name)
+		}
+	}
+
+	return chain, nil
+}
+
+// GazetteerGeocoder answers from a gazetteer: exact names score 1, prefix and fuzzy matches less
+type GazetteerGeocoder struct {
+	gazetteer *Gazetteer
+}
+
+// NewGazetteerGeocoder returns a geocoder over gazetteer, or the bundled gazetteer when nil
+func NewGazetteerGeocoder(gazetteer *Gazetteer) *GazetteerGeocoder {
+	if gazetteer == nil {
+		gazetteer = BundledGazetteer()
+	}
+	return &GazetteerGeocoder{gazetteer: gazetteer}
+}
+
+func (g *GazetteerGeocoder) Name() string {
+	return ProviderGazetteer
+}
+
+func (g *GazetteerGeocoder) Geocode(query string) ([]GeocodeCandidate, error) {
+	matches := g.gazetteer.Search(query, MaxCandidates)
+	if len(matches) == 0 {
+		return nil, &NoResultsError{Query: query}
+	}
+	return gazetteerCandidates(matches), nil
+}
+
+// DatabaseGeocoder matches the names and addresses of saved Locations
+type DatabaseGeocoder struct {
+	db *gorm.DB
+}
+
+// NewDatabaseGeocoder returns a geocoder over the locations table
+func NewDatabaseGeocoder(db *gorm.DB) *DatabaseGeocoder {
+	return &DatabaseGeocoder{db: db}
+}
+
+func (g *DatabaseGeocoder) Name() string {
+	return ProviderDatabase
+}
+
+func (g *DatabaseGeocoder) Geocode(query string) ([]GeocodeCandidate, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	pattern := "%" + escapeLike(query) + "%"
+	var locations []Location
+	err := g.db.Where("name ILIKE ? OR address ILIKE ?", pattern, pattern).
+		Order(gorm.Expr("(name = ?) DESC, length(name)", query)).
+		Limit(MaxCandidates).
+		Find(&locations).Error
+	if err != nil {
+		return nil, fmt.Errorf("location lookup failed: %v", err)
+	}
+	if len(locations) == 0 {
+		return nil, &NoResultsError{Query: query}
+	}
+
+	candidates := make([]GeocodeCandidate, 0, len(locations))
+	for rank, location := range locations {
+		candidates = append(candidates, GeocodeCandidate{
+			ID:         fmt.Sprintf("location:%d", location.ID),
+			Location:   location,
+			Confidence: candidateScore(query, location.Name, location.Address, rank),
+			Source:     ProviderDatabase,
+		})
+	}
+	return candidates, nil
+}
+
+// escapeLike escapes LIKE wildcards in user input
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package geo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type stubGeocoder struct {
+	name       string
+	candidates []GeocodeCandidate
+	err        error
+	calls      int
+}
+
+func (s *stubGeocoder) Name() string { return s.name }
+
+func (s *stubGeocoder) Geocode(query string) ([]GeocodeCandidate, error) {
+	s.calls++
+	return s.candidates, s.err
+}
+
+func stubCandidate(source, name string, lat, lng, confidence float64) GeocodeCandidate {
+	return GeocodeCandidate{
+		ID:         source + ":" + name,
+		Location:   Location{Name: name, Latitude: lat, Longitude: lng},
+		Confidence: confidence,
+		Source:     source,
+	}
+}
+
+func TestGeocoderChainStopsAtConfidentProvider(t *testing.T) {
+	local := &stubGeocoder{name: ProviderGazetteer, candidates: []GeocodeCandidate{
+		stubCandidate(ProviderGazetteer, "日月潭", 23.8667, 120.9167, 1),
+	}}
+	remote := &stubGeocoder{name: ProviderGoogle}
+
+	candidates, err := NewGeocoderChain(local, remote).Geocode("日月潭")
+	if err != nil {
+		t.Fatalf("Geocode() error = %v", err)
+	}
+	if remote.calls != 0 {
+		t.Errorf("Google was called %d times after a confident gazetteer match", remote.calls)
+	}
+	if candidates[0].Source != ProviderGazetteer || !reflect.DeepEqual(candidates[0].Providers, []string{ProviderGazetteer}) {
+		t.Errorf("top candidate = %+v", candidates[0])
+	}
+}
+
+func TestGeocoderChainFallsBackAndMerges(t *testing.T) {
+	google := &stubGeocoder{name: ProviderGoogle, err: fmt.Errorf("OVER_QUERY_LIMIT")}
+	database := &stubGeocoder{name: ProviderDatabase, candidates: []GeocodeCandidate{
+		stubCandidate(ProviderDatabase, "阿宗麵線", 25.0436, 121.5077, 0.8),
+		stubCandidate(ProviderDatabase, "阿宗麵線 信義店", 25.0330, 121.5654, 0.6),
+	}}
+	nominatim := &stubGeocoder{name: ProviderNominatim, candidates: []GeocodeCandidate{
+		stubCandidate(ProviderNominatim, "阿宗麵線", 25.0437, 121.5078, 0.8),
+	}}
+
+	candidates, err := NewGeocoderChain(google, database, nominatim).Geocode("阿宗麵線")
+	if err != nil {
+		t.Fatalf("Geocode() error = %v", err)
+	}
+	if nominatim.calls != 1 {
+		t.Errorf("Nominatim should be asked when no candidate is confident, got %d calls", nominatim.calls)
+	}
+	if len(candidates) != 2 {
+		t.Fatalf("got %d candidates, want the shared place merged: %+v", len(candidates), candidates)
+	}
+
+	top := candidates[0]
+	if !reflect.DeepEqual(top.Providers, []string{ProviderDatabase, ProviderNominatim}) {
+		t.Errorf("merged providers = %v", top.Providers)
+	}
+	// database 0.8*0.95 beats nominatim 0.8*0.9, plus the agreement bonus
+	if top.Source != ProviderDatabase || top.Confidence != 0.8*0.95+agreementBonus {
+		t.Errorf("top candidate = %+v", top)
+	}
+}
+
+func TestGeocoderChainReportsProviderErrors(t *testing.T) {
+	chain := NewGeocoderChain(
+		&stubGeocoder{name: ProviderGazetteer, err: fmt.Errorf("not in gazetteer")},
+		&stubGeocoder{name: ProviderNominatim, err: fmt.Errorf("status 503")},
+	)
+	_, err := chain.Geocode("zzzz")
+	if err == nil || !strings.Contains(err.Error(), "gazetteer: not in gazetteer") || !strings.Contains(err.Error(), "nominatim: status 503") {
+		t.Errorf("Geocode() error = %v", err)
+	}
+}
+
+func TestGeocoderChainFromEnv(t *testing.T) {
+	t.Setenv("GOOGLE_PLACES_API_KEY", "")
+
+	t.Setenv("GEOCODER_CHAIN", "gazetteer, google, database, nominatim:0.5")
+	chain, err := GeocoderChainFromEnv(nil, Resources{})
+	if err != nil {
+		t.Fatalf("GeocoderChainFromEnv() error = %v", err)
+	}
+	// Google has no key and there is no database
+	if got := chain.Providers(); !reflect.DeepEqual(got, []string{ProviderGazetteer, ProviderNominatim}) {
+		t.Errorf("Providers() = %v", got)
+	}
+	if chain.links[1].weight != 0.5 {
+		t.Errorf("nominatim weight = %v, want 0.5", chain.links[1].weight)
+	}
+
+	t.Setenv("GEOCODER_CHAIN", "gazetteer,bing")
+	if _, err := GeocoderChainFromEnv(nil, Resources{}); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+}
+
+func TestNominatimGeocoder(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		w.Write([]byte(`[
+			{"place_id":1,"lat":"25.0339","lon":"121.5645","name":"台北101","display_name":"台北101, 信義區, 臺北市, 臺灣","type":"attraction"},
+			{"place_id":2,"lat":"35.6812","lon":"139.7671","name":"東京駅","display_name":"東京駅, 東京都, 日本","type":"station"}
+		]`))
+	}))
+	defer server.Close()
+
+	n := NewNominatimGeocoder(server.URL, "SpatialTest/1.0 (ops@example.org)", BundledBoundaries())
+	n.limiter = &intervalLimiter{interval: time.Millisecond}
+
+	candidates, err := n.Geocode("台北101")
+	if err != nil {
+		t.Fatalf("Geocode() error = %v", err)
+	}
+	if userAgent != "SpatialTest/1.0 (ops@example.org)" {
+		t.Errorf("User-Agent = %q", userAgent)
+	}
+	if len(candidates) != 1 {
+		t.Fatalf("results outside Taiwan should be dropped, got %+v", candidates)
+	}
+	if candidates[0].ID != "nominatim:1" || candidates[0].Source != ProviderNominatim || candidates[0].Location.Name != "台北101" {
+		t.Errorf("candidate = %+v", candidates[0])
+	}
+}
+
+func TestIntervalLimiterSpacesRequests(t *testing.T) {
+	limiter := &intervalLimiter{interval: 30 * time.Millisecond}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.wait(time.Second); err != nil {
+			t.Fatalf("wait() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("3 requests took %v, want at least 60ms", elapsed)
+	}
+
+	// A caller that would queue past maxWait is turned away instead
+	limiter.next = time.Now().Add(time.Second)
+	if err := limiter.wait(100 * time.Millisecond); err == nil {
+		t.Error("expected a rate limit error")
+	}
+}
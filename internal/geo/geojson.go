@@ -0,0 +1,87 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// GeoJSON is a raw GeoJSON geometry, typically read from PostGIS with ST_AsGeoJSON
+type GeoJSON json.RawMessage
+
+// Scan implements sql.Scanner for json/text columns
+func (g *GeoJSON) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*g = nil
+	case []byte:
+		*g = append((*g)[:0], v...)
+	case string:
+		*g = GeoJSON(v)
+	default:
+		return fmt.Errorf("cannot scan %T into GeoJSON", value)
+	}
+	return nil
+}
+
+// MarshalJSON embeds the geometry as-is
+func (g GeoJSON) MarshalJSON() ([]byte, error) {
+	if len(g) == 0 {
+		return []byte("null"), nil
+	}
+	return g, nil
+}
+
+// UnmarshalJSON keeps the raw geometry
+func (g *GeoJSON) UnmarshalJSON(data []byte) error {
+	*g = append((*g)[:0], data...)
+	return nil
+}
+
+// ParsePolygonGeoJSON validates a Polygon or MultiPolygon given as a bare
+// geometry or a Feature, and returns the geometry ready for ST_GeomFromGeoJSON
+func ParsePolygonGeoJSON(data []byte) (GeoJSON, error) {
+	var object struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+		Geometry    json.RawMessage `json:"geometry"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %v", err)
+	}
+
+	if object.Type == "Feature" {
+		if len(object.Geometry) == 0 {
+			return nil, fmt.Errorf("feature has no geometry")
+		}
+		return ParsePolygonGeoJSON(object.Geometry)
+	}
+
+	polygons, err := decodePolygons(object.Type, object.Coordinates)
+	if err != nil {
+		return nil, err
+	}
+	if len(polygons) == 0 {
+		return nil, fmt.Errorf("geometry has no polygons")
+	}
+	for _, polygon := range polygons {
+		for _, ring := range polygon.rings {
+			if len(ring) < 4 || ring[0] != ring[len(ring)-1] {
+				return nil, fmt.Errorf("polygon rings must be closed with at least 4 points")
+			}
+			for _, point := range ring {
+				if point[0] < -180 || point[0] > 180 || point[1] < -90 || point[1] > 90 {
+					return nil, fmt.Errorf("coordinate [%v, %v] is out of range", point[0], point[1])
+				}
+			}
+		}
+	}
+
+	geometry, err := json.Marshal(struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	}{object.Type, object.Coordinates})
+	if err != nil {
+		return nil, err
+	}
+	return GeoJSON(geometry), nil
+}
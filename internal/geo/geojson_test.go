@@ -0,0 +1,33 @@
+package geo
+
+import "testing"
+
+func TestParsePolygonGeoJSON(t *testing.T) {
+	valid := []string{
+		`{"type":"Polygon","coordinates":[[[121.5,25.0],[121.6,25.0],[121.6,25.1],[121.5,25.0]]]}`,
+		`{"type":"Feature","properties":{},"geometry":{"type":"MultiPolygon","coordinates":[[[[121.5,25.0],[121.6,25.0],[121.6,25.1],[121.5,25.0]]]]}}`,
+	}
+	for _, data := range valid {
+		geometry, err := ParsePolygonGeoJSON([]byte(data))
+		if err != nil {
+			t.Errorf("ParsePolygonGeoJSON(%s) error = %v", data, err)
+			continue
+		}
+		if len(geometry) == 0 || geometry[0] != '{' {
+			t.Errorf("unexpected geometry %s", geometry)
+		}
+	}
+
+	invalid := []string{
+		`{"type":"Point","coordinates":[121.5,25.0]}`,
+		`{"type":"Polygon","coordinates":[[[121.5,25.0],[121.6,25.0],[121.5,25.0]]]}`,
+		`{"type":"Polygon","coordinates":[[[121.5,25.0],[121.6,25.0],[121.6,25.1],[121.5,25.2]]]}`,
+		`{"type":"Polygon","coordinates":[[[200,25.0],[121.6,25.0],[121.6,25.1],[200,25.0]]]}`,
+		`{"type":"Feature","properties":{}}`,
+	}
+	for _, data := range invalid {
+		if _, err := ParsePolygonGeoJSON([]byte(data)); err == nil {
+			t.Errorf("ParsePolygonGeoJSON(%s) should fail", data)
+		}
+	}
+}
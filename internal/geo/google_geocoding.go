@@ -0,0 +1,100 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// ProviderGoogleGeocoding is the source name of Google reverse geocoding results
+const ProviderGoogleGeocoding = "google_geocoding"
+
+// GoogleGeocodingClient calls the Google Geocoding API for reverse lookups
+type GoogleGeocodingClient struct {
+	client  *http.Client
+	apiKey  string
+	baseURL string
+}
+
+// GoogleAddress is the part of a reverse geocoding answer the platform uses
+type GoogleAddress struct {
+	FormattedAddress string `json:"formattedAddress"`
+	Road             string `json:"road"`
+	PlaceID          string `json:"placeId"`
+}
+
+type googleGeocodingResponse struct {
+	Results []struct {
+		FormattedAddress  string `json:"formatted_address"`
+		PlaceID           string `json:"place_id"`
+		AddressComponents []struct {
+			LongName string   `json:"long_name"`
+			Types    []string `json:"types"`
+		} `json:"address_components"`
+	} `json:"results"`
+	Status string `json:"status"`
+}
+
+// NewGoogleGeocodingClient uses GOOGLE_GEOCODING_API_KEY; reverse geocoding through
+// Google is optional, so a missing key returns an error the caller can ignore
+func NewGoogleGeocodingClient() (*GoogleGeocodingClient, error) {
+	apiKey := os.Getenv("GOOGLE_GEOCODING_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("GOOGLE_GEOCODING_API_KEY environment variable not set")
+	}
+
+	return &GoogleGeocodingClient{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		apiKey:  apiKey,
+		baseURL: "https://maps.googleapis.com/maps/api/geocode/json",
+	}, nil
+}
+
+// Reverse returns the street address and road at a coordinate
+func (g *GoogleGeocodingClient) Reverse(latitude, longitude float64) (*GoogleAddress, error) {
+	params := url.Values{}
+	params.Set("latlng", fmt.Sprintf("%.6f,%.6f", latitude, longitude))
+	params.Set("key", g.apiKey)
+	params.Set("language", "zh-TW")
+
+	resp, err := g.client.Get(fmt.Sprintf("%s?%s", g.baseURL, params.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("google geocoding request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("google geocoding API returned status: %d", resp.StatusCode)
+	}
+
+	var result googleGeocodingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+	if result.Status != "OK" {
+		return nil, fmt.Errorf("google geocoding API error: %s", result.Status)
+	}
+	if len(result.Results) == 0 {
+		return nil, fmt.Errorf("no address at %.6f,%.6f", latitude, longitude)
+	}
+
+	// Results are most specific first; take the road from the first that names one
+	address := &GoogleAddress{
+		FormattedAddress: result.Results[0].FormattedAddress,
+		PlaceID:          result.Results[0].PlaceID,
+	}
+	for _, candidate := range result.Results {
+		for _, component := range candidate.AddressComponents {
+			if containsString(component.Types, "route") {
+				address.Road = component.LongName
+				return address, nil
+			}
+		}
+	}
+	return address, nil
+}
@@ -7,7 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"strings"
+	"sort"
 	"time"
 )
 
@@ -15,6 +15,7 @@ type GooglePlacesService struct {
 	client *http.Client
 	apiKey string
 	baseURL string
+	boundaries *Boundaries
 }
 
 type GooglePlacesResponse struct {
@@ -28,12 +29,21 @@ type GooglePlacesResponse struct {
 			} `json:"location"`
 		} `json:"geometry"`
 		FormattedAddress string   `json:"formatted_address"`
+		Vicinity         string   `json:"vicinity"` // Nearby Search has this instead of formatted_address
 		Types            []string `json:"types"`
+		Rating           float64  `json:"rating"`
+		UserRatingsTotal int      `json:"user_ratings_total"`
+		PriceLevel       *int     `json:"price_level"`
+		OpeningHours     *struct {
+			OpenNow *bool `json:"open_now"`
+		} `json:"opening_hours"`
 	} `json:"results"`
 	Status string `json:"status"`
 }
 
-func NewGooglePlacesService() (*GooglePlacesService, error) {
+// NewGooglePlacesService returns a client for the key in GOOGLE_PLACES_API_KEY;
+// results outside boundaries are dropped
+func NewGooglePlacesService(boundaries *Boundaries) (*GooglePlacesService, error) {
 	apiKey := os.Getenv("GOOGLE_PLACES_API_KEY")
 	if apiKey == "" {
 		return nil, fmt.Errorf("GOOGLE_PLACES_API_KEY environment variable not set")
@@ -43,27 +53,32 @@ func NewGooglePlacesService() (*GooglePlacesService, error) {
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
-		apiKey:  apiKey,
-		baseURL: "https://maps.googleapis.com/maps/api/place",
+		apiKey:     apiKey,
+		baseURL:    "https://maps.googleapis.com/maps/api/place",
+		boundaries: boundaries,
 	}, nil
 }
 
+// SearchPlace returns the best-ranked match for query
 func (g *GooglePlacesService) SearchPlace(query string) (*Location, error) {
-	// Extract city name from query if present (e.g., "嘉義火雞肉飯" -> "嘉義")
-	cityKeywords := []string{
-		"台北", "新北", "桃園", "台中", "台南", "高雄", "基隆", "新竹", "嘉義", "彰化",
-		"南投", "雲林", "屏東", "宜蘭", "花蓮", "台東", "澎湖", "金門", "馬祖",
+	candidates, err := g.SearchCandidates(query)
+	if err != nil {
+		return nil, err
 	}
+	return &candidates[0].Location, nil
+}
 
-	var cityName string
-	lowerQuery := strings.ToLower(query)
-	for _, city := range cityKeywords {
-		if strings.Contains(lowerQuery, strings.ToLower(city)) {
-			cityName = city
-			break
-		}
-	}
+func (g *GooglePlacesService) Name() string {
+	return ProviderGoogle
+}
+
+// Geocode implements Geocoder with a text search
+func (g *GooglePlacesService) Geocode(query string) ([]GeocodeCandidate, error) {
+	return g.SearchCandidates(query)
+}
 
+// SearchCandidates returns up to MaxCandidates matches within Taiwan, best first
+func (g *GooglePlacesService) SearchCandidates(query string) ([]GeocodeCandidate, error) {
 	// Prepare URL with parameters
 	params := url.Values{}
 	params.Set("query", query+" Taiwan") // Add Taiwan context
@@ -97,64 +112,65 @@ func (g *GooglePlacesService) SearchPlace(query string) (*Location, error) {
 	}
 
 	// Check API response status
+	if result.Status == "ZERO_RESULTS" {
+		return nil, &NoResultsError{Query: query}
+	}
 	if result.Status != "OK" {
 		return nil, fmt.Errorf("google places API error: %s", result.Status)
 	}
 
-	if len(result.Results) == 0 {
-		return nil, fmt.Errorf("no results found for: %s", query)
+	candidates := rankGoogleResults(query, &result, g.boundaries)
+	if len(candidates) == 0 {
+		return nil, &NoResultsError{Query: query}
 	}
 
-	// If city name was detected in query, validate results match the city
-	var place *struct {
-		Name     string `json:"name"`
-		PlaceID  string `json:"place_id"`
-		Geometry struct {
-			Location struct {
-				Lat float64 `json:"lat"`
-				Lng float64 `json:"lng"`
-			} `json:"location"`
-		} `json:"geometry"`
-		FormattedAddress string   `json:"formatted_address"`
-		Types            []string `json:"types"`
-	}
+	return candidates, nil
+}
 
-	if cityName != "" {
-		// Try to find a result that matches the specified city
-		fmt.Printf("🔍 Looking for results in city: %s\n", cityName)
-		for i := range result.Results {
-			if strings.Contains(result.Results[i].FormattedAddress, cityName) {
-				place = &result.Results[i]
-				fmt.Printf("✅ Found matching result in %s: %s\n", cityName, place.FormattedAddress)
-				break
-			}
+// rankGoogleResults scores text search results against the query, drops those outside
+// boundaries and returns the best MaxCandidates
+func rankGoogleResults(query string, result *GooglePlacesResponse, boundaries *Boundaries) []GeocodeCandidate {
+	candidates := make([]GeocodeCandidate, 0, len(result.Results))
+	for rank, place := range result.Results {
+		lat, lng := place.Geometry.Location.Lat, place.Geometry.Location.Lng
+		if !boundaries.InTerritory(lat, lng) {
+			continue
 		}
 
-		if place == nil {
-			fmt.Printf("⚠️ No results found in %s, using first result: %s\n", cityName, result.Results[0].FormattedAddress)
-			place = &result.Results[0]
+		id := place.PlaceID
+		if id == "" {
+			id = fmt.Sprintf("c%d", rank+1)
 		}
-	} else {
-		// No city specified, use first result
-		place = &result.Results[0]
-	}
 
-	location := &Location{
-		Latitude:  place.Geometry.Location.Lat,
-		Longitude: place.Geometry.Location.Lng,
-		Name:      place.FormattedAddress,
+		candidates = append(candidates, GeocodeCandidate{
+			ID: id,
+			Location: Location{
+				Name:      place.Name,
+				Latitude:  lat,
+				Longitude: lng,
+				Address:   place.FormattedAddress,
+			},
+			Confidence: candidateScore(query, place.Name, place.FormattedAddress, rank),
+			Source:     ProviderGoogle,
+		})
 	}
 
-	// Validate that the location is within Taiwan bounds
-	if !IsWithinTaiwan(location.Latitude, location.Longitude) {
-		return nil, fmt.Errorf("location %s is outside Taiwan boundaries", query)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	if len(candidates) > MaxCandidates {
+		candidates = candidates[:MaxCandidates]
 	}
-
-	return location, nil
+	return candidates
 }
 
 // SearchNearbyPlaces 搜尋附近地點（使用 Google Places API Nearby Search）
 func (g *GooglePlacesService) SearchNearbyPlaces(lat, lng float64, category string, radiusMeters int, limit int) ([]LocationWithDistance, error) {
+	return g.SearchNearbyPlacesWithFilters(lat, lng, category, radiusMeters, limit, NearbyFilters{})
+}
+
+// SearchNearbyPlacesWithFilters 搜尋附近地點，營業中與價位條件交由 Google 篩選
+func (g *GooglePlacesService) SearchNearbyPlacesWithFilters(lat, lng float64, category string, radiusMeters int, limit int, filters NearbyFilters) ([]LocationWithDistance, error) {
 	// Map category to Google Places type
 	placeType := mapCategoryToGoogleType(category)
 
@@ -165,6 +181,12 @@ func (g *GooglePlacesService) SearchNearbyPlaces(lat, lng float64, category stri
 	if placeType != "" {
 		params.Set("type", placeType)
 	}
+	if filters.OpenNow {
+		params.Set("opennow", "true")
+	}
+	if filters.MaxPriceLevel > 0 {
+		params.Set("maxprice", fmt.Sprintf("%d", filters.MaxPriceLevel))
+	}
 	params.Set("key", g.apiKey)
 	params.Set("language", "zh-TW")
 
@@ -218,37 +240,68 @@ func (g *GooglePlacesService) SearchNearbyPlaces(lat, lng float64, category stri
 			place.Geometry.Location.Lat, place.Geometry.Location.Lng,
 		)
 
+		address := place.FormattedAddress
+		if address == "" {
+			address = place.Vicinity
+		}
+		locationType := category // Use Type field instead of Category
+		if placeType == "" {
+			locationType = categoryFromGoogleTypes(place.Types)
+		}
+
+		var openNow *bool
+		if place.OpeningHours != nil {
+			openNow = place.OpeningHours.OpenNow
+		}
+
 		locations = append(locations, LocationWithDistance{
 			Location: Location{
 				Name:      place.Name,
 				Latitude:  place.Geometry.Location.Lat,
 				Longitude: place.Geometry.Location.Lng,
-				Address:   place.FormattedAddress,
-				Type:      category, // Use Type field instead of Category
+				Address:   address,
+				Type:      locationType,
+				PlaceID:   place.PlaceID,
 			},
-			Distance: distance,
+			Distance:    distance,
+			Source:      NearbySourceGoogle,
+			Rating:      place.Rating,
+			RatingCount: place.UserRatingsTotal,
+			PriceLevel:  place.PriceLevel,
+			OpenNow:     openNow,
 		})
 	}
 
 	return locations, nil
 }
 
+// categoryGoogleTypes 內部類別對應的 Google Places API type
+var categoryGoogleTypes = map[string]string{
+	"restaurant": "restaurant",
+	"cafe":       "cafe",
+	"attraction": "tourist_attraction",
+	"hotel":      "lodging",
+	"park":       "park",
+	"museum":     "museum",
+	"general":    "",
+}
+
 // mapCategoryToGoogleType 將內部類別對應到 Google Places API 的 type
 func mapCategoryToGoogleType(category string) string {
-	typeMap := map[string]string{
-		"restaurant": "restaurant",
-		"cafe":       "cafe",
-		"attraction": "tourist_attraction",
-		"hotel":      "lodging",
-		"park":       "park",
-		"museum":     "museum",
-		"general":    "",
-	}
+	return categoryGoogleTypes[category]
+}
 
-	if googleType, ok := typeMap[category]; ok {
-		return googleType
+// categoryFromGoogleTypes returns the category of the first of a place's
+// Google types that has one, or "poi"
+func categoryFromGoogleTypes(types []string) string {
+	for _, googleType := range types {
+		for category, mapped := range categoryGoogleTypes {
+			if mapped != "" && mapped == googleType {
+				return category
+			}
+		}
 	}
-	return ""
+	return "poi"
 }
 
 // calculateDistanceInMeters 使用 Haversine 公式計算兩點距離（米）
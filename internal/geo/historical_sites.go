@@ -0,0 +1,213 @@
+package geo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"intelligent-spatial-platform/internal/cluster"
+	"intelligent-spatial-platform/internal/tiles"
+)
+
+// MaxSiteImages is how many images a historical site can have
+const MaxSiteImages = 20
+
+// SiteError is a historical site that cannot be saved as given
+type SiteError struct {
+	Reason string
+}
+
+func (e *SiteError) Error() string {
+	return e.Reason
+}
+
+// ErrTooManyImages is returned when adding an image to a site that has MaxSiteImages
+var ErrTooManyImages error = &SiteError{Reason: fmt.Sprintf("a historical site can have at most %d images", MaxSiteImages)}
+
+// Validate checks the fields an admin can set; the site must lie within
+// boundaries. Problems are returned as a *SiteError.
+func (s *HistoricalSite) Validate(boundaries *Boundaries) error {
+	s.Name = strings.TrimSpace(s.Name)
+	if s.Name == "" {
+		return &SiteError{Reason: "name is required"}
+	}
+	if utf8.RuneCountInString(s.Name) > 200 {
+		return &SiteError{Reason: "name must be at most 200 characters"}
+	}
+	if utf8.RuneCountInString(s.Era) > 100 {
+		return &SiteError{Reason: "era must be at most 100 characters"}
+	}
+	if !boundaries.InTerritory(s.Latitude, s.Longitude) {
+		return &SiteError{Reason: fmt.Sprintf("coordinate (%.6f, %.6f) is outside Taiwan", s.Latitude, s.Longitude)}
+	}
+	if len(s.Images) > MaxSiteImages {
+		return ErrTooManyImages
+	}
+	return nil
+}
+
+// ListHistoricalSites returns every site, inactive ones included, for admins
+func (s *Service) ListHistoricalSites() ([]HistoricalSite, error) {
+	var sites []HistoricalSite
+	err := s.db.Order("id").Find(&sites).Error
+	return sites, err
+}
+
+// GetHistoricalSite returns a single site, active or not
+func (s *Service) GetHistoricalSite(id uint) (*HistoricalSite, error) {
+	var site HistoricalSite
+	if err := s.db.First(&site, id).Error; err != nil {
+		return nil, err
+	}
+	return &site, nil
+}
+
+func (s *Service) CreateHistoricalSite(site *HistoricalSite) error {
+	if err := site.Validate(s.resources.Boundaries); err != nil {
+		return err
+	}
+	// Select explicitly so IsActive=false is not replaced by the column default
+	fields := []string{"Name", "Description", "Era", "Latitude", "Longitude", "Address", "Images", "AudioGuide", "IsActive", "CreatedAt", "UpdatedAt"}
+	if err := s.db.Select(fields).Create(site).Error; err != nil {
+		return err
+	}
+	s.resources.TileCache.Invalidate(tiles.LayerHistoricalSites)
+	s.updateSiteCluster(site)
+	return nil
+}
+
+// UpdateHistoricalSite replaces a site's descriptive fields. Images and the
+// audio guide are changed through their own methods, so an edit made while a
+// file is uploading cannot drop it.
+func (s *Service) UpdateHistoricalSite(id uint, site *HistoricalSite) (*HistoricalSite, error) {
+	if err := site.Validate(s.resources.Boundaries); err != nil {
+		return nil, err
+	}
+
+	result := s.db.Model(&HistoricalSite{ID: id}).
+		Select("Name", "Description", "Era", "Latitude", "Longitude", "Address", "IsActive").
+		Updates(site)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	s.resources.TileCache.Invalidate(tiles.LayerHistoricalSites)
+
+	updated, err := s.GetHistoricalSite(id)
+	if err != nil {
+		return nil, err
+	}
+	s.updateSiteCluster(updated)
+	return updated, nil
+}
+
+// DeactivateHistoricalSite hides a site from players and the map. The row and
+// its media are kept, so setting isActive again restores it.
+func (s *Service) DeactivateHistoricalSite(id uint) error {
+	result := s.db.Model(&HistoricalSite{ID: id}).Update("is_active", false)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	s.resources.TileCache.Invalidate(tiles.LayerHistoricalSites)
+	s.resources.Clusters.Remove(tiles.LayerHistoricalSites, strconv.FormatUint(uint64(id), 10))
+	return nil
+}
+
+// updateSiteCluster shows an active site on the clustered map and hides an inactive one
+func (s *Service) updateSiteCluster(site *HistoricalSite) {
+	id := strconv.FormatUint(uint64(site.ID), 10)
+	if !site.IsActive {
+		s.resources.Clusters.Remove(tiles.LayerHistoricalSites, id)
+		return
+	}
+	s.resources.Clusters.Upsert(cluster.Point{Layer: tiles.LayerHistoricalSites, ID: id, Name: site.Name, Latitude: site.Latitude, Longitude: site.Longitude})
+}
+
+// AddHistoricalSiteImage appends a stored image to a site
+func (s *Service) AddHistoricalSiteImage(id uint, image string) (*HistoricalSite, error) {
+	return s.changeHistoricalSite(id, func(site *HistoricalSite) error {
+		if len(site.Images) >= MaxSiteImages {
+			return ErrTooManyImages
+		}
+		site.Images = append(site.Images, image)
+		return nil
+	})
+}
+
+// RemoveHistoricalSiteImage removes the image at index and returns it, so the
+// caller can delete the stored file
+func (s *Service) RemoveHistoricalSiteImage(id uint, index int) (*HistoricalSite, string, error) {
+	var removed string
+	site, err := s.changeHistoricalSite(id, func(site *HistoricalSite) error {
+		if index < 0 || index >= len(site.Images) {
+			return fmt.Errorf("site has no image %d", index)
+		}
+		removed = site.Images[index]
+		site.Images = append(site.Images[:index:index], site.Images[index+1:]...)
+		return nil
+	})
+	return site, removed, err
+}
+
+// SetHistoricalSiteAudio replaces a site's audio guide, or clears it when audio
+// is empty, and returns the previous one
+func (s *Service) SetHistoricalSiteAudio(id uint, audio string) (*HistoricalSite, string, error) {
+	var previous string
+	site, err := s.changeHistoricalSite(id, func(site *HistoricalSite) error {
+		previous = site.AudioGuide
+		site.AudioGuide = audio
+		return nil
+	})
+	return site, previous, err
+}
+
+// changeHistoricalSite locks a site's row while change edits its media, so
+// concurrent uploads to the same site do not overwrite each other
+func (s *Service) changeHistoricalSite(id uint, change func(*HistoricalSite) error) (*HistoricalSite, error) {
+	var site HistoricalSite
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&site, id).Error; err != nil {
+			return err
+		}
+		if err := change(&site); err != nil {
+			return err
+		}
+		return tx.Model(&site).Select("Images", "AudioGuide").Updates(&site).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &site, nil
+}
+
+// MediaURLs turns stored media references into URLs clients can load
+// (implemented by media.Service)
+type MediaURLs interface {
+	URL(value string) string
+	ThumbnailURL(value string) string
+}
+
+// WithMediaURLs returns a copy of the site whose images, thumbnails and audio
+// guide are loadable URLs
+func (s *HistoricalSite) WithMediaURLs(urls MediaURLs) *HistoricalSite {
+	site := *s
+	site.Images = make([]string, len(s.Images))
+	site.Thumbnails = make([]string, len(s.Images))
+	for i, image := range s.Images {
+		site.Images[i] = urls.URL(image)
+		site.Thumbnails[i] = urls.ThumbnailURL(image)
+	}
+	if s.AudioGuide != "" {
+		site.AudioGuide = urls.URL(s.AudioGuide)
+	}
+	return &site
+}
@@ -0,0 +1,63 @@
+package geo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHistoricalSiteValidate(t *testing.T) {
+	site := &HistoricalSite{Name: "  赤崁樓 ", Latitude: 22.997524, Longitude: 120.202536}
+	if err := site.Validate(BundledBoundaries()); err != nil || site.Name != "赤崁樓" {
+		t.Errorf("Validate() = %v, name %q", err, site.Name)
+	}
+
+	for name, site := range map[string]*HistoricalSite{
+		"missing name":    {Name: " ", Latitude: 22.99, Longitude: 120.20},
+		"outside Taiwan":  {Name: "東京塔", Latitude: 35.6586, Longitude: 139.7454},
+		"too many images": {Name: "赤崁樓", Latitude: 22.99, Longitude: 120.20, Images: make([]string, MaxSiteImages+1)},
+	} {
+		var siteErr *SiteError
+		if err := site.Validate(BundledBoundaries()); !errors.As(err, &siteErr) {
+			t.Errorf("%s: Validate() = %v, want a *SiteError", name, err)
+		}
+	}
+}
+
+type fakeMediaURLs struct{}
+
+func (fakeMediaURLs) URL(value string) string {
+	if strings.Contains(value, "://") {
+		return value
+	}
+	return "/signed/" + value
+}
+
+func (fakeMediaURLs) ThumbnailURL(value string) string {
+	if strings.Contains(value, "://") {
+		return value
+	}
+	return "/signed/thumb/" + value
+}
+
+func TestWithMediaURLs(t *testing.T) {
+	site := &HistoricalSite{
+		Images:     []string{"sites/1/a.jpg", "https://example.org/b.jpg"},
+		AudioGuide: "sites/1/guide.mp3",
+	}
+	signed := site.WithMediaURLs(fakeMediaURLs{})
+
+	if strings.Join(signed.Images, ",") != "/signed/sites/1/a.jpg,https://example.org/b.jpg" {
+		t.Errorf("images = %v", signed.Images)
+	}
+	if strings.Join(signed.Thumbnails, ",") != "/signed/thumb/sites/1/a.jpg,https://example.org/b.jpg" {
+		t.Errorf("thumbnails = %v", signed.Thumbnails)
+	}
+	if signed.AudioGuide != "/signed/sites/1/guide.mp3" {
+		t.Errorf("audio guide = %q", signed.AudioGuide)
+	}
+	// The stored site keeps its keys
+	if site.Images[0] != "sites/1/a.jpg" || site.Thumbnails != nil {
+		t.Errorf("original site changed: %+v", site)
+	}
+}
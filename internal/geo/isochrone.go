@@ -0,0 +1,104 @@
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"intelligent-spatial-platform/internal/routing"
+)
+
+// How an isochrone was worked out
+const (
+	IsochroneNetwork  = "network"  // a search of the road network
+	IsochroneEstimate = "estimate" // a circle from a typical speed, without a road network
+)
+
+// Isochrone request limits
+const (
+	MaxIsochrones       = 5
+	MaxIsochroneMinutes = int(routing.MaxIsochroneDuration / 60)
+)
+
+// Isochrones returns the areas profile can reach from a point within each of
+// minutes, as GeoJSON Polygon features in the order asked. They come from the
+// loaded road network, or are estimated from typical speeds when there is
+// none or the point is too far from its roads.
+func (s *Service) Isochrones(profile routing.Profile, latitude, longitude float64, minutes []int) ([]GeoFeature, error) {
+	if len(minutes) == 0 || len(minutes) > MaxIsochrones {
+		return nil, fmt.Errorf("between 1 and %d travel times may be asked for", MaxIsochrones)
+	}
+	durations := make([]float64, len(minutes))
+	for i, m := range minutes {
+		if m < 1 || m > MaxIsochroneMinutes {
+			return nil, fmt.Errorf("travel times must be between 1 and %d minutes", MaxIsochroneMinutes)
+		}
+		durations[i] = float64(m) * 60
+	}
+
+	isochrones, err := s.reachable(profile, routing.LatLng{Latitude: latitude, Longitude: longitude}, durations)
+	if err != nil {
+		return nil, err
+	}
+
+	features := make([]GeoFeature, len(isochrones))
+	for i, isochrone := range isochrones {
+		method := IsochroneNetwork
+		if isochrone.Estimated {
+			method = IsochroneEstimate
+		}
+		features[i] = GeoFeature{
+			Type: "Feature",
+			Properties: map[string]interface{}{
+				"minutes":     minutes[i],
+				"profile":     profile.String(),
+				"method":      method,
+				"description": isochroneDescription(profile, minutes[i]),
+			},
+			Geometry: Geometry{Type: "Polygon", Coordinates: [][][2]float64{isochrone.Coordinates()}},
+		}
+	}
+	return features, nil
+}
+
+// ReachableArea is the isochrone for one travel time as a search area
+func (s *Service) ReachableArea(profile routing.Profile, latitude, longitude float64, minutes int) (*Area, error) {
+	features, err := s.Isochrones(profile, latitude, longitude, []int{minutes})
+	if err != nil {
+		return nil, err
+	}
+	geometry, err := json.Marshal(features[0].Geometry)
+	if err != nil {
+		return nil, err
+	}
+	return NewArea(geometry, isochroneDescription(profile, minutes))
+}
+
+// reachable searches the loaded road network, falling back to estimates
+func (s *Service) reachable(profile routing.Profile, origin routing.LatLng, durations []float64) ([]routing.Isochrone, error) {
+	graph := s.resources.Roads
+	if graph == nil {
+		return routing.EstimateIsochrones(profile, origin, durations)
+	}
+
+	isochrones, err := graph.Isochrones(profile, origin, durations)
+	var snapErr *routing.SnapError
+	if errors.As(err, &snapErr) {
+		log.Printf("⚠️ No %s road near (%.6f, %.6f), estimating isochrones", profile, origin.Latitude, origin.Longitude)
+		return routing.EstimateIsochrones(profile, origin, durations)
+	}
+	return isochrones, err
+}
+
+// isochroneDescription says an isochrone the way a player asks for it
+func isochroneDescription(profile routing.Profile, minutes int) string {
+	verb := "走路"
+	switch profile {
+	case routing.Bike:
+		verb = "騎車"
+	case routing.Car:
+		verb = "開車"
+	}
+	return fmt.Sprintf("%s %d 分鐘內", verb, minutes)
+}
@@ -0,0 +1,78 @@
+package geo
+
+import (
+	"strings"
+	"testing"
+
+	"intelligent-spatial-platform/internal/routing"
+)
+
+func TestIsochronesWithoutRoadNetwork(t *testing.T) {
+	service := &Service{}
+
+	features, err := service.Isochrones(routing.Foot, 25.0330, 121.5654, []int{5, 15})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(features) != 2 || features[0].Geometry.Type != "Polygon" {
+		t.Fatalf("features = %+v", features)
+	}
+	properties := features[1].Properties
+	if properties["method"] != IsochroneEstimate || properties["minutes"] != 15 || properties["description"] != "走路 15 分鐘內" {
+		t.Errorf("properties = %v", properties)
+	}
+
+	if _, err := service.Isochrones(routing.Foot, 25.0330, 121.5654, []int{90}); err == nil {
+		t.Error("more than an hour should fail")
+	}
+	if _, err := service.Isochrones(routing.Foot, 25.0330, 121.5654, nil); err == nil {
+		t.Error("no travel time should fail")
+	}
+}
+
+func TestReachableArea(t *testing.T) {
+	area, err := (&Service{}).ReachableArea(routing.Bike, 25.0330, 121.5654, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if area.Description != "騎車 10 分鐘內" {
+		t.Errorf("Description = %q", area.Description)
+	}
+
+	// 10 minutes at 14 km/h over the detour factor is about 1.8 km
+	if !area.Contains(25.0330, 121.5654) || !area.Contains(25.0430, 121.5654) {
+		t.Error("points within 1.1 km should be in the area")
+	}
+	if area.Contains(25.0530, 121.5654) {
+		t.Error("a point 2.2 km away should not be in the area")
+	}
+	if reach := area.Reach(25.0330, 121.5654); reach < 1700 || reach > 1900 {
+		t.Errorf("Reach() = %.0f m", reach)
+	}
+}
+
+func TestPlacesInArea(t *testing.T) {
+	area, err := NewArea([]byte(`{"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[121.56,25.03],[121.57,25.03],[121.57,25.04],[121.56,25.04],[121.56,25.03]]]}}`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	places := placesInArea([]LocationWithDistance{
+		{Location: Location{Name: "inside", Latitude: 25.035, Longitude: 121.565}},
+		{Location: Location{Name: "outside", Latitude: 25.045, Longitude: 121.565}},
+	}, area)
+	if len(places) != 1 || places[0].Name != "inside" {
+		t.Errorf("placesInArea() = %+v", places)
+	}
+
+	sql, _, err := buildSQL(t, PlaceQuery{Near: &Near{Latitude: 25.035, Longitude: 121.565, Radius: 1000}, Within: area}, locationsTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sql, "ST_Covers(ST_SetSRID(ST_GeomFromGeoJSON(") {
+		t.Errorf("SQL does not filter by the area: %s", sql)
+	}
+
+	if _, err := NewArea([]byte(`{"type":"Point","coordinates":[121.56,25.03]}`), ""); err == nil {
+		t.Error("a point is not an area")
+	}
+}
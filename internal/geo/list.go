@@ -0,0 +1,341 @@
+package geo
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Sort orders for PlaceQuery
+const (
+	SortID       = "id"       // oldest first, the default without Near
+	SortDistance = "distance" // nearest first, the default with Near
+	SortName     = "name"
+	SortVisits   = "visits" // most visited first; historical sites only
+)
+
+// Page sizes and the largest radius a PlaceQuery may ask for, in meters
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+	MaxQueryRadius  = 50000.0
+)
+
+// BBox is a west, south, east, north box in degrees
+type BBox struct {
+	West, South, East, North float64
+}
+
+// ParseBBox reads "west,south,east,north"
+func ParseBBox(text string) (*BBox, error) {
+	parts := strings.Split(text, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("bbox must be west,south,east,north")
+	}
+	var values [4]float64
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("bbox must be west,south,east,north")
+		}
+		values[i] = value
+	}
+	box := &BBox{West: values[0], South: values[1], East: values[2], North: values[3]}
+	if box.West < -180 || box.East > 180 || box.South < -90 || box.North > 90 || box.West >= box.East || box.South >= box.North {
+		return nil, fmt.Errorf("bbox %s is not a valid west,south,east,north box", text)
+	}
+	return box, nil
+}
+
+// Near selects places within Radius meters of a point
+type Near struct {
+	Latitude  float64
+	Longitude float64
+	Radius    float64
+}
+
+// PlaceQuery filters, sorts and pages locations or historical sites. All set
+// filters must match.
+type PlaceQuery struct {
+	BBox   *BBox
+	Near   *Near
+	Within *Area  // such as an isochrone
+	Type   string // locations only
+	Era    string // historical sites only, matched as a substring
+	Text   string // in the name, address or description; 臺 and 台 match each other
+	Sort   string
+	Cursor string // NextCursor of the previous page
+	Limit  int
+}
+
+// QueryError is a PlaceQuery that cannot be run as given
+type QueryError struct {
+	Reason string
+}
+
+func (e *QueryError) Error() string {
+	return e.Reason
+}
+
+// LocationResult is a listed location; Distance is set for queries with Near
+type LocationResult struct {
+	Location
+	Distance *float64 `json:"distance,omitempty" gorm:"->;-:migration"`
+}
+
+// HistoricalSiteResult is a listed historical site; Distance is set for queries with Near
+type HistoricalSiteResult struct {
+	HistoricalSite
+	Distance *float64 `json:"distance,omitempty" gorm:"->;-:migration"`
+}
+
+// cursor is where a page ended: the sort it was made for and the sort value and
+// id of its last row
+type cursor struct {
+	Sort  string          `json:"s"`
+	Value json.RawMessage `json:"v,omitempty"`
+	ID    uint            `json:"id"`
+}
+
+func encodeCursor(sort string, value interface{}, id uint) string {
+	c := cursor{Sort: sort, ID: id}
+	if value != nil {
+		c.Value, _ = json.Marshal(value)
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(text, sort string, value interface{}) (uint, error) {
+	data, err := base64.RawURLEncoding.DecodeString(text)
+	var c cursor
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err == nil && value != nil {
+		err = json.Unmarshal(c.Value, value)
+	}
+	if err != nil || c.ID == 0 {
+		return 0, &QueryError{Reason: "invalid cursor"}
+	}
+	if c.Sort != sort {
+		return 0, &QueryError{Reason: "cursor belongs to a query with another sort"}
+	}
+	return c.ID, nil
+}
+
+// placeTable describes what a table can be queried by
+type placeTable struct {
+	name         string
+	textColumns  []string
+	activeOnly   bool
+	hasType      bool
+	hasEra       bool
+	hasVisitSort bool
+}
+
+var (
+	locationsTable       = placeTable{name: "locations", textColumns: []string{"name", "address"}, hasType: true}
+	historicalSitesTable = placeTable{name: "historical_sites", textColumns: []string{"name", "address", "description"}, activeOnly: true, hasEra: true, hasVisitSort: true}
+)
+
+// pageRow is what build needs from each result to make the next cursor
+type pageRow struct {
+	id       uint
+	name     string
+	visits   int
+	distance *float64
+}
+
+// build turns q into a statement on table that fetches one row more than the
+// page, so the caller can tell whether there is a next page
+func (q *PlaceQuery) build(db *gorm.DB, table placeTable) (*gorm.DB, string, int, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		return nil, "", 0, &QueryError{Reason: fmt.Sprintf("limit must be at most %d", MaxPageSize)}
+	}
+
+	sort := q.Sort
+	if sort == "" {
+		sort = SortID
+		if q.Near != nil {
+			sort = SortDistance
+		}
+	}
+	switch {
+	case sort == SortDistance && q.Near == nil:
+		return nil, "", 0, &QueryError{Reason: "sorting by distance needs lat and lng"}
+	case sort == SortVisits && !table.hasVisitSort:
+		return nil, "", 0, &QueryError{Reason: "only historical sites can be sorted by visits"}
+	case sort != SortID && sort != SortDistance && sort != SortName && sort != SortVisits:
+		return nil, "", 0, &QueryError{Reason: fmt.Sprintf("sort must be %s, %s, %s or %s", SortID, SortDistance, SortName, SortVisits)}
+	}
+	if q.Type != "" && !table.hasType {
+		return nil, "", 0, &QueryError{Reason: "only locations can be filtered by type"}
+	}
+	if q.Era != "" && !table.hasEra {
+		return nil, "", 0, &QueryError{Reason: "only historical sites can be filtered by era"}
+	}
+
+	distance := "ST_Distance(geog, ST_GeogFromText(?))"
+	var point string
+	query := db.Table(table.name)
+	if near := q.Near; near != nil {
+		if near.Radius <= 0 || near.Radius > MaxQueryRadius {
+			return nil, "", 0, &QueryError{Reason: fmt.Sprintf("radius must be between 1 and %.0f meters", MaxQueryRadius)}
+		}
+		point = wktPoint(near.Latitude, near.Longitude)
+		query = query.
+			Select(table.name+".*, "+distance+" AS distance", point).
+			Where("ST_DWithin(geog, ST_GeogFromText(?), ?)", point, near.Radius)
+	} else {
+		query = query.Select(table.name + ".*")
+	}
+	if table.activeOnly {
+		query = query.Where("is_active = true")
+	}
+	if box := q.BBox; box != nil {
+		query = query.Where(BoundsCondition, box.West, box.South, box.East, box.North)
+	}
+	if area := q.Within; area != nil {
+		query = query.Where("ST_Covers(ST_SetSRID(ST_GeomFromGeoJSON(?), 4326)::geography, geog)", string(area.Geometry))
+	}
+	if q.Type != "" {
+		query = query.Where("type = ?", q.Type)
+	}
+	if q.Era != "" {
+		query = query.Where("era ILIKE ?", likePattern(q.Era))
+	}
+	if text := strings.TrimSpace(q.Text); text != "" {
+		pattern := likePattern(strings.ReplaceAll(text, "臺", "台"))
+		conditions := make([]string, len(table.textColumns))
+		args := make([]interface{}, len(table.textColumns))
+		for i, column := range table.textColumns {
+			conditions[i] = fmt.Sprintf("translate(%s, '臺', '台') ILIKE ?", column)
+			args[i] = pattern
+		}
+		query = query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+	}
+
+	// Keyset pagination: each sort ends on id, so every row has one position
+	switch sort {
+	case SortID:
+		if q.Cursor != "" {
+			id, err := decodeCursor(q.Cursor, sort, nil)
+			if err != nil {
+				return nil, "", 0, err
+			}
+			query = query.Where("id > ?", id)
+		}
+		query = query.Order("id")
+	case SortName:
+		if q.Cursor != "" {
+			var name string
+			id, err := decodeCursor(q.Cursor, sort, &name)
+			if err != nil {
+				return nil, "", 0, err
+			}
+			query = query.Where("(name, id) > (?, ?)", name, id)
+		}
+		query = query.Order("name, id")
+	case SortVisits:
+		if q.Cursor != "" {
+			var visits int
+			id, err := decodeCursor(q.Cursor, sort, &visits)
+			if err != nil {
+				return nil, "", 0, err
+			}
+			query = query.Where("(visit_count < ? OR (visit_count = ? AND id > ?))", visits, visits, id)
+		}
+		query = query.Order("visit_count DESC, id")
+	case SortDistance:
+		if q.Cursor != "" {
+			var last float64
+			id, err := decodeCursor(q.Cursor, sort, &last)
+			if err != nil {
+				return nil, "", 0, err
+			}
+			query = query.Where("("+distance+" > ? OR ("+distance+" = ? AND id > ?))", point, last, point, last, id)
+		}
+		query = query.Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                distance + ", id",
+			Vars:               []interface{}{point},
+			WithoutParentheses: true,
+		}})
+	}
+
+	return query.Limit(limit + 1), sort, limit, nil
+}
+
+// nextCursor trims the extra row fetched by build and returns the cursor
+// after the last row kept, or "" on the last page
+func nextCursor(sort string, limit int, rows []pageRow) string {
+	if len(rows) <= limit {
+		return ""
+	}
+	last := rows[limit-1]
+	switch sort {
+	case SortName:
+		return encodeCursor(sort, last.name, last.id)
+	case SortVisits:
+		return encodeCursor(sort, last.visits, last.id)
+	case SortDistance:
+		return encodeCursor(sort, last.distance, last.id)
+	}
+	return encodeCursor(sort, nil, last.id)
+}
+
+// QueryLocations returns a page of locations matching q and the cursor of the next page
+func (s *Service) QueryLocations(q PlaceQuery) ([]LocationResult, string, error) {
+	query, sort, limit, err := q.build(s.db, locationsTable)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var results []LocationResult
+	if err := query.Find(&results).Error; err != nil {
+		return nil, "", err
+	}
+
+	rows := make([]pageRow, len(results))
+	for i, result := range results {
+		rows[i] = pageRow{id: result.ID, name: result.Name, distance: result.Distance}
+	}
+	next := nextCursor(sort, limit, rows)
+	return results[:min(len(results), limit)], next, nil
+}
+
+// QueryHistoricalSites returns a page of active historical sites matching q
+// and the cursor of the next page
+func (s *Service) QueryHistoricalSites(q PlaceQuery) ([]HistoricalSiteResult, string, error) {
+	query, sort, limit, err := q.build(s.db, historicalSitesTable)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var results []HistoricalSiteResult
+	if err := query.Find(&results).Error; err != nil {
+		return nil, "", err
+	}
+
+	rows := make([]pageRow, len(results))
+	for i, result := range results {
+		rows[i] = pageRow{id: result.ID, name: result.Name, visits: result.VisitCount, distance: result.Distance}
+	}
+	next := nextCursor(sort, limit, rows)
+	return results[:min(len(results), limit)], next, nil
+}
+
+// likePattern matches text anywhere, with LIKE wildcards in it taken literally
+func likePattern(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + replacer.Replace(text) + "%"
+}
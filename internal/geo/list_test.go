@@ -0,0 +1,118 @@
+package geo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRunDB builds SQL without a database
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=none"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func buildSQL(t *testing.T, q PlaceQuery, table placeTable) (string, string, error) {
+	t.Helper()
+	query, sort, _, err := q.build(dryRunDB(t), table)
+	if err != nil {
+		return "", "", err
+	}
+	var results []HistoricalSiteResult
+	stmt := query.Find(&results).Statement
+	return stmt.SQL.String(), sort, nil
+}
+
+func TestPlaceQueryBuild(t *testing.T) {
+	box, err := ParseBBox("121.50, 25.00, 121.60, 25.10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql, sort, err := buildSQL(t, PlaceQuery{
+		BBox: box,
+		Near: &Near{Latitude: 25.03, Longitude: 121.56, Radius: 1500},
+		Era:  "清代",
+		Text: "臺北",
+	}, historicalSitesTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sort != SortDistance {
+		t.Errorf("default sort with a point = %q", sort)
+	}
+	for _, want := range []string{
+		"historical_sites.*, ST_Distance(geog, ST_GeogFromText($1)) AS distance",
+		"ST_DWithin(geog, ST_GeogFromText(",
+		"geog::geometry && ST_MakeEnvelope(",
+		"is_active = true",
+		"era ILIKE",
+		"translate(description, '臺', '台') ILIKE",
+		"ORDER BY ST_Distance(geog, ST_GeogFromText(",
+		"LIMIT 51",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("SQL is missing %q:\n%s", want, sql)
+		}
+	}
+}
+
+func TestPlaceQueryCursor(t *testing.T) {
+	cursor := nextCursor(SortVisits, 2, []pageRow{{id: 9, visits: 40}, {id: 3, visits: 12}, {id: 5, visits: 12}})
+	if cursor == "" {
+		t.Fatal("a full page should have a next cursor")
+	}
+	if nextCursor(SortVisits, 2, []pageRow{{id: 9}, {id: 3}}) != "" {
+		t.Error("the last page should have no cursor")
+	}
+
+	sql, _, err := buildSQL(t, PlaceQuery{Sort: SortVisits, Cursor: cursor, Limit: 2}, historicalSitesTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sql, "(visit_count < $1 OR (visit_count = $2 AND id > $3))") || !strings.Contains(sql, "ORDER BY visit_count DESC, id") {
+		t.Errorf("SQL = %s", sql)
+	}
+
+	var queryErr *QueryError
+	if _, _, err := buildSQL(t, PlaceQuery{Sort: SortName, Cursor: cursor}, historicalSitesTable); !errors.As(err, &queryErr) {
+		t.Errorf("a cursor for another sort should fail, got %v", err)
+	}
+	if _, _, err := buildSQL(t, PlaceQuery{Cursor: "not a cursor"}, locationsTable); !errors.As(err, &queryErr) {
+		t.Errorf("a malformed cursor should fail, got %v", err)
+	}
+}
+
+func TestPlaceQueryErrors(t *testing.T) {
+	for name, q := range map[string]PlaceQuery{
+		"distance without a point": {Sort: SortDistance},
+		"unknown sort":             {Sort: "rating"},
+		"visits on locations":      {Sort: SortVisits},
+		"era on locations":         {Era: "清代"},
+		"radius too large":         {Near: &Near{Latitude: 25, Longitude: 121.5, Radius: MaxQueryRadius + 1}},
+		"limit too large":          {Limit: MaxPageSize + 1},
+	} {
+		var queryErr *QueryError
+		if _, _, err := buildSQL(t, q, locationsTable); !errors.As(err, &queryErr) {
+			t.Errorf("%s: got %v", name, err)
+		}
+	}
+
+	for _, text := range []string{"121.6,25,121.5,25.1", "121.5,25", "a,b,c,d", "-181,0,0,1"} {
+		if _, err := ParseBBox(text); err == nil {
+			t.Errorf("ParseBBox(%q) should fail", text)
+		}
+	}
+}
+
+func TestLikePatternEscapes(t *testing.T) {
+	if got := likePattern(`100%_\`); got != `%100\%\_\\%` {
+		t.Errorf("likePattern() = %q", got)
+	}
+}
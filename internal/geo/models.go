@@ -11,6 +11,7 @@ type Location struct {
 	Longitude float64   `json:"longitude" gorm:"not null"`
 	Address   string    `json:"address"`
 	Type      string    `json:"type"` // poi, landmark, historical_site, etc.
+	PlaceID   string    `json:"placeId,omitempty" gorm:"uniqueIndex:idx_locations_place_id,where:place_id <> ''"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
@@ -23,7 +24,8 @@ type HistoricalSite struct {
 	Latitude    float64   `json:"latitude" gorm:"not null"`
 	Longitude   float64   `json:"longitude" gorm:"not null"`
 	Address     string    `json:"address"`
-	Images      []string  `json:"images" gorm:"type:text[]"`
+	Images      []string  `json:"images" gorm:"type:text[]"` // media keys or external URLs
+	Thumbnails  []string  `json:"thumbnails,omitempty" gorm:"-"`
 	AudioGuide  string    `json:"audioGuide"`
 	IsActive    bool      `json:"isActive" gorm:"default:true"`
 	VisitCount  int       `json:"visitCount" gorm:"default:0"`
@@ -31,24 +33,29 @@ type HistoricalSite struct {
 	UpdatedAt   time.Time `json:"updatedAt"`
 }
 
+// Route is a saved road route; Start/End repeat the first and last waypoints
 type Route struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Name        string    `json:"name" gorm:"not null"`
-	Description string    `json:"description"`
-	StartLat    float64   `json:"startLat"`
-	StartLng    float64   `json:"startLng"`
-	EndLat      float64   `json:"endLat"`
-	EndLng      float64   `json:"endLng"`
-	Waypoints   []Waypoint `json:"waypoints" gorm:"foreignKey:RouteID"`
-	Distance    float64   `json:"distance"` // in meters
-	Duration    int       `json:"duration"` // in seconds
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Name        string     `json:"name" gorm:"not null"`
+	Description string     `json:"description"`
+	Profile     string     `json:"profile" gorm:"not null;default:foot"` // foot, bike, car
+	StartLat    float64    `json:"startLat"`
+	StartLng    float64    `json:"startLng"`
+	EndLat      float64    `json:"endLat"`
+	EndLng      float64    `json:"endLng"`
+	Waypoints   []Waypoint `json:"waypoints" gorm:"foreignKey:RouteID;constraint:OnDelete:CASCADE"`
+	Distance    float64    `json:"distance"` // in meters
+	Duration    int        `json:"duration"` // in seconds
+	Geom        string     `json:"-" gorm:"type:geography(LineString,4326);index:idx_routes_geom,type:gist"`
+	Geometry    GeoJSON    `json:"geometry" gorm:"->;-:migration"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
 }
 
+// Waypoint is a stop the route was asked to pass through, in order
 type Waypoint struct {
 	ID        uint    `json:"id" gorm:"primaryKey"`
-	RouteID   uint    `json:"routeId"`
+	RouteID   uint    `json:"routeId" gorm:"index"`
 	Latitude  float64 `json:"latitude"`
 	Longitude float64 `json:"longitude"`
 	Order     int     `json:"order"`
@@ -73,18 +80,10 @@ type Bounds struct {
 	West  float64 `json:"west"`
 }
 
-// Taiwan boundary for validation
+// TaiwanBounds is the bounding box of Taiwan, Penghu, Kinmen and Matsu
 var TaiwanBounds = &Bounds{
-	North: 25.5,  // Extended boundary
-	South: 21.8,  // Extended boundary to include Kenting
-	East:  122.2, // Extended boundary
-	West:  119.8, // Extended boundary
+	North: 26.42, // Dongyin, Matsu
+	South: 21.85, // Orchid Island / Eluanbi
+	East:  122.1, // Sandiaojiao
+	West:  118.1, // Lieyu, Kinmen
 }
-
-// IsWithinTaiwan checks if the given coordinates are within Taiwan boundaries
-func IsWithinTaiwan(latitude, longitude float64) bool {
-	return latitude >= TaiwanBounds.South &&
-		   latitude <= TaiwanBounds.North &&
-		   longitude >= TaiwanBounds.West &&
-		   longitude <= TaiwanBounds.East
-}
\ No newline at end of file
@@ -0,0 +1,124 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Filter values the voice phrases 評價高的 and 便宜的 stand for
+const (
+	HighRating      = 4.0
+	CheapPriceLevel = 1 // Google's "inexpensive"
+)
+
+// NearbyFilters narrow a nearby search. A place missing an attribute, such as
+// one of ours without opening hours, passes the filter on it but ranks lower.
+type NearbyFilters struct {
+	OpenNow       bool    `json:"openNow,omitempty"`
+	MinRating     float64 `json:"minRating,omitempty"`     // 1-5
+	MaxPriceLevel int     `json:"maxPriceLevel,omitempty"` // 1-4; 0 is no limit
+}
+
+// IsZero reports whether f filters nothing
+func (f NearbyFilters) IsZero() bool {
+	return f == NearbyFilters{}
+}
+
+// Match reports whether place passes every filter
+func (f NearbyFilters) Match(place *LocationWithDistance) bool {
+	if f.OpenNow && place.OpenNow != nil && !*place.OpenNow {
+		return false
+	}
+	if f.MinRating > 0 && place.Rating > 0 && place.Rating < f.MinRating {
+		return false
+	}
+	if f.MaxPriceLevel > 0 && place.PriceLevel != nil && *place.PriceLevel > f.MaxPriceLevel {
+		return false
+	}
+	return true
+}
+
+// Description 篩選條件的中文說明，例如「營業中、評價 4.0 星以上」
+func (f NearbyFilters) Description() string {
+	var parts []string
+	if f.OpenNow {
+		parts = append(parts, "營業中")
+	}
+	if f.MinRating > 0 {
+		parts = append(parts, fmt.Sprintf("評價 %.1f 星以上", f.MinRating))
+	}
+	if f.MaxPriceLevel > 0 {
+		if f.MaxPriceLevel <= CheapPriceLevel {
+			parts = append(parts, "平價")
+		} else {
+			parts = append(parts, "價位 "+strings.Repeat("$", f.MaxPriceLevel)+" 以內")
+		}
+	}
+	return strings.Join(parts, "、")
+}
+
+// NearbyRankFunc scores a nearby result found within radius meters; results
+// are listed by descending score
+type NearbyRankFunc func(place *LocationWithDistance, radius float64) float64
+
+// Constants of DefaultNearbyRank
+const (
+	// rankHalfDistance is the smallest distance (m) at which distance halves
+	// the score; larger searches use half their radius
+	rankHalfDistance = 100.0
+
+	// ratingPrior and ratingPriorCount pull ratings from few reviews toward a
+	// middling score: a 5.0 from 3 reviews ranks below a 4.6 from 800
+	ratingPrior      = 3.5
+	ratingPriorCount = 20.0
+
+	unknownOpenFactor = 0.8 // no opening hours, as for most of our own places
+	closedFactor      = 0.3
+)
+
+// DefaultNearbyRank multiplies distance decay, rating confidence and whether
+// the place is open now
+func DefaultNearbyRank(place *LocationWithDistance, radius float64) float64 {
+	half := max(radius/2, rankHalfDistance)
+	distance := math.Exp(-math.Ln2 * place.Distance / half)
+
+	votes := float64(place.RatingCount)
+	if place.Rating <= 0 {
+		votes = 0
+	}
+	rating := (place.Rating*votes + ratingPrior*ratingPriorCount) / (votes + ratingPriorCount) / 5
+
+	open := unknownOpenFactor
+	if place.OpenNow != nil {
+		open = closedFactor
+		if *place.OpenNow {
+			open = 1
+		}
+	}
+	return distance * rating * open
+}
+
+// rankNearby drops places failing filters, scores the rest with rank and
+// sorts them best first, nearer first on equal scores
+func rankNearby(places []LocationWithDistance, filters NearbyFilters, radius float64, rank NearbyRankFunc) []LocationWithDistance {
+	if rank == nil {
+		rank = DefaultNearbyRank
+	}
+	kept := places[:0]
+	for _, place := range places {
+		if !filters.Match(&place) {
+			continue
+		}
+		place.Score = rank(&place, radius)
+		kept = append(kept, place)
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		if kept[i].Score != kept[j].Score {
+			return kept[i].Score > kept[j].Score
+		}
+		return kept[i].Distance < kept[j].Distance
+	})
+	return kept
+}
@@ -0,0 +1,72 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func boolPtr(v bool) *bool { return &v }
+func intPtr(v int) *int    { return &v }
+
+func TestDefaultNearbyRank(t *testing.T) {
+	few := &LocationWithDistance{Distance: 100, Rating: 5.0, RatingCount: 3}
+	many := &LocationWithDistance{Distance: 100, Rating: 4.6, RatingCount: 800}
+	if DefaultNearbyRank(few, 500) >= DefaultNearbyRank(many, 500) {
+		t.Error("a 5.0 from 3 reviews should rank below a 4.6 from 800")
+	}
+
+	unrated := &LocationWithDistance{Distance: 100}
+	poor := &LocationWithDistance{Distance: 100, Rating: 2.1, RatingCount: 500}
+	if DefaultNearbyRank(unrated, 500) <= DefaultNearbyRank(poor, 500) {
+		t.Error("an unrated place should rank above a poorly rated one")
+	}
+
+	open := &LocationWithDistance{Distance: 100, OpenNow: boolPtr(true)}
+	closed := &LocationWithDistance{Distance: 100, OpenNow: boolPtr(false)}
+	if !(DefaultNearbyRank(open, 500) > DefaultNearbyRank(unrated, 500) && DefaultNearbyRank(unrated, 500) > DefaultNearbyRank(closed, 500)) {
+		t.Error("open should rank above unknown, and unknown above closed")
+	}
+
+	near := DefaultNearbyRank(&LocationWithDistance{Distance: 0}, 1000)
+	half := DefaultNearbyRank(&LocationWithDistance{Distance: 500}, 1000)
+	if math.Abs(half/near-0.5) > 1e-9 {
+		t.Errorf("score at half the radius = %.3f of the score at the center, want 0.5", half/near)
+	}
+}
+
+func TestRankNearbyFilters(t *testing.T) {
+	places := []LocationWithDistance{
+		{Location: Location{Name: "closed"}, Distance: 50, OpenNow: boolPtr(false), Rating: 4.8, RatingCount: 300},
+		{Location: Location{Name: "ours"}, Distance: 300},
+		{Location: Location{Name: "pricey"}, Distance: 80, OpenNow: boolPtr(true), Rating: 4.5, RatingCount: 200, PriceLevel: intPtr(3)},
+		{Location: Location{Name: "cheap"}, Distance: 120, OpenNow: boolPtr(true), Rating: 4.3, RatingCount: 900, PriceLevel: intPtr(1)},
+		{Location: Location{Name: "poor"}, Distance: 60, OpenNow: boolPtr(true), Rating: 3.2, RatingCount: 40, PriceLevel: intPtr(1)},
+	}
+
+	ranked := rankNearby(append([]LocationWithDistance(nil), places...), NearbyFilters{OpenNow: true, MinRating: HighRating, MaxPriceLevel: CheapPriceLevel}, 500, nil)
+	var names []string
+	for _, place := range ranked {
+		names = append(names, place.Name)
+	}
+	if len(names) != 2 || names[0] != "cheap" || names[1] != "ours" {
+		t.Errorf("ranked = %v, want [cheap ours]: places without the attributes are kept after", names)
+	}
+
+	byName := func(place *LocationWithDistance, radius float64) float64 {
+		return float64(len(place.Name))
+	}
+	ranked = rankNearby(append([]LocationWithDistance(nil), places...), NearbyFilters{}, 500, byName)
+	if len(ranked) != len(places) || ranked[0].Name != "closed" || ranked[0].Score != 6 {
+		t.Errorf("a custom ranking should order the results: %+v", ranked[0])
+	}
+}
+
+func TestNearbyFiltersDescription(t *testing.T) {
+	filters := NearbyFilters{OpenNow: true, MinRating: HighRating, MaxPriceLevel: CheapPriceLevel}
+	if got := filters.Description(); got != "營業中、評價 4.0 星以上、平價" {
+		t.Errorf("Description() = %q", got)
+	}
+	if !(NearbyFilters{}).IsZero() || filters.IsZero() {
+		t.Error("IsZero is wrong")
+	}
+}
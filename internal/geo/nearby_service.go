@@ -3,15 +3,46 @@ package geo
 import (
 	"fmt"
 	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"intelligent-spatial-platform/internal/cluster"
+	"intelligent-spatial-platform/internal/tiles"
+)
+
+// Nearby search sources: where NearbySearchResult and each of its locations came from
+const (
+	NearbySourceDatabase = "database"
+	NearbySourceGoogle   = "google_api"
+	NearbySourceHybrid   = "hybrid" // both, with duplicates merged
+)
+
+// How close a Google result must be to one of our places to be the same place:
+// similar names within duplicateDistance, or equal names within
+// sameNameDistance, since large places have entrances far apart
+const (
+	duplicateDistance  = 60.0  // m
+	sameNameDistance   = 200.0 // m
+	similarNameOverlap = 0.6
 )
 
 // LocationWithDistance 帶距離的地點
 type LocationWithDistance struct {
 	Location
-	Distance float64 `json:"distance"` // 距離（米）
-	Bearing  float64 `json:"bearing"`  // 方位角（度）
+	Distance         float64 `json:"distance"`                   // 距離（米）
+	Bearing          float64 `json:"bearing"`                    // 方位角（度）
+	Source           string  `json:"source,omitempty"`           // database, google_api, hybrid
+	HistoricalSiteID uint    `json:"historicalSiteId,omitempty"` // 古蹟（Location.ID 為 0）
+	Rating           float64 `json:"rating,omitempty"`           // Google 評分 1-5，0 為無評分
+	RatingCount      int     `json:"ratingCount,omitempty"`      // 評分人數
+	PriceLevel       *int    `json:"priceLevel,omitempty"`       // 0 免費至 4 非常昂貴，nil 為未知
+	OpenNow          *bool   `json:"openNow,omitempty"`          // nil 為無營業時間資料
+	Score            float64 `json:"score"`                      // 排序分數，越高越前面
 }
 
 // NearbySearchResult 附近搜尋結果
@@ -21,6 +52,8 @@ type NearbySearchResult struct {
 	Radius       float64                `json:"radius"`
 	Center       *Location              `json:"center"`
 	Source       string                 `json:"source"`       // database, google_api, hybrid
+	Filters      *NearbyFilters         `json:"filters,omitempty"`
+	Area         *Area                  `json:"area,omitempty"` // 搜尋範圍（取代半徑），例如等時圈
 	Warning      string                 `json:"warning,omitempty"`
 	AIResponse   string                 `json:"aiResponse"`   // AI 口語化描述
 }
@@ -30,67 +63,409 @@ type NearbySearchService struct {
 	db           *gorm.DB
 	geocoding    *GeocodingService
 	googlePlaces *GooglePlacesService
+	source       string // NearbySourceDatabase, NearbySourceGoogle or NearbySourceHybrid
+	saveGoogle   bool   // save Google results as locations
+	rank         NearbyRankFunc
+	tileCache    *tiles.Cache
+	clusters     *cluster.Index
 }
 
-func NewNearbySearchService(db *gorm.DB, geocoding *GeocodingService) *NearbySearchService {
-	// Initialize Google Places service
-	googlePlaces, err := NewGooglePlacesService()
-	if err != nil {
-		log.Printf("⚠️ Failed to initialize Google Places: %v", err)
-		googlePlaces = nil
+// NewNearbySearchService searches the source named by NEARBY_SEARCH_SOURCE
+// (database, google or hybrid, the default); NEARBY_SAVE_GOOGLE_RESULTS=true
+// saves Google results as locations so later searches find them offline
+func NewNearbySearchService(db *gorm.DB, geocoding *GeocodingService, resources Resources) *NearbySearchService {
+	source := NearbySourceHybrid
+	switch value := strings.ToLower(strings.TrimSpace(os.Getenv("NEARBY_SEARCH_SOURCE"))); value {
+	case "", NearbySourceHybrid:
+	case NearbySourceDatabase:
+		source = NearbySourceDatabase
+	case "google", NearbySourceGoogle:
+		source = NearbySourceGoogle
+	default:
+		log.Printf("⚠️ Unknown NEARBY_SEARCH_SOURCE %q, using hybrid", value)
+	}
+	saveGoogle, _ := strconv.ParseBool(os.Getenv("NEARBY_SAVE_GOOGLE_RESULTS"))
+
+	var googlePlaces *GooglePlacesService
+	if source != NearbySourceDatabase {
+		// Initialize Google Places service
+		var err error
+		googlePlaces, err = NewGooglePlacesService(resources.Boundaries)
+		if err != nil {
+			log.Printf("⚠️ Failed to initialize Google Places: %v", err)
+			googlePlaces = nil
+		}
 	}
 
 	return &NearbySearchService{
 		db:           db,
 		geocoding:    geocoding,
 		googlePlaces: googlePlaces,
+		source:       source,
+		saveGoogle:   saveGoogle,
+		rank:         DefaultNearbyRank,
+		tileCache:    resources.TileCache,
+		clusters:     resources.Clusters,
+	}
+}
+
+// SetRanking replaces DefaultNearbyRank as the order of results
+func (s *NearbySearchService) SetRanking(rank NearbyRankFunc) {
+	if rank == nil {
+		rank = DefaultNearbyRank
 	}
+	s.rank = rank
 }
 
-// SearchNearby 搜尋附近地點（使用 Google Places API）
+// SearchNearby 搜尋附近地點，依設定查詢資料庫、Google Places 或兩者合併
 func (s *NearbySearchService) SearchNearby(
 	centerLat, centerLng float64,
 	category string,
 	radiusMeters float64,
 	limit int,
 ) (*NearbySearchResult, error) {
+	return s.SearchNearbyWithFilters(centerLat, centerLng, category, radiusMeters, limit, NearbyFilters{})
+}
+
+// SearchNearbyWithFilters 搜尋附近符合篩選條件的地點，依排序函式由高至低排列
+func (s *NearbySearchService) SearchNearbyWithFilters(
+	centerLat, centerLng float64,
+	category string,
+	radiusMeters float64,
+	limit int,
+	filters NearbyFilters,
+) (*NearbySearchResult, error) {
+	return s.search(centerLat, centerLng, category, radiusMeters, nil, limit, filters)
+}
+
+// SearchNearbyInArea 搜尋範圍內（例如「走路 15 分鐘內」的等時圈）符合篩選條件的地點
+func (s *NearbySearchService) SearchNearbyInArea(
+	centerLat, centerLng float64,
+	category string,
+	area *Area,
+	limit int,
+	filters NearbyFilters,
+) (*NearbySearchResult, error) {
+	radius := min(area.Reach(centerLat, centerLng), MaxQueryRadius)
+	return s.search(centerLat, centerLng, category, radius, area, limit, filters)
+}
+
+// search queries the configured sources within radiusMeters of the center and,
+// given an area, keeps only the places inside it
+func (s *NearbySearchService) search(
+	centerLat, centerLng float64,
+	category string,
+	radiusMeters float64,
+	area *Area,
+	limit int,
+	filters NearbyFilters,
+) (*NearbySearchResult, error) {
 
 	if limit == 0 {
 		limit = 20 // 預設返回 20 個結果
 	}
+	limit = min(limit, MaxPageSize)
+
+	log.Printf("🔍 Searching nearby (%s): lat=%.6f, lng=%.6f, category=%s, radius=%.0fm, filters=%+v",
+		s.source, centerLat, centerLng, category, radiusMeters, filters)
+
+	result := &NearbySearchResult{
+		Radius: radiusMeters,
+		Center: &Location{
+			Latitude:  centerLat,
+			Longitude: centerLng,
+		},
+		Source: s.source,
+		Area:   area,
+	}
+	if !filters.IsZero() {
+		result.Filters = &filters
+	}
+
+	var local, remote []LocationWithDistance
+	var localErr, remoteErr error
+	if s.source != NearbySourceGoogle {
+		local, localErr = s.searchDatabase(centerLat, centerLng, category, radiusMeters, area, limit)
+		if localErr != nil {
+			log.Printf("⚠️ Nearby database search failed: %v", localErr)
+		}
+	}
+	if s.source != NearbySourceDatabase {
+		if s.googlePlaces == nil {
+			remoteErr = fmt.Errorf("Google Places API not available")
+		} else {
+			remote, remoteErr = s.googlePlaces.SearchNearbyPlacesWithFilters(centerLat, centerLng, category, int(radiusMeters), limit, filters)
+			if remoteErr != nil {
+				remoteErr = fmt.Errorf("Google Places API 查詢失敗: %v", remoteErr)
+			} else if area != nil {
+				remote = placesInArea(remote, area)
+			}
+		}
+	}
 
-	// 使用 Google Places API 搜尋
-	if s.googlePlaces == nil {
-		return nil, fmt.Errorf("Google Places API not available")
+	switch s.source {
+	case NearbySourceDatabase:
+		if localErr != nil {
+			return nil, fmt.Errorf("資料庫查詢失敗: %v", localErr)
+		}
+	case NearbySourceGoogle:
+		if remoteErr != nil {
+			return nil, remoteErr
+		}
+	default:
+		switch {
+		case localErr != nil && remoteErr != nil:
+			return nil, fmt.Errorf("資料庫查詢失敗: %v; %v", localErr, remoteErr)
+		case remoteErr != nil:
+			result.Source = NearbySourceDatabase
+			if s.googlePlaces != nil {
+				// Without an API key this is the expected setup, not worth a warning
+				log.Printf("⚠️ %v, using database results only", remoteErr)
+				result.Warning = "Google Places 暫時無法使用，僅列出資料庫中的地點"
+			}
+		case localErr != nil:
+			result.Source = NearbySourceGoogle
+			result.Warning = "資料庫暫時無法使用，僅列出 Google Places 的地點"
+		}
 	}
 
-	log.Printf("🔍 Using Google Places API to search nearby: lat=%.6f, lng=%.6f, category=%s, radius=%.0fm",
-		centerLat, centerLng, category, radiusMeters)
+	merged := mergeNearby(local, remote)
+	if s.saveGoogle && len(remote) > 0 && localErr == nil {
+		if err := s.saveGoogleResults(merged); err != nil {
+			log.Printf("⚠️ Failed to save Google Places results: %v", err)
+		}
+	}
+
+	merged = rankNearby(merged, filters, radiusMeters, s.rank)
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	for i := range merged {
+		merged[i].Bearing = calculateBearing(centerLat, centerLng, merged[i].Latitude, merged[i].Longitude)
+	}
+
+	log.Printf("✅ Nearby search found %d results (local %d, google %d, category: %s, radius: %.0fm)",
+		len(merged), len(local), len(remote), category, radiusMeters)
 
-	results, err := s.googlePlaces.SearchNearbyPlaces(
-		centerLat, centerLng,
-		category,
-		int(radiusMeters),
-		limit,
-	)
+	result.Locations = merged
+	result.Total = len(merged)
+	return result, nil
+}
 
+// searchDatabase returns the nearest saved locations of category within
+// radiusMeters and area, if any, and for sightseeing categories the nearest
+// historical sites
+func (s *NearbySearchService) searchDatabase(lat, lng float64, category string, radiusMeters float64, area *Area, limit int) ([]LocationWithDistance, error) {
+	near := &Near{Latitude: lat, Longitude: lng, Radius: min(radiusMeters, MaxQueryRadius)}
+
+	q := PlaceQuery{Near: near, Within: area, Limit: limit}
+	if category != "" && category != "general" {
+		q.Type = category
+	}
+	query, _, _, err := q.build(s.db, locationsTable)
 	if err != nil {
-		return nil, fmt.Errorf("Google Places API 查詢失敗: %v", err)
+		return nil, err
+	}
+	var locations []LocationResult
+	if err := query.Find(&locations).Error; err != nil {
+		return nil, err
 	}
 
-	log.Printf("✅ Google Places API 查詢成功：找到 %d 個結果（類別: %s，半徑: %.0fm）",
-		len(results), category, radiusMeters)
+	results := make([]LocationWithDistance, 0, len(locations))
+	for _, location := range locations[:min(len(locations), limit)] {
+		results = append(results, LocationWithDistance{
+			Location: location.Location,
+			Distance: valueOrZero(location.Distance),
+			Source:   NearbySourceDatabase,
+		})
+	}
 
-	return &NearbySearchResult{
-		Locations: results,
-		Total:     len(results),
-		Radius:    radiusMeters,
-		Center: &Location{
-			Latitude:  centerLat,
-			Longitude: centerLng,
-		},
-		Source: "google_api",
-	}, nil
+	if !includesHistoricalSites(category) {
+		return results, nil
+	}
+	query, _, _, err = (&PlaceQuery{Near: near, Within: area, Limit: limit}).build(s.db, historicalSitesTable)
+	if err != nil {
+		return nil, err
+	}
+	var sites []HistoricalSiteResult
+	if err := query.Find(&sites).Error; err != nil {
+		return nil, err
+	}
+	for _, site := range sites[:min(len(sites), limit)] {
+		results = append(results, LocationWithDistance{
+			Location: Location{
+				Name:      site.Name,
+				Latitude:  site.Latitude,
+				Longitude: site.Longitude,
+				Address:   site.Address,
+				Type:      "historical_site",
+			},
+			Distance:         valueOrZero(site.Distance),
+			Source:           NearbySourceDatabase,
+			HistoricalSiteID: site.ID,
+		})
+	}
+	return results, nil
+}
+
+// placesInArea keeps the places that lie in area
+func placesInArea(places []LocationWithDistance, area *Area) []LocationWithDistance {
+	kept := places[:0]
+	for _, place := range places {
+		if area.Contains(place.Latitude, place.Longitude) {
+			kept = append(kept, place)
+		}
+	}
+	return kept
+}
+
+// includesHistoricalSites reports whether a search for category should list
+// historical sites as well as locations
+func includesHistoricalSites(category string) bool {
+	return category == "" || category == "general" || category == "attraction"
+}
+
+// mergeNearby folds each Google result into the database result for the same
+// place, if any, and appends the others. A merged result keeps our name,
+// position and IDs, and takes the place ID, any missing address, the rating,
+// price level and opening state from Google.
+func mergeNearby(local, remote []LocationWithDistance) []LocationWithDistance {
+	merged := append([]LocationWithDistance(nil), local...)
+	for _, place := range remote {
+		if i := findSamePlace(merged[:len(local)], place); i >= 0 {
+			existing := &merged[i]
+			existing.Source = NearbySourceHybrid
+			if existing.PlaceID == "" {
+				existing.PlaceID = place.PlaceID
+			}
+			if existing.Address == "" {
+				existing.Address = place.Address
+			}
+			if existing.Type == "" {
+				existing.Type = place.Type
+			}
+			existing.Rating, existing.RatingCount = place.Rating, place.RatingCount
+			existing.PriceLevel, existing.OpenNow = place.PriceLevel, place.OpenNow
+			continue
+		}
+		merged = append(merged, place)
+	}
+	return merged
+}
+
+// findSamePlace returns the index of the database result that is the same
+// place as a Google result, or -1
+func findSamePlace(local []LocationWithDistance, place LocationWithDistance) int {
+	if place.PlaceID != "" {
+		for i, existing := range local {
+			if existing.PlaceID == place.PlaceID {
+				return i
+			}
+		}
+	}
+
+	best, bestDistance := -1, math.Inf(1)
+	for i, existing := range local {
+		if existing.Source == NearbySourceHybrid || (existing.PlaceID != "" && place.PlaceID != "") {
+			continue // merged already, or known to be another Google place
+		}
+		distance := calculateDistanceInMeters(existing.Latitude, existing.Longitude, place.Latitude, place.Longitude)
+		if distance < bestDistance && similarPlaceNames(existing.Name, place.Name, distance) {
+			best, bestDistance = i, distance
+		}
+	}
+	return best
+}
+
+// similarPlaceNames reports whether two names this far apart name one place
+func similarPlaceNames(a, b string, distance float64) bool {
+	a, b = normalizePlaceName(a), normalizePlaceName(b)
+	if a == "" || b == "" {
+		return false
+	}
+	if a == b {
+		return distance <= sameNameDistance
+	}
+	if distance > duplicateDistance {
+		return false
+	}
+	if strings.Contains(a, b) || strings.Contains(b, a) {
+		return true
+	}
+	return max(bigramOverlap(a, b), bigramOverlap(b, a)) >= similarNameOverlap
+}
+
+// saveGoogleResults stores the Google results that matched nothing as new
+// locations, setting their IDs in results, and records the place ID of
+// locations they were merged into. Rows already saved with a place ID are kept.
+func (s *NearbySearchService) saveGoogleResults(results []LocationWithDistance) error {
+	var fresh []Location
+	var freshIndex []int
+	for i, result := range results {
+		switch {
+		case result.Source == NearbySourceGoogle && result.PlaceID != "":
+			fresh = append(fresh, result.Location)
+			freshIndex = append(freshIndex, i)
+		case result.Source == NearbySourceHybrid && result.ID != 0:
+			err := s.db.Model(&Location{}).
+				Where("id = ? AND (place_id IS NULL OR place_id = '')", result.ID).
+				Updates(map[string]interface{}{
+					"place_id": result.PlaceID,
+					"address":  gorm.Expr("COALESCE(NULLIF(address, ''), ?)", result.Address),
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if len(fresh) == 0 {
+		return nil
+	}
+
+	if err := insertGoogleLocations(s.db, fresh).Error; err != nil {
+		return err
+	}
+	var points []cluster.Point
+	for i, location := range fresh {
+		results[freshIndex[i]].ID = location.ID
+		results[freshIndex[i]].CreatedAt = location.CreatedAt
+		results[freshIndex[i]].UpdatedAt = location.UpdatedAt
+		// Place IDs saved before are skipped by the insert and get no ID
+		if location.ID != 0 {
+			points = append(points, location.clusterPoint())
+		}
+	}
+	s.tileCache.Invalidate(tiles.LayerLocations)
+	s.clusters.Upsert(points...)
+	log.Printf("💾 Saved %d Google Places results as locations", len(fresh))
+	return nil
+}
+
+// insertGoogleLocations inserts locations, skipping place IDs saved before
+func insertGoogleLocations(db *gorm.DB, locations []Location) *gorm.DB {
+	return db.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "place_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "place_id <> ''"}}},
+		DoNothing:   true,
+	}).Create(&locations)
+}
+
+// calculateBearing 計算從起點到終點的方位角（度，正北為 0，順時針）
+func calculateBearing(lat1, lng1, lat2, lng2 float64) float64 {
+	lat1Rad := lat1 * math.Pi / 180
+	lat2Rad := lat2 * math.Pi / 180
+	deltaLng := (lng2 - lng1) * math.Pi / 180
+
+	y := math.Sin(deltaLng) * math.Cos(lat2Rad)
+	x := math.Cos(lat1Rad)*math.Sin(lat2Rad) - math.Sin(lat1Rad)*math.Cos(lat2Rad)*math.Cos(deltaLng)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+func valueOrZero(value *float64) float64 {
+	if value == nil {
+		return 0
+	}
+	return *value
 }
 
 // SearchNearbyByType 依類型搜尋（快捷方法）
@@ -0,0 +1,109 @@
+package geo
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func nearbyPlace(name string, lat, lng float64, placeID, source string) LocationWithDistance {
+	return LocationWithDistance{
+		Location: Location{Name: name, Latitude: lat, Longitude: lng, PlaceID: placeID},
+		Source:   source,
+	}
+}
+
+func TestMergeNearby(t *testing.T) {
+	local := []LocationWithDistance{
+		nearbyPlace("臺北101", 25.03360, 121.56450, "", NearbySourceDatabase),
+		nearbyPlace("鼎泰豐 信義店", 25.03330, 121.56520, "ChIJdin", NearbySourceDatabase),
+		nearbyPlace("四四南村", 25.03150, 121.56190, "", NearbySourceDatabase),
+	}
+	local[0].ID = 1
+	remote := []LocationWithDistance{
+		// Same name apart from 臺/台, 40 m away: merged
+		nearbyPlace("台北101", 25.03395, 121.56455, "ChIJ101", NearbySourceGoogle),
+		// Same place ID under another name: merged
+		nearbyPlace("Din Tai Fung", 25.03335, 121.56525, "ChIJdin", NearbySourceGoogle),
+		// Same name but 1 km away: another place
+		nearbyPlace("四四南村", 25.04050, 121.56190, "ChIJ44", NearbySourceGoogle),
+		// Unrelated
+		nearbyPlace("象山步道", 25.02740, 121.57060, "ChIJhill", NearbySourceGoogle),
+	}
+	remote[0].Address = "110台北市信義區信義路五段7號"
+
+	merged := mergeNearby(local, remote)
+	if len(merged) != 5 {
+		t.Fatalf("merged %d results, want 5: %+v", len(merged), merged)
+	}
+
+	taipei101 := merged[0]
+	if taipei101.Source != NearbySourceHybrid || taipei101.ID != 1 || taipei101.Name != "臺北101" {
+		t.Errorf("database result should keep its name and ID when merged: %+v", taipei101)
+	}
+	if taipei101.PlaceID != "ChIJ101" || taipei101.Address != remote[0].Address {
+		t.Errorf("merged result should take the place ID and missing address from Google: %+v", taipei101)
+	}
+	if merged[1].Source != NearbySourceHybrid || merged[1].Name != "鼎泰豐 信義店" {
+		t.Errorf("results with one place ID should merge: %+v", merged[1])
+	}
+	if merged[2].Source != NearbySourceDatabase {
+		t.Errorf("a place with the same name 1 km away should not merge: %+v", merged[2])
+	}
+	if merged[3].PlaceID != "ChIJ44" || merged[4].PlaceID != "ChIJhill" {
+		t.Errorf("unmatched Google results should be appended: %+v", merged[3:])
+	}
+}
+
+func TestSimilarPlaceNames(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		distance float64
+		want     bool
+	}{
+		{"國立故宮博物院", "故宮博物院", 30, true},
+		{"國立故宮博物院", "故宮博物院", 150, false},
+		{"Taipei 101", "taipei101", 150, true},
+		{"星巴克 信義門市", "星巴克 信義店", 20, true},
+		{"星巴克", "路易莎咖啡", 10, false},
+		{"", "星巴克", 0, false},
+	}
+	for _, c := range cases {
+		if got := similarPlaceNames(c.a, c.b, c.distance); got != c.want {
+			t.Errorf("similarPlaceNames(%q, %q, %.0f) = %v", c.a, c.b, c.distance, got)
+		}
+	}
+}
+
+func TestCalculateBearing(t *testing.T) {
+	for _, c := range []struct {
+		lat, lng float64
+		want     float64
+		name     string
+	}{
+		{25.1, 121.5, 0, "北"},
+		{25.0, 121.6, 90, "東"},
+		{24.9, 121.5, 180, "南"},
+		{25.0, 121.4, 270, "西"},
+	} {
+		bearing := calculateBearing(25.0, 121.5, c.lat, c.lng)
+		if math.Abs(bearing-c.want) > 0.1 {
+			t.Errorf("bearing to (%.1f, %.1f) = %.2f, want %.0f", c.lat, c.lng, bearing, c.want)
+		}
+		if got := GetDirectionDescription(bearing); got != c.name {
+			t.Errorf("direction for %.2f = %s, want %s", bearing, got, c.name)
+		}
+	}
+}
+
+func TestInsertGoogleLocationsSkipsSavedPlaces(t *testing.T) {
+	locations := []Location{{Name: "象山步道", Latitude: 25.0274, Longitude: 121.5706, Type: "park", PlaceID: "ChIJhill"}}
+	// Without the default transaction, which would need a connection
+	db := dryRunDB(t).Session(&gorm.Session{SkipDefaultTransaction: true})
+	sql := insertGoogleLocations(db, locations).Statement.SQL.String()
+	if !strings.Contains(sql, `ON CONFLICT ("place_id")`) || !strings.Contains(sql, `WHERE place_id <> '' DO NOTHING`) {
+		t.Errorf("SQL = %s", sql)
+	}
+}
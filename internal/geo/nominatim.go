@@ -0,0 +1,183 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNominatimURL       = "https://nominatim.openstreetmap.org/search"
+	defaultNominatimUserAgent = "IntelligentSpatialPlatform/1.0 (contact@example.com)"
+
+	// nominatimInterval is the public instance's usage policy: at most one request per second
+	nominatimInterval = time.Second
+
+	// nominatimMaxWait is how long a lookup may queue for its slot before giving up
+	nominatimMaxWait = 3 * time.Second
+)
+
+type NominatimResponse []struct {
+	Lat         string `json:"lat"`
+	Lon         string `json:"lon"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	PlaceID     int    `json:"place_id"`
+	Type        string `json:"type"`
+	Class       string `json:"class"`
+}
+
+// intervalLimiter spaces calls at least interval apart
+type intervalLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// wait blocks until the caller's slot, or fails if that is more than maxWait away
+func (l *intervalLimiter) wait(maxWait time.Duration) error {
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	delay := slot.Sub(now)
+	if delay > maxWait {
+		l.mu.Unlock()
+		return fmt.Errorf("rate limited, next request allowed in %v", delay.Round(time.Millisecond))
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	time.Sleep(delay)
+	return nil
+}
+
+// nominatimLimiter is shared by every NominatimGeocoder in the process
+var nominatimLimiter = &intervalLimiter{interval: nominatimInterval}
+
+// NominatimGeocoder searches OpenStreetMap through a Nominatim instance
+type NominatimGeocoder struct {
+	client     *http.Client
+	baseURL    string
+	userAgent  string
+	limiter    *intervalLimiter
+	boundaries *Boundaries
+}
+
+// NewNominatimGeocoder returns a geocoder for baseURL (the public instance when empty).
+// The public instance requires an identifying User-Agent; set NOMINATIM_USER_AGENT to
+// include a real contact address in production. Results outside boundaries are dropped.
+func NewNominatimGeocoder(baseURL, userAgent string, boundaries *Boundaries) *NominatimGeocoder {
+	if baseURL == "" {
+		baseURL = defaultNominatimURL
+	}
+	if userAgent == "" {
+		userAgent = defaultNominatimUserAgent
+	}
+	return &NominatimGeocoder{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		baseURL:    baseURL,
+		userAgent:  userAgent,
+		limiter:    nominatimLimiter,
+		boundaries: boundaries,
+	}
+}
+
+func (n *NominatimGeocoder) Name() string {
+	return ProviderNominatim
+}
+
+// Geocode returns up to MaxCandidates matches within Taiwan, best first
+func (n *NominatimGeocoder) Geocode(locationName string) ([]GeocodeCandidate, error) {
+	// Add Taiwan context to improve accuracy for Taiwan locations
+	query := locationName
+	if !strings.Contains(strings.ToLower(locationName), "taiwan") &&
+		!strings.Contains(strings.ToLower(locationName), "台灣") {
+		query = locationName + ", Taiwan"
+	}
+
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("format", "jsonv2")
+	params.Set("limit", fmt.Sprintf("%d", MaxCandidates))
+	params.Set("countrycodes", "tw") // Limit to Taiwan
+	params.Set("accept-language", "zh-TW")
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s?%s", n.baseURL, params.Encode()), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("User-Agent", n.userAgent)
+
+	if err := n.limiter.wait(nominatimMaxWait); err != nil {
+		return nil, err
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("geocoding request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("geocoding API returned status: %d", resp.StatusCode)
+	}
+
+	var results NominatimResponse
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+
+	candidates := make([]GeocodeCandidate, 0, len(results))
+	for rank, result := range results {
+		lat, err := parseFloat(result.Lat)
+		if err != nil {
+			continue
+		}
+		lng, err := parseFloat(result.Lon)
+		if err != nil {
+			continue
+		}
+		if !n.boundaries.InTerritory(lat, lng) {
+			continue
+		}
+
+		name := result.Name
+		if name == "" {
+			name, _, _ = strings.Cut(result.DisplayName, ",")
+		}
+		candidates = append(candidates, GeocodeCandidate{
+			ID: fmt.Sprintf("nominatim:%d", result.PlaceID),
+			Location: Location{
+				Name:      name,
+				Latitude:  lat,
+				Longitude: lng,
+				Address:   result.DisplayName,
+				Type:      result.Type,
+			},
+			Confidence: candidateScore(locationName, name, result.DisplayName, rank),
+			Source:     ProviderNominatim,
+		})
+	}
+
+	if len(candidates) == 0 {
+		return nil, &NoResultsError{Query: locationName}
+	}
+	sortCandidates(candidates)
+	return candidates, nil
+}
+
+func parseFloat(s string) (float64, error) {
+	// Simple float parsing
+	var f float64
+	_, err := fmt.Sscanf(s, "%f", &f)
+	return f, err
+}
@@ -0,0 +1,367 @@
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPlaceDetailsCacheTTL keeps details for an hour: long enough for a
+	// conversation about a place, short enough that hours stay current
+	DefaultPlaceDetailsCacheTTL = time.Hour
+
+	// DefaultPlaceDetailsCacheSize is how many places the cache holds
+	DefaultPlaceDetailsCacheSize = 1000
+
+	// maxPlaceReviews and maxReviewLength trim the reviews summary
+	maxPlaceReviews = 3
+	maxReviewLength = 200 // characters
+)
+
+var (
+	// ErrPlaceNotFound is a place ID Google does not know, or no longer knows
+	ErrPlaceNotFound = errors.New("place not found")
+
+	// ErrInvalidPlaceID is a place ID that cannot be one
+	ErrInvalidPlaceID = errors.New("invalid place ID")
+
+	// ErrPlacesUnavailable means Google Places is not configured
+	ErrPlacesUnavailable = errors.New("Google Places API not available")
+)
+
+// placeIDPattern matches Google place IDs, which are URL-safe base64 text
+var placeIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{10,512}$`)
+
+// PlaceDetails is what Google Place Details knows about a place
+type PlaceDetails struct {
+	PlaceID            string             `json:"placeId"`
+	Name               string             `json:"name"`
+	Address            string             `json:"address"`
+	AddressComponents  []AddressComponent `json:"addressComponents,omitempty"`
+	Latitude           float64            `json:"latitude"`
+	Longitude          float64            `json:"longitude"`
+	Phone              string             `json:"phone,omitempty"`
+	InternationalPhone string             `json:"internationalPhone,omitempty"`
+	Website            string             `json:"website,omitempty"`
+	GoogleMapsURL      string             `json:"googleMapsUrl,omitempty"`
+	BusinessStatus     string             `json:"businessStatus,omitempty"` // OPERATIONAL, CLOSED_TEMPORARILY, CLOSED_PERMANENTLY
+	OpeningHours       *OpeningHours      `json:"openingHours,omitempty"`
+	PriceLevel         *int               `json:"priceLevel,omitempty"`
+	Reviews            ReviewSummary      `json:"reviews"`
+	Photos             []PlacePhoto       `json:"photos,omitempty"`
+	FetchedAt          time.Time          `json:"fetchedAt"`
+}
+
+// AddressComponent is one part of an address, such as the district or road
+type AddressComponent struct {
+	LongName  string   `json:"longName"`
+	ShortName string   `json:"shortName"`
+	Types     []string `json:"types"` // e.g. administrative_area_level_1, route
+}
+
+// OpeningHours are a place's regular hours
+type OpeningHours struct {
+	OpenNow     *bool    `json:"openNow,omitempty"` // when the details were fetched
+	WeekdayText []string `json:"weekdayText"`       // Monday first, e.g. "星期一: 11:00 – 21:00"
+}
+
+// Today returns the hours of the day it is in Taiwan at now, or ""
+func (h *OpeningHours) Today(now time.Time) string {
+	if h == nil || len(h.WeekdayText) != 7 {
+		return ""
+	}
+	return h.WeekdayText[(int(now.In(TaipeiTime).Weekday())+6)%7]
+}
+
+// ReviewSummary is the rating and a few of the most relevant reviews
+type ReviewSummary struct {
+	Rating float64       `json:"rating,omitempty"`
+	Total  int           `json:"total"`
+	Recent []PlaceReview `json:"recent,omitempty"`
+}
+
+// PlaceReview is a review trimmed to maxReviewLength characters
+type PlaceReview struct {
+	Author       string `json:"author"`
+	Rating       int    `json:"rating"`
+	Text         string `json:"text"`
+	RelativeTime string `json:"relativeTime"` // e.g. "2 週前"
+}
+
+// PlacePhoto is a Google photo reference; the image itself comes from the
+// Place Photos API, and Attributions must be shown with it
+type PlacePhoto struct {
+	Reference    string   `json:"reference"`
+	Width        int      `json:"width"`
+	Height       int      `json:"height"`
+	Attributions []string `json:"attributions,omitempty"`
+}
+
+// placeDetailsFields are the fields asked of Place Details; each is billed
+const placeDetailsFields = "place_id,name,formatted_address,address_components,geometry/location," +
+	"formatted_phone_number,international_phone_number,website,url,business_status," +
+	"opening_hours,price_level,rating,user_ratings_total,reviews,photos"
+
+// googlePlaceDetailsResponse is the Place Details response
+type googlePlaceDetailsResponse struct {
+	Result struct {
+		PlaceID           string `json:"place_id"`
+		Name              string `json:"name"`
+		FormattedAddress  string `json:"formatted_address"`
+		AddressComponents []struct {
+			LongName  string   `json:"long_name"`
+			ShortName string   `json:"short_name"`
+			Types     []string `json:"types"`
+		} `json:"address_components"`
+		Geometry struct {
+			Location struct {
+				Lat float64 `json:"lat"`
+				Lng float64 `json:"lng"`
+			} `json:"location"`
+		} `json:"geometry"`
+		FormattedPhoneNumber     string `json:"formatted_phone_number"`
+		InternationalPhoneNumber string `json:"international_phone_number"`
+		Website                  string `json:"website"`
+		URL                      string `json:"url"`
+		BusinessStatus           string `json:"business_status"`
+		OpeningHours             *struct {
+			OpenNow     *bool    `json:"open_now"`
+			WeekdayText []string `json:"weekday_text"`
+		} `json:"opening_hours"`
+		PriceLevel       *int    `json:"price_level"`
+		Rating           float64 `json:"rating"`
+		UserRatingsTotal int     `json:"user_ratings_total"`
+		Reviews          []struct {
+			AuthorName              string `json:"author_name"`
+			Rating                  int    `json:"rating"`
+			Text                    string `json:"text"`
+			RelativeTimeDescription string `json:"relative_time_description"`
+		} `json:"reviews"`
+		Photos []struct {
+			PhotoReference   string   `json:"photo_reference"`
+			Width            int      `json:"width"`
+			Height           int      `json:"height"`
+			HTMLAttributions []string `json:"html_attributions"`
+		} `json:"photos"`
+	} `json:"result"`
+	Status       string `json:"status"`
+	ErrorMessage string `json:"error_message"`
+}
+
+// PlaceDetails looks up a place by its Google place ID
+func (g *GooglePlacesService) PlaceDetails(placeID string) (*PlaceDetails, error) {
+	if !placeIDPattern.MatchString(placeID) {
+		return nil, ErrInvalidPlaceID
+	}
+
+	params := url.Values{}
+	params.Set("place_id", placeID)
+	params.Set("fields", placeDetailsFields)
+	params.Set("language", "zh-TW")
+	params.Set("reviews_sort", "most_relevant")
+	params.Set("key", g.apiKey)
+
+	requestURL := fmt.Sprintf("%s/details/json?%s", g.baseURL, params.Encode())
+
+	resp, err := g.client.Get(requestURL)
+	if err != nil {
+		return nil, fmt.Errorf("google place details request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("google places API returned status: %d", resp.StatusCode)
+	}
+
+	var result googlePlaceDetailsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+
+	switch result.Status {
+	case "OK":
+	case "NOT_FOUND", "ZERO_RESULTS":
+		return nil, ErrPlaceNotFound
+	case "INVALID_REQUEST":
+		return nil, ErrInvalidPlaceID
+	default:
+		return nil, fmt.Errorf("google places API error: %s %s", result.Status, result.ErrorMessage)
+	}
+
+	return placeDetailsFromGoogle(&result, time.Now()), nil
+}
+
+func placeDetailsFromGoogle(response *googlePlaceDetailsResponse, now time.Time) *PlaceDetails {
+	place := &response.Result
+	details := &PlaceDetails{
+		PlaceID:            place.PlaceID,
+		Name:               place.Name,
+		Address:            place.FormattedAddress,
+		Latitude:           place.Geometry.Location.Lat,
+		Longitude:          place.Geometry.Location.Lng,
+		Phone:              place.FormattedPhoneNumber,
+		InternationalPhone: place.InternationalPhoneNumber,
+		Website:            place.Website,
+		GoogleMapsURL:      place.URL,
+		BusinessStatus:     place.BusinessStatus,
+		PriceLevel:         place.PriceLevel,
+		Reviews:            ReviewSummary{Rating: place.Rating, Total: place.UserRatingsTotal},
+		FetchedAt:          now,
+	}
+
+	for _, component := range place.AddressComponents {
+		details.AddressComponents = append(details.AddressComponents, AddressComponent{
+			LongName:  component.LongName,
+			ShortName: component.ShortName,
+			Types:     component.Types,
+		})
+	}
+	if hours := place.OpeningHours; hours != nil {
+		details.OpeningHours = &OpeningHours{OpenNow: hours.OpenNow, WeekdayText: hours.WeekdayText}
+	}
+	for _, review := range place.Reviews[:min(len(place.Reviews), maxPlaceReviews)] {
+		text := []rune(strings.TrimSpace(review.Text))
+		if len(text) > maxReviewLength {
+			text = append(text[:maxReviewLength], '…')
+		}
+		details.Reviews.Recent = append(details.Reviews.Recent, PlaceReview{
+			Author:       review.AuthorName,
+			Rating:       review.Rating,
+			Text:         string(text),
+			RelativeTime: review.RelativeTimeDescription,
+		})
+	}
+	for _, photo := range place.Photos {
+		details.Photos = append(details.Photos, PlacePhoto{
+			Reference:    photo.PhotoReference,
+			Width:        photo.Width,
+			Height:       photo.Height,
+			Attributions: photo.HTMLAttributions,
+		})
+	}
+	return details
+}
+
+// placeDetailsEntry is a cached lookup
+type placeDetailsEntry struct {
+	details   *PlaceDetails
+	expiresAt time.Time
+}
+
+// placeDetailsCall is a lookup in flight that identical lookups wait for
+type placeDetailsCall struct {
+	done    chan struct{}
+	details *PlaceDetails
+	err     error
+}
+
+// PlaceDetailsCache keeps place details in memory for a TTL, so follow-up
+// questions about the same place do not reach Google again
+type PlaceDetailsCache struct {
+	ttl     time.Duration
+	maxSize int
+	now     func() time.Time
+
+	mu       sync.Mutex
+	entries  map[string]*placeDetailsEntry
+	inFlight map[string]*placeDetailsCall
+}
+
+// NewPlaceDetailsCache keeps up to maxSize places for ttl each
+func NewPlaceDetailsCache(ttl time.Duration, maxSize int) *PlaceDetailsCache {
+	if maxSize <= 0 {
+		maxSize = DefaultPlaceDetailsCacheSize
+	}
+	return &PlaceDetailsCache{
+		ttl:      ttl,
+		maxSize:  maxSize,
+		now:      time.Now,
+		entries:  make(map[string]*placeDetailsEntry),
+		inFlight: make(map[string]*placeDetailsCall),
+	}
+}
+
+// Resolve returns the cached details of placeID, or calls lookup and caches
+// its answer. Concurrent calls for one place share a lookup; errors are not cached.
+func (c *PlaceDetailsCache) Resolve(placeID string, lookup func(string) (*PlaceDetails, error)) (*PlaceDetails, error) {
+	c.mu.Lock()
+	if entry, ok := c.entries[placeID]; ok {
+		if c.now().Before(entry.expiresAt) {
+			c.mu.Unlock()
+			return entry.details, nil
+		}
+		delete(c.entries, placeID)
+	}
+	if call, ok := c.inFlight[placeID]; ok {
+		c.mu.Unlock()
+		<-call.done
+		return call.details, call.err
+	}
+	call := &placeDetailsCall{done: make(chan struct{})}
+	c.inFlight[placeID] = call
+	c.mu.Unlock()
+
+	// Release waiters even if lookup panics
+	defer func() {
+		c.mu.Lock()
+		delete(c.inFlight, placeID)
+		if call.err == nil && c.ttl > 0 {
+			c.put(placeID, call.details)
+		}
+		c.mu.Unlock()
+		close(call.done)
+	}()
+
+	call.err = fmt.Errorf("place details lookup for %s did not complete", placeID)
+	call.details, call.err = lookup(placeID)
+	return call.details, call.err
+}
+
+// put stores details, first dropping expired entries and then the entry
+// closest to expiry when the cache is full; c.mu must be held
+func (c *PlaceDetailsCache) put(placeID string, details *PlaceDetails) {
+	now := c.now()
+	if len(c.entries) >= c.maxSize {
+		var oldest string
+		for id, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, id)
+			} else if oldest == "" || entry.expiresAt.Before(c.entries[oldest].expiresAt) {
+				oldest = id
+			}
+		}
+		if len(c.entries) >= c.maxSize && oldest != "" {
+			delete(c.entries, oldest)
+		}
+	}
+	c.entries[placeID] = &placeDetailsEntry{details: details, expiresAt: now.Add(c.ttl)}
+}
+
+// Len returns how many places are cached, expired or not
+func (c *PlaceDetailsCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// GetPlaceDetails returns the details of a Google place, cached when the
+// service has a PlaceDetailsCache
+func (s *Service) GetPlaceDetails(placeID string) (*PlaceDetails, error) {
+	if s.places == nil {
+		return nil, ErrPlacesUnavailable
+	}
+	if !placeIDPattern.MatchString(placeID) {
+		return nil, ErrInvalidPlaceID
+	}
+	if cache := s.resources.PlaceDetailsCache; cache != nil {
+		return cache.Resolve(placeID, s.places.PlaceDetails)
+	}
+	return s.places.PlaceDetails(placeID)
+}
@@ -0,0 +1,163 @@
+package geo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const placeDetailsJSON = `{
+	"status": "OK",
+	"result": {
+		"place_id": "ChIJdinTaiFung101",
+		"name": "鼎泰豐 101店",
+		"formatted_address": "110台灣台北市信義區市府路45號B1",
+		"address_components": [
+			{"long_name": "信義區", "short_name": "信義區", "types": ["administrative_area_level_2", "political"]},
+			{"long_name": "台北市", "short_name": "台北市", "types": ["administrative_area_level_1", "political"]}
+		],
+		"geometry": {"location": {"lat": 25.0338, "lng": 121.5645}},
+		"formatted_phone_number": "02 8101 7799",
+		"website": "https://www.dintaifung.com.tw/",
+		"business_status": "OPERATIONAL",
+		"opening_hours": {
+			"open_now": true,
+			"weekday_text": ["星期一: 11:00 – 21:00", "星期二: 11:00 – 21:00", "星期三: 11:00 – 21:00",
+				"星期四: 11:00 – 21:00", "星期五: 11:00 – 21:30", "星期六: 10:30 – 21:30", "星期日: 10:30 – 21:00"]
+		},
+		"price_level": 2,
+		"rating": 4.4,
+		"user_ratings_total": 12345,
+		"reviews": [
+			{"author_name": "A", "rating": 5, "text": "小籠包很好吃", "relative_time_description": "1 週前"},
+			{"author_name": "B", "rating": 4, "text": "排隊很久但值得", "relative_time_description": "2 週前"},
+			{"author_name": "C", "rating": 4, "text": "服務好", "relative_time_description": "1 個月前"},
+			{"author_name": "D", "rating": 3, "text": "普通", "relative_time_description": "2 個月前"}
+		],
+		"photos": [{"photo_reference": "photo-1", "width": 1200, "height": 800, "html_attributions": ["<a>A</a>"]}]
+	}
+}`
+
+func TestGooglePlaceDetails(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		if r.URL.Query().Get("place_id") == "ChIJmissingPlace" {
+			w.Write([]byte(`{"status": "NOT_FOUND"}`))
+			return
+		}
+		w.Write([]byte(placeDetailsJSON))
+	}))
+	defer server.Close()
+	places := &GooglePlacesService{client: server.Client(), apiKey: "key", baseURL: server.URL, boundaries: BundledBoundaries()}
+
+	details, err := places.PlaceDetails("ChIJdinTaiFung101")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(query, "fields=") || !strings.Contains(query, "language=zh-TW") {
+		t.Errorf("request query = %s", query)
+	}
+	if details.Phone != "02 8101 7799" || details.Website == "" || len(details.AddressComponents) != 2 {
+		t.Errorf("details = %+v", details)
+	}
+	if details.Reviews.Rating != 4.4 || details.Reviews.Total != 12345 || len(details.Reviews.Recent) != maxPlaceReviews {
+		t.Errorf("reviews = %+v", details.Reviews)
+	}
+	if len(details.Photos) != 1 || details.Photos[0].Reference != "photo-1" {
+		t.Errorf("photos = %+v", details.Photos)
+	}
+	saturday := time.Date(2024, 6, 1, 12, 0, 0, 0, TaipeiTime)
+	if got := details.OpeningHours.Today(saturday); got != "星期六: 10:30 – 21:30" {
+		t.Errorf("Today() on a Saturday = %q", got)
+	}
+	// 20:00 UTC on Saturday is already Sunday in Taiwan
+	if got := details.OpeningHours.Today(time.Date(2024, 6, 1, 20, 0, 0, 0, time.UTC)); got != "星期日: 10:30 – 21:00" {
+		t.Errorf("Today() early on Sunday in Taiwan = %q", got)
+	}
+
+	if _, err := places.PlaceDetails("ChIJmissingPlace"); !errors.Is(err, ErrPlaceNotFound) {
+		t.Errorf("NOT_FOUND should be ErrPlaceNotFound, got %v", err)
+	}
+	if _, err := places.PlaceDetails("../etc"); !errors.Is(err, ErrInvalidPlaceID) {
+		t.Errorf("a malformed ID should be ErrInvalidPlaceID, got %v", err)
+	}
+}
+
+func TestPlaceDetailsCache(t *testing.T) {
+	cache := NewPlaceDetailsCache(time.Hour, 2)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	var lookups atomic.Int32
+	lookup := func(placeID string) (*PlaceDetails, error) {
+		lookups.Add(1)
+		if placeID == "broken" {
+			return nil, errors.New("google is down")
+		}
+		return &PlaceDetails{PlaceID: placeID}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		if details, err := cache.Resolve("a", lookup); err != nil || details.PlaceID != "a" {
+			t.Fatalf("Resolve() = %+v, %v", details, err)
+		}
+	}
+	if lookups.Load() != 1 {
+		t.Errorf("a cached place should not be looked up again, %d lookups", lookups.Load())
+	}
+
+	cache.Resolve("broken", lookup)
+	cache.Resolve("broken", lookup)
+	if lookups.Load() != 3 {
+		t.Errorf("errors should not be cached, %d lookups", lookups.Load())
+	}
+
+	now = now.Add(time.Minute)
+	cache.Resolve("b", lookup)
+	cache.Resolve("c", lookup) // full: drops "a", the closest to expiry
+	if cache.Len() != 2 {
+		t.Errorf("cache holds %d places, want 2", cache.Len())
+	}
+	cache.Resolve("a", lookup)
+	if lookups.Load() != 6 {
+		t.Errorf("the evicted place should be looked up again, %d lookups", lookups.Load())
+	}
+
+	now = now.Add(2 * time.Hour)
+	cache.Resolve("c", lookup)
+	if lookups.Load() != 7 {
+		t.Errorf("an expired place should be looked up again, %d lookups", lookups.Load())
+	}
+}
+
+func TestPlaceDetailsCacheCoalesces(t *testing.T) {
+	cache := NewPlaceDetailsCache(time.Hour, 0)
+	release := make(chan struct{})
+	var lookups atomic.Int32
+	lookup := func(placeID string) (*PlaceDetails, error) {
+		lookups.Add(1)
+		<-release
+		return &PlaceDetails{PlaceID: placeID}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache.Resolve("a", lookup)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if lookups.Load() != 1 {
+		t.Errorf("concurrent lookups of one place should share a call, %d lookups", lookups.Load())
+	}
+}
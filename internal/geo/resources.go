@@ -0,0 +1,63 @@
+package geo
+
+import (
+	"intelligent-spatial-platform/internal/cluster"
+	"intelligent-spatial-platform/internal/media"
+	"intelligent-spatial-platform/internal/routing"
+	"intelligent-spatial-platform/internal/segment"
+	"intelligent-spatial-platform/internal/tiles"
+)
+
+// Resources is the reference data shared by the services. main loads it once
+// from the environment and passes it to each service constructor; tests build
+// their own.
+type Resources struct {
+	// Boundaries answers land, territory and admin area lookups
+	Boundaries *Boundaries
+
+	// Gazetteer resolves and finds place names
+	Gazetteer *Gazetteer
+
+	// Segmenter splits commands into words and place names; see NewPlaceSegmenter
+	Segmenter *segment.Segmenter
+
+	// GeocodeCache keeps geocoding answers; nil turns caching off
+	GeocodeCache *GeocodeCache
+
+	// PlaceDetailsCache keeps Google place details for follow-up questions; nil
+	// turns caching off
+	PlaceDetailsCache *PlaceDetailsCache
+
+	// Roads is the road network for routes and isochrones; nil means routes are
+	// unavailable and isochrones are estimated
+	Roads *routing.Graph
+
+	// TileCache keeps rendered vector tiles; writers invalidate it. Nil turns
+	// tile caching off.
+	TileCache *tiles.Cache
+
+	// Media stores and signs uploaded historical site media; nil turns uploads off
+	Media *media.Service
+
+	// Clusters groups map points per zoom; writers keep it current. Nil turns
+	// clustering off.
+	Clusters *cluster.Index
+}
+
+// WithDefaults fills unset reference data with the bundled copies
+func (r Resources) WithDefaults() Resources {
+	if r.Segmenter == nil {
+		if r.Gazetteer == nil {
+			r.Segmenter = BundledSegmenter()
+		} else {
+			r.Segmenter = NewPlaceSegmenter(r.Gazetteer, nil)
+		}
+	}
+	if r.Boundaries == nil {
+		r.Boundaries = BundledBoundaries()
+	}
+	if r.Gazetteer == nil {
+		r.Gazetteer = BundledGazetteer()
+	}
+	return r
+}
@@ -0,0 +1,219 @@
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// landmarkRadius (m) is how far the nearest landmark may be
+	landmarkRadius = 2000.0
+
+	// historicalSiteRadius (m) is how far the nearest historical site may be
+	historicalSiteRadius = 5000.0
+)
+
+// ErrOutsideTaiwan is returned for coordinates outside every county
+var ErrOutsideTaiwan = errors.New("coordinate is outside Taiwan")
+
+// NearbyPlace is a named place near a reverse-geocoded point
+type NearbyPlace struct {
+	ID        uint    `json:"id,omitempty"`
+	Name      string  `json:"name"`
+	Type      string  `json:"type,omitempty"`
+	Address   string  `json:"address,omitempty"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Distance  float64 `json:"distance"` // meters
+	Source    string  `json:"source"`   // database, gazetteer
+}
+
+// ReverseGeocodeResult describes what is at a coordinate
+type ReverseGeocodeResult struct {
+	Latitude       float64      `json:"latitude"`
+	Longitude      float64      `json:"longitude"`
+	County         string       `json:"county"`
+	CountyEn       string       `json:"countyEn,omitempty"`
+	District       string       `json:"district,omitempty"`
+	OnLand         bool         `json:"onLand"`
+	Road           string       `json:"road,omitempty"`    // nearest road, from Google
+	Address        string       `json:"address,omitempty"` // formatted address
+	Landmark       *NearbyPlace `json:"landmark,omitempty"`
+	HistoricalSite *NearbyPlace `json:"historicalSite,omitempty"`
+	Sources        []string     `json:"sources"` // boundaries, database, gazetteer, google_geocoding
+	Warnings       []string     `json:"warnings,omitempty"`
+}
+
+// ReverseGeocoder combines the admin polygons, saved places, the gazetteer and,
+// when configured, Google Geocoding
+type ReverseGeocoder struct {
+	db         *gorm.DB
+	google     *GoogleGeocodingClient
+	boundaries *Boundaries
+	gazetteer  *Gazetteer
+}
+
+// NewReverseGeocoder returns a reverse geocoder; db and google may be nil
+func NewReverseGeocoder(db *gorm.DB, google *GoogleGeocodingClient, resources Resources) *ReverseGeocoder {
+	resources = resources.WithDefaults()
+	return &ReverseGeocoder{db: db, google: google, boundaries: resources.Boundaries, gazetteer: resources.Gazetteer}
+}
+
+// Reverse returns the county, district, nearest road or landmark and nearest
+// historical site for a coordinate. Only the admin lookup is required; the other
+// sources add detail when available and record a warning when they fail.
+func (r *ReverseGeocoder) Reverse(latitude, longitude float64) (*ReverseGeocodeResult, error) {
+	area := r.boundaries.Lookup(latitude, longitude)
+	if area == nil {
+		return nil, ErrOutsideTaiwan
+	}
+
+	result := &ReverseGeocodeResult{
+		Latitude:  latitude,
+		Longitude: longitude,
+		County:    area.County,
+		CountyEn:  area.CountyEn,
+		District:  area.District,
+		OnLand:    area.OnLand,
+		Sources:   []string{"boundaries"},
+	}
+
+	if r.db != nil {
+		if landmark, err := r.nearestLocation(latitude, longitude); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("locations: %v", err))
+		} else if landmark != nil {
+			result.Landmark = landmark
+			result.addSource(ProviderDatabase)
+		}
+
+		if site, err := r.nearestHistoricalSite(latitude, longitude); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("historical sites: %v", err))
+		} else if site != nil {
+			result.HistoricalSite = site
+			result.addSource(ProviderDatabase)
+		}
+	}
+
+	if place, distance := r.gazetteer.Nearest(latitude, longitude, landmarkRadius); place != nil &&
+		(result.Landmark == nil || distance < result.Landmark.Distance) {
+		result.Landmark = &NearbyPlace{
+			ID:        place.ID,
+			Name:      place.Name,
+			Type:      place.Type,
+			Address:   place.County + place.District,
+			Latitude:  place.Latitude,
+			Longitude: place.Longitude,
+			Distance:  distance,
+			Source:    ProviderGazetteer,
+		}
+		result.addSource(ProviderGazetteer)
+	}
+
+	if r.google != nil {
+		if address, err := r.google.Reverse(latitude, longitude); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("google geocoding: %v", err))
+		} else {
+			result.Road = address.Road
+			result.Address = address.FormattedAddress
+			result.addSource(ProviderGoogleGeocoding)
+		}
+	}
+
+	if result.Address == "" {
+		result.Address = result.County + result.District + result.Road
+	}
+
+	return result, nil
+}
+
+func (r *ReverseGeocodeResult) addSource(source string) {
+	if !containsString(r.Sources, source) {
+		r.Sources = append(r.Sources, source)
+	}
+}
+
+// Summary describes the place in one Chinese sentence, for speech and as a fallback
+// when the AI is unavailable
+func (r *ReverseGeocodeResult) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "你在%s%s", r.County, r.District)
+	if r.Road != "" {
+		fmt.Fprintf(&b, "的%s附近", r.Road)
+	}
+	if !r.OnLand {
+		b.WriteString("（海上）")
+	}
+	if r.Landmark != nil {
+		fmt.Fprintf(&b, "，靠近%s（約%s）", r.Landmark.Name, FormatDistance(r.Landmark.Distance))
+	}
+	if r.HistoricalSite != nil {
+		fmt.Fprintf(&b, "，最近的古蹟是%s（約%s）", r.HistoricalSite.Name, FormatDistance(r.HistoricalSite.Distance))
+	}
+	b.WriteString("。")
+	return b.String()
+}
+
+func (r *ReverseGeocoder) nearestLocation(latitude, longitude float64) (*NearbyPlace, error) {
+	var row LocationWithDistance
+	err := r.db.Raw(`
+		SELECT id, name, type, address, latitude, longitude, ST_Distance(geog, ST_GeogFromText(?)) AS distance
+		FROM locations
+		WHERE ST_DWithin(geog, ST_GeogFromText(?), ?)
+		ORDER BY distance
+		LIMIT 1
+	`, wktPoint(latitude, longitude), wktPoint(latitude, longitude), landmarkRadius).Scan(&row).Error
+	if err != nil || row.ID == 0 {
+		return nil, err
+	}
+
+	return &NearbyPlace{
+		ID:        row.ID,
+		Name:      row.Name,
+		Type:      row.Type,
+		Address:   row.Address,
+		Latitude:  row.Latitude,
+		Longitude: row.Longitude,
+		Distance:  row.Distance,
+		Source:    ProviderDatabase,
+	}, nil
+}
+
+func (r *ReverseGeocoder) nearestHistoricalSite(latitude, longitude float64) (*NearbyPlace, error) {
+	var row struct {
+		ID        uint
+		Name      string
+		Address   string
+		Latitude  float64
+		Longitude float64
+		Distance  float64
+	}
+	err := r.db.Raw(`
+		SELECT id, name, address, latitude, longitude, ST_Distance(geog, ST_GeogFromText(?)) AS distance
+		FROM historical_sites
+		WHERE is_active = true
+		AND ST_DWithin(geog, ST_GeogFromText(?), ?)
+		ORDER BY distance
+		LIMIT 1
+	`, wktPoint(latitude, longitude), wktPoint(latitude, longitude), historicalSiteRadius).Scan(&row).Error
+	if err != nil || row.ID == 0 {
+		return nil, err
+	}
+
+	return &NearbyPlace{
+		ID:        row.ID,
+		Name:      row.Name,
+		Type:      "historical_site",
+		Address:   row.Address,
+		Latitude:  row.Latitude,
+		Longitude: row.Longitude,
+		Distance:  row.Distance,
+		Source:    ProviderDatabase,
+	}, nil
+}
+
+func wktPoint(latitude, longitude float64) string {
+	return fmt.Sprintf("POINT(%f %f)", longitude, latitude)
+}
@@ -0,0 +1,82 @@
+package geo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestGoogleGeocoding(t *testing.T, status int, body string) *GoogleGeocodingClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("latlng") == "" {
+			t.Errorf("missing latlng in %s", r.URL)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return &GoogleGeocodingClient{client: server.Client(), apiKey: "test", baseURL: server.URL}
+}
+
+func TestReverseGeocodeNearTaipei101(t *testing.T) {
+	google := newTestGoogleGeocoding(t, http.StatusOK, `{"status":"OK","results":[
+		{"formatted_address":"110台灣臺北市信義區信義路五段7號","place_id":"g1",
+		 "address_components":[{"long_name":"7","types":["street_number"]},{"long_name":"信義路五段","types":["route"]}]}
+	]}`)
+
+	result, err := NewReverseGeocoder(nil, google, Resources{}).Reverse(25.0345, 121.5640)
+	if err != nil {
+		t.Fatalf("Reverse() error = %v", err)
+	}
+	if result.County != "臺北市" || result.District != "信義區" {
+		t.Errorf("admin area = %s %s", result.County, result.District)
+	}
+	if result.Road != "信義路五段" || !strings.Contains(result.Address, "信義路五段7號") {
+		t.Errorf("road = %q, address = %q", result.Road, result.Address)
+	}
+	if result.Landmark == nil || result.Landmark.Name != "臺北101" || result.Landmark.Distance > 200 {
+		t.Errorf("landmark = %+v", result.Landmark)
+	}
+	for _, source := range []string{"boundaries", ProviderGazetteer, ProviderGoogleGeocoding} {
+		if !containsString(result.Sources, source) {
+			t.Errorf("sources %v missing %s", result.Sources, source)
+		}
+	}
+
+	summary := result.Summary()
+	for _, want := range []string{"臺北市信義區", "信義路五段", "臺北101"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("Summary() = %q, missing %s", summary, want)
+		}
+	}
+}
+
+func TestReverseGeocodeWithoutOptionalSources(t *testing.T) {
+	google := newTestGoogleGeocoding(t, http.StatusOK, `{"status":"REQUEST_DENIED","results":[]}`)
+
+	// Rural Taitung: no landmark nearby, Google refuses
+	result, err := NewReverseGeocoder(nil, google, Resources{}).Reverse(22.9, 121.1)
+	if err != nil {
+		t.Fatalf("Reverse() error = %v", err)
+	}
+	if result.County != "臺東縣" {
+		t.Errorf("county = %s", result.County)
+	}
+	if result.Landmark != nil || result.Road != "" {
+		t.Errorf("unexpected detail: %+v", result)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "REQUEST_DENIED") {
+		t.Errorf("warnings = %v", result.Warnings)
+	}
+	if result.Address != result.County+result.District {
+		t.Errorf("address fallback = %q", result.Address)
+	}
+}
+
+func TestReverseGeocodeOutsideTaiwan(t *testing.T) {
+	if _, err := NewReverseGeocoder(nil, nil, Resources{}).Reverse(35.68, 139.77); !errors.Is(err, ErrOutsideTaiwan) {
+		t.Errorf("Reverse(Tokyo) error = %v, want ErrOutsideTaiwan", err)
+	}
+}
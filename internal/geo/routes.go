@@ -0,0 +1,238 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"gorm.io/gorm"
+
+	"intelligent-spatial-platform/internal/geofile"
+	"intelligent-spatial-platform/internal/routing"
+)
+
+// MaxRouteStops limits how many points one route may pass through
+const MaxRouteStops = 25
+
+// routeColumns selects the geometry as GeoJSON alongside the plain columns
+const routeColumns = "id, name, description, profile, start_lat, start_lng, end_lat, end_lng, distance, duration, created_at, updated_at, ST_AsGeoJSON(geom) AS geometry"
+
+// CalculateRoute finds the fastest road route for profile through stops, in
+// order, on the loaded road network and saves it with its waypoints
+func (s *Service) CalculateRoute(profile routing.Profile, stops []Waypoint, name string) (*Route, error) {
+	if len(stops) < 2 || len(stops) > MaxRouteStops {
+		return nil, fmt.Errorf("a route needs between 2 and %d points", MaxRouteStops)
+	}
+
+	graph := s.resources.Roads
+	if graph == nil {
+		return nil, routing.ErrNoRoadNetwork
+	}
+
+	points := make([]routing.LatLng, len(stops))
+	for i, stop := range stops {
+		points[i] = routing.LatLng{Latitude: stop.Latitude, Longitude: stop.Longitude}
+	}
+	path, err := graph.Route(profile, points)
+	if err != nil {
+		return nil, err
+	}
+
+	route, geometry, err := newRoute(profile, stops, name, path)
+	if err != nil {
+		return nil, err
+	}
+	return s.saveRoute(route, geometry)
+}
+
+// ImportRoute saves the first route of an uploaded document, or its first track
+// when it has no routes. The document must already be validated.
+func (s *Service) ImportRoute(doc *geofile.Document, profile routing.Profile) (*Route, error) {
+	route, geometry, err := newImportedRoute(doc, profile)
+	if err != nil {
+		return nil, err
+	}
+	return s.saveRoute(route, geometry)
+}
+
+// saveRoute stores a route, its waypoints and its LineString geometry
+func (s *Service) saveRoute(route *Route, geometry GeoJSON) (*Route, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Geom").Create(route).Error; err != nil {
+			return err
+		}
+		return tx.Exec(
+			"UPDATE routes SET geom = ST_SetSRID(ST_GeomFromGeoJSON(?), 4326)::geography WHERE id = ?",
+			string(geometry), route.ID,
+		).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetRoute(route.ID)
+}
+
+// GetRoute returns a saved route with its geometry and waypoints
+func (s *Service) GetRoute(id uint) (*Route, error) {
+	var route Route
+	err := s.db.Model(&Route{}).Select(routeColumns).
+		Preload("Waypoints", func(db *gorm.DB) *gorm.DB { return db.Order(`"order"`) }).
+		First(&route, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &route, nil
+}
+
+// ExportRoute writes a saved route, with its waypoints, to an export file
+func ExportRoute(w geofile.Writer, route *Route) error {
+	var line struct {
+		Coordinates [][]float64 `json:"coordinates"`
+	}
+	if len(route.Geometry) > 0 {
+		if err := json.Unmarshal(route.Geometry, &line); err != nil {
+			return fmt.Errorf("invalid route geometry: %v", err)
+		}
+	}
+
+	for _, waypoint := range route.Waypoints {
+		err := w.WriteWaypoint(geofile.Point{Latitude: waypoint.Latitude, Longitude: waypoint.Longitude, Name: waypoint.Name})
+		if err != nil {
+			return err
+		}
+	}
+
+	export := &geofile.Line{
+		Name:        route.Name,
+		Description: route.Description,
+		Type:        route.Profile,
+		Properties: map[string]interface{}{
+			"distance": route.Distance,
+			"duration": route.Duration,
+		},
+	}
+	for _, position := range line.Coordinates {
+		if len(position) >= 2 {
+			export.Points = append(export.Points, geofile.Point{Latitude: position[1], Longitude: position[0]})
+		}
+	}
+	return w.WriteRoute(export)
+}
+
+// newImportedRoute turns an uploaded route or track into a Route. Distance is
+// measured along the line and duration comes from track times, when it has them.
+func newImportedRoute(doc *geofile.Document, profile routing.Profile) (*Route, GeoJSON, error) {
+	var line *geofile.Line
+	switch {
+	case len(doc.Routes) > 0:
+		line = &doc.Routes[0]
+	case len(doc.Tracks) > 0:
+		line = &doc.Tracks[0]
+	default:
+		return nil, nil, fmt.Errorf("the file has no route or track")
+	}
+	if len(line.Points) < 2 {
+		return nil, nil, fmt.Errorf("a route needs at least 2 points")
+	}
+
+	coordinates := make([][2]float64, len(line.Points))
+	distance := 0.0
+	for i, point := range line.Points {
+		coordinates[i] = [2]float64{point.Longitude, point.Latitude}
+		if i > 0 {
+			previous := line.Points[i-1]
+			distance += calculateDistance(previous.Latitude, previous.Longitude, point.Latitude, point.Longitude)
+		}
+	}
+	geometry, err := json.Marshal(struct {
+		Type        string       `json:"type"`
+		Coordinates [][2]float64 `json:"coordinates"`
+	}{"LineString", coordinates})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Stops are the file's waypoints, or else the two ends of the line
+	stops := doc.Waypoints
+	if len(stops) < 2 || len(stops) > MaxRouteStops {
+		stops = []geofile.Point{line.Points[0], line.Points[len(line.Points)-1]}
+	}
+
+	first, last := line.Points[0], line.Points[len(line.Points)-1]
+	route := &Route{
+		Name:        firstNonEmpty(line.Name, doc.Metadata.Name, "Imported route"),
+		Description: firstNonEmpty(line.Description, doc.Metadata.Description, fmt.Sprintf("imported %s route, %d points", profile, len(line.Points))),
+		Profile:     profile.String(),
+		StartLat:    first.Latitude,
+		StartLng:    first.Longitude,
+		EndLat:      last.Latitude,
+		EndLng:      last.Longitude,
+		Distance:    math.Round(distance*10) / 10,
+		Geometry:    GeoJSON(geometry),
+	}
+	if !line.Start.IsZero() && line.End.After(line.Start) {
+		route.Duration = int(math.Round(line.End.Sub(line.Start).Seconds()))
+	}
+	for i, stop := range stops {
+		route.Waypoints = append(route.Waypoints, Waypoint{
+			Latitude:  stop.Latitude,
+			Longitude: stop.Longitude,
+			Order:     i,
+			Name:      stop.Name,
+		})
+	}
+	return route, GeoJSON(geometry), nil
+}
+
+// newRoute turns a computed path into a Route and its LineString geometry
+func newRoute(profile routing.Profile, stops []Waypoint, name string, path *routing.Path) (*Route, GeoJSON, error) {
+	first, last := stops[0], stops[len(stops)-1]
+	if name == "" {
+		name = fmt.Sprintf("Route from (%.6f, %.6f) to (%.6f, %.6f)", first.Latitude, first.Longitude, last.Latitude, last.Longitude)
+	}
+
+	// A LineString needs two positions even when both points snap to the same spot
+	coordinates := path.Coordinates()
+	if len(coordinates) == 1 {
+		coordinates = append(coordinates, coordinates[0])
+	}
+	geometry, err := json.Marshal(struct {
+		Type        string       `json:"type"`
+		Coordinates [][2]float64 `json:"coordinates"`
+	}{"LineString", coordinates})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	route := &Route{
+		Name:        name,
+		Description: fmt.Sprintf("%s route, %d stops", profile, len(stops)),
+		Profile:     profile.String(),
+		StartLat:    first.Latitude,
+		StartLng:    first.Longitude,
+		EndLat:      last.Latitude,
+		EndLng:      last.Longitude,
+		Distance:    math.Round(path.Distance*10) / 10,
+		Duration:    int(math.Round(path.Duration)),
+		Geometry:    GeoJSON(geometry),
+	}
+	for i, stop := range stops {
+		route.Waypoints = append(route.Waypoints, Waypoint{
+			Latitude:  stop.Latitude,
+			Longitude: stop.Longitude,
+			Order:     i,
+			Name:      stop.Name,
+		})
+	}
+	return route, GeoJSON(geometry), nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package geo
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"intelligent-spatial-platform/internal/geofile"
+	"intelligent-spatial-platform/internal/routing"
+)
+
+func TestNewRouteFromPath(t *testing.T) {
+	builder := routing.NewBuilder()
+	err := builder.ReadGeoJSON(strings.NewReader(`{"type":"FeatureCollection","features":[
+		{"type":"Feature","properties":{"highway":"residential"},
+		 "geometry":{"type":"LineString","coordinates":[[121.560,25.030],[121.562,25.030],[121.562,25.032]]}}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stops := []Waypoint{
+		{Latitude: 25.030, Longitude: 121.560, Name: "起點"},
+		{Latitude: 25.032, Longitude: 121.562, Name: "終點"},
+	}
+	path, err := graph.Route(routing.Car, []routing.LatLng{{Latitude: 25.030, Longitude: 121.560}, {Latitude: 25.032, Longitude: 121.562}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	route, geometry, err := newRoute(routing.Car, stops, "", path)
+	if err != nil {
+		t.Fatalf("newRoute() error = %v", err)
+	}
+	// about 202 m east and 222 m north at 30 km/h
+	if route.Distance < 420 || route.Distance > 426 || route.Duration != int(route.Distance/(30/3.6)+0.5) {
+		t.Errorf("distance = %v m, duration = %v s", route.Distance, route.Duration)
+	}
+	if route.Profile != "car" || route.EndLat != 25.032 || !strings.HasPrefix(route.Name, "Route from") {
+		t.Errorf("route = %+v", route)
+	}
+	if len(route.Waypoints) != 2 || route.Waypoints[1].Order != 1 || route.Waypoints[1].Name != "終點" {
+		t.Errorf("waypoints = %+v", route.Waypoints)
+	}
+
+	var line struct {
+		Type        string       `json:"type"`
+		Coordinates [][2]float64 `json:"coordinates"`
+	}
+	if err := json.Unmarshal(geometry, &line); err != nil {
+		t.Fatal(err)
+	}
+	if line.Type != "LineString" || len(line.Coordinates) != 3 || line.Coordinates[1] != [2]float64{121.562, 25.030} {
+		t.Errorf("geometry = %s", geometry)
+	}
+}
+
+func TestNewImportedRoute(t *testing.T) {
+	start := time.Date(2024, 3, 1, 6, 30, 0, 0, time.UTC)
+	doc := &geofile.Document{
+		Metadata: geofile.Metadata{Name: "晨跑"},
+		Tracks: []geofile.Line{{
+			Start: start,
+			End:   start.Add(10 * time.Minute),
+			Points: []geofile.Point{
+				{Latitude: 25.030, Longitude: 121.560, Time: start},
+				{Latitude: 25.030, Longitude: 121.562},
+				{Latitude: 25.032, Longitude: 121.562, Time: start.Add(10 * time.Minute)},
+			},
+		}},
+	}
+
+	route, geometry, err := newImportedRoute(doc, routing.Foot)
+	if err != nil {
+		t.Fatalf("newImportedRoute() error = %v", err)
+	}
+	if route.Name != "晨跑" || route.Profile != "foot" || route.Duration != 600 {
+		t.Errorf("route = %+v", route)
+	}
+	if route.Distance < 420 || route.Distance > 426 {
+		t.Errorf("distance = %v m", route.Distance)
+	}
+	if len(route.Waypoints) != 2 || route.Waypoints[1].Latitude != 25.032 {
+		t.Errorf("waypoints = %+v", route.Waypoints)
+	}
+
+	var buf bytes.Buffer
+	w, err := geofile.NewWriter(&buf, geofile.GPX, geofile.Metadata{Name: route.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportRoute(w, route); err != nil {
+		t.Fatalf("ExportRoute() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	exported, err := geofile.Read(&buf, geofile.GPX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exported.Waypoints) != 2 || len(exported.Routes) != 1 || len(exported.Routes[0].Points) != 3 {
+		t.Errorf("exported = %+v (geometry %s)", exported, geometry)
+	}
+
+	if _, _, err := newImportedRoute(&geofile.Document{Waypoints: doc.Tracks[0].Points}, routing.Foot); err == nil {
+		t.Error("a document with only waypoints should not import as a route")
+	}
+}
@@ -0,0 +1,43 @@
+package geo
+
+import (
+	"sync"
+
+	"intelligent-spatial-platform/internal/segment"
+)
+
+var (
+	bundledSegmenter     *segment.Segmenter
+	bundledSegmenterOnce sync.Once
+)
+
+// BundledSegmenter returns a segmenter over the bundled gazetteer, built on first use
+func BundledSegmenter() *segment.Segmenter {
+	bundledSegmenterOnce.Do(func() {
+		bundledSegmenter = NewPlaceSegmenter(BundledGazetteer(), nil)
+	})
+	return bundledSegmenter
+}
+
+// NewPlaceSegmenter returns a segmenter that knows the command words, every name
+// in gazetteer and the extra words (shops, landmarks missing from the gazetteer)
+// in userDict, which may be nil
+func NewPlaceSegmenter(gazetteer *Gazetteer, userDict *segment.Dictionary) *segment.Segmenter {
+	dict := segment.BaseDictionary()
+	gazetteer.addToDictionary(dict)
+	if userDict != nil {
+		dict.Merge(userDict)
+	}
+	return segment.New(dict)
+}
+
+// addToDictionary adds every place name, alias and romanization as a place word;
+// better-known places get a higher frequency
+func (g *Gazetteer) addToDictionary(dict *segment.Dictionary) {
+	for i := range g.places {
+		freq := 5000 + float64(g.places[i].Rank)*100
+		for _, name := range g.places[i].Names() {
+			dict.Add(name, segment.KindPlace, freq)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package geo
+
+import (
+	"strings"
+	"testing"
+
+	"intelligent-spatial-platform/internal/segment"
+)
+
+func TestPlaceSegmenterUsesGazetteer(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"我想去那個很有名的鼎泰豐", "鼎泰豐"},
+		{"去嘉義市吃雞肉飯後去阿里山", "嘉義市"},
+		{"帶我去台北101", "台北101"},
+		{"take me to Taipei 101 please", "Taipei 101"},
+		{"前往高雄中山路", "高雄中山路"},
+	}
+
+	for _, tt := range tests {
+		analysis := BundledSegmenter().Analyze(tt.text)
+		if len(analysis.Places) == 0 || analysis.Places[0].Text != tt.want {
+			t.Errorf("Analyze(%q) places = %+v, want %s first", tt.text, analysis.Places, tt.want)
+		}
+	}
+}
+
+func TestPlaceSegmenterUserDictionary(t *testing.T) {
+	dict := segment.NewDictionary()
+	if err := dict.LoadUserDictionary(strings.NewReader("阿宗麵線\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	analysis := NewPlaceSegmenter(BundledGazetteer(), dict).Analyze("阿宗麵線好吃嗎")
+	if len(analysis.Places) != 1 || !analysis.Places[0].Known {
+		t.Errorf("expected 阿宗麵線 as a known place, got %+v", analysis.Places)
+	}
+}
+
+func BenchmarkPlaceSegmenterAnalyze(b *testing.B) {
+	s := BundledSegmenter()
+	text := "我想去那個很有名的鼎泰豐，然後去嘉義市吃雞肉飯後騎車去阿里山看日出"
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s.Analyze(text)
+	}
+}
@@ -3,26 +3,51 @@ package geo
 import (
 	"fmt"
 	"math"
+	"strconv"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"intelligent-spatial-platform/internal/cluster"
+	"intelligent-spatial-platform/internal/tiles"
 )
 
 type Service struct {
 	db        *gorm.DB
 	geocoding *GeocodingService
+	reverse   *ReverseGeocoder
+	places    *GooglePlacesService // nil without an API key
+	resources Resources
 }
 
-func NewService(db *gorm.DB) *Service {
+func NewService(db *gorm.DB, resources Resources) *Service {
+	resources = resources.WithDefaults()
+
 	// Initialize geocoding service
-	geocoding, err := NewGeocodingService()
+	geocoding, err := NewGeocodingServiceWithDB(db, resources)
 	if err != nil {
 		fmt.Printf("Warning: Failed to initialize geocoding service: %v\n", err)
 		geocoding = nil
 	}
 
+	// Google reverse geocoding adds road names when a key is configured
+	googleGeocoding, err := NewGoogleGeocodingClient()
+	if err != nil {
+		googleGeocoding = nil
+	}
+
+	// Place details need Google Places; without a key they are unavailable
+	places, err := NewGooglePlacesService(resources.Boundaries)
+	if err != nil {
+		places = nil
+	}
+
 	return &Service{
 		db:        db,
 		geocoding: geocoding,
+		reverse:   NewReverseGeocoder(db, googleGeocoding, resources),
+		places:    places,
+		resources: resources,
 	}
 }
 
@@ -31,14 +56,23 @@ func (s *Service) GetGeocoding() *GeocodingService {
 	return s.geocoding
 }
 
-func (s *Service) GetAllLocations() ([]Location, error) {
-	var locations []Location
-	err := s.db.Find(&locations).Error
-	return locations, err
+// GeocodeCache returns the shared geocoding cache, or nil when caching is off
+func (s *Service) GeocodeCache() *GeocodeCache {
+	return s.resources.GeocodeCache
 }
 
 func (s *Service) CreateLocation(location *Location) error {
-	return s.db.Create(location).Error
+	if err := s.db.Create(location).Error; err != nil {
+		return err
+	}
+	s.resources.TileCache.Invalidate(tiles.LayerLocations)
+	s.resources.Clusters.Upsert(location.clusterPoint())
+	return nil
+}
+
+// clusterPoint is the location as shown on the clustered map
+func (l *Location) clusterPoint() cluster.Point {
+	return cluster.Point{Layer: tiles.LayerLocations, ID: strconv.FormatUint(uint64(l.ID), 10), Name: l.Name, Latitude: l.Latitude, Longitude: l.Longitude}
 }
 
 func (s *Service) GetLocationByID(id uint) (*Location, error) {
@@ -50,32 +84,20 @@ func (s *Service) GetLocationByID(id uint) (*Location, error) {
 	return &location, nil
 }
 
-func (s *Service) GetHistoricalSites() ([]HistoricalSite, error) {
-	var sites []HistoricalSite
-	err := s.db.Where("is_active = true").Find(&sites).Error
-	return sites, err
-}
-
 func (s *Service) GetNearbyHistoricalSite(lat, lng, radiusMeters float64) (*HistoricalSite, error) {
 	var site HistoricalSite
 
 	query := `
-		SELECT *, ST_Distance(
-			ST_GeogFromText('POINT(' || longitude || ' ' || latitude || ')'),
-			ST_GeogFromText('POINT(' || ? || ' ' || ? || ')')
-		) as distance
+		SELECT *
 		FROM historical_sites
 		WHERE is_active = true
-		AND ST_DWithin(
-			ST_GeogFromText('POINT(' || longitude || ' ' || latitude || ')'),
-			ST_GeogFromText('POINT(' || ? || ' ' || ? || ')'),
-			?
-		)
-		ORDER BY distance
+		AND ST_DWithin(geog, ST_GeogFromText(?), ?)
+		ORDER BY geog <-> ST_GeogFromText(?)
 		LIMIT 1
 	`
 
-	err := s.db.Raw(query, lng, lat, lng, lat, radiusMeters).Scan(&site).Error
+	point := wktPoint(lat, lng)
+	err := s.db.Raw(query, point, radiusMeters, point).Scan(&site).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -92,10 +114,6 @@ func (s *Service) GetNearbyHistoricalSite(lat, lng, radiusMeters float64) (*Hist
 	return &site, nil
 }
 
-func (s *Service) CreateHistoricalSite(site *HistoricalSite) error {
-	return s.db.Create(site).Error
-}
-
 func (s *Service) SearchNearbyLocations(lat, lng, radiusKm float64, locationType string) ([]Location, error) {
 	var locations []Location
 
@@ -105,81 +123,42 @@ func (s *Service) SearchNearbyLocations(lat, lng, radiusKm float64, locationType
 		query = query.Where("type = ?", locationType)
 	}
 
-	query = query.Where(
-		"ST_DWithin(ST_GeogFromText('POINT(' || longitude || ' ' || latitude || ')'), ST_GeogFromText(?), ?)",
-		fmt.Sprintf("POINT(%f %f)", lng, lat), radiusKm*1000,
-	)
+	point := wktPoint(lat, lng)
+	query = query.Where("ST_DWithin(geog, ST_GeogFromText(?), ?)", point, radiusKm*1000)
 
-	query = query.Order(
-		fmt.Sprintf("ST_Distance(ST_GeogFromText('POINT(' || longitude || ' ' || latitude || ')'), ST_GeogFromText('POINT(%f %f)'))", lng, lat),
-	)
+	// <-> orders by distance through the GiST index on geog
+	query = query.Clauses(clause.OrderBy{Expression: clause.Expr{
+		SQL:                "geog <-> ST_GeogFromText(?)",
+		Vars:               []interface{}{point},
+		WithoutParentheses: true,
+	}})
 
 	err := query.Find(&locations).Error
 	return locations, err
 }
 
-func (s *Service) CalculateRoute(startLat, startLng, endLat, endLng float64) (*Route, error) {
-	distance := calculateDistance(startLat, startLng, endLat, endLng)
-	duration := int(distance / 5 * 60) // Assume walking speed of 5 km/h
-
-	route := &Route{
-		Name:        fmt.Sprintf("Route from (%.6f, %.6f) to (%.6f, %.6f)", startLat, startLng, endLat, endLng),
-		Description: "Auto-generated route",
-		StartLat:    startLat,
-		StartLng:    startLng,
-		EndLat:      endLat,
-		EndLng:      endLng,
-		Distance:    distance,
-		Duration:    duration,
-	}
-
-	if err := s.db.Create(route).Error; err != nil {
-		return nil, err
-	}
-
-	return route, nil
-}
-
-func (s *Service) GetGeoFeatures(bounds map[string]float64) ([]GeoFeature, error) {
-	var features []GeoFeature
-
-	var locations []Location
-	err := s.db.Where(
-		"latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?",
-		bounds["south"], bounds["north"], bounds["west"], bounds["east"],
-	).Find(&locations).Error
-
-	if err != nil {
-		return nil, err
-	}
-
-	for _, location := range locations {
-		feature := GeoFeature{
-			Type: "Feature",
-			Properties: map[string]interface{}{
-				"id":      location.ID,
-				"name":    location.Name,
-				"address": location.Address,
-				"type":    location.Type,
-			},
-			Geometry: Geometry{
-				Type:        "Point",
-				Coordinates: []float64{location.Longitude, location.Latitude},
-			},
-		}
-		features = append(features, feature)
+// GeocodeLocation searches for a location using the geocoding service
+func (s *Service) GeocodeLocation(locationName string) (*Location, error) {
+	if s.geocoding == nil {
+		return nil, fmt.Errorf("geocoding service not available")
 	}
 
-	return features, nil
+	return s.geocoding.GeocodeLocation(locationName)
 }
 
-// GeocodeLocation searches for a location using the geocoding service
-func (s *Service) GeocodeLocation(locationName string) (*Location, error) {
+// GeocodeCandidates returns ranked matches for locationName, best first
+func (s *Service) GeocodeCandidates(locationName string) ([]GeocodeCandidate, error) {
 	if s.geocoding == nil {
 		return nil, fmt.Errorf("geocoding service not available")
 	}
 
-	return s.geocoding.GeocodeLocation(locationName)
+	return s.geocoding.GeocodeCandidates(locationName)
+}
+
+// ReverseGeocode describes the county, district, nearest road or landmark and
+// nearest historical site at a coordinate
+func (s *Service) ReverseGeocode(latitude, longitude float64) (*ReverseGeocodeResult, error) {
+	return s.reverse.Reverse(latitude, longitude)
 }
 
 func calculateDistance(lat1, lng1, lat2, lng2 float64) float64 {
@@ -0,0 +1,46 @@
+package geo
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// PointColumn is a geography(Point,4326) column that PostgreSQL generates from
+// a table's latitude and longitude. Distance queries use it with ST_DWithin and
+// <-> so the GiST index applies, instead of building a point for every row.
+const PointColumn = "geog"
+
+// PointTables are the tables MigratePointColumns gives a PointColumn
+var PointTables = []string{"locations", "historical_sites", "items"}
+
+// BoundsCondition selects rows whose point lies in a west, south, east, north
+// box. It goes through the index on the column's geometry, which compares plain
+// longitude and latitude; a geography box would follow great circles.
+const BoundsCondition = "geog::geometry && ST_MakeEnvelope(?, ?, ?, ?, 4326)"
+
+// MigratePointColumns adds PointColumn to each of PointTables, with a GiST
+// index for distances and one on its geometry for bounding boxes. Existing
+// rows are filled in when the column is added; running it again does nothing.
+// Generated columns need PostgreSQL 12 or later.
+func MigratePointColumns(db *gorm.DB, tables ...string) error {
+	if len(tables) == 0 {
+		tables = PointTables
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range tables {
+			statements := []string{
+				fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s geography(Point,4326)
+					GENERATED ALWAYS AS (ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)::geography) STORED`, table, PointColumn),
+				fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%s_%s ON %s USING GIST (%s)", table, PointColumn, table, PointColumn),
+				fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%s_%s_bbox ON %s USING GIST ((%s::geometry))", table, PointColumn, table, PointColumn),
+			}
+			for _, statement := range statements {
+				if err := tx.Exec(statement).Error; err != nil {
+					return fmt.Errorf("failed to index %s: %v", table, err)
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,186 @@
+package geo
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The benchmarks below compare the indexed geog column with the per-row
+// ST_GeogFromText and latitude/longitude ranges the queries used before. They
+// need PostGIS:
+//
+//	POSTGIS_TEST_DSN="host=localhost user=postgres dbname=spatial_test sslmode=disable" \
+//		go test ./internal/geo -run '^$' -bench 'Nearby|Nearest|Bounds'
+//
+// Tables are created in a throwaway schema, so any database will do.
+
+// legacyNearbyLocationsSQL is SearchNearbyLocations before the geog column
+const legacyNearbyLocationsSQL = `
+	SELECT * FROM locations
+	WHERE ST_DWithin(ST_GeogFromText('POINT(' || longitude || ' ' || latitude || ')'), ST_GeogFromText(?), ?)
+	ORDER BY ST_Distance(ST_GeogFromText('POINT(' || longitude || ' ' || latitude || ')'), ST_GeogFromText(?))`
+
+// legacyNearestSiteSQL is GetNearbyHistoricalSite before the geog column
+const legacyNearestSiteSQL = `
+	SELECT *, ST_Distance(
+		ST_GeogFromText('POINT(' || longitude || ' ' || latitude || ')'),
+		ST_GeogFromText(?)
+	) AS distance
+	FROM historical_sites
+	WHERE is_active = true
+	AND ST_DWithin(ST_GeogFromText('POINT(' || longitude || ' ' || latitude || ')'), ST_GeogFromText(?), ?)
+	ORDER BY distance
+	LIMIT 1`
+
+// legacyBoundsSQL is a bounding box query before BoundsCondition
+const legacyBoundsSQL = "SELECT * FROM locations WHERE latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?"
+
+var benchmarkSizes = []int{10_000, 200_000}
+
+// openBenchmarkDB connects to POSTGIS_TEST_DSN and switches to an empty schema
+func openBenchmarkDB(b *testing.B) *gorm.DB {
+	dsn := os.Getenv("POSTGIS_TEST_DSN")
+	if dsn == "" {
+		b.Skip("POSTGIS_TEST_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		b.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		b.Fatal(err)
+	}
+	// One connection, so the search path set below applies to every query
+	sqlDB.SetMaxOpenConns(1)
+	b.Cleanup(func() {
+		db.Exec("DROP SCHEMA IF EXISTS spatial_bench CASCADE")
+		sqlDB.Close()
+	})
+
+	for _, statement := range []string{
+		"CREATE EXTENSION IF NOT EXISTS postgis",
+		"DROP SCHEMA IF EXISTS spatial_bench CASCADE",
+		"CREATE SCHEMA spatial_bench",
+		"SET search_path TO spatial_bench, public",
+	} {
+		if err := db.Exec(statement).Error; err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := db.AutoMigrate(&Location{}, &HistoricalSite{}); err != nil {
+		b.Fatal(err)
+	}
+	if err := MigratePointColumns(db, "locations", "historical_sites"); err != nil {
+		b.Fatal(err)
+	}
+	return db
+}
+
+// seedPlaces fills both tables with count random places across Taiwan's main island
+func seedPlaces(b *testing.B, db *gorm.DB, count int) {
+	b.Helper()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("TRUNCATE locations, historical_sites").Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`INSERT INTO locations (name, latitude, longitude, type, created_at, updated_at)
+			SELECT 'poi ' || i, 21.9 + random() * 3.4, 120.1 + random() * 1.9, 'poi', now(), now()
+			FROM generate_series(1, ?) AS i`, count).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`INSERT INTO historical_sites (name, latitude, longitude, is_active, visit_count, created_at, updated_at)
+			SELECT 'site ' || i, 21.9 + random() * 3.4, 120.1 + random() * 1.9, true, 0, now(), now()
+			FROM generate_series(1, ?) AS i`, count).Error
+	})
+	if err == nil {
+		err = db.Exec("ANALYZE locations, historical_sites").Error
+	}
+	if err != nil {
+		b.Fatal(err)
+	}
+}
+
+func BenchmarkNearbyLocations(b *testing.B) {
+	db := openBenchmarkDB(b)
+	service := &Service{db: db}
+	point := wktPoint(25.0330, 121.5654)
+
+	for _, size := range benchmarkSizes {
+		seedPlaces(b, db, size)
+
+		b.Run(fmt.Sprintf("rows=%d/per-row", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				var locations []Location
+				if err := db.Raw(legacyNearbyLocationsSQL, point, 2000.0, point).Scan(&locations).Error; err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("rows=%d/indexed", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := service.SearchNearbyLocations(25.0330, 121.5654, 2, ""); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkNearestHistoricalSite(b *testing.B) {
+	db := openBenchmarkDB(b)
+	reverse := NewReverseGeocoder(db, nil, Resources{})
+	point := wktPoint(22.9975, 120.2025)
+
+	for _, size := range benchmarkSizes {
+		seedPlaces(b, db, size)
+
+		b.Run(fmt.Sprintf("rows=%d/per-row", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				var site HistoricalSite
+				if err := db.Raw(legacyNearestSiteSQL, point, point, historicalSiteRadius).Scan(&site).Error; err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("rows=%d/indexed", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := reverse.nearestHistoricalSite(22.9975, 120.2025); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkBoundsQuery(b *testing.B) {
+	db := openBenchmarkDB(b)
+	// About 2 km by 2 km around Taipei 101
+	box := &BBox{West: 121.555, South: 25.024, East: 121.575, North: 25.042}
+
+	for _, size := range benchmarkSizes {
+		seedPlaces(b, db, size)
+
+		b.Run(fmt.Sprintf("rows=%d/per-row", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				var locations []Location
+				if err := db.Raw(legacyBoundsSQL, box.South, box.North, box.West, box.East).Scan(&locations).Error; err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("rows=%d/indexed", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				var locations []Location
+				if err := db.Where(BoundsCondition, box.West, box.South, box.East, box.North).Find(&locations).Error; err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package geo
+
+import "time"
+
+// TaipeiTime is Taiwan's time zone, in which opening hours and schedules are
+// written. It falls back to a fixed UTC+8 when the zone database is missing.
+var TaipeiTime = loadTaipeiTime()
+
+func loadTaipeiTime() *time.Location {
+	location, err := time.LoadLocation("Asia/Taipei")
+	if err != nil {
+		return time.FixedZone("CST", 8*60*60)
+	}
+	return location
+}
@@ -0,0 +1,216 @@
+// Package geofile reads and writes routes, waypoints and tracks as GPX 1.1,
+// KML 2.2 and GeoJSON. Writers stream, so a long track never has to be held in
+// memory; readers load the whole document for validation before it is stored.
+package geofile
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strings"
+	"time"
+)
+
+// Format is a supported file format
+type Format string
+
+const (
+	GPX     Format = "gpx"
+	KML     Format = "kml"
+	GeoJSON Format = "geojson"
+)
+
+// Creator names this platform in exported files
+const Creator = "intelligent-spatial-platform"
+
+// ParseFormat accepts gpx, kml, geojson and json, in any case
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "gpx":
+		return GPX, nil
+	case "kml":
+		return KML, nil
+	case "geojson", "json":
+		return GeoJSON, nil
+	}
+	return "", fmt.Errorf("unsupported format: %s (use gpx, kml or geojson)", name)
+}
+
+// DetectFormat guesses the format from a file name, then from a content type
+func DetectFormat(filename, contentType string) (Format, error) {
+	if ext := strings.TrimPrefix(path.Ext(strings.ToLower(filename)), "."); ext != "" {
+		if format, err := ParseFormat(ext); err == nil {
+			return format, nil
+		}
+	}
+
+	contentType = strings.ToLower(contentType)
+	switch {
+	case strings.Contains(contentType, "gpx"):
+		return GPX, nil
+	case strings.Contains(contentType, "kml"):
+		return KML, nil
+	case strings.Contains(contentType, "json"):
+		return GeoJSON, nil
+	}
+	return "", fmt.Errorf("cannot tell the file format; pass ?format=gpx, kml or geojson")
+}
+
+// ContentType is the media type to serve the format with
+func (f Format) ContentType() string {
+	switch f {
+	case GPX:
+		return "application/gpx+xml"
+	case KML:
+		return "application/vnd.google-earth.kml+xml"
+	}
+	return "application/geo+json"
+}
+
+// Extension is the usual file extension, without the dot
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// Point is a position with optional elevation, time and name
+type Point struct {
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	Elevation *float64  `json:"elevation,omitempty"` // meters
+	Time      time.Time `json:"time,omitempty"`
+	Name      string    `json:"name,omitempty"`
+}
+
+// Line is a route or a track. Start and End bound the track times; Properties
+// are written as GeoJSON properties and KML extended data.
+type Line struct {
+	Name        string                 `json:"name,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Type        string                 `json:"type,omitempty"` // e.g. the route profile
+	Start       time.Time              `json:"start,omitempty"`
+	End         time.Time              `json:"end,omitempty"`
+	Properties  map[string]interface{} `json:"properties,omitempty"`
+	Points      []Point                `json:"points"`
+}
+
+// Metadata describes a whole file
+type Metadata struct {
+	Name        string    `json:"name,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Time        time.Time `json:"time,omitempty"`
+}
+
+// Document is everything read from a file
+type Document struct {
+	Metadata  Metadata `json:"metadata"`
+	Waypoints []Point  `json:"waypoints"`
+	Routes    []Line   `json:"routes"`
+	Tracks    []Line   `json:"tracks"`
+}
+
+// Read parses a document in the given format
+func Read(r io.Reader, format Format) (*Document, error) {
+	switch format {
+	case GPX:
+		return readGPX(r)
+	case KML:
+		return readKML(r)
+	case GeoJSON:
+		return readGeoJSON(r)
+	}
+	return nil, fmt.Errorf("unsupported format: %s", format)
+}
+
+// ValidationError points at the element that failed validation
+type ValidationError struct {
+	Element string // e.g. `track "晨跑"`
+	Index   int    // point index, -1 for the element itself
+	Reason  string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("%s: %s", e.Element, e.Reason)
+	}
+	return fmt.Sprintf("%s point %d: %s", e.Element, e.Index, e.Reason)
+}
+
+// Validate checks coordinates, line lengths and track time order. inBounds, when
+// set, rejects points outside the area the platform serves.
+func (d *Document) Validate(inBounds func(latitude, longitude float64) bool) error {
+	if len(d.Waypoints) == 0 && len(d.Routes) == 0 && len(d.Tracks) == 0 {
+		return &ValidationError{Element: "document", Index: -1, Reason: "no waypoints, routes or tracks"}
+	}
+
+	for i, point := range d.Waypoints {
+		if reason := checkPoint(point, inBounds); reason != "" {
+			return &ValidationError{Element: fmt.Sprintf("waypoint %d", i), Index: -1, Reason: reason}
+		}
+	}
+	for i := range d.Routes {
+		if err := d.Routes[i].validate(fmt.Sprintf("route %d", i), inBounds, false); err != nil {
+			return err
+		}
+	}
+	for i := range d.Tracks {
+		if err := d.Tracks[i].validate(fmt.Sprintf("track %d", i), inBounds, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *Line) validate(element string, inBounds func(latitude, longitude float64) bool, timed bool) error {
+	if l.Name != "" {
+		element = fmt.Sprintf("%s %q", element, l.Name)
+	}
+	if len(l.Points) < 2 {
+		return &ValidationError{Element: element, Index: -1, Reason: "needs at least 2 points"}
+	}
+
+	var previous time.Time
+	for i, point := range l.Points {
+		if reason := checkPoint(point, inBounds); reason != "" {
+			return &ValidationError{Element: element, Index: i, Reason: reason}
+		}
+		if timed && !point.Time.IsZero() {
+			if point.Time.Before(previous) {
+				return &ValidationError{Element: element, Index: i, Reason: "time goes backwards"}
+			}
+			previous = point.Time
+		}
+	}
+	return nil
+}
+
+func checkPoint(point Point, inBounds func(latitude, longitude float64) bool) string {
+	switch {
+	case math.IsNaN(point.Latitude) || math.IsNaN(point.Longitude) || math.IsInf(point.Latitude, 0) || math.IsInf(point.Longitude, 0):
+		return "coordinate is not a number"
+	case point.Latitude < -90 || point.Latitude > 90 || point.Longitude < -180 || point.Longitude > 180:
+		return fmt.Sprintf("coordinate (%v, %v) is out of range", point.Latitude, point.Longitude)
+	case point.Elevation != nil && (math.IsNaN(*point.Elevation) || math.IsInf(*point.Elevation, 0)):
+		return "elevation is not a number"
+	case inBounds != nil && !inBounds(point.Latitude, point.Longitude):
+		return fmt.Sprintf("coordinate (%.6f, %.6f) is outside Taiwan", point.Latitude, point.Longitude)
+	}
+	return ""
+}
+
+// parseTime reads an RFC 3339 time; empty text is the zero time
+func parseTime(text string) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, text)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", text)
+	}
+	return t, nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
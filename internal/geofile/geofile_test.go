@@ -0,0 +1,207 @@
+package geofile
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func inTaiwan(latitude, longitude float64) bool {
+	return latitude > 21.8 && latitude < 26.5 && longitude > 118 && longitude < 122.2
+}
+
+func sampleTrack() []Point {
+	start := time.Date(2024, 3, 1, 6, 30, 0, 0, time.UTC)
+	elevation := 12.5
+	return []Point{
+		{Latitude: 25.0330, Longitude: 121.5654, Elevation: &elevation, Time: start},
+		{Latitude: 25.0340, Longitude: 121.5660, Time: start.Add(30 * time.Second)},
+		{Latitude: 25.0352, Longitude: 121.5671, Time: start.Add(75 * time.Second)},
+	}
+}
+
+func writeSample(t *testing.T, format Format) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, format, Metadata{
+		Name: "信義區晨跑 & 路線",
+		Time: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []error{
+		w.WriteWaypoint(Point{Latitude: 25.0340, Longitude: 121.5645, Name: "台北101"}),
+		w.WriteRoute(&Line{
+			Name:       "到象山",
+			Type:       "foot",
+			Properties: map[string]interface{}{"distance": "1200"},
+			Points: []Point{
+				{Latitude: 25.0340, Longitude: 121.5645},
+				{Latitude: 25.0275, Longitude: 121.5765},
+			},
+		}),
+	}
+	track := sampleTrack()
+	steps = append(steps, w.BeginTrack(&Line{Name: "晨跑", Start: track[0].Time, End: track[2].Time}))
+	for _, point := range track {
+		steps = append(steps, w.WriteTrackPoint(point))
+	}
+	steps = append(steps, w.EndTrack(), w.Close())
+	for i, err := range steps {
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+	}
+	return buf.String()
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []Format{GPX, KML, GeoJSON} {
+		t.Run(string(format), func(t *testing.T) {
+			text := writeSample(t, format)
+			doc, err := Read(strings.NewReader(text), format)
+			if err != nil {
+				t.Fatalf("Read() error = %v\n%s", err, text)
+			}
+			if err := doc.Validate(inTaiwan); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+
+			if doc.Metadata.Name != "信義區晨跑 & 路線" {
+				t.Errorf("metadata = %+v", doc.Metadata)
+			}
+			if len(doc.Waypoints) != 1 || doc.Waypoints[0].Name != "台北101" || doc.Waypoints[0].Latitude != 25.0340 {
+				t.Errorf("waypoints = %+v", doc.Waypoints)
+			}
+			if len(doc.Routes) != 1 || doc.Routes[0].Name != "到象山" || doc.Routes[0].Type != "foot" || len(doc.Routes[0].Points) != 2 {
+				t.Fatalf("routes = %+v", doc.Routes)
+			}
+			if format != GPX && doc.Routes[0].Properties["distance"] != "1200" {
+				t.Errorf("route properties = %v", doc.Routes[0].Properties)
+			}
+
+			if len(doc.Tracks) != 1 {
+				t.Fatalf("tracks = %+v", doc.Tracks)
+			}
+			track := doc.Tracks[0]
+			want := sampleTrack()
+			if track.Name != "晨跑" || len(track.Points) != len(want) {
+				t.Fatalf("track = %+v", track)
+			}
+			if !track.Start.Equal(want[0].Time) || !track.End.Equal(want[2].Time) {
+				t.Errorf("track time range = %v - %v", track.Start, track.End)
+			}
+			if e := track.Points[0].Elevation; e == nil || *e != 12.5 {
+				t.Errorf("elevation = %v", e)
+			}
+			if track.Points[2].Longitude != 121.5671 {
+				t.Errorf("last point = %+v", track.Points[2])
+			}
+			// KML keeps only the track's time span, not per-point times
+			if format != KML && !track.Points[1].Time.Equal(want[1].Time) {
+				t.Errorf("point time = %v, want %v", track.Points[1].Time, want[1].Time)
+			}
+		})
+	}
+}
+
+func TestReadGXTrack(t *testing.T) {
+	doc, err := Read(strings.NewReader(`<?xml version="1.0"?>
+<kml xmlns="http://www.opengis.net/kml/2.2" xmlns:gx="http://www.google.com/kml/ext/2.2">
+<Document><name>騎車</name><Folder><Placemark><name>河濱</name>
+  <gx:Track>
+    <when>2024-03-01T06:30:00Z</when><when>2024-03-01T06:31:00Z</when>
+    <gx:coord>121.50 25.05 3</gx:coord><gx:coord>121.51 25.06 4</gx:coord>
+  </gx:Track>
+</Placemark></Folder></Document></kml>`), KML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Metadata.Name != "騎車" || len(doc.Tracks) != 1 {
+		t.Fatalf("doc = %+v", doc)
+	}
+	track := doc.Tracks[0]
+	if track.Name != "河濱" || len(track.Points) != 2 || track.Points[1].Latitude != 25.06 || track.End.Minute() != 31 {
+		t.Errorf("track = %+v", track)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	track := sampleTrack()
+	backwards := append([]Point(nil), track...)
+	backwards[2].Time = track[0].Time.Add(-time.Minute)
+
+	tests := []struct {
+		name string
+		doc  Document
+		want string
+	}{
+		{"empty", Document{}, "no waypoints"},
+		{"outside Taiwan", Document{Waypoints: []Point{{Latitude: 35.68, Longitude: 139.77}}}, "outside Taiwan"},
+		{"out of range", Document{Routes: []Line{{Points: []Point{{Latitude: 95, Longitude: 121}, track[1]}}}}, "out of range"},
+		{"single point", Document{Routes: []Line{{Name: "短", Points: track[:1]}}}, `route 0 "短": needs at least 2 points`},
+		{"time goes backwards", Document{Tracks: []Line{{Points: backwards}}}, "track 0 point 2: time goes backwards"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.doc.Validate(inTaiwan)
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriterOrder(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, GPX, Metadata{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteTrackPoint(Point{}); err != ErrWriteOrder {
+		t.Errorf("point outside a track: error = %v", err)
+	}
+	if err := w.BeginTrack(&Line{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteWaypoint(Point{}); err != ErrWriteOrder {
+		t.Errorf("waypoint inside a track: error = %v", err)
+	}
+	// Close ends the open track
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(buf.String(), "</trkseg>\n  </trk>\n</gpx>\n") {
+		t.Errorf("output = %s", buf.String())
+	}
+	if err := w.WriteRoute(&Line{}); err != ErrWriteOrder {
+		t.Errorf("route after close: error = %v", err)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		filename, contentType string
+		want                  Format
+	}{
+		{"morning.GPX", "", GPX},
+		{"trip.kml", "application/octet-stream", KML},
+		{"track.json", "", GeoJSON},
+		{"", "application/geo+json", GeoJSON},
+		{"upload", "application/gpx+xml", GPX},
+	}
+	for _, tt := range tests {
+		got, err := DetectFormat(tt.filename, tt.contentType)
+		if err != nil || got != tt.want {
+			t.Errorf("DetectFormat(%q, %q) = %v, %v, want %v", tt.filename, tt.contentType, got, err, tt.want)
+		}
+	}
+	if _, err := DetectFormat("notes.txt", "text/plain"); err == nil {
+		t.Error("DetectFormat(notes.txt) should fail")
+	}
+}
@@ -0,0 +1,371 @@
+package geofile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// geojsonWriter writes a FeatureCollection. File metadata goes in a "metadata"
+// foreign member; track times go in a "coordTimes" property, as togeojson does,
+// so they are buffered while the coordinates stream.
+type geojsonWriter struct {
+	*stream
+	features int
+	times    []int64 // unix nanoseconds of the open track's points, 0 when untimed
+	track    *Line
+}
+
+func newGeoJSONWriter(s *stream, metadata Metadata) *geojsonWriter {
+	header := map[string]string{"creator": Creator}
+	if metadata.Name != "" {
+		header["name"] = metadata.Name
+	}
+	if metadata.Description != "" {
+		header["description"] = metadata.Description
+	}
+	if !metadata.Time.IsZero() {
+		header["time"] = formatTime(metadata.Time)
+	}
+	s.write(`{"type":"FeatureCollection","metadata":`)
+	s.writeJSON(header)
+	s.write(`,"features":[`)
+	return &geojsonWriter{stream: s}
+}
+
+func (w *geojsonWriter) WriteWaypoint(point Point) error {
+	if err := w.enter(stageWaypoints); err != nil {
+		return err
+	}
+	properties := map[string]interface{}{"kind": "waypoint"}
+	if point.Name != "" {
+		properties["name"] = point.Name
+	}
+	if !point.Time.IsZero() {
+		properties["time"] = formatTime(point.Time)
+	}
+	w.beginFeature(properties)
+	w.write(`{"type":"Point","coordinates":`)
+	w.position(point)
+	w.write("}}")
+	return w.err
+}
+
+func (w *geojsonWriter) WriteRoute(route *Line) error {
+	if err := w.enter(stageRoutes); err != nil {
+		return err
+	}
+	properties := lineProperties(route, "route")
+	w.beginFeature(properties)
+	w.write(`{"type":"LineString","coordinates":[`)
+	for i, point := range route.Points {
+		if i > 0 {
+			w.write(",")
+		}
+		w.position(point)
+	}
+	w.write("]}}")
+	return w.err
+}
+
+func (w *geojsonWriter) BeginTrack(track *Line) error {
+	if err := w.beginTrack(); err != nil {
+		return err
+	}
+	w.track = track
+	w.times = w.times[:0]
+	// Properties come last in a track feature, once the point times are known
+	w.separate()
+	w.write(`{"type":"Feature","geometry":{"type":"LineString","coordinates":[`)
+	return w.err
+}
+
+func (w *geojsonWriter) WriteTrackPoint(point Point) error {
+	if err := w.trackPoint(); err != nil {
+		return err
+	}
+	if w.points > 1 {
+		w.write(",")
+	}
+	w.position(point)
+	var t int64
+	if !point.Time.IsZero() {
+		t = point.Time.UnixNano()
+	}
+	w.times = append(w.times, t)
+	return w.err
+}
+
+func (w *geojsonWriter) EndTrack() error {
+	if err := w.endTrack(); err != nil {
+		return err
+	}
+	properties := lineProperties(w.track, "track")
+	timed := false
+	coordTimes := make([]interface{}, len(w.times))
+	for i, t := range w.times {
+		if t != 0 {
+			coordTimes[i] = formatTime(time.Unix(0, t))
+			timed = true
+		}
+	}
+	if timed {
+		properties["coordTimes"] = coordTimes
+	}
+	w.write(`]},"properties":`)
+	w.writeJSON(properties)
+	w.write("}")
+	w.track = nil
+	return w.err
+}
+
+func (w *geojsonWriter) Close() error {
+	if w.inTrack {
+		w.EndTrack()
+	}
+	if w.close() {
+		w.write("]}\n")
+	}
+	return w.flush()
+}
+
+func (w *geojsonWriter) separate() {
+	if w.features > 0 {
+		w.write(",\n")
+	} else {
+		w.write("\n")
+	}
+	w.features++
+}
+
+func (w *geojsonWriter) beginFeature(properties map[string]interface{}) {
+	w.separate()
+	w.write(`{"type":"Feature","properties":`)
+	w.writeJSON(properties)
+	w.write(`,"geometry":`)
+}
+
+func (w *geojsonWriter) position(point Point) {
+	w.write("[")
+	w.write(formatFloat(point.Longitude))
+	w.write(",")
+	w.write(formatFloat(point.Latitude))
+	if point.Elevation != nil {
+		w.write(",")
+		w.write(formatFloat(*point.Elevation))
+	}
+	w.write("]")
+}
+
+func lineProperties(line *Line, kind string) map[string]interface{} {
+	properties := make(map[string]interface{}, len(line.Properties)+6)
+	for key, value := range line.Properties {
+		properties[key] = value
+	}
+	properties["kind"] = kind
+	if line.Name != "" {
+		properties["name"] = line.Name
+	}
+	if line.Description != "" {
+		properties["description"] = line.Description
+	}
+	if line.Type != "" {
+		properties["type"] = line.Type
+	}
+	if !line.Start.IsZero() {
+		properties["start"] = formatTime(line.Start)
+	}
+	if !line.End.IsZero() {
+		properties["end"] = formatTime(line.End)
+	}
+	return properties
+}
+
+func (s *stream) writeJSON(value interface{}) {
+	if s.err != nil {
+		return
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		s.err = err
+		return
+	}
+	_, s.err = s.w.Write(data)
+}
+
+type geojsonFeature struct {
+	Type       string                 `json:"type"`
+	Properties map[string]interface{} `json:"properties"`
+	Geometry   *struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+}
+
+// readGeoJSON accepts a FeatureCollection or a single Feature. Points are
+// waypoints; LineStrings and MultiLineString parts are routes, or tracks when
+// marked kind=track or carrying coordTimes.
+func readGeoJSON(r io.Reader) (*Document, error) {
+	var file struct {
+		Type     string `json:"type"`
+		Metadata struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			Time        string `json:"time"`
+		} `json:"metadata"`
+		Features []geojsonFeature `json:"features"`
+		geojsonFeature
+	}
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %v", err)
+	}
+
+	var features []geojsonFeature
+	switch file.Type {
+	case "FeatureCollection":
+		features = file.Features
+	case "Feature":
+		features = []geojsonFeature{file.geojsonFeature}
+	default:
+		return nil, fmt.Errorf("invalid GeoJSON: expected a FeatureCollection or Feature, got %q", file.Type)
+	}
+
+	doc := &Document{Metadata: Metadata{Name: file.Metadata.Name, Description: file.Metadata.Description}}
+	var err error
+	if doc.Metadata.Time, err = parseTime(file.Metadata.Time); err != nil {
+		return nil, err
+	}
+
+	for i := range features {
+		if err := features[i].addTo(doc); err != nil {
+			return nil, fmt.Errorf("feature %d: %v", i, err)
+		}
+	}
+	return doc, nil
+}
+
+func (f *geojsonFeature) addTo(doc *Document) error {
+	if f.Geometry == nil {
+		return nil
+	}
+	properties := f.Properties
+	name, _ := properties["name"].(string)
+
+	switch f.Geometry.Type {
+	case "Point":
+		var position []float64
+		if err := json.Unmarshal(f.Geometry.Coordinates, &position); err != nil {
+			return fmt.Errorf("invalid Point coordinates: %v", err)
+		}
+		point, err := geojsonPoint(position)
+		if err != nil {
+			return err
+		}
+		point.Name = name
+		if text, ok := properties["time"].(string); ok {
+			if point.Time, err = parseTime(text); err != nil {
+				return err
+			}
+		}
+		doc.Waypoints = append(doc.Waypoints, point)
+
+	case "LineString":
+		var positions [][]float64
+		if err := json.Unmarshal(f.Geometry.Coordinates, &positions); err != nil {
+			return fmt.Errorf("invalid LineString coordinates: %v", err)
+		}
+		times, _ := properties["coordTimes"].([]interface{})
+		return addGeoJSONLine(doc, properties, positions, times)
+
+	case "MultiLineString":
+		var parts [][][]float64
+		if err := json.Unmarshal(f.Geometry.Coordinates, &parts); err != nil {
+			return fmt.Errorf("invalid MultiLineString coordinates: %v", err)
+		}
+		// coordTimes is nested per part for a MultiLineString
+		nested, _ := properties["coordTimes"].([]interface{})
+		for i, positions := range parts {
+			var times []interface{}
+			if i < len(nested) {
+				times, _ = nested[i].([]interface{})
+			}
+			if err := addGeoJSONLine(doc, properties, positions, times); err != nil {
+				return err
+			}
+		}
+	}
+	// Other geometries carry nothing a route or track can use
+	return nil
+}
+
+func addGeoJSONLine(doc *Document, properties map[string]interface{}, positions [][]float64, times []interface{}) error {
+	if len(times) > 0 && len(times) != len(positions) {
+		return fmt.Errorf("coordTimes has %d times for %d coordinates", len(times), len(positions))
+	}
+
+	line := Line{}
+	for key, value := range properties {
+		text, _ := value.(string)
+		switch key {
+		case "name":
+			line.Name = text
+		case "description", "desc":
+			line.Description = text
+		case "type":
+			line.Type = text
+		case "kind", "coordTimes", "start", "end":
+		default:
+			if line.Properties == nil {
+				line.Properties = make(map[string]interface{})
+			}
+			line.Properties[key] = value
+		}
+	}
+
+	for i, position := range positions {
+		point, err := geojsonPoint(position)
+		if err != nil {
+			return err
+		}
+		if len(times) > 0 {
+			text, _ := times[i].(string)
+			if point.Time, err = parseTime(text); err != nil {
+				return err
+			}
+		}
+		line.Points = append(line.Points, point)
+	}
+
+	if kind, _ := properties["kind"].(string); kind != "track" && len(times) == 0 {
+		doc.Routes = append(doc.Routes, line)
+		return nil
+	}
+
+	line.setTimeRange()
+	var err error
+	if text, ok := properties["start"].(string); ok && line.Start.IsZero() {
+		if line.Start, err = parseTime(text); err != nil {
+			return err
+		}
+	}
+	if text, ok := properties["end"].(string); ok && line.End.IsZero() {
+		if line.End, err = parseTime(text); err != nil {
+			return err
+		}
+	}
+	doc.Tracks = append(doc.Tracks, line)
+	return nil
+}
+
+func geojsonPoint(position []float64) (Point, error) {
+	if len(position) < 2 || len(position) > 3 {
+		return Point{}, fmt.Errorf("invalid position %v", position)
+	}
+	point := Point{Longitude: position[0], Latitude: position[1]}
+	if len(position) == 3 {
+		elevation := position[2]
+		point.Elevation = &elevation
+	}
+	return point, nil
+}
@@ -0,0 +1,254 @@
+package geofile
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type gpxWriter struct {
+	*stream
+}
+
+func newGPXWriter(s *stream, metadata Metadata) *gpxWriter {
+	s.write(xml.Header)
+	s.printf(`<gpx version="1.1" creator="%s" xmlns="http://www.topografix.com/GPX/1/1">`+"\n", Creator)
+	if metadata != (Metadata{}) {
+		s.write("  <metadata>")
+		writeXMLElement(s, "name", metadata.Name)
+		writeXMLElement(s, "desc", metadata.Description)
+		if !metadata.Time.IsZero() {
+			writeXMLElement(s, "time", formatTime(metadata.Time))
+		}
+		s.write("</metadata>\n")
+	}
+	return &gpxWriter{stream: s}
+}
+
+func (w *gpxWriter) WriteWaypoint(point Point) error {
+	if err := w.enter(stageWaypoints); err != nil {
+		return err
+	}
+	w.write("  ")
+	w.point("wpt", point)
+	w.write("\n")
+	return w.err
+}
+
+func (w *gpxWriter) WriteRoute(route *Line) error {
+	if err := w.enter(stageRoutes); err != nil {
+		return err
+	}
+	w.write("  <rte>")
+	w.lineHeader(route)
+	for _, point := range route.Points {
+		w.write("\n    ")
+		w.point("rtept", point)
+	}
+	w.write("\n  </rte>\n")
+	return w.err
+}
+
+func (w *gpxWriter) BeginTrack(track *Line) error {
+	if err := w.beginTrack(); err != nil {
+		return err
+	}
+	w.write("  <trk>")
+	w.lineHeader(track)
+	w.write("\n    <trkseg>")
+	return w.err
+}
+
+func (w *gpxWriter) WriteTrackPoint(point Point) error {
+	if err := w.trackPoint(); err != nil {
+		return err
+	}
+	w.write("\n      ")
+	w.point("trkpt", point)
+	return w.err
+}
+
+func (w *gpxWriter) EndTrack() error {
+	if err := w.endTrack(); err != nil {
+		return err
+	}
+	w.write("\n    </trkseg>\n  </trk>\n")
+	return w.err
+}
+
+func (w *gpxWriter) Close() error {
+	if w.inTrack {
+		w.EndTrack()
+	}
+	if w.close() {
+		w.write("</gpx>\n")
+	}
+	return w.flush()
+}
+
+// lineHeader writes the elements rte and trk share, in schema order
+func (w *gpxWriter) lineHeader(line *Line) {
+	writeXMLElement(w.stream, "name", line.Name)
+	writeXMLElement(w.stream, "desc", line.Description)
+	writeXMLElement(w.stream, "type", line.Type)
+}
+
+// point writes a wptType element: ele, time and name, in schema order
+func (w *gpxWriter) point(element string, point Point) {
+	w.printf(`<%s lat="%s" lon="%s">`, element, formatFloat(point.Latitude), formatFloat(point.Longitude))
+	if point.Elevation != nil {
+		writeXMLElement(w.stream, "ele", formatFloat(*point.Elevation))
+	}
+	if !point.Time.IsZero() {
+		writeXMLElement(w.stream, "time", formatTime(point.Time))
+	}
+	writeXMLElement(w.stream, "name", point.Name)
+	w.printf("</%s>", element)
+}
+
+// writeXMLElement writes <name>text</name>, skipping empty text
+func writeXMLElement(s *stream, name, text string) {
+	if text == "" || s.err != nil {
+		return
+	}
+	s.printf("<%s>", name)
+	if s.err == nil {
+		s.err = xml.EscapeText(s.w, []byte(text))
+	}
+	s.printf("</%s>", name)
+}
+
+type gpxPoint struct {
+	Lat       string `xml:"lat,attr"`
+	Lon       string `xml:"lon,attr"`
+	Elevation string `xml:"ele"`
+	Time      string `xml:"time"`
+	Name      string `xml:"name"`
+}
+
+type gpxLine struct {
+	Name        string `xml:"name"`
+	Description string `xml:"desc"`
+	Type        string `xml:"type"`
+}
+
+type gpxFile struct {
+	XMLName  xml.Name `xml:"gpx"`
+	Metadata struct {
+		Name        string `xml:"name"`
+		Description string `xml:"desc"`
+		Time        string `xml:"time"`
+	} `xml:"metadata"`
+	// GPX 1.0 keeps these at the top level
+	Name        string `xml:"name"`
+	Description string `xml:"desc"`
+	Time        string `xml:"time"`
+
+	Waypoints []gpxPoint `xml:"wpt"`
+	Routes    []struct {
+		gpxLine
+		Points []gpxPoint `xml:"rtept"`
+	} `xml:"rte"`
+	Tracks []struct {
+		gpxLine
+		Segments []struct {
+			Points []gpxPoint `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+func readGPX(r io.Reader) (*Document, error) {
+	var file gpxFile
+	if err := xml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid GPX: %v", err)
+	}
+
+	doc := &Document{Metadata: Metadata{
+		Name:        firstNonEmpty(file.Metadata.Name, file.Name),
+		Description: firstNonEmpty(file.Metadata.Description, file.Description),
+	}}
+	var err error
+	if doc.Metadata.Time, err = parseTime(firstNonEmpty(file.Metadata.Time, file.Time)); err != nil {
+		return nil, err
+	}
+
+	for i, wpt := range file.Waypoints {
+		point, err := wpt.point()
+		if err != nil {
+			return nil, fmt.Errorf("waypoint %d: %v", i, err)
+		}
+		doc.Waypoints = append(doc.Waypoints, point)
+	}
+
+	for i, rte := range file.Routes {
+		route := Line{Name: rte.Name, Description: rte.Description, Type: rte.Type}
+		for j, rtept := range rte.Points {
+			point, err := rtept.point()
+			if err != nil {
+				return nil, fmt.Errorf("route %d point %d: %v", i, j, err)
+			}
+			route.Points = append(route.Points, point)
+		}
+		doc.Routes = append(doc.Routes, route)
+	}
+
+	for i, trk := range file.Tracks {
+		track := Line{Name: trk.Name, Description: trk.Description, Type: trk.Type}
+		for _, segment := range trk.Segments {
+			for _, trkpt := range segment.Points {
+				point, err := trkpt.point()
+				if err != nil {
+					return nil, fmt.Errorf("track %d point %d: %v", i, len(track.Points), err)
+				}
+				track.Points = append(track.Points, point)
+			}
+		}
+		track.setTimeRange()
+		doc.Tracks = append(doc.Tracks, track)
+	}
+
+	return doc, nil
+}
+
+func (p gpxPoint) point() (Point, error) {
+	latitude, errLat := strconv.ParseFloat(strings.TrimSpace(p.Lat), 64)
+	longitude, errLon := strconv.ParseFloat(strings.TrimSpace(p.Lon), 64)
+	if errLat != nil || errLon != nil {
+		return Point{}, fmt.Errorf("invalid lat/lon %q, %q", p.Lat, p.Lon)
+	}
+
+	point := Point{Latitude: latitude, Longitude: longitude, Name: strings.TrimSpace(p.Name)}
+	if text := strings.TrimSpace(p.Elevation); text != "" {
+		elevation, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return Point{}, fmt.Errorf("invalid elevation %q", p.Elevation)
+		}
+		point.Elevation = &elevation
+	}
+	var err error
+	point.Time, err = parseTime(p.Time)
+	return point, err
+}
+
+// setTimeRange fills Start and End from the first and last timed points
+func (l *Line) setTimeRange() {
+	for _, point := range l.Points {
+		if !point.Time.IsZero() {
+			if l.Start.IsZero() {
+				l.Start = point.Time
+			}
+			l.End = point.Time
+		}
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
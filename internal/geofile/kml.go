@@ -0,0 +1,385 @@
+package geofile
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// kmlWriter writes KML 2.2. Waypoints become Point placemarks; routes and tracks
+// become LineString placemarks, with a track's times kept as its TimeSpan.
+type kmlWriter struct {
+	*stream
+}
+
+func newKMLWriter(s *stream, metadata Metadata) *kmlWriter {
+	s.write(xml.Header)
+	s.write(`<kml xmlns="http://www.opengis.net/kml/2.2">` + "\n<Document>")
+	writeXMLElement(s, "name", metadata.Name)
+	writeXMLElement(s, "description", metadata.Description)
+	if !metadata.Time.IsZero() {
+		s.write("<TimeStamp>")
+		writeXMLElement(s, "when", formatTime(metadata.Time))
+		s.write("</TimeStamp>")
+	}
+	s.write("\n")
+	return &kmlWriter{stream: s}
+}
+
+func (w *kmlWriter) WriteWaypoint(point Point) error {
+	if err := w.enter(stageWaypoints); err != nil {
+		return err
+	}
+	w.write("  <Placemark>")
+	writeXMLElement(w.stream, "name", point.Name)
+	if !point.Time.IsZero() {
+		w.write("<TimeStamp>")
+		writeXMLElement(w.stream, "when", formatTime(point.Time))
+		w.write("</TimeStamp>")
+	}
+	w.write("<Point><coordinates>")
+	w.coordinate(point)
+	w.write("</coordinates></Point></Placemark>\n")
+	return w.err
+}
+
+func (w *kmlWriter) WriteRoute(route *Line) error {
+	if err := w.enter(stageRoutes); err != nil {
+		return err
+	}
+	w.beginLine(route)
+	for i, point := range route.Points {
+		if i > 0 {
+			w.write(" ")
+		}
+		w.coordinate(point)
+	}
+	w.endLine()
+	return w.err
+}
+
+func (w *kmlWriter) BeginTrack(track *Line) error {
+	if err := w.beginTrack(); err != nil {
+		return err
+	}
+	w.beginLine(track)
+	return w.err
+}
+
+func (w *kmlWriter) WriteTrackPoint(point Point) error {
+	if err := w.trackPoint(); err != nil {
+		return err
+	}
+	if w.points > 1 {
+		w.write("\n")
+	}
+	w.coordinate(point)
+	return w.err
+}
+
+func (w *kmlWriter) EndTrack() error {
+	if err := w.endTrack(); err != nil {
+		return err
+	}
+	w.endLine()
+	return w.err
+}
+
+func (w *kmlWriter) Close() error {
+	if w.inTrack {
+		w.EndTrack()
+	}
+	if w.close() {
+		w.write("</Document>\n</kml>\n")
+	}
+	return w.flush()
+}
+
+// beginLine writes a placemark up to the opening of its coordinates, in schema order
+func (w *kmlWriter) beginLine(line *Line) {
+	w.write("  <Placemark>")
+	writeXMLElement(w.stream, "name", line.Name)
+	writeXMLElement(w.stream, "description", line.Description)
+	if !line.Start.IsZero() || !line.End.IsZero() {
+		w.write("<TimeSpan>")
+		if !line.Start.IsZero() {
+			writeXMLElement(w.stream, "begin", formatTime(line.Start))
+		}
+		if !line.End.IsZero() {
+			writeXMLElement(w.stream, "end", formatTime(line.End))
+		}
+		w.write("</TimeSpan>")
+	}
+
+	data := make(map[string]string, len(line.Properties)+1)
+	for key, value := range line.Properties {
+		data[key] = fmt.Sprint(value)
+	}
+	if line.Type != "" {
+		data["type"] = line.Type
+	}
+	if len(data) > 0 {
+		keys := make([]string, 0, len(data))
+		for key := range data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		w.write("<ExtendedData>")
+		for _, key := range keys {
+			w.write(`<Data name="`)
+			if w.err == nil {
+				w.err = xml.EscapeText(w.w, []byte(key))
+			}
+			w.write(`">`)
+			writeXMLElement(w.stream, "value", data[key])
+			w.write("</Data>")
+		}
+		w.write("</ExtendedData>")
+	}
+	w.write("\n    <LineString><tessellate>1</tessellate><coordinates>\n")
+}
+
+func (w *kmlWriter) endLine() {
+	w.write("\n    </coordinates></LineString></Placemark>\n")
+}
+
+func (w *kmlWriter) coordinate(point Point) {
+	w.write(formatFloat(point.Longitude))
+	w.write(",")
+	w.write(formatFloat(point.Latitude))
+	if point.Elevation != nil {
+		w.write(",")
+		w.write(formatFloat(*point.Elevation))
+	}
+}
+
+type kmlCoordinates struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlPlacemark struct {
+	Name        string `xml:"name"`
+	Description string `xml:"description"`
+	TimeStamp   *struct {
+		When string `xml:"when"`
+	} `xml:"TimeStamp"`
+	TimeSpan *struct {
+		Begin string `xml:"begin"`
+		End   string `xml:"end"`
+	} `xml:"TimeSpan"`
+	ExtendedData []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value"`
+	} `xml:"ExtendedData>Data"`
+	Point         *kmlCoordinates `xml:"Point"`
+	LineString    *kmlCoordinates `xml:"LineString"`
+	MultiGeometry *struct {
+		Points      []kmlCoordinates `xml:"Point"`
+		LineStrings []kmlCoordinates `xml:"LineString"`
+	} `xml:"MultiGeometry"`
+	Track *kmlTrack `xml:"Track"` // gx:Track
+}
+
+type kmlTrack struct {
+	When  []string `xml:"when"`
+	Coord []string `xml:"coord"` // gx:coord, "lng lat [alt]"
+}
+
+// readKML collects every Placemark, however deeply it is nested in folders.
+// LineStrings with a TimeSpan and gx:Tracks are tracks; other LineStrings are routes.
+func readKML(r io.Reader) (*Document, error) {
+	doc := &Document{}
+	decoder := xml.NewDecoder(r)
+	var path []string
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid KML: %v", err)
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			if element.Name.Local == "Placemark" {
+				var placemark kmlPlacemark
+				if err := decoder.DecodeElement(&placemark, &element); err != nil {
+					return nil, fmt.Errorf("invalid KML placemark: %v", err)
+				}
+				if err := placemark.addTo(doc); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			path = append(path, element.Name.Local)
+		case xml.EndElement:
+			if len(path) > 0 {
+				path = path[:len(path)-1]
+			}
+		case xml.CharData:
+			// The document's own name and description
+			if n := len(path); n >= 2 && path[n-2] == "Document" {
+				switch path[n-1] {
+				case "name":
+					if doc.Metadata.Name == "" {
+						doc.Metadata.Name = strings.TrimSpace(string(element))
+					}
+				case "description":
+					if doc.Metadata.Description == "" {
+						doc.Metadata.Description = strings.TrimSpace(string(element))
+					}
+				}
+			}
+		}
+	}
+
+	if len(doc.Waypoints) == 0 && len(doc.Routes) == 0 && len(doc.Tracks) == 0 {
+		return nil, fmt.Errorf("invalid KML: no placemarks")
+	}
+	return doc, nil
+}
+
+func (p *kmlPlacemark) addTo(doc *Document) error {
+	name := strings.TrimSpace(p.Name)
+	element := fmt.Sprintf("placemark %q", name)
+
+	var points []kmlCoordinates
+	var lines []kmlCoordinates
+	if p.Point != nil {
+		points = append(points, *p.Point)
+	}
+	if p.LineString != nil {
+		lines = append(lines, *p.LineString)
+	}
+	if p.MultiGeometry != nil {
+		points = append(points, p.MultiGeometry.Points...)
+		lines = append(lines, p.MultiGeometry.LineStrings...)
+	}
+
+	for _, geometry := range points {
+		coordinates, err := parseKMLCoordinates(geometry.Coordinates)
+		if err != nil || len(coordinates) != 1 {
+			return fmt.Errorf("%s: invalid point coordinates", element)
+		}
+		point := coordinates[0]
+		point.Name = name
+		if p.TimeStamp != nil {
+			if point.Time, err = parseTime(p.TimeStamp.When); err != nil {
+				return fmt.Errorf("%s: %v", element, err)
+			}
+		}
+		doc.Waypoints = append(doc.Waypoints, point)
+	}
+
+	properties := make(map[string]interface{})
+	lineType := ""
+	for _, data := range p.ExtendedData {
+		if data.Name == "type" {
+			lineType = data.Value
+			continue
+		}
+		properties[data.Name] = data.Value
+	}
+	if len(properties) == 0 {
+		properties = nil
+	}
+
+	for _, geometry := range lines {
+		coordinates, err := parseKMLCoordinates(geometry.Coordinates)
+		if err != nil {
+			return fmt.Errorf("%s: %v", element, err)
+		}
+		line := Line{
+			Name:        name,
+			Description: strings.TrimSpace(p.Description),
+			Type:        lineType,
+			Properties:  properties,
+			Points:      coordinates,
+		}
+		if p.TimeSpan == nil {
+			doc.Routes = append(doc.Routes, line)
+			continue
+		}
+		if line.Start, err = parseTime(p.TimeSpan.Begin); err != nil {
+			return fmt.Errorf("%s: %v", element, err)
+		}
+		if line.End, err = parseTime(p.TimeSpan.End); err != nil {
+			return fmt.Errorf("%s: %v", element, err)
+		}
+		doc.Tracks = append(doc.Tracks, line)
+	}
+
+	if p.Track != nil {
+		track, err := p.Track.line()
+		if err != nil {
+			return fmt.Errorf("%s: %v", element, err)
+		}
+		track.Name = name
+		track.Description = strings.TrimSpace(p.Description)
+		track.Type = lineType
+		track.Properties = properties
+		doc.Tracks = append(doc.Tracks, track)
+	}
+	return nil
+}
+
+func (t *kmlTrack) line() (Line, error) {
+	if len(t.When) != 0 && len(t.When) != len(t.Coord) {
+		return Line{}, fmt.Errorf("gx:Track has %d times for %d coordinates", len(t.When), len(t.Coord))
+	}
+
+	var line Line
+	for i, coord := range t.Coord {
+		point, err := parseKMLPosition(strings.Fields(coord))
+		if err != nil {
+			return Line{}, err
+		}
+		if len(t.When) > 0 {
+			if point.Time, err = parseTime(t.When[i]); err != nil {
+				return Line{}, err
+			}
+		}
+		line.Points = append(line.Points, point)
+	}
+	line.setTimeRange()
+	return line, nil
+}
+
+// parseKMLCoordinates reads whitespace-separated lng,lat[,alt] tuples
+func parseKMLCoordinates(text string) ([]Point, error) {
+	var points []Point
+	for _, tuple := range strings.Fields(text) {
+		point, err := parseKMLPosition(strings.Split(tuple, ","))
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+func parseKMLPosition(fields []string) (Point, error) {
+	if len(fields) < 2 || len(fields) > 3 {
+		return Point{}, fmt.Errorf("invalid coordinate %q", strings.Join(fields, ","))
+	}
+	longitude, errLon := strconv.ParseFloat(fields[0], 64)
+	latitude, errLat := strconv.ParseFloat(fields[1], 64)
+	if errLon != nil || errLat != nil {
+		return Point{}, fmt.Errorf("invalid coordinate %q", strings.Join(fields, ","))
+	}
+
+	point := Point{Latitude: latitude, Longitude: longitude}
+	if len(fields) == 3 {
+		elevation, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return Point{}, fmt.Errorf("invalid altitude %q", fields[2])
+		}
+		point.Elevation = &elevation
+	}
+	return point, nil
+}
@@ -0,0 +1,138 @@
+package geofile
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ErrWriteOrder is returned when a writer is used out of order
+var ErrWriteOrder = errors.New("write waypoints, then routes, then tracks, and close a track before starting another")
+
+// Writer streams a document. Waypoints, routes and tracks must be written in
+// that order, which GPX requires; a track is written point by point between
+// BeginTrack and EndTrack. Close finishes the document and must always be called.
+type Writer interface {
+	WriteWaypoint(point Point) error
+	WriteRoute(route *Line) error
+	BeginTrack(track *Line) error // track.Points is ignored
+	WriteTrackPoint(point Point) error
+	EndTrack() error
+	Close() error
+}
+
+// NewWriter starts a document in format on w
+func NewWriter(w io.Writer, format Format, metadata Metadata) (Writer, error) {
+	s := &stream{w: bufio.NewWriterSize(w, 32*1024)}
+
+	var writer Writer
+	switch format {
+	case GPX:
+		writer = newGPXWriter(s, metadata)
+	case KML:
+		writer = newKMLWriter(s, metadata)
+	case GeoJSON:
+		writer = newGeoJSONWriter(s, metadata)
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return writer, nil
+}
+
+// Writer stages; a writer only moves forward through them
+const (
+	stageWaypoints = iota
+	stageRoutes
+	stageTracks
+	stageClosed
+)
+
+// stream is the buffered output shared by the format writers. The first write
+// error sticks and is returned by every later call.
+type stream struct {
+	w       *bufio.Writer
+	err     error
+	stage   int
+	inTrack bool
+	points  int // points written in the open track
+}
+
+// enter moves the writer to stage, failing if it is already past it or inside a track
+func (s *stream) enter(stage int) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.stage > stage || s.inTrack {
+		return ErrWriteOrder
+	}
+	s.stage = stage
+	return nil
+}
+
+func (s *stream) beginTrack() error {
+	if err := s.enter(stageTracks); err != nil {
+		return err
+	}
+	s.inTrack = true
+	s.points = 0
+	return nil
+}
+
+func (s *stream) trackPoint() error {
+	if s.err != nil {
+		return s.err
+	}
+	if !s.inTrack {
+		return ErrWriteOrder
+	}
+	s.points++
+	return nil
+}
+
+func (s *stream) endTrack() error {
+	if s.err != nil {
+		return s.err
+	}
+	if !s.inTrack {
+		return ErrWriteOrder
+	}
+	s.inTrack = false
+	return nil
+}
+
+// close marks the stream closed; ok is false when it already was
+func (s *stream) close() (ok bool) {
+	if s.stage == stageClosed {
+		return false
+	}
+	s.stage = stageClosed
+	return true
+}
+
+func (s *stream) flush() error {
+	if s.err == nil {
+		s.err = s.w.Flush()
+	}
+	return s.err
+}
+
+func (s *stream) write(text string) {
+	if s.err == nil {
+		_, s.err = s.w.WriteString(text)
+	}
+}
+
+func (s *stream) printf(format string, args ...interface{}) {
+	if s.err == nil {
+		_, s.err = fmt.Fprintf(s.w, format, args...)
+	}
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,254 @@
+// Package importer loads POIs and historical sites from open data files. A file
+// is read into records, compared with what is stored to produce a plan, and the
+// plan is shown as a diff before it is applied. Records match stored rows by
+// name and proximity, so importing the same file again changes nothing.
+package importer
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"intelligent-spatial-platform/internal/geo"
+)
+
+// Kind is the table records are imported into
+type Kind string
+
+const (
+	KindLocation       Kind = "location"
+	KindHistoricalSite Kind = "historical_site"
+)
+
+// ParseKind accepts location(s) and historical_site(s), with - or _
+func ParseKind(name string) (Kind, error) {
+	switch strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "-", "_") {
+	case "location", "locations", "poi", "pois":
+		return KindLocation, nil
+	case "historical_site", "historical_sites", "site", "sites":
+		return KindHistoricalSite, nil
+	}
+	return "", fmt.Errorf("unsupported kind: %s (use location or historical_site)", name)
+}
+
+// DefaultMatchRadius is how close two records with the same name must be to count as one place, in meters
+const DefaultMatchRadius = 200.0
+
+// moveThreshold is how far a matched place must move before its coordinates are updated, in meters
+const moveThreshold = 1.0
+
+// Record is one place from a file, or a stored row when ID is set
+type Record struct {
+	ID          uint     `json:"id,omitempty"`
+	Source      string   `json:"source,omitempty"` // where in the file, e.g. "line 12"
+	Name        string   `json:"name"`
+	Latitude    float64  `json:"latitude"`
+	Longitude   float64  `json:"longitude"`
+	Address     string   `json:"address,omitempty"`
+	Type        string   `json:"type,omitempty"` // locations only
+	Description string   `json:"description,omitempty"`
+	Era         string   `json:"era,omitempty"`    // historical sites only
+	Images      []string `json:"images,omitempty"` // historical sites only
+}
+
+// FieldChange is one field an update sets
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// Update changes a stored row to match a record
+type Update struct {
+	Record  Record        `json:"record"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// Skip is a record that will not be imported
+type Skip struct {
+	Record Record `json:"record"`
+	Reason string `json:"reason"`
+}
+
+// Plan is what an import would do
+type Plan struct {
+	Kind      Kind     `json:"kind"`
+	Creates   []Record `json:"creates"`
+	Updates   []Update `json:"updates"`
+	Unchanged int      `json:"unchanged"`
+	Skips     []Skip   `json:"skips"`
+}
+
+// Empty reports whether applying the plan would change nothing
+func (p *Plan) Empty() bool {
+	return len(p.Creates) == 0 && len(p.Updates) == 0
+}
+
+// BuildPlan compares records with the stored rows of kind. Invalid records and
+// repeats of an earlier record in the same file are skipped; a record with the
+// name of a stored row within radius meters updates that row, and any other
+// record is created. Records outside boundaries are invalid. Updates only set
+// fields the record has, so a sparse file never blanks stored data.
+func BuildPlan(kind Kind, records, existing []Record, boundaries *geo.Boundaries, radius float64) *Plan {
+	if radius <= 0 {
+		radius = DefaultMatchRadius
+	}
+	plan := &Plan{Kind: kind}
+
+	stored := indexByName(existing)
+	var accepted []Record
+	seen := make(map[string][]int) // normalized name -> indexes into accepted
+
+	for _, record := range records {
+		record.Name = strings.TrimSpace(record.Name)
+		if reason := checkRecord(record, boundaries); reason != "" {
+			plan.Skips = append(plan.Skips, Skip{Record: record, Reason: reason})
+			continue
+		}
+
+		key := geo.NormalizePlaceName(record.Name)
+		if i := nearest(accepted, seen[key], record, radius); i >= 0 {
+			plan.Skips = append(plan.Skips, Skip{Record: record, Reason: "duplicate of " + accepted[i].Source})
+			continue
+		}
+		seen[key] = append(seen[key], len(accepted))
+		accepted = append(accepted, record)
+
+		i := nearest(existing, stored[key], record, radius)
+		if i < 0 {
+			plan.Creates = append(plan.Creates, record)
+			continue
+		}
+		changes := diff(kind, existing[i], record)
+		if len(changes) == 0 {
+			plan.Unchanged++
+			continue
+		}
+		record.ID = existing[i].ID
+		plan.Updates = append(plan.Updates, Update{Record: record, Changes: changes})
+	}
+	return plan
+}
+
+func indexByName(records []Record) map[string][]int {
+	index := make(map[string][]int, len(records))
+	for i, record := range records {
+		key := geo.NormalizePlaceName(record.Name)
+		index[key] = append(index[key], i)
+	}
+	return index
+}
+
+// nearest returns the candidate closest to record within radius, or -1
+func nearest(records []Record, candidates []int, record Record, radius float64) int {
+	best, bestDistance := -1, radius
+	for _, i := range candidates {
+		distance := distanceMeters(records[i].Latitude, records[i].Longitude, record.Latitude, record.Longitude)
+		if distance <= bestDistance {
+			best, bestDistance = i, distance
+		}
+	}
+	return best
+}
+
+func checkRecord(record Record, boundaries *geo.Boundaries) string {
+	switch {
+	case record.Name == "":
+		return "missing name"
+	case math.IsNaN(record.Latitude) || math.IsNaN(record.Longitude) || (record.Latitude == 0 && record.Longitude == 0):
+		return "missing coordinates"
+	case !boundaries.InTerritory(record.Latitude, record.Longitude):
+		return fmt.Sprintf("coordinate (%.6f, %.6f) is outside Taiwan", record.Latitude, record.Longitude)
+	}
+	return ""
+}
+
+// diff lists the fields of kind that record would change on stored
+func diff(kind Kind, stored, record Record) []FieldChange {
+	var changes []FieldChange
+	text := func(field, old, new string) {
+		if new != "" && new != old {
+			changes = append(changes, FieldChange{Field: field, Old: old, New: new})
+		}
+	}
+
+	if distanceMeters(stored.Latitude, stored.Longitude, record.Latitude, record.Longitude) > moveThreshold {
+		changes = append(changes,
+			FieldChange{Field: "latitude", Old: stored.Latitude, New: record.Latitude},
+			FieldChange{Field: "longitude", Old: stored.Longitude, New: record.Longitude},
+		)
+	}
+	text("address", stored.Address, record.Address)
+
+	switch kind {
+	case KindLocation:
+		text("type", stored.Type, record.Type)
+	case KindHistoricalSite:
+		text("description", stored.Description, record.Description)
+		text("era", stored.Era, record.Era)
+		if len(record.Images) > 0 && strings.Join(record.Images, "\n") != strings.Join(stored.Images, "\n") {
+			changes = append(changes, FieldChange{Field: "images", Old: stored.Images, New: record.Images})
+		}
+	}
+	return changes
+}
+
+// WriteReport prints the plan as a diff: + creates, ~ updates with each
+// changed field, ! skips, then a summary line
+func (p *Plan) WriteReport(w io.Writer) error {
+	var b strings.Builder
+	for _, record := range p.Creates {
+		fmt.Fprintf(&b, "+ %s (%.6f, %.6f) [%s]\n", record.Name, record.Latitude, record.Longitude, record.Source)
+	}
+	for _, update := range p.Updates {
+		fmt.Fprintf(&b, "~ %s #%d [%s]\n", update.Record.Name, update.Record.ID, update.Record.Source)
+		for _, change := range update.Changes {
+			fmt.Fprintf(&b, "    %s: %s -> %s\n", change.Field, reportValue(change.Old), reportValue(change.New))
+		}
+	}
+
+	skips := append([]Skip(nil), p.Skips...)
+	sort.SliceStable(skips, func(i, j int) bool { return skips[i].Reason < skips[j].Reason })
+	for _, skip := range skips {
+		name := skip.Record.Name
+		if name == "" {
+			name = "(no name)"
+		}
+		fmt.Fprintf(&b, "! %s [%s]: %s\n", name, skip.Record.Source, skip.Reason)
+	}
+
+	fmt.Fprintf(&b, "%s: %d to create, %d to update, %d unchanged, %d skipped\n",
+		p.Kind, len(p.Creates), len(p.Updates), p.Unchanged, len(p.Skips))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func reportValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		const maxLength = 60
+		if runes := []rune(v); len(runes) > maxLength {
+			v = string(runes[:maxLength]) + "…"
+		}
+		return fmt.Sprintf("%q", v)
+	case float64:
+		return fmt.Sprintf("%.6f", v)
+	}
+	return fmt.Sprint(value)
+}
+
+func distanceMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	const R = 6371000 // Earth's radius in meters
+
+	lat1Rad := lat1 * math.Pi / 180
+	lat2Rad := lat2 * math.Pi / 180
+	deltaLatRad := (lat2 - lat1) * math.Pi / 180
+	deltaLngRad := (lng2 - lng1) * math.Pi / 180
+
+	a := math.Sin(deltaLatRad/2)*math.Sin(deltaLatRad/2) +
+		math.Cos(lat1Rad)*math.Cos(lat2Rad)*
+			math.Sin(deltaLngRad/2)*math.Sin(deltaLngRad/2)
+	return R * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
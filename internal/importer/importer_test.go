@@ -0,0 +1,160 @@
+package importer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"intelligent-spatial-platform/internal/geo"
+)
+
+const museumsCSV = "\ufeff館名,緯度,經度,地址,類別\n" +
+	"國立故宮博物院,25.1024,121.5485,臺北市士林區至善路二段221號,museum\n" +
+	"國立臺灣博物館,25.0429,121.5149,臺北市中正區襄陽路2號,\n" +
+	"國立台灣博物館,25.0430,121.5150,,\n" + // same place, 臺/台 spelling
+	"東京國立博物館,35.7188,139.7765,,museum\n" +
+	",25.03,121.56,,\n"
+
+func TestReadCSVAndPlan(t *testing.T) {
+	records, err := ReadCSV(strings.NewReader(museumsCSV), Options{
+		Mapping: map[string]string{FieldName: "館名"},
+		Type:    "museum",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 5 || records[1].Address != "臺北市中正區襄陽路2號" || records[1].Type != "museum" || records[1].Source != "line 3" {
+		t.Fatalf("records = %+v", records)
+	}
+
+	existing := []Record{
+		// Stored 30 m away with an older address
+		{ID: 7, Name: "國立故宮博物院", Latitude: 25.1026, Longitude: 121.5486, Address: "士林區至善路二段221號", Type: "museum"},
+		// Same name, other end of town: a different place
+		{ID: 8, Name: "國立臺灣博物館", Latitude: 25.0000, Longitude: 121.3000},
+	}
+	plan := BuildPlan(KindLocation, records, existing, geo.BundledBoundaries(), 0)
+
+	if len(plan.Creates) != 1 || plan.Creates[0].Name != "國立臺灣博物館" {
+		t.Errorf("creates = %+v", plan.Creates)
+	}
+	if len(plan.Updates) != 1 || plan.Updates[0].Record.ID != 7 {
+		t.Fatalf("updates = %+v", plan.Updates)
+	}
+	fields := []string{}
+	for _, change := range plan.Updates[0].Changes {
+		fields = append(fields, change.Field)
+	}
+	if strings.Join(fields, ",") != "latitude,longitude,address" {
+		t.Errorf("changed fields = %v", fields)
+	}
+
+	reasons := map[string]bool{}
+	for _, skip := range plan.Skips {
+		reasons[skip.Reason] = true
+	}
+	if len(plan.Skips) != 3 || !reasons["duplicate of line 3"] || !reasons["missing name"] {
+		t.Errorf("skips = %+v", plan.Skips)
+	}
+
+	var report bytes.Buffer
+	if err := plan.WriteReport(&report); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"+ 國立臺灣博物館 (25.042900, 121.514900) [line 3]",
+		"~ 國立故宮博物院 #7 [line 2]",
+		`    address: "士林區至善路二段221號" -> "臺北市士林區至善路二段221號"`,
+		"outside Taiwan",
+		"location: 1 to create, 1 to update, 0 unchanged, 3 skipped",
+	} {
+		if !strings.Contains(report.String(), want) {
+			t.Errorf("report is missing %q:\n%s", want, report.String())
+		}
+	}
+}
+
+func TestPlanIsIdempotent(t *testing.T) {
+	records, err := ReadCSV(strings.NewReader(museumsCSV), Options{Mapping: map[string]string{FieldName: "館名"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := BuildPlan(KindLocation, records, nil, geo.BundledBoundaries(), 0)
+
+	// Store what the first run created, as Apply would
+	var stored []Record
+	for i, record := range first.Creates {
+		record.ID = uint(i + 1)
+		stored = append(stored, record)
+	}
+	second := BuildPlan(KindLocation, records, stored, geo.BundledBoundaries(), 0)
+	if !second.Empty() || second.Unchanged != len(first.Creates) {
+		t.Errorf("second run = %+v", second)
+	}
+}
+
+func TestReadHeritageJSON(t *testing.T) {
+	data := `{"data": [
+		{"caseId": "19830528000003", "caseName": "赤崁樓", "assetsClassifyName": "古蹟",
+		 "belongCity": "臺南市", "belongAddress": "中西區赤崁里民族路二段212號",
+		 "latitude": "22.997524", "longitude": 120.202536,
+		 "pastHistory": "荷蘭人於1653年興建普羅民遮城", "buildingYearName": "清代",
+		 "representImage": "https://example.org/chihkan.jpg"}
+	]}`
+	records, err := ReadHeritageJSON(strings.NewReader(data), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("records = %+v", records)
+	}
+	record := records[0]
+	if record.Name != "赤崁樓" || record.Source != "case 19830528000003" || record.Address != "臺南市中西區赤崁里民族路二段212號" {
+		t.Errorf("record = %+v", record)
+	}
+	if record.Latitude != 22.997524 || record.Longitude != 120.202536 || record.Era != "清代" || record.Type != "古蹟" {
+		t.Errorf("record = %+v", record)
+	}
+	if len(record.Images) != 1 || !strings.HasPrefix(record.Description, "荷蘭人") {
+		t.Errorf("record = %+v", record)
+	}
+
+	plan := BuildPlan(KindHistoricalSite, records, []Record{{ID: 3, Name: "赤崁樓", Latitude: 22.99752, Longitude: 120.20254}}, geo.BundledBoundaries(), 0)
+	if len(plan.Updates) != 1 || len(plan.Updates[0].Changes) != 4 {
+		t.Errorf("plan = %+v", plan)
+	}
+}
+
+func TestReadGeoJSONAndTWD97(t *testing.T) {
+	records, err := ReadGeoJSON(strings.NewReader(`{"type":"FeatureCollection","features":[
+		{"type":"Feature","properties":{"名稱":"臺北101","類別":"landmark"},"geometry":{"type":"Point","coordinates":[121.5645,25.0340]}}
+	]}`), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Name != "臺北101" || records[0].Type != "landmark" || records[0].Latitude != 25.0340 {
+		t.Errorf("records = %+v", records)
+	}
+
+	// TWD97 TM2 of Taipei 101
+	records, err = ReadCSV(strings.NewReader("name,TWD97X,TWD97Y\n臺北101,306316,2769804\n"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Latitude < 25.02 || records[0].Latitude > 25.05 || records[0].Longitude < 121.55 || records[0].Longitude > 121.58 {
+		t.Errorf("records = %+v", records)
+	}
+}
+
+func TestParseMapping(t *testing.T) {
+	mapping, err := ParseMapping("name=場所名稱, Latitude=Y")
+	if err != nil || mapping[FieldName] != "場所名稱" || mapping[FieldLatitude] != "Y" {
+		t.Errorf("ParseMapping() = %v, %v", mapping, err)
+	}
+	if _, err := ParseMapping("height=高度"); err == nil {
+		t.Error("an unknown field should fail")
+	}
+	if _, err := ReadCSV(strings.NewReader("a,b\n1,2\n"), Options{}); err == nil {
+		t.Error("a CSV without a name column should fail")
+	}
+}
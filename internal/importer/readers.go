@@ -0,0 +1,360 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"intelligent-spatial-platform/internal/coordinate"
+)
+
+// Format is a supported input file format
+type Format string
+
+const (
+	FormatCSV      Format = "csv"
+	FormatGeoJSON  Format = "geojson"
+	FormatHeritage Format = "heritage" // Ministry of Culture heritage open data JSON
+)
+
+// ParseFormat accepts csv, geojson and heritage
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "csv":
+		return FormatCSV, nil
+	case "geojson":
+		return FormatGeoJSON, nil
+	case "heritage", "moc", "boch":
+		return FormatHeritage, nil
+	}
+	return "", fmt.Errorf("unsupported format: %s (use csv, geojson or heritage)", name)
+}
+
+// DetectFormat guesses the format from a file name; .json files are heritage data
+func DetectFormat(filename string) (Format, error) {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".geojson":
+		return FormatGeoJSON, nil
+	case ".json":
+		return FormatHeritage, nil
+	}
+	return "", fmt.Errorf("cannot tell the format of %s; pass it explicitly", filename)
+}
+
+// Record fields a column can be mapped to
+const (
+	FieldName        = "name"
+	FieldLatitude    = "latitude"
+	FieldLongitude   = "longitude"
+	FieldX           = "x" // projected easting, see Options.CRS
+	FieldY           = "y" // projected northing
+	FieldAddress     = "address"
+	FieldType        = "type"
+	FieldDescription = "description"
+	FieldEra         = "era"
+	FieldImages      = "images" // one URL, or several separated by ; or |
+)
+
+// defaultColumns are the column names recognised for each field when the
+// mapping does not name one, compared case-insensitively
+var defaultColumns = map[string][]string{
+	FieldName:        {"name", "名稱", "title", "標題", "casename"},
+	FieldLatitude:    {"latitude", "lat", "緯度", "wgs84_lat", "py"},
+	FieldLongitude:   {"longitude", "lng", "lon", "經度", "wgs84_lon", "px"},
+	FieldX:           {"x", "twd97x", "twd97_x", "x坐標", "x座標"},
+	FieldY:           {"y", "twd97y", "twd97_y", "y坐標", "y座標"},
+	FieldAddress:     {"address", "地址", "add", "belongaddress"},
+	FieldType:        {"type", "類別", "類型", "category"},
+	FieldDescription: {"description", "描述", "簡介", "說明", "desc", "toldescribe", "pasthistory"},
+	FieldEra:         {"era", "年代", "建造年代", "buildingyearname"},
+	FieldImages:      {"images", "image", "圖片", "picture1", "representimage"},
+}
+
+// Options control how a file's columns become record fields
+type Options struct {
+	// Mapping names the column for a field, e.g. {"name": "場所名稱"}; fields
+	// left out use the default column names
+	Mapping map[string]string
+	// CRS of x/y columns; TWD97 TM2 when empty. Used only when there are no
+	// latitude/longitude values.
+	CRS coordinate.CRS
+	// Type is given to records without one, e.g. "museum" for a list of museums
+	Type string
+}
+
+// ParseMapping reads "field=column,field=column"
+func ParseMapping(text string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for _, pair := range strings.Split(text, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		field, column, ok := strings.Cut(pair, "=")
+		field = strings.ToLower(strings.TrimSpace(field))
+		if _, known := defaultColumns[field]; !ok || !known {
+			return nil, fmt.Errorf("invalid mapping %q: use field=column with field one of name, latitude, longitude, x, y, address, type, description, era, images", pair)
+		}
+		mapping[field] = strings.TrimSpace(column)
+	}
+	return mapping, nil
+}
+
+// Read parses a file in format into records
+func Read(r io.Reader, format Format, options Options) ([]Record, error) {
+	switch format {
+	case FormatCSV:
+		return ReadCSV(r, options)
+	case FormatGeoJSON:
+		return ReadGeoJSON(r, options)
+	case FormatHeritage:
+		return ReadHeritageJSON(r, options)
+	}
+	return nil, fmt.Errorf("unsupported format: %s", format)
+}
+
+// ReadCSV reads a CSV file with a header row. A UTF-8 byte order mark, common
+// in files saved by Excel, is ignored.
+func ReadCSV(r io.Reader, options Options) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %v", err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	columns, err := resolveColumns(header, options.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+		values := make(map[string]string, len(columns))
+		for field, index := range columns {
+			if index < len(row) {
+				values[field] = strings.TrimSpace(row[index])
+			}
+		}
+		records = append(records, newRecord(fmt.Sprintf("line %d", line), values, options))
+	}
+	return records, nil
+}
+
+// ReadGeoJSON reads the Point features of a FeatureCollection; properties are
+// mapped like CSV columns and the geometry gives the coordinates
+func ReadGeoJSON(r io.Reader, options Options) ([]Record, error) {
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Properties map[string]interface{} `json:"properties"`
+			Geometry   *struct {
+				Type        string    `json:"type"`
+				Coordinates []float64 `json:"coordinates"`
+			} `json:"geometry"`
+		} `json:"features"`
+	}
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %v", err)
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("invalid GeoJSON: expected a FeatureCollection, got %q", collection.Type)
+	}
+
+	var records []Record
+	for i, feature := range collection.Features {
+		values := mapProperties(feature.Properties, options.Mapping)
+		record := newRecord(fmt.Sprintf("feature %d", i), values, options)
+		if geometry := feature.Geometry; geometry != nil && geometry.Type == "Point" && len(geometry.Coordinates) >= 2 {
+			record.Longitude, record.Latitude = geometry.Coordinates[0], geometry.Coordinates[1]
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// ReadHeritageJSON reads the cultural heritage open data published by the
+// Bureau of Cultural Heritage (文化部文化資產局): an array of cases with caseId,
+// caseName, belongCity, belongAddress, latitude, longitude, pastHistory,
+// buildingYearName and representImage. The array may also be wrapped in an
+// object, as some exports are.
+func ReadHeritageJSON(r io.Reader, options Options) ([]Record, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid heritage JSON: %v", err)
+	}
+
+	var cases []map[string]interface{}
+	if err := json.Unmarshal(raw, &cases); err != nil {
+		var wrapped map[string]json.RawMessage
+		if json.Unmarshal(raw, &wrapped) != nil {
+			return nil, fmt.Errorf("invalid heritage JSON: expected an array of cases")
+		}
+		for _, key := range []string{"data", "records", "result", "items"} {
+			if json.Unmarshal(wrapped[key], &cases) == nil && cases != nil {
+				break
+			}
+		}
+		if cases == nil {
+			return nil, fmt.Errorf("invalid heritage JSON: no array of cases found")
+		}
+	}
+
+	var records []Record
+	for i, heritage := range cases {
+		values := mapProperties(heritage, options.Mapping)
+		// The address usually leaves out the city, which is in its own field
+		if city := stringValue(lookupFold(heritage, "belongCity")); city != "" && !strings.HasPrefix(values[FieldAddress], city) {
+			values[FieldAddress] = city + values[FieldAddress]
+		}
+		if values[FieldType] == "" {
+			values[FieldType] = stringValue(lookupFold(heritage, "assetsClassifyName"))
+		}
+
+		source := fmt.Sprintf("case %d", i)
+		if id := stringValue(lookupFold(heritage, "caseId")); id != "" {
+			source = "case " + id
+		}
+		records = append(records, newRecord(source, values, options))
+	}
+	return records, nil
+}
+
+// resolveColumns finds the index of each field's column in a header
+func resolveColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	index := make(map[string]int, len(header))
+	for i, column := range header {
+		key := strings.ToLower(strings.TrimSpace(column))
+		if _, ok := index[key]; !ok {
+			index[key] = i
+		}
+	}
+
+	columns := make(map[string]int)
+	for field, names := range defaultColumns {
+		if column, ok := mapping[field]; ok {
+			i, found := index[strings.ToLower(column)]
+			if !found {
+				return nil, fmt.Errorf("column %q mapped to %s is not in the header", column, field)
+			}
+			columns[field] = i
+			continue
+		}
+		for _, name := range names {
+			if i, found := index[name]; found {
+				columns[field] = i
+				break
+			}
+		}
+	}
+	if _, ok := columns[FieldName]; !ok {
+		return nil, fmt.Errorf("no name column; map one with name=<column>")
+	}
+	return columns, nil
+}
+
+// mapProperties picks field values out of a JSON object
+func mapProperties(properties map[string]interface{}, mapping map[string]string) map[string]string {
+	values := make(map[string]string)
+	for field, names := range defaultColumns {
+		if column, ok := mapping[field]; ok {
+			names = []string{column}
+		}
+		for _, name := range names {
+			if value := stringValue(lookupFold(properties, name)); value != "" {
+				values[field] = value
+				break
+			}
+		}
+	}
+	return values
+}
+
+func lookupFold(properties map[string]interface{}, key string) interface{} {
+	if value, ok := properties[key]; ok {
+		return value
+	}
+	for name, value := range properties {
+		if strings.EqualFold(name, key) {
+			return value
+		}
+	}
+	return nil
+}
+
+func stringValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			if text := stringValue(item); text != "" {
+				parts = append(parts, text)
+			}
+		}
+		return strings.Join(parts, ";")
+	}
+	return strings.TrimSpace(fmt.Sprint(value))
+}
+
+// newRecord builds a record from field values. Unparseable coordinates are left
+// at zero, which BuildPlan skips as missing.
+func newRecord(source string, values map[string]string, options Options) Record {
+	record := Record{
+		Source:      source,
+		Name:        values[FieldName],
+		Address:     values[FieldAddress],
+		Type:        values[FieldType],
+		Description: values[FieldDescription],
+		Era:         values[FieldEra],
+	}
+	if record.Type == "" {
+		record.Type = options.Type
+	}
+	for _, image := range strings.FieldsFunc(values[FieldImages], func(r rune) bool { return r == ';' || r == '|' }) {
+		if image = strings.TrimSpace(image); image != "" {
+			record.Images = append(record.Images, image)
+		}
+	}
+
+	latitude, errLat := strconv.ParseFloat(values[FieldLatitude], 64)
+	longitude, errLon := strconv.ParseFloat(values[FieldLongitude], 64)
+	if errLat == nil && errLon == nil {
+		record.Latitude, record.Longitude = latitude, longitude
+		return record
+	}
+
+	x, errX := strconv.ParseFloat(values[FieldX], 64)
+	y, errY := strconv.ParseFloat(values[FieldY], 64)
+	if errX == nil && errY == nil {
+		crs := options.CRS
+		if crs == "" {
+			crs = coordinate.TWD97
+		}
+		if lat, lng, err := coordinate.ToWGS84(crs, coordinate.Point{X: x, Y: y}); err == nil {
+			record.Latitude, record.Longitude = lat, lng
+		}
+	}
+	return record
+}
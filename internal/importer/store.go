@@ -0,0 +1,132 @@
+package importer
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"intelligent-spatial-platform/internal/geo"
+	"intelligent-spatial-platform/internal/tiles"
+)
+
+// Store reads and writes the locations and historical_sites tables
+type Store struct {
+	db *gorm.DB
+}
+
+// NewStore returns a store on db
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// Existing loads the stored rows of kind as records to plan against
+func (s *Store) Existing(kind Kind) ([]Record, error) {
+	var records []Record
+	switch kind {
+	case KindLocation:
+		var locations []geo.Location
+		if err := s.db.Order("id").Find(&locations).Error; err != nil {
+			return nil, err
+		}
+		for _, location := range locations {
+			records = append(records, Record{
+				ID:        location.ID,
+				Name:      location.Name,
+				Latitude:  location.Latitude,
+				Longitude: location.Longitude,
+				Address:   location.Address,
+				Type:      location.Type,
+			})
+		}
+	case KindHistoricalSite:
+		var sites []geo.HistoricalSite
+		if err := s.db.Order("id").Find(&sites).Error; err != nil {
+			return nil, err
+		}
+		for _, site := range sites {
+			records = append(records, Record{
+				ID:          site.ID,
+				Name:        site.Name,
+				Latitude:    site.Latitude,
+				Longitude:   site.Longitude,
+				Address:     site.Address,
+				Description: site.Description,
+				Era:         site.Era,
+				Images:      site.Images,
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported kind: %s", kind)
+	}
+	return records, nil
+}
+
+// Apply creates and updates rows as planned, all or nothing
+func (s *Store) Apply(plan *Plan) error {
+	if plan.Empty() {
+		return nil
+	}
+
+	var model interface{}
+	var creates interface{}
+	switch plan.Kind {
+	case KindLocation:
+		model = &geo.Location{}
+		locations := make([]geo.Location, len(plan.Creates))
+		for i, record := range plan.Creates {
+			locations[i] = geo.Location{
+				Name:      record.Name,
+				Latitude:  record.Latitude,
+				Longitude: record.Longitude,
+				Address:   record.Address,
+				Type:      record.Type,
+			}
+		}
+		creates = locations
+	case KindHistoricalSite:
+		model = &geo.HistoricalSite{}
+		sites := make([]geo.HistoricalSite, len(plan.Creates))
+		for i, record := range plan.Creates {
+			sites[i] = geo.HistoricalSite{
+				Name:        record.Name,
+				Description: record.Description,
+				Era:         record.Era,
+				Latitude:    record.Latitude,
+				Longitude:   record.Longitude,
+				Address:     record.Address,
+				Images:      record.Images,
+				IsActive:    true,
+			}
+		}
+		creates = sites
+	default:
+		return fmt.Errorf("unsupported kind: %s", plan.Kind)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if len(plan.Creates) > 0 {
+			if err := tx.CreateInBatches(creates, 100).Error; err != nil {
+				return err
+			}
+		}
+		for _, update := range plan.Updates {
+			columns := make(map[string]interface{}, len(update.Changes))
+			for _, change := range update.Changes {
+				columns[change.Field] = change.New
+			}
+			if err := tx.Model(model).Where("id = ?", update.Record.ID).Updates(columns).Error; err != nil {
+				return fmt.Errorf("failed to update %s #%d: %v", update.Record.Name, update.Record.ID, err)
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// TileLayer is the vector tile layer showing rows of kind
+func (k Kind) TileLayer() string {
+	if k == KindHistoricalSite {
+		return tiles.LayerHistoricalSites
+	}
+	return tiles.LayerLocations
+}
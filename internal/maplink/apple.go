@@ -0,0 +1,71 @@
+package maplink
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// AppleExtractor parses maps.apple.com links (ll=, q=, address=, daddr=)
+type AppleExtractor struct{}
+
+func (e *AppleExtractor) Provider() Provider {
+	return ProviderApple
+}
+
+func (e *AppleExtractor) Match(u *url.URL) bool {
+	return strings.EqualFold(u.Hostname(), "maps.apple.com")
+}
+
+func (e *AppleExtractor) Extract(u *url.URL) (*Link, error) {
+	query := u.Query()
+	link := &Link{Kind: KindPlace}
+
+	if z := query.Get("z"); z != "" {
+		link.Zoom, _ = strconv.ParseFloat(z, 64)
+	}
+
+	// Directions: daddr is the destination, saddr the optional start
+	if daddr := query.Get("daddr"); daddr != "" {
+		link.Kind = KindDirections
+		link.Origin = query.Get("saddr")
+		if lat, lng, ok := parseCoordPair(daddr); ok {
+			return link.withCoordinates(lat, lng), nil
+		}
+		link.Query = daddr
+		return link, nil
+	}
+
+	// q= labels the pin; it is the query only when no ll= pin is given
+	link.Query = query.Get("q")
+	if lat, lng, ok := parseCoordPair(query.Get("ll")); ok {
+		if _, _, qIsCoords := parseCoordPair(link.Query); qIsCoords {
+			link.Query = ""
+		}
+		return link.withCoordinates(lat, lng), nil
+	}
+
+	if lat, lng, ok := parseCoordPair(link.Query); ok {
+		link.Kind = KindCoordinates
+		link.Query = ""
+		return link.withCoordinates(lat, lng), nil
+	}
+
+	if link.Query == "" {
+		link.Query = query.Get("address")
+	}
+	if link.Query != "" {
+		if query.Get("address") == "" && query.Get("sll") != "" {
+			link.Kind = KindSearch
+		}
+		return link, nil
+	}
+
+	// A bare map view centered with sll= (search location)
+	if lat, lng, ok := parseCoordPair(query.Get("sll")); ok {
+		link.Kind = KindCoordinates
+		return link.withCoordinates(lat, lng), nil
+	}
+
+	return nil, nil
+}
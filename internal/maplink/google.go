@@ -0,0 +1,175 @@
+package maplink
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// !3d<lat>!4d<lng> in the data parameter is the actual place pin
+	googlePinPattern = regexp.MustCompile(`!3d(-?\d+(?:\.\d+)?)!4d(-?\d+(?:\.\d+)?)`)
+	// !1d<lng>!2d<lat> pairs are route waypoints in /dir/ data
+	googleWaypointPattern = regexp.MustCompile(`!1d(-?\d+(?:\.\d+)?)!2d(-?\d+(?:\.\d+)?)`)
+	// @<lat>,<lng>,<zoom>z is the viewport center
+	googleViewportPattern = regexp.MustCompile(`@(-?\d+(?:\.\d+)?),(-?\d+(?:\.\d+)?)(?:,(\d+(?:\.\d+)?)z)?`)
+)
+
+// GoogleExtractor parses google.com/maps links: place, search, dir, @viewport,
+// ?q=/?ll= and api=1 URLs
+type GoogleExtractor struct{}
+
+func (e *GoogleExtractor) Provider() Provider {
+	return ProviderGoogle
+}
+
+func (e *GoogleExtractor) Match(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	if !strings.Contains(host, "google.") {
+		return false
+	}
+	return strings.HasPrefix(host, "maps.") || strings.HasPrefix(u.Path, "/maps")
+}
+
+func (e *GoogleExtractor) Extract(u *url.URL) (*Link, error) {
+	raw := u.EscapedPath() + "?" + u.RawQuery
+	segments := googlePathSegments(u)
+	query := u.Query()
+
+	link := &Link{}
+	if vp := googleViewportPattern.FindStringSubmatch(raw); vp != nil && vp[3] != "" {
+		link.Zoom, _ = strconv.ParseFloat(vp[3], 64)
+	}
+
+	action := ""
+	if len(segments) > 0 {
+		action = segments[0]
+	}
+
+	switch action {
+	case "place":
+		link.Kind = KindPlace
+		if len(segments) > 1 {
+			link.Query = segments[1]
+		}
+		if lat, lng, ok := lastPin(raw); ok {
+			return link.withCoordinates(lat, lng), nil
+		}
+		if lat, lng, ok := parseCoordPair(link.Query); ok {
+			return link.withCoordinates(lat, lng), nil
+		}
+		if lat, lng, ok := viewport(raw); ok {
+			return link.withCoordinates(lat, lng), nil
+		}
+		return nonEmpty(link), nil
+
+	case "search":
+		link.Kind = KindSearch
+		link.Query = query.Get("query")
+		if len(segments) > 1 {
+			link.Query = segments[1]
+		}
+		if lat, lng, ok := parseCoordPair(link.Query); ok {
+			link.Kind = KindCoordinates
+			link.Query = ""
+			return link.withCoordinates(lat, lng), nil
+		}
+		if lat, lng, ok := lastPin(raw); ok {
+			return link.withCoordinates(lat, lng), nil
+		}
+		return nonEmpty(link), nil
+
+	case "dir":
+		link.Kind = KindDirections
+		waypoints := segments[1:]
+		if destination := query.Get("destination"); destination != "" {
+			waypoints = []string{query.Get("origin"), destination}
+		}
+		if len(waypoints) > 1 {
+			link.Origin = waypoints[0]
+		}
+		if len(waypoints) > 0 {
+			link.Query = waypoints[len(waypoints)-1]
+		}
+		if lat, lng, ok := parseCoordPair(link.Query); ok {
+			link.Query = ""
+			return link.withCoordinates(lat, lng), nil
+		}
+		if pairs := googleWaypointPattern.FindAllStringSubmatch(raw, -1); len(pairs) > 0 {
+			last := pairs[len(pairs)-1]
+			lng, _ := strconv.ParseFloat(last[1], 64)
+			lat, _ := strconv.ParseFloat(last[2], 64)
+			if validLatLng(lat, lng) {
+				return link.withCoordinates(lat, lng), nil
+			}
+		}
+		return nonEmpty(link), nil
+	}
+
+	// Plain map URLs: ?q=, ?ll=, ?center=, ?query= or a bare @viewport
+	for _, key := range []string{"q", "query", "ll", "center"} {
+		value := query.Get(key)
+		if value == "" {
+			continue
+		}
+		if lat, lng, ok := parseCoordPair(value); ok {
+			link.Kind = KindCoordinates
+			return link.withCoordinates(lat, lng), nil
+		}
+		if key == "q" || key == "query" {
+			link.Kind = KindSearch
+			link.Query = value
+			return link, nil
+		}
+	}
+
+	if lat, lng, ok := viewport(raw); ok {
+		link.Kind = KindCoordinates
+		return link.withCoordinates(lat, lng), nil
+	}
+
+	return nil, nil
+}
+
+// googlePathSegments returns the decoded path segments after /maps, skipping
+// the @viewport and data= segments
+func googlePathSegments(u *url.URL) []string {
+	var segments []string
+	for _, segment := range strings.Split(u.EscapedPath(), "/") {
+		if segment == "" || segment == "maps" || strings.HasPrefix(segment, "@") || strings.HasPrefix(segment, "data=") {
+			continue
+		}
+		segments = append(segments, decodeSegment(segment))
+	}
+	return segments
+}
+
+func lastPin(raw string) (float64, float64, bool) {
+	pins := googlePinPattern.FindAllStringSubmatch(raw, -1)
+	if len(pins) == 0 {
+		return 0, 0, false
+	}
+	last := pins[len(pins)-1]
+	lat, _ := strconv.ParseFloat(last[1], 64)
+	lng, _ := strconv.ParseFloat(last[2], 64)
+	return lat, lng, validLatLng(lat, lng)
+}
+
+func viewport(raw string) (float64, float64, bool) {
+	vp := googleViewportPattern.FindStringSubmatch(raw)
+	if vp == nil {
+		return 0, 0, false
+	}
+	lat, _ := strconv.ParseFloat(vp[1], 64)
+	lng, _ := strconv.ParseFloat(vp[2], 64)
+	return lat, lng, validLatLng(lat, lng)
+}
+
+// nonEmpty returns nil for links that carry neither coordinates nor a query
+func nonEmpty(link *Link) *Link {
+	if !link.HasCoordinates && link.Query == "" {
+		return nil
+	}
+	return link
+}
@@ -0,0 +1,192 @@
+// Package maplink extracts locations from map links shared by Google Maps,
+// Apple Maps and OpenStreetMap, expanding short links through a RedirectResolver.
+package maplink
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Provider identifies the map service a link came from
+type Provider string
+
+const (
+	ProviderGoogle        Provider = "google"
+	ProviderApple         Provider = "apple"
+	ProviderOpenStreetMap Provider = "openstreetmap"
+)
+
+// Kind describes what the link points at
+type Kind string
+
+const (
+	KindCoordinates Kind = "coordinates" // a map view or dropped pin
+	KindPlace       Kind = "place"       // a named place
+	KindSearch      Kind = "search"      // a search query
+	KindDirections  Kind = "directions"  // a route; Destination is the target
+)
+
+// Link is the typed result of parsing a map link.
+// HasCoordinates is false when only a place name is known and it still needs geocoding.
+type Link struct {
+	Provider       Provider `json:"provider"`
+	Kind           Kind     `json:"kind"`
+	HasCoordinates bool     `json:"hasCoordinates"`
+	Latitude       float64  `json:"latitude,omitempty"`
+	Longitude      float64  `json:"longitude,omitempty"`
+	Zoom           float64  `json:"zoom,omitempty"`
+	Query          string   `json:"query,omitempty"`  // place name or search text to geocode
+	Origin         string   `json:"origin,omitempty"` // directions start, if given
+	URL            string   `json:"url"`              // the (expanded) link that was parsed
+	ShortURL       string   `json:"shortUrl,omitempty"`
+}
+
+// Extractor parses links for a single map provider
+type Extractor interface {
+	Provider() Provider
+	Match(u *url.URL) bool
+	Extract(u *url.URL) (*Link, error)
+}
+
+// Parser finds map links in text and dispatches them to provider extractors
+type Parser struct {
+	resolver   RedirectResolver
+	extractors []Extractor
+}
+
+var (
+	urlPattern      = regexp.MustCompile(`https?://[^\s<>"'，。！？、）」]+`)
+	coordPairRegexp = regexp.MustCompile(`^\s*(-?\d+(?:\.\d+)?)\s*,\s*\+?(-?\d+(?:\.\d+)?)\s*$`)
+)
+
+// builtinExtractors are the providers supported out of the box
+var builtinExtractors = []Extractor{
+	&GoogleExtractor{},
+	&AppleExtractor{},
+	&OSMExtractor{},
+}
+
+// NewParser creates a parser with the built-in Google, Apple and OpenStreetMap extractors
+func NewParser(resolver RedirectResolver) *Parser {
+	return &Parser{
+		resolver:   resolver,
+		extractors: builtinExtractors,
+	}
+}
+
+// FindURL returns the first http(s) link in text, or "" if there is none
+func FindURL(text string) string {
+	return strings.TrimRight(urlPattern.FindString(text), ".,;:!?)")
+}
+
+// ContainsLink reports whether text contains a link from a supported map provider
+func ContainsLink(text string) bool {
+	raw := FindURL(text)
+	if raw == "" {
+		return false
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	if IsShortLink(u) {
+		return true
+	}
+	for _, extractor := range builtinExtractors {
+		if extractor.Match(u) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse extracts the location from the first map link in text.
+// It returns (nil, nil) when text contains no link from a supported provider.
+func (p *Parser) Parse(text string) (*Link, error) {
+	raw := FindURL(text)
+	if raw == "" {
+		return nil, nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, nil
+	}
+
+	shortURL := ""
+	if IsShortLink(u) {
+		if p.resolver == nil {
+			return nil, fmt.Errorf("short link %s cannot be expanded: no resolver configured", raw)
+		}
+		expanded, err := p.resolver.Resolve(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand short link %s: %v", raw, err)
+		}
+		shortURL = raw
+		if u, err = url.Parse(expanded); err != nil {
+			return nil, fmt.Errorf("short link expanded to an invalid URL: %v", err)
+		}
+	}
+
+	for _, extractor := range p.extractors {
+		if !extractor.Match(u) {
+			continue
+		}
+
+		link, err := extractor.Extract(u)
+		if err != nil {
+			return nil, err
+		}
+		if link == nil {
+			return nil, fmt.Errorf("unrecognized %s link: %s", extractor.Provider(), u.String())
+		}
+
+		link.Provider = extractor.Provider()
+		link.URL = u.String()
+		link.ShortURL = shortURL
+		return link, nil
+	}
+
+	if shortURL != "" {
+		return nil, fmt.Errorf("short link %s expanded to an unsupported URL: %s", shortURL, u.String())
+	}
+	return nil, nil
+}
+
+// parseCoordPair parses "lat,lng" as used in most map link parameters
+func parseCoordPair(value string) (float64, float64, bool) {
+	matches := coordPairRegexp.FindStringSubmatch(value)
+	if matches == nil {
+		return 0, 0, false
+	}
+	lat, err1 := strconv.ParseFloat(matches[1], 64)
+	lng, err2 := strconv.ParseFloat(matches[2], 64)
+	if err1 != nil || err2 != nil || !validLatLng(lat, lng) {
+		return 0, 0, false
+	}
+	return lat, lng, true
+}
+
+func validLatLng(lat, lng float64) bool {
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180
+}
+
+// withCoordinates sets the link target position
+func (l *Link) withCoordinates(lat, lng float64) *Link {
+	l.Latitude = lat
+	l.Longitude = lng
+	l.HasCoordinates = true
+	return l
+}
+
+// decodeSegment URL-decodes a path segment and turns "+" into spaces
+func decodeSegment(segment string) string {
+	decoded, err := url.PathUnescape(segment)
+	if err != nil {
+		decoded = segment
+	}
+	return strings.TrimSpace(strings.ReplaceAll(decoded, "+", " "))
+}
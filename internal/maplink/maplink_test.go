@@ -0,0 +1,190 @@
+package maplink
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		provider Provider
+		kind     Kind
+		hasCoord bool
+		lat, lng float64
+		query    string
+		origin   string
+	}{
+		{
+			name:     "google viewport",
+			text:     "去這裡 https://www.google.com/maps/@23.0162277,120.2353557,15z",
+			provider: ProviderGoogle, kind: KindCoordinates, hasCoord: true,
+			lat: 23.0162277, lng: 120.2353557,
+		},
+		{
+			name:     "google place pin wins over viewport",
+			text:     "https://www.google.com/maps/place/%E5%8F%B0%E5%8C%97101/@25.0339,121.5619,17z/data=!3m1!4b1!4m6!3m5!1s0x0:0x0!8m2!3d25.0337!4d121.5645",
+			provider: ProviderGoogle, kind: KindPlace, hasCoord: true,
+			lat: 25.0337, lng: 121.5645, query: "台北101",
+		},
+		{
+			name:     "google place name only",
+			text:     "https://www.google.com.tw/maps/place/%E8%B5%A4%E5%B4%81%E6%A8%93",
+			provider: ProviderGoogle, kind: KindPlace, query: "赤崁樓",
+		},
+		{
+			name:     "google search query",
+			text:     "https://www.google.com/maps/search/%E9%BC%8E%E6%B3%B0%E8%B1%90+%E4%BF%A1%E7%BE%A9",
+			provider: ProviderGoogle, kind: KindSearch, query: "鼎泰豐 信義",
+		},
+		{
+			name:     "google search coordinates",
+			text:     "https://www.google.com/maps/search/?api=1&query=22.6273,120.3014",
+			provider: ProviderGoogle, kind: KindCoordinates, hasCoord: true,
+			lat: 22.6273, lng: 120.3014,
+		},
+		{
+			name:     "google directions by name",
+			text:     "https://www.google.com/maps/dir/%E5%8F%B0%E5%8C%97%E8%BB%8A%E7%AB%99/%E5%8F%B0%E5%8C%97101/",
+			provider: ProviderGoogle, kind: KindDirections, query: "台北101", origin: "台北車站",
+		},
+		{
+			name:     "google directions with data waypoints",
+			text:     "https://www.google.com/maps/dir/A/B/@25.04,121.53,14z/data=!4m8!4m7!1m2!1d121.517!2d25.047!1m2!1d121.5645!2d25.0337",
+			provider: ProviderGoogle, kind: KindDirections, hasCoord: true,
+			lat: 25.0337, lng: 121.5645, query: "B", origin: "A",
+		},
+		{
+			name:     "google api=1 directions",
+			text:     "https://www.google.com/maps/dir/?api=1&destination=24.1477,120.6736",
+			provider: ProviderGoogle, kind: KindDirections, hasCoord: true,
+			lat: 24.1477, lng: 120.6736,
+		},
+		{
+			name:     "google q parameter",
+			text:     "https://maps.google.com/?q=25.1023,121.5485",
+			provider: ProviderGoogle, kind: KindCoordinates, hasCoord: true,
+			lat: 25.1023, lng: 121.5485,
+		},
+		{
+			name:     "apple pin with label",
+			text:     "https://maps.apple.com/?ll=25.0339,121.5645&q=Taipei%20101&z=16",
+			provider: ProviderApple, kind: KindPlace, hasCoord: true,
+			lat: 25.0339, lng: 121.5645, query: "Taipei 101",
+		},
+		{
+			name:     "apple address",
+			text:     "https://maps.apple.com/?address=No.%207,%20Section%205,%20Xinyi%20Road,%20Taipei",
+			provider: ProviderApple, kind: KindPlace, query: "No. 7, Section 5, Xinyi Road, Taipei",
+		},
+		{
+			name:     "apple directions",
+			text:     "https://maps.apple.com/?saddr=Taipei&daddr=22.9997,120.2270",
+			provider: ProviderApple, kind: KindDirections, hasCoord: true,
+			lat: 22.9997, lng: 120.2270, origin: "Taipei",
+		},
+		{
+			name:     "osm marker wins over view",
+			text:     "https://www.openstreetmap.org/?mlat=25.0478&mlon=121.5170#map=17/25.0400/121.5000",
+			provider: ProviderOpenStreetMap, kind: KindCoordinates, hasCoord: true,
+			lat: 25.0478, lng: 121.5170,
+		},
+		{
+			name:     "osm map view",
+			text:     "https://www.openstreetmap.org/#map=15/23.9937/121.6011",
+			provider: ProviderOpenStreetMap, kind: KindCoordinates, hasCoord: true,
+			lat: 23.9937, lng: 121.6011,
+		},
+	}
+
+	parser := NewParser(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, err := parser.Parse(tt.text)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if link == nil {
+				t.Fatal("Parse() returned no link")
+			}
+			if link.Provider != tt.provider || link.Kind != tt.kind {
+				t.Errorf("got %s/%s, want %s/%s", link.Provider, link.Kind, tt.provider, tt.kind)
+			}
+			if link.HasCoordinates != tt.hasCoord {
+				t.Fatalf("HasCoordinates = %v, want %v", link.HasCoordinates, tt.hasCoord)
+			}
+			if tt.hasCoord && (math.Abs(link.Latitude-tt.lat) > 1e-6 || math.Abs(link.Longitude-tt.lng) > 1e-6) {
+				t.Errorf("coordinates = (%v, %v), want (%v, %v)", link.Latitude, link.Longitude, tt.lat, tt.lng)
+			}
+			if link.Query != tt.query {
+				t.Errorf("Query = %q, want %q", link.Query, tt.query)
+			}
+			if link.Origin != tt.origin {
+				t.Errorf("Origin = %q, want %q", link.Origin, tt.origin)
+			}
+		})
+	}
+}
+
+func TestParseNoLink(t *testing.T) {
+	parser := NewParser(nil)
+	for _, text := range []string{"往北走 100 公尺", "https://example.com/maps/@25,121"} {
+		link, err := parser.Parse(text)
+		if err != nil || link != nil {
+			t.Errorf("Parse(%q) = %v, %v; want nil, nil", text, link, err)
+		}
+	}
+}
+
+func TestParseShortLink(t *testing.T) {
+	resolver := StaticResolver{
+		"https://maps.app.goo.gl/abc123": "https://www.google.com/maps/place/Taipei+101/data=!3d25.0337!4d121.5645",
+	}
+	parser := NewParser(resolver)
+
+	link, err := parser.Parse("帶我去 https://maps.app.goo.gl/abc123")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if link.ShortURL != "https://maps.app.goo.gl/abc123" || !link.HasCoordinates || link.Query != "Taipei 101" {
+		t.Errorf("unexpected link %+v", link)
+	}
+
+	if _, err := parser.Parse("https://maps.app.goo.gl/unknown"); err == nil {
+		t.Error("expected an error for an unresolvable short link")
+	}
+	if _, err := NewParser(nil).Parse("https://maps.app.goo.gl/abc123"); err == nil {
+		t.Error("expected an error without a resolver")
+	}
+}
+
+func TestHTTPResolver(t *testing.T) {
+	target := "https://www.openstreetmap.org/?mlat=25.0&mlon=121.5"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") == "" {
+			t.Error("resolver should send a User-Agent")
+		}
+		http.Redirect(w, r, target, http.StatusFound)
+	}))
+	defer server.Close()
+
+	expanded, err := NewHTTPResolver().Resolve(server.URL + "/short")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if expanded != target {
+		t.Errorf("Resolve() = %q, want %q", expanded, target)
+	}
+}
+
+func TestContainsLink(t *testing.T) {
+	if !ContainsLink("看看 https://maps.apple.com/?q=Taipei") {
+		t.Error("expected Apple Maps link to be detected")
+	}
+	if ContainsLink("https://github.com/maps") {
+		t.Error("non-map link should not be detected")
+	}
+}
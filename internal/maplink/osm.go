@@ -0,0 +1,60 @@
+package maplink
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// #map=<zoom>/<lat>/<lon>
+var osmMapFragmentPattern = regexp.MustCompile(`map=(\d+(?:\.\d+)?)/(-?\d+(?:\.\d+)?)/(-?\d+(?:\.\d+)?)`)
+
+// OSMExtractor parses openstreetmap.org links (mlat/mlon markers and #map= views)
+type OSMExtractor struct{}
+
+func (e *OSMExtractor) Provider() Provider {
+	return ProviderOpenStreetMap
+}
+
+func (e *OSMExtractor) Match(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	return host == "openstreetmap.org" || strings.HasSuffix(host, ".openstreetmap.org") ||
+		host == "osm.org" || host == "www.osm.org"
+}
+
+func (e *OSMExtractor) Extract(u *url.URL) (*Link, error) {
+	query := u.Query()
+	link := &Link{Kind: KindCoordinates}
+
+	fragment := osmMapFragmentPattern.FindStringSubmatch(u.Fragment)
+	if fragment != nil {
+		link.Zoom, _ = strconv.ParseFloat(fragment[1], 64)
+	}
+
+	// A marker (mlat/mlon) is the shared point; #map= is only the view
+	if lat, latErr := strconv.ParseFloat(query.Get("mlat"), 64); latErr == nil {
+		if lng, lngErr := strconv.ParseFloat(query.Get("mlon"), 64); lngErr == nil && validLatLng(lat, lng) {
+			return link.withCoordinates(lat, lng), nil
+		}
+	}
+
+	if search := query.Get("query"); search != "" {
+		if lat, lng, ok := parseCoordPair(search); ok {
+			return link.withCoordinates(lat, lng), nil
+		}
+		link.Kind = KindSearch
+		link.Query = search
+		return link, nil
+	}
+
+	if fragment != nil {
+		lat, _ := strconv.ParseFloat(fragment[2], 64)
+		lng, _ := strconv.ParseFloat(fragment[3], 64)
+		if validLatLng(lat, lng) {
+			return link.withCoordinates(lat, lng), nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,104 @@
+package maplink
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RedirectResolver expands a short link to the URL it redirects to.
+// Tests swap in StaticResolver so no network access is needed.
+type RedirectResolver interface {
+	Resolve(shortURL string) (string, error)
+}
+
+// shortLinkHosts are hosts whose links must be expanded before parsing
+var shortLinkHosts = map[string]bool{
+	"maps.app.goo.gl": true,
+	"goo.gl":          true,
+	"g.co":            true,
+	"maps.apple":      true,
+}
+
+// IsShortLink reports whether the URL is a map short link that needs expanding
+func IsShortLink(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	if !shortLinkHosts[host] {
+		return false
+	}
+	// goo.gl itself is retired except for the /maps/ and /app/ prefixes
+	if host == "goo.gl" {
+		return strings.HasPrefix(u.Path, "/maps") || strings.HasPrefix(u.Path, "/app")
+	}
+	return true
+}
+
+// HTTPResolver follows redirects over the network without downloading the target page
+type HTTPResolver struct {
+	client  *http.Client
+	maxHops int
+}
+
+// NewHTTPResolver creates a resolver that follows up to 5 redirects
+func NewHTTPResolver() *HTTPResolver {
+	return &HTTPResolver{
+		client: &http.Client{
+			Timeout: 5 * time.Second,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		maxHops: 5,
+	}
+}
+
+// Resolve follows Location headers until a non-redirect response or a non-short link is reached
+func (r *HTTPResolver) Resolve(shortURL string) (string, error) {
+	current := shortURL
+
+	for hop := 0; hop < r.maxHops; hop++ {
+		req, err := http.NewRequest("GET", current, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to create request: %v", err)
+		}
+		req.Header.Set("User-Agent", "IntelligentSpatialPlatform/1.0")
+
+		resp, err := r.client.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("redirect request failed: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 {
+			if hop == 0 {
+				return "", fmt.Errorf("short link did not redirect (status %d)", resp.StatusCode)
+			}
+			return current, nil
+		}
+
+		location, err := resp.Location()
+		if err != nil {
+			return "", fmt.Errorf("redirect without location: %v", err)
+		}
+		current = location.String()
+
+		if !IsShortLink(location) {
+			return current, nil
+		}
+	}
+
+	return "", fmt.Errorf("too many redirects expanding %s", shortURL)
+}
+
+// StaticResolver expands short links from a fixed map, for tests and offline use
+type StaticResolver map[string]string
+
+// Resolve returns the configured expansion for shortURL
+func (r StaticResolver) Resolve(shortURL string) (string, error) {
+	if expanded, ok := r[shortURL]; ok {
+		return expanded, nil
+	}
+	return "", fmt.Errorf("unknown short link: %s", shortURL)
+}
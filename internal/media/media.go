@@ -0,0 +1,251 @@
+// Package media stores uploaded images and audio guides. Objects live in a
+// pluggable Storage under opaque keys; images get a JPEG thumbnail next to the
+// original, and clients reach both through expiring signed URLs.
+package media
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/png"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Upload size limits
+const (
+	MaxImageSize = 10 << 20
+	MaxAudioSize = 30 << 20
+)
+
+// maxImagePixels rejects images that would take too much memory to decode
+const maxImagePixels = 40_000_000
+
+// ErrUnsupportedType is returned for an upload that is not an accepted image or audio format
+var ErrUnsupportedType = errors.New("unsupported media type")
+
+// ErrTooLarge is returned for an upload over its size limit
+var ErrTooLarge = errors.New("media file is too large")
+
+// imageTypes maps accepted image content types to the extension they are stored with
+var imageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// audioTypes maps accepted audio content types to the extension they are stored with
+var audioTypes = map[string]string{
+	"audio/mpeg": ".mp3",
+	"audio/mp4":  ".m4a",
+	"audio/ogg":  ".ogg",
+	"audio/wav":  ".wav",
+	"audio/webm": ".webm",
+}
+
+// contentTypes is the inverse of the tables above, for serving
+var contentTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".mp3":  "audio/mpeg",
+	".m4a":  "audio/mp4",
+	".ogg":  "audio/ogg",
+	".wav":  "audio/wav",
+	".webm": "audio/webm",
+}
+
+// ContentType returns the content type a key is served with
+func ContentType(key string) string {
+	if contentType, ok := contentTypes[strings.ToLower(path.Ext(key))]; ok {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// ThumbnailKey is where the thumbnail of an image key is stored
+func ThumbnailKey(key string) string {
+	return strings.TrimSuffix(key, path.Ext(key)) + ".thumb.jpg"
+}
+
+// IsKey reports whether a stored media reference is a storage key rather than
+// an external URL, such as the links brought in by open data imports
+func IsKey(value string) bool {
+	return ValidKey(value)
+}
+
+// Service stores uploads and signs URLs to them
+type Service struct {
+	storage Storage
+	signer  *Signer
+}
+
+// NewService stores media in storage and signs URLs with signer
+func NewService(storage Storage, signer *Signer) *Service {
+	return &Service{storage: storage, signer: signer}
+}
+
+// Storage returns the backing storage
+func (s *Service) Storage() Storage {
+	return s.storage
+}
+
+// Signer returns the URL signer
+func (s *Service) Signer() *Signer {
+	return s.signer
+}
+
+// SaveImage stores an uploaded image under prefix with its thumbnail and
+// returns the key of the original. The original is kept byte for byte.
+func (s *Service) SaveImage(prefix string, r io.Reader) (string, error) {
+	data, err := readLimited(r, MaxImageSize)
+	if err != nil {
+		return "", err
+	}
+	ext, ok := imageTypes[http.DetectContentType(data)]
+	if !ok {
+		return "", ErrUnsupportedType
+	}
+	thumbnail, err := Thumbnail(data)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := newKey(prefix, ext)
+	if err != nil {
+		return "", err
+	}
+	if err := s.storage.Put(key, bytes.NewReader(data)); err != nil {
+		return "", fmt.Errorf("failed to store image: %v", err)
+	}
+	if err := s.storage.Put(ThumbnailKey(key), bytes.NewReader(thumbnail)); err != nil {
+		s.storage.Delete(key)
+		return "", fmt.Errorf("failed to store thumbnail: %v", err)
+	}
+	return key, nil
+}
+
+// SaveAudio stores an uploaded audio file under prefix and returns its key.
+// filename is only used to recognise MP3 files without an ID3 header.
+func (s *Service) SaveAudio(prefix, filename string, r io.Reader) (string, error) {
+	data, err := readLimited(r, MaxAudioSize)
+	if err != nil {
+		return "", err
+	}
+	ext, ok := audioTypes[audioType(data, filename)]
+	if !ok {
+		return "", ErrUnsupportedType
+	}
+
+	key, err := newKey(prefix, ext)
+	if err != nil {
+		return "", err
+	}
+	if err := s.storage.Put(key, bytes.NewReader(data)); err != nil {
+		return "", fmt.Errorf("failed to store audio: %v", err)
+	}
+	return key, nil
+}
+
+// Delete removes a stored object and its thumbnail, if it has one. External
+// URLs are left alone.
+func (s *Service) Delete(key string) error {
+	if !IsKey(key) {
+		return nil
+	}
+	if err := s.storage.Delete(key); err != nil {
+		return err
+	}
+	if _, isImage := imageTypes[ContentType(key)]; isImage {
+		return s.storage.Delete(ThumbnailKey(key))
+	}
+	return nil
+}
+
+// URL returns a signed URL for a stored key; external URLs are returned as
+// they are. A nil service returns every value unchanged.
+func (s *Service) URL(value string) string {
+	if s == nil || !IsKey(value) {
+		return value
+	}
+	return s.signer.URL(value)
+}
+
+// ThumbnailURL returns a signed URL for the thumbnail of a stored image, or
+// the value itself for an external URL
+func (s *Service) ThumbnailURL(value string) string {
+	if s == nil || !IsKey(value) {
+		return value
+	}
+	return s.signer.URL(ThumbnailKey(value))
+}
+
+// audioType detects the content type of an audio file
+func audioType(data []byte, filename string) string {
+	switch detected := http.DetectContentType(data); detected {
+	case "audio/mpeg":
+		return detected
+	case "audio/wave":
+		return "audio/wav"
+	case "application/ogg":
+		return "audio/ogg"
+	case "video/mp4":
+		// M4A audio and MP4 video share a container
+		return "audio/mp4"
+	case "video/webm":
+		return "audio/webm"
+	}
+	// MPEG frames without an ID3 tag are not sniffed
+	if strings.EqualFold(path.Ext(filename), ".mp3") && len(data) > 1 && data[0] == 0xFF && data[1]&0xE0 == 0xE0 {
+		return "audio/mpeg"
+	}
+	return ""
+}
+
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+func newKey(prefix, ext string) (string, error) {
+	var id [12]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	key := hex.EncodeToString(id[:]) + ext
+	if prefix != "" {
+		key = strings.Trim(prefix, "/") + "/" + key
+	}
+	if !ValidKey(key) {
+		return "", fmt.Errorf("invalid media key %q", key)
+	}
+	return key, nil
+}
+
+// decodeImage decodes an image, refusing ones too large to hold in memory
+func decodeImage(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxImagePixels {
+		return nil, fmt.Errorf("image of %dx%d pixels is too large", config.Width, config.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid image: %v", err)
+	}
+	return img, nil
+}
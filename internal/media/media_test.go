@@ -0,0 +1,156 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: 200, G: 40, B: 40, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSaveImageStoresThumbnail(t *testing.T) {
+	storage, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := NewService(storage, NewSigner([]byte("secret"), time.Hour))
+
+	key, err := service.SaveImage("sites/7", bytes.NewReader(testPNG(t, 1000, 500)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(key, "sites/7/") || !strings.HasSuffix(key, ".png") || !IsKey(key) {
+		t.Fatalf("key = %q", key)
+	}
+
+	object, err := storage.Open(ThumbnailKey(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer object.Close()
+	thumbnail, err := jpeg.Decode(object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := thumbnail.Bounds().Size(); size.X != ThumbnailSize || size.Y != ThumbnailSize/2 {
+		t.Errorf("thumbnail size = %v", size)
+	}
+	if r, _, _, _ := thumbnail.At(10, 10).RGBA(); r>>8 < 180 {
+		t.Errorf("thumbnail lost its colour: r = %d", r>>8)
+	}
+
+	if err := service.Delete(key); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{key, ThumbnailKey(key)} {
+		if _, err := storage.Open(k); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Open(%q) after delete: %v", k, err)
+		}
+	}
+}
+
+func TestSaveRejectsUnsupportedAndLargeFiles(t *testing.T) {
+	storage, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := NewService(storage, NewSigner([]byte("secret"), 0))
+
+	if _, err := service.SaveImage("sites/1", strings.NewReader("<svg></svg>")); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("SaveImage(svg) = %v", err)
+	}
+	if _, err := service.SaveAudio("sites/1", "guide.txt", strings.NewReader("hello")); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("SaveAudio(text) = %v", err)
+	}
+	large := io.LimitReader(zeros{}, MaxImageSize+1)
+	if _, err := service.SaveImage("sites/1", large); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("SaveImage(large) = %v", err)
+	}
+
+	mp3 := append([]byte{0xFF, 0xFB, 0x90, 0x64}, make([]byte, 400)...)
+	key, err := service.SaveAudio("sites/1", "guide.mp3", bytes.NewReader(mp3))
+	if err != nil || ContentType(key) != "audio/mpeg" {
+		t.Errorf("SaveAudio(mp3) = %q, %v", key, err)
+	}
+}
+
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func TestSignerURL(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	signer := NewSigner([]byte("secret"), time.Hour)
+	signer.now = func() time.Time { return now }
+
+	signed := signer.URL("sites/7/a b.jpg")
+	if !strings.HasPrefix(signed, DefaultURLPrefix+"sites/7/a%20b.jpg?expires=") {
+		t.Fatalf("URL = %q", signed)
+	}
+	// URLs are stable within a window, so browsers can cache them
+	now = now.Add(time.Minute)
+	if again := signer.URL("sites/7/a b.jpg"); again != signed {
+		t.Errorf("URL changed within the window: %q, %q", signed, again)
+	}
+
+	query := signed[strings.Index(signed, "?")+1:]
+	values := map[string]string{}
+	for _, pair := range strings.Split(query, "&") {
+		name, value, _ := strings.Cut(pair, "=")
+		values[name] = value
+	}
+	if err := signer.Verify("sites/7/a b.jpg", values["expires"], values["sig"]); err != nil {
+		t.Errorf("Verify() = %v", err)
+	}
+	if err := signer.Verify("sites/7/other.jpg", values["expires"], values["sig"]); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify(other key) = %v", err)
+	}
+	if err := NewSigner([]byte("other"), time.Hour).Verify("sites/7/a b.jpg", values["expires"], values["sig"]); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify(other secret) = %v", err)
+	}
+	now = now.Add(2 * time.Hour)
+	if err := signer.Verify("sites/7/a b.jpg", values["expires"], values["sig"]); !errors.Is(err, ErrExpired) {
+		t.Errorf("Verify(expired) = %v", err)
+	}
+}
+
+func TestValidKey(t *testing.T) {
+	for key, want := range map[string]bool{
+		"sites/7/a.jpg":             true,
+		"a.jpg":                     true,
+		"":                          false,
+		"/etc/passwd":               false,
+		"sites/../../etc/passwd":    false,
+		"sites//a.jpg":              false,
+		"sites/.upload-1":           false,
+		"https://example.org/a.jpg": false,
+		"sites\\a.jpg":              false,
+	} {
+		if got := ValidKey(key); got != want {
+			t.Errorf("ValidKey(%q) = %v", key, got)
+		}
+	}
+}
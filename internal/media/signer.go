@@ -0,0 +1,90 @@
+package media
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultURLPrefix is where the server serves signed media
+const DefaultURLPrefix = "/api/v1/media/"
+
+// DefaultURLTTL is how long a signed URL stays valid
+const DefaultURLTTL = time.Hour
+
+var (
+	ErrInvalidSignature = errors.New("invalid media signature")
+	ErrExpired          = errors.New("media URL has expired")
+)
+
+// Signer produces and checks expiring media URLs of the form
+// <prefix><key>?expires=<unix seconds>&sig=<HMAC-SHA256 of key and expiry>
+type Signer struct {
+	secret []byte
+	ttl    time.Duration
+	prefix string
+	now    func() time.Time
+}
+
+// NewSigner signs with secret; a ttl of zero uses DefaultURLTTL
+func NewSigner(secret []byte, ttl time.Duration) *Signer {
+	if ttl <= 0 {
+		ttl = DefaultURLTTL
+	}
+	return &Signer{secret: secret, ttl: ttl, prefix: DefaultURLPrefix, now: time.Now}
+}
+
+// URL signs key. Expiry times are rounded up to a quarter of the TTL, so the
+// same media gets the same URL for a while and browsers can cache it.
+func (s *Signer) URL(key string) string {
+	window := int64(s.ttl / time.Second / 4)
+	if window < 1 {
+		window = 1
+	}
+	expires := s.now().Add(s.ttl).Unix()
+	expires += (window - expires%window) % window
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("sig", s.signature(key, expires))
+	return s.prefix + escapeKey(key) + "?" + query.Encode()
+}
+
+// Verify checks the expires and sig query values of a request for key
+func (s *Signer) Verify(key, expires, signature string) error {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !hmac.Equal([]byte(signature), []byte(s.signature(key, unix))) {
+		return ErrInvalidSignature
+	}
+	if s.now().Unix() > unix {
+		return ErrExpired
+	}
+	return nil
+}
+
+// Expiry returns when a verified URL stops being valid
+func (s *Signer) Expiry(expires string) time.Time {
+	unix, _ := strconv.ParseInt(expires, 10, 64)
+	return time.Unix(unix, 0)
+}
+
+func (s *Signer) signature(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strconv.FormatInt(expires, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func escapeKey(key string) string {
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
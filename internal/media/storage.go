@@ -0,0 +1,133 @@
+package media
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned for a key with no stored object
+var ErrNotFound = errors.New("media not found")
+
+// Storage keeps media objects by key. Keys are slash-separated relative paths
+// such as "sites/12/3f9a1c.jpg". LocalStorage is the built-in implementation;
+// an object store can be plugged in by implementing the same methods.
+type Storage interface {
+	Put(key string, r io.Reader) error
+	Open(key string) (Object, error)
+	Delete(key string) error // deleting a missing key is not an error
+}
+
+// Object is an opened media object; it seeks so that audio can be streamed in ranges
+type Object interface {
+	io.ReadSeekCloser
+	ModTime() time.Time
+}
+
+// ValidKey reports whether key is a clean relative path that cannot escape the storage root
+func ValidKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || strings.Contains(key, "://") {
+		return false
+	}
+	if path.Clean(key) != key {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." || part == "." || strings.HasPrefix(part, ".") {
+			return false
+		}
+	}
+	return true
+}
+
+// LocalStorage keeps objects as files under a directory
+type LocalStorage struct {
+	dir string
+}
+
+// NewLocalStorage stores objects under dir, creating it when needed
+func NewLocalStorage(dir string) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("media storage: %v", err)
+	}
+	return &LocalStorage{dir: dir}, nil
+}
+
+func (s *LocalStorage) path(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", fmt.Errorf("invalid media key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes the object through a temporary file, so a failed upload leaves nothing behind
+func (s *LocalStorage) Put(key string, r io.Reader) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), target)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+func (s *LocalStorage) Open(key string) (Object, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(target)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &localObject{File: file, modTime: info.ModTime()}, nil
+}
+
+func (s *LocalStorage) Delete(key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+type localObject struct {
+	*os.File
+	modTime time.Time
+}
+
+func (o *localObject) ModTime() time.Time {
+	return o.modTime
+}
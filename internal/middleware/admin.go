@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuth protects admin endpoints with the ADMIN_API_TOKEN shared secret,
+// sent as "Authorization: Bearer <token>" or "X-Admin-Token: <token>".
+// Admin endpoints are disabled when the variable is not set.
+func AdminAuth() gin.HandlerFunc {
+	token := os.Getenv("ADMIN_API_TOKEN")
+
+	return func(c *gin.Context) {
+		if token == "" {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Admin API is disabled. Set ADMIN_API_TOKEN to enable it.",
+			})
+			c.Abort()
+			return
+		}
+
+		provided := c.GetHeader("X-Admin-Token")
+		if provided == "" {
+			provided = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		}
+
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid admin token"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
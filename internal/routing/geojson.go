@@ -0,0 +1,110 @@
+package routing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// coordinatePrecision (1e-7 degrees, about 1 cm) decides when two GeoJSON
+// vertices are the same node
+const coordinatePrecision = 1e7
+
+// ReadGeoJSON adds the LineString and MultiLineString features of a GeoJSON
+// FeatureCollection to the builder. Feature properties are read as OSM tags
+// (highway, oneway, maxspeed, foot, bicycle, access ...); a line without a highway
+// property is treated as an ordinary road open to every profile.
+func (b *Builder) ReadGeoJSON(r io.Reader) error {
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Properties map[string]interface{} `json:"properties"`
+			Geometry   *struct {
+				Type        string          `json:"type"`
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+		} `json:"features"`
+	}
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return fmt.Errorf("invalid GeoJSON: %v", err)
+	}
+	if collection.Type != "FeatureCollection" {
+		return fmt.Errorf("expected a FeatureCollection, got %q", collection.Type)
+	}
+
+	nodeIDs := make(map[[2]int64]int64)
+	nodeID := func(position []float64) (int64, error) {
+		if len(position) < 2 || position[0] < -180 || position[0] > 180 || position[1] < -90 || position[1] > 90 {
+			return 0, fmt.Errorf("invalid position %v", position)
+		}
+		key := [2]int64{int64(math.Round(position[0] * coordinatePrecision)), int64(math.Round(position[1] * coordinatePrecision))}
+		if id, ok := nodeIDs[key]; ok {
+			return id, nil
+		}
+		id := int64(len(nodeIDs) + 1)
+		nodeIDs[key] = id
+		b.SetNode(id, position[1], position[0])
+		return id, nil
+	}
+
+	for i, feature := range collection.Features {
+		if feature.Geometry == nil {
+			continue
+		}
+
+		var lines [][][]float64
+		switch feature.Geometry.Type {
+		case "LineString":
+			var line [][]float64
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &line); err != nil {
+				return fmt.Errorf("feature %d: %v", i, err)
+			}
+			lines = append(lines, line)
+		case "MultiLineString":
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &lines); err != nil {
+				return fmt.Errorf("feature %d: %v", i, err)
+			}
+		default:
+			continue
+		}
+
+		tags := geoJSONTags(feature.Properties)
+		if tags["highway"] == "" {
+			tags["highway"] = "road"
+		}
+		for _, line := range lines {
+			refs := make([]int64, 0, len(line))
+			for _, position := range line {
+				id, err := nodeID(position)
+				if err != nil {
+					return fmt.Errorf("feature %d: %v", i, err)
+				}
+				refs = append(refs, id)
+			}
+			b.AddWay(refs, tags)
+		}
+	}
+	return nil
+}
+
+// geoJSONTags turns feature properties into string tags
+func geoJSONTags(properties map[string]interface{}) map[string]string {
+	tags := make(map[string]string, len(properties))
+	for key, value := range properties {
+		switch v := value.(type) {
+		case string:
+			tags[key] = v
+		case float64:
+			tags[key] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			if v {
+				tags[key] = "yes"
+			} else {
+				tags[key] = "no"
+			}
+		}
+	}
+	return tags
+}
@@ -0,0 +1,226 @@
+package routing
+
+import (
+	"fmt"
+	"math"
+)
+
+// LatLng is a WGS84 coordinate
+type LatLng struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Graph is a road network in compressed adjacency form. Nodes are only kept where
+// ways meet or end; the shape points between them are folded into the segment
+// geometry, which keeps the search space small and leaves room for shortcut edges.
+type Graph struct {
+	vertices []LatLng
+	segments []segment
+	shapes   []LatLng // segment geometries, back to back
+
+	// outgoing arcs of vertex v are arcs[firstArc[v]:firstArc[v+1]]
+	firstArc []int32
+	arcs     []arc
+
+	index *segmentIndex
+}
+
+// segment is a stretch of road between two vertices
+type segment struct {
+	from, to   int32
+	shapeStart int32 // shapes[shapeStart:shapeEnd] runs from -> to, endpoints included
+	shapeEnd   int32
+	length     float32 // meters
+	forward    [profileCount]bool
+	backward   [profileCount]bool
+	speed      [profileCount]float32 // m/s
+}
+
+// arc is a segment leaving a vertex in one direction
+type arc struct {
+	segment int32
+	reverse bool // travels to -> from
+}
+
+func (s *segment) allows(profile Profile, reverse bool) bool {
+	if reverse {
+		return s.backward[profile]
+	}
+	return s.forward[profile]
+}
+
+func (s *segment) usable(profile Profile) bool {
+	return s.forward[profile] || s.backward[profile]
+}
+
+// Stats describes the size of a graph
+type Stats struct {
+	Vertices int `json:"vertices"`
+	Segments int `json:"segments"`
+	Points   int `json:"points"`
+}
+
+// Stats returns the vertex, segment and shape point counts
+func (g *Graph) Stats() Stats {
+	return Stats{Vertices: len(g.vertices), Segments: len(g.segments), Points: len(g.shapes)}
+}
+
+// Builder collects OSM-style nodes and ways and turns them into a Graph
+type Builder struct {
+	nodes map[int64]LatLng
+	ways  []builderWay
+}
+
+type builderWay struct {
+	refs   []int64
+	access wayAccess
+}
+
+// NewBuilder returns an empty builder
+func NewBuilder() *Builder {
+	return &Builder{nodes: make(map[int64]LatLng)}
+}
+
+// SetNode records the position of node id
+func (b *Builder) SetNode(id int64, latitude, longitude float64) {
+	b.nodes[id] = LatLng{Latitude: latitude, Longitude: longitude}
+}
+
+// AddWay adds a way through node ids with its OSM tags. It reports whether any
+// profile can use the way; ways no profile can use are dropped.
+func (b *Builder) AddWay(refs []int64, tags map[string]string) bool {
+	if len(refs) < 2 {
+		return false
+	}
+	access := evaluateWay(tags)
+	if !access.usable() {
+		return false
+	}
+	b.ways = append(b.ways, builderWay{refs: append([]int64(nil), refs...), access: access})
+	return true
+}
+
+// Build splits the ways at shared nodes and indexes the result. Ways that run
+// off the edge of the extract are cut where their nodes are missing.
+func (b *Builder) Build() (*Graph, error) {
+	// A node becomes a vertex where a way ends or where ways (or a way and itself) meet
+	uses := make(map[int64]int, len(b.nodes)/4)
+	for _, way := range b.ways {
+		for i, ref := range way.refs {
+			if _, ok := b.nodes[ref]; !ok {
+				continue
+			}
+			uses[ref]++
+			if i == 0 || i == len(way.refs)-1 {
+				uses[ref]++
+			}
+		}
+	}
+
+	g := &Graph{}
+	vertexOf := make(map[int64]int32)
+	vertex := func(ref int64) int32 {
+		if id, ok := vertexOf[ref]; ok {
+			return id
+		}
+		id := int32(len(g.vertices))
+		vertexOf[ref] = id
+		g.vertices = append(g.vertices, b.nodes[ref])
+		return id
+	}
+
+	for _, way := range b.ways {
+		start := -1 // index in refs of the open segment's first node
+		for i, ref := range way.refs {
+			if _, ok := b.nodes[ref]; !ok {
+				start = -1
+				continue
+			}
+			if start < 0 {
+				start = i
+				continue
+			}
+			if uses[ref] < 2 && i < len(way.refs)-1 {
+				if _, ok := b.nodes[way.refs[i+1]]; ok {
+					continue // shape point
+				}
+			}
+			g.addSegment(vertex(way.refs[start]), vertex(ref), way.refs[start:i+1], b.nodes, way.access)
+			start = i
+		}
+	}
+
+	if len(g.segments) == 0 {
+		return nil, fmt.Errorf("road network has no routable ways")
+	}
+
+	g.buildArcs()
+	g.index = newSegmentIndex(g)
+	return g, nil
+}
+
+func (g *Graph) addSegment(from, to int32, refs []int64, nodes map[int64]LatLng, access wayAccess) {
+	shapeStart := int32(len(g.shapes))
+	length := 0.0
+	for i, ref := range refs {
+		point := nodes[ref]
+		if i > 0 {
+			length += haversine(g.shapes[len(g.shapes)-1], point)
+		}
+		g.shapes = append(g.shapes, point)
+	}
+	if from == to && length == 0 {
+		g.shapes = g.shapes[:shapeStart]
+		return
+	}
+
+	g.segments = append(g.segments, segment{
+		from:       from,
+		to:         to,
+		shapeStart: shapeStart,
+		shapeEnd:   int32(len(g.shapes)),
+		length:     float32(length),
+		forward:    access.forward,
+		backward:   access.backward,
+		speed:      access.speed,
+	})
+}
+
+// buildArcs lays out each vertex's outgoing arcs contiguously
+func (g *Graph) buildArcs() {
+	g.firstArc = make([]int32, len(g.vertices)+1)
+	for _, s := range g.segments {
+		g.firstArc[s.from+1]++
+		g.firstArc[s.to+1]++
+	}
+	for v := 1; v < len(g.firstArc); v++ {
+		g.firstArc[v] += g.firstArc[v-1]
+	}
+
+	g.arcs = make([]arc, g.firstArc[len(g.vertices)])
+	next := append([]int32(nil), g.firstArc[:len(g.vertices)]...)
+	for i, s := range g.segments {
+		g.arcs[next[s.from]] = arc{segment: int32(i)}
+		next[s.from]++
+		g.arcs[next[s.to]] = arc{segment: int32(i), reverse: true}
+		next[s.to]++
+	}
+}
+
+func (g *Graph) shape(s *segment) []LatLng {
+	return g.shapes[s.shapeStart:s.shapeEnd]
+}
+
+const earthRadius = 6371000.0
+
+// haversine returns the great-circle distance in meters
+func haversine(a, b LatLng) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLng := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(min(h, 1)))
+}
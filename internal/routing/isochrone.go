@@ -0,0 +1,284 @@
+package routing
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+)
+
+// MaxIsochroneDuration (s) is the longest travel time an isochrone may cover
+const MaxIsochroneDuration = 60 * 60.0
+
+const (
+	// isochroneSectors is how many directions around the origin an outline is drawn in
+	isochroneSectors = 72
+	// isochroneMargin (m) is how far from a reachable road point still counts as reached
+	isochroneMargin = 50.0
+	// detourFactor is how much longer trips on roads are than the straight line
+	detourFactor = 1.3
+
+	metersPerDegree = 111320.0
+)
+
+// Isochrone is the area reachable from an origin within a travel time
+type Isochrone struct {
+	Duration  float64  `json:"duration"`  // seconds
+	Outline   []LatLng `json:"outline"`   // closed ring, counterclockwise
+	Estimated bool     `json:"estimated"` // a circle from a typical speed, not a road search
+}
+
+// Coordinates returns the outline as a GeoJSON Polygon ring of [lng, lat] pairs
+func (i *Isochrone) Coordinates() [][2]float64 {
+	coordinates := make([][2]float64, len(i.Outline))
+	for j, point := range i.Outline {
+		coordinates[j] = [2]float64{point.Longitude, point.Latitude}
+	}
+	return coordinates
+}
+
+// Isochrones finds the area profile can reach from origin within each of
+// durations (seconds). The search starts on the nearest usable road, like
+// Route, and the outline joins the farthest point reached in each direction
+// from origin, so it is star-shaped around it.
+func (g *Graph) Isochrones(profile Profile, origin LatLng, durations []float64) ([]Isochrone, error) {
+	if err := checkIsochrones(profile, durations); err != nil {
+		return nil, err
+	}
+	snap, ok := g.Snap(origin, profile, DefaultSnapDistance)
+	if !ok {
+		return nil, &SnapError{Index: 0, Profile: profile, MaxDistance: DefaultSnapDistance}
+	}
+
+	longest := 0.0
+	for _, duration := range durations {
+		longest = max(longest, duration)
+	}
+	labels := g.reach(profile, snap, longest)
+
+	isochrones := make([]Isochrone, len(durations))
+	for i, duration := range durations {
+		points := g.reachablePoints(profile, snap, labels, duration)
+		isochrones[i] = Isochrone{Duration: duration, Outline: outline(origin, points)}
+	}
+	return isochrones, nil
+}
+
+// EstimateIsochrones approximates isochrones without a road network: circles
+// as wide as profile travels in each duration at its typical speed, shrunk by
+// the usual detour of roads over the straight line
+func EstimateIsochrones(profile Profile, origin LatLng, durations []float64) ([]Isochrone, error) {
+	if err := checkIsochrones(profile, durations); err != nil {
+		return nil, err
+	}
+
+	isochrones := make([]Isochrone, len(durations))
+	for i, duration := range durations {
+		radius := typicalSpeeds[profile] / 3.6 * duration / detourFactor
+		ring := make([]LatLng, 0, isochroneSectors+1)
+		for sector := 0; sector < isochroneSectors; sector++ {
+			ring = append(ring, offsetPoint(origin, sectorAngle(sector), radius))
+		}
+		isochrones[i] = Isochrone{Duration: duration, Outline: append(ring, ring[0]), Estimated: true}
+	}
+	return isochrones, nil
+}
+
+func checkIsochrones(profile Profile, durations []float64) error {
+	if profile < 0 || profile >= profileCount {
+		return fmt.Errorf("unknown routing profile: %d", profile)
+	}
+	if len(durations) == 0 {
+		return fmt.Errorf("an isochrone needs a travel time")
+	}
+	for _, duration := range durations {
+		if duration <= 0 || duration > MaxIsochroneDuration {
+			return fmt.Errorf("isochrone travel times must be between 0 and %.0f seconds", MaxIsochroneDuration)
+		}
+	}
+	return nil
+}
+
+// reach runs Dijkstra from both ends of the start segment and labels every
+// vertex reachable within limit seconds
+func (g *Graph) reach(profile Profile, from *Snap, limit float64) *labelSet {
+	start := &g.segments[from.segment]
+	startSpeed := float64(start.speed[profile])
+
+	labels := newLabelSet()
+	queue := &searchQueue{}
+	seed := func(v int32, distance float64) {
+		cost := distance / startSpeed
+		if cost > limit {
+			return
+		}
+		if current := labels.get(v); current != nil && current.cost <= cost {
+			return
+		}
+		labels.set(v, label{cost: cost, distance: distance, arc: -1, parent: -1})
+		heap.Push(queue, searchItem{vertex: v, cost: cost, priority: cost})
+	}
+	if start.forward[profile] {
+		seed(start.to, float64(start.length)-from.offset)
+	}
+	if start.backward[profile] {
+		seed(start.from, from.offset)
+	}
+
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(searchItem)
+		current := labels.get(item.vertex)
+		if current.closed || item.cost > current.cost {
+			continue
+		}
+		current.closed = true
+		reached, travelled := current.cost, current.distance
+
+		for a := g.firstArc[item.vertex]; a < g.firstArc[item.vertex+1]; a++ {
+			s := &g.segments[g.arcs[a].segment]
+			if !s.allows(profile, g.arcs[a].reverse) {
+				continue
+			}
+			next := s.to
+			if g.arcs[a].reverse {
+				next = s.from
+			}
+			cost := reached + float64(s.length)/float64(s.speed[profile])
+			if cost > limit {
+				continue
+			}
+			if known := labels.get(next); known != nil && (known.closed || known.cost <= cost) {
+				continue
+			}
+			labels.set(next, label{cost: cost, distance: travelled + float64(s.length), arc: a, parent: item.vertex})
+			heap.Push(queue, searchItem{vertex: next, cost: cost, priority: cost})
+		}
+	}
+	return labels
+}
+
+// reachablePoints lists the road points reachable within limit seconds: the
+// reached vertices and the shape points along every arc leaving them, up to
+// where the time runs out part way along
+func (g *Graph) reachablePoints(profile Profile, from *Snap, labels *labelSet, limit float64) []LatLng {
+	points := []LatLng{from.Point}
+
+	// Out along the start segment from the snapped point
+	start := &g.segments[from.segment]
+	startShape := g.shape(start)
+	budget := limit * float64(start.speed[profile])
+	if start.forward[profile] {
+		path := append([]LatLng{from.Point}, startShape[from.piece+1:]...)
+		points = walk(points, path, budget)
+	}
+	if start.backward[profile] {
+		path := []LatLng{from.Point}
+		for i := from.piece; i >= 0; i-- {
+			path = append(path, startShape[i])
+		}
+		points = walk(points, path, budget)
+	}
+
+	for v, i := range labels.index {
+		reached := labels.labels[i].cost
+		if reached > limit {
+			continue
+		}
+		points = append(points, g.vertices[v])
+		for a := g.firstArc[v]; a < g.firstArc[v+1]; a++ {
+			s := &g.segments[g.arcs[a].segment]
+			if !s.allows(profile, g.arcs[a].reverse) {
+				continue
+			}
+			path := g.shape(s)
+			if g.arcs[a].reverse {
+				reversed := make([]LatLng, len(path))
+				for j, point := range path {
+					reversed[len(path)-1-j] = point
+				}
+				path = reversed
+			}
+			points = walk(points, path, (limit-reached)*float64(s.speed[profile]))
+		}
+	}
+	return points
+}
+
+// walk appends the points of path up to budget meters along it, ending with
+// the point where the budget runs out
+func walk(points, path []LatLng, budget float64) []LatLng {
+	for i := 1; i < len(path); i++ {
+		step := haversine(path[i-1], path[i])
+		if step >= budget {
+			if step > 0 {
+				t := budget / step
+				points = append(points, LatLng{
+					Latitude:  path[i-1].Latitude + t*(path[i].Latitude-path[i-1].Latitude),
+					Longitude: path[i-1].Longitude + t*(path[i].Longitude-path[i-1].Longitude),
+				})
+			}
+			return points
+		}
+		budget -= step
+		points = append(points, path[i])
+	}
+	return points
+}
+
+// outline is the star-shaped hull around origin of disks of isochroneMargin
+// around points: in each sector's direction it reaches as far as the farthest
+// disk that direction crosses. Each point also stretches its own sector, so
+// far points between the sector directions are not lost.
+func outline(origin LatLng, points []LatLng) []LatLng {
+	scale := math.Max(math.Cos(origin.Latitude*math.Pi/180), 0.01)
+	var reach [isochroneSectors]float64
+	for sector := range reach {
+		reach[sector] = isochroneMargin
+	}
+	sectorWidth := 2 * math.Pi / isochroneSectors
+	for _, point := range points {
+		dx := (point.Longitude - origin.Longitude) * scale * metersPerDegree
+		dy := (point.Latitude - origin.Latitude) * metersPerDegree
+		distance := math.Hypot(dx, dy)
+		if distance == 0 {
+			continue
+		}
+		angle := math.Atan2(dy, dx)
+		own := int((angle+math.Pi)/sectorWidth) % isochroneSectors
+		reach[own] = max(reach[own], distance)
+
+		// Sectors whose direction passes through the disk around the point
+		halfWidth := math.Asin(math.Min(1, isochroneMargin/distance))
+		span := int(halfWidth/sectorWidth) + 1
+		for k := -span; k <= span; k++ {
+			sector := ((own+k)%isochroneSectors + isochroneSectors) % isochroneSectors
+			delta := math.Remainder(sectorAngle(sector)-angle, 2*math.Pi)
+			across := distance * math.Sin(delta)
+			if math.Abs(delta) > math.Pi/2 || math.Abs(across) > isochroneMargin {
+				continue
+			}
+			far := distance*math.Cos(delta) + math.Sqrt(isochroneMargin*isochroneMargin-across*across)
+			reach[sector] = max(reach[sector], far)
+		}
+	}
+
+	ring := make([]LatLng, 0, isochroneSectors+1)
+	for sector := 0; sector < isochroneSectors; sector++ {
+		ring = append(ring, offsetPoint(origin, sectorAngle(sector), reach[sector]))
+	}
+	return append(ring, ring[0])
+}
+
+// sectorAngle is the middle of a sector, counterclockwise from west
+func sectorAngle(sector int) float64 {
+	return -math.Pi + (float64(sector)+0.5)*2*math.Pi/isochroneSectors
+}
+
+// offsetPoint moves meters from origin in the direction angle (radians,
+// counterclockwise from east) on a local equirectangular plane
+func offsetPoint(origin LatLng, angle, meters float64) LatLng {
+	scale := math.Max(math.Cos(origin.Latitude*math.Pi/180), 0.01)
+	return LatLng{
+		Latitude:  origin.Latitude + meters*math.Sin(angle)/metersPerDegree,
+		Longitude: origin.Longitude + meters*math.Cos(angle)/(metersPerDegree*scale),
+	}
+}
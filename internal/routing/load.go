@@ -0,0 +1,34 @@
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrNoRoadNetwork is returned when routing is asked for before a network is loaded
+var ErrNoRoadNetwork = errors.New("road network not loaded")
+
+// LoadFile builds a graph from an OSM PBF (.pbf) or GeoJSON (.geojson, .json) extract
+func LoadFile(path string) (*Graph, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	builder := NewBuilder()
+	switch lower := strings.ToLower(path); {
+	case strings.HasSuffix(lower, ".pbf"):
+		err = builder.ReadOSMPBF(file)
+	case strings.HasSuffix(lower, ".geojson"), strings.HasSuffix(lower, ".json"):
+		err = builder.ReadGeoJSON(file)
+	default:
+		return nil, fmt.Errorf("unsupported road network format: %s (use .osm.pbf or .geojson)", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return builder.Build()
+}
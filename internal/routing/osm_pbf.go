@@ -0,0 +1,411 @@
+package routing
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Limits from the OSM PBF specification
+const (
+	maxBlobHeaderSize = 64 * 1024
+	maxBlobSize       = 32 * 1024 * 1024
+)
+
+// supportedPBFFeatures are the required_features this reader understands
+var supportedPBFFeatures = map[string]bool{
+	"OsmSchema-V0.6": true,
+	"DenseNodes":     true,
+}
+
+// ReadOSMPBF adds the highways of an OSM PBF extract to the builder. The file is
+// read twice, ways first and then only the nodes those ways use, so a country
+// extract does not have to keep every node in memory.
+func (b *Builder) ReadOSMPBF(r io.ReadSeeker) error {
+	needed := make(map[int64]struct{})
+	err := readPBFBlocks(r, func(block *pbfBlock) error {
+		return block.eachWay(func(refs []int64, tags map[string]string) {
+			if b.AddWay(refs, tags) {
+				for _, ref := range refs {
+					needed[ref] = struct{}{}
+				}
+			}
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind OSM PBF: %v", err)
+	}
+	return readPBFBlocks(r, func(block *pbfBlock) error {
+		return block.eachNode(func(id int64, latitude, longitude float64) {
+			if _, ok := needed[id]; ok {
+				b.SetNode(id, latitude, longitude)
+			}
+		})
+	})
+}
+
+// readPBFBlocks checks the OSMHeader and calls fn for each OSMData block
+func readPBFBlocks(r io.Reader, fn func(*pbfBlock) error) error {
+	sawHeader := false
+	for {
+		blobType, data, err := readPBFBlob(r)
+		if err == io.EOF {
+			if !sawHeader {
+				return fmt.Errorf("OSM PBF has no header")
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch blobType {
+		case "OSMHeader":
+			if err := checkPBFHeader(data); err != nil {
+				return err
+			}
+			sawHeader = true
+		case "OSMData":
+			if !sawHeader {
+				return fmt.Errorf("OSM PBF data before header")
+			}
+			block, err := parsePBFBlock(data)
+			if err != nil {
+				return err
+			}
+			if err := fn(block); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// readPBFBlob reads one length-prefixed BlobHeader and its Blob, decompressed
+func readPBFBlob(r io.Reader) (string, []byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return "", nil, fmt.Errorf("truncated OSM PBF")
+		}
+		return "", nil, err
+	}
+	headerSize := binary.BigEndian.Uint32(size[:])
+	if headerSize > maxBlobHeaderSize {
+		return "", nil, fmt.Errorf("OSM PBF blob header too large: %d bytes", headerSize)
+	}
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return "", nil, fmt.Errorf("truncated OSM PBF blob header")
+	}
+
+	var blobType string
+	var dataSize uint64
+	if err := eachField(header, func(f protoField) error {
+		switch f.number {
+		case 1:
+			blobType = string(f.bytes)
+		case 3:
+			dataSize = f.varint
+		}
+		return nil
+	}); err != nil {
+		return "", nil, err
+	}
+	if dataSize > maxBlobSize {
+		return "", nil, fmt.Errorf("OSM PBF blob too large: %d bytes", dataSize)
+	}
+
+	blob := make([]byte, dataSize)
+	if _, err := io.ReadFull(r, blob); err != nil {
+		return "", nil, fmt.Errorf("truncated OSM PBF blob")
+	}
+
+	var raw, compressed []byte
+	var rawSize uint64
+	unsupported := ""
+	if err := eachField(blob, func(f protoField) error {
+		switch f.number {
+		case 1:
+			raw = f.bytes
+		case 2:
+			rawSize = f.varint
+		case 3:
+			compressed = f.bytes
+		case 4, 6, 7:
+			unsupported = map[int]string{4: "lzma", 6: "lz4", 7: "zstd"}[f.number]
+		}
+		return nil
+	}); err != nil {
+		return "", nil, err
+	}
+
+	switch {
+	case raw != nil:
+		return blobType, raw, nil
+	case compressed != nil:
+		if rawSize > maxBlobSize {
+			return "", nil, fmt.Errorf("OSM PBF blob too large: %d bytes", rawSize)
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid zlib data in OSM PBF: %v", err)
+		}
+		defer zr.Close()
+		data := make([]byte, 0, rawSize)
+		buffer := bytes.NewBuffer(data)
+		if _, err := io.Copy(buffer, io.LimitReader(zr, maxBlobSize+1)); err != nil {
+			return "", nil, fmt.Errorf("invalid zlib data in OSM PBF: %v", err)
+		}
+		if buffer.Len() > maxBlobSize {
+			return "", nil, fmt.Errorf("OSM PBF blob too large")
+		}
+		return blobType, buffer.Bytes(), nil
+	case unsupported != "":
+		return "", nil, fmt.Errorf("OSM PBF %s compression is not supported", unsupported)
+	}
+	return blobType, nil, nil
+}
+
+func checkPBFHeader(data []byte) error {
+	return eachField(data, func(f protoField) error {
+		if f.number == 4 && !supportedPBFFeatures[string(f.bytes)] {
+			return fmt.Errorf("OSM PBF requires unsupported feature %s", f.bytes)
+		}
+		return nil
+	})
+}
+
+// pbfBlock is a decoded PrimitiveBlock
+type pbfBlock struct {
+	strings     []string
+	groups      [][]byte
+	granularity int64
+	latOffset   int64
+	lonOffset   int64
+}
+
+func parsePBFBlock(data []byte) (*pbfBlock, error) {
+	block := &pbfBlock{granularity: 100}
+	err := eachField(data, func(f protoField) error {
+		switch f.number {
+		case 1:
+			return eachField(f.bytes, func(s protoField) error {
+				if s.number == 1 {
+					block.strings = append(block.strings, string(s.bytes))
+				}
+				return nil
+			})
+		case 2:
+			block.groups = append(block.groups, f.bytes)
+		case 17:
+			block.granularity = int64(f.varint)
+		case 19:
+			block.latOffset = int64(f.varint)
+		case 20:
+			block.lonOffset = int64(f.varint)
+		}
+		return nil
+	})
+	return block, err
+}
+
+func (b *pbfBlock) coordinate(lat, lon int64) (float64, float64) {
+	return 1e-9 * float64(b.latOffset+b.granularity*lat), 1e-9 * float64(b.lonOffset+b.granularity*lon)
+}
+
+func (b *pbfBlock) tags(keys, values []uint64) (map[string]string, error) {
+	if len(keys) != len(values) {
+		return nil, fmt.Errorf("OSM PBF way has %d keys and %d values", len(keys), len(values))
+	}
+	tags := make(map[string]string, len(keys))
+	for i := range keys {
+		if keys[i] >= uint64(len(b.strings)) || values[i] >= uint64(len(b.strings)) {
+			return nil, fmt.Errorf("OSM PBF string index out of range")
+		}
+		tags[b.strings[keys[i]]] = b.strings[values[i]]
+	}
+	return tags, nil
+}
+
+// eachWay calls fn with the node refs and tags of every way in the block
+func (b *pbfBlock) eachWay(fn func(refs []int64, tags map[string]string)) error {
+	for _, group := range b.groups {
+		err := eachField(group, func(f protoField) error {
+			if f.number != 3 {
+				return nil
+			}
+			var keys, values []uint64
+			var refs []int64
+			err := eachField(f.bytes, func(w protoField) error {
+				var err error
+				switch w.number {
+				case 2:
+					keys, err = packedVarints(w.bytes)
+				case 3:
+					values, err = packedVarints(w.bytes)
+				case 8:
+					var deltas []uint64
+					deltas, err = packedVarints(w.bytes)
+					var id int64
+					refs = make([]int64, len(deltas))
+					for i, delta := range deltas {
+						id += zigzag(delta)
+						refs[i] = id
+					}
+				}
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			tags, err := b.tags(keys, values)
+			if err != nil {
+				return err
+			}
+			fn(refs, tags)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eachNode calls fn with the position of every plain and dense node in the block
+func (b *pbfBlock) eachNode(fn func(id int64, latitude, longitude float64)) error {
+	for _, group := range b.groups {
+		err := eachField(group, func(f protoField) error {
+			switch f.number {
+			case 1:
+				var id, lat, lon int64
+				if err := eachField(f.bytes, func(n protoField) error {
+					switch n.number {
+					case 1:
+						id = zigzag(n.varint)
+					case 8:
+						lat = zigzag(n.varint)
+					case 9:
+						lon = zigzag(n.varint)
+					}
+					return nil
+				}); err != nil {
+					return err
+				}
+				latitude, longitude := b.coordinate(lat, lon)
+				fn(id, latitude, longitude)
+			case 2:
+				var ids, lats, lons []uint64
+				if err := eachField(f.bytes, func(d protoField) error {
+					var err error
+					switch d.number {
+					case 1:
+						ids, err = packedVarints(d.bytes)
+					case 8:
+						lats, err = packedVarints(d.bytes)
+					case 9:
+						lons, err = packedVarints(d.bytes)
+					}
+					return err
+				}); err != nil {
+					return err
+				}
+				if len(lats) != len(ids) || len(lons) != len(ids) {
+					return fmt.Errorf("OSM PBF dense nodes have mismatched arrays")
+				}
+				var id, lat, lon int64
+				for i := range ids {
+					id += zigzag(ids[i])
+					lat += zigzag(lats[i])
+					lon += zigzag(lons[i])
+					latitude, longitude := b.coordinate(lat, lon)
+					fn(id, latitude, longitude)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// protoField is one field of a protobuf message; bytes is set for
+// length-delimited fields and varint for the rest
+type protoField struct {
+	number int
+	varint uint64
+	bytes  []byte
+}
+
+// eachField walks the fields of a protobuf message
+func eachField(data []byte, fn func(protoField) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("invalid protobuf field key")
+		}
+		data = data[n:]
+
+		field := protoField{number: int(key >> 3)}
+		switch key & 7 {
+		case 0:
+			value, n := binary.Uvarint(data)
+			if n <= 0 {
+				return fmt.Errorf("invalid protobuf varint")
+			}
+			field.varint = value
+			data = data[n:]
+		case 1:
+			if len(data) < 8 {
+				return fmt.Errorf("truncated protobuf fixed64")
+			}
+			field.varint = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		case 2:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return fmt.Errorf("truncated protobuf field")
+			}
+			field.bytes = data[n : n+int(length)]
+			data = data[n+int(length):]
+		case 5:
+			if len(data) < 4 {
+				return fmt.Errorf("truncated protobuf fixed32")
+			}
+			field.varint = uint64(binary.LittleEndian.Uint32(data))
+			data = data[4:]
+		default:
+			return fmt.Errorf("unsupported protobuf wire type %d", key&7)
+		}
+
+		if err := fn(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func packedVarints(data []byte) ([]uint64, error) {
+	values := make([]uint64, 0, len(data))
+	for len(data) > 0 {
+		value, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid packed protobuf varint")
+		}
+		values = append(values, value)
+		data = data[n:]
+	}
+	return values, nil
+}
+
+func zigzag(value uint64) int64 {
+	return int64(value>>1) ^ -int64(value&1)
+}
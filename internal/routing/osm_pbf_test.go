@@ -0,0 +1,163 @@
+package routing
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// protobuf encoding helpers for building a PBF extract in memory
+
+func appendKey(buf []byte, number, wireType int) []byte {
+	return binary.AppendUvarint(buf, uint64(number<<3|wireType))
+}
+
+func appendVarintField(buf []byte, number int, value uint64) []byte {
+	return binary.AppendUvarint(appendKey(buf, number, 0), value)
+}
+
+func appendBytesField(buf []byte, number int, value []byte) []byte {
+	buf = binary.AppendUvarint(appendKey(buf, number, 2), uint64(len(value)))
+	return append(buf, value...)
+}
+
+func packed(values ...uint64) []byte {
+	var buf []byte
+	for _, value := range values {
+		buf = binary.AppendUvarint(buf, value)
+	}
+	return buf
+}
+
+func zigzagEncode(value int64) uint64 {
+	return uint64((value << 1) ^ (value >> 63))
+}
+
+// deltas zigzag-encodes the differences between consecutive values
+func deltas(values ...int64) []byte {
+	encoded := make([]uint64, len(values))
+	var previous int64
+	for i, value := range values {
+		encoded[i] = zigzagEncode(value - previous)
+		previous = value
+	}
+	return packed(encoded...)
+}
+
+func appendBlob(file []byte, blobType string, data []byte, compress bool) []byte {
+	var blob []byte
+	if compress {
+		var compressed bytes.Buffer
+		w := zlib.NewWriter(&compressed)
+		w.Write(data)
+		w.Close()
+		blob = appendVarintField(blob, 2, uint64(len(data)))
+		blob = appendBytesField(blob, 3, compressed.Bytes())
+	} else {
+		blob = appendBytesField(blob, 1, data)
+	}
+
+	header := appendBytesField(nil, 1, []byte(blobType))
+	header = appendVarintField(header, 3, uint64(len(blob)))
+	file = binary.BigEndian.AppendUint32(file, uint32(len(header)))
+	file = append(file, header...)
+	return append(file, blob...)
+}
+
+// testPBF holds the A-C street from testNetwork as dense nodes 1-3 and a plain
+// node 4 (D), a residential way 1-2-3, a one-way 4-1 and a building outline
+func testPBF(features ...string) []byte {
+	var header []byte
+	for _, feature := range features {
+		header = appendBytesField(header, 4, []byte(feature))
+	}
+	file := appendBlob(nil, "OSMHeader", header, false)
+
+	var stringTable []byte
+	for _, s := range []string{"", "highway", "residential", "oneway", "yes", "building"} {
+		stringTable = appendBytesField(stringTable, 1, []byte(s))
+	}
+
+	// Granularity 100 nanodegrees with offsets, as osmium writes them
+	const granularity = 100
+	latOffset, lonOffset := int64(25_000_000_000), int64(121_000_000_000)
+	coordinate := func(degrees float64, offset int64) int64 {
+		return (int64(degrees*1e9+0.5) - offset) / granularity
+	}
+
+	dense := appendBytesField(nil, 1, deltas(1, 2, 3))
+	dense = appendBytesField(dense, 8, deltas(coordinate(25.030, latOffset), coordinate(25.030, latOffset), coordinate(25.030, latOffset)))
+	dense = appendBytesField(dense, 9, deltas(coordinate(121.560, lonOffset), coordinate(121.562, lonOffset), coordinate(121.564, lonOffset)))
+	nodes := appendBytesField(nil, 2, dense)
+
+	plain := appendVarintField(nil, 1, zigzagEncode(4))
+	plain = appendVarintField(plain, 8, zigzagEncode(coordinate(25.032, latOffset)))
+	plain = appendVarintField(plain, 9, zigzagEncode(coordinate(121.560, lonOffset)))
+	nodes = appendBytesField(nodes, 1, plain)
+
+	way := func(id uint64, keys, values []uint64, refs ...int64) []byte {
+		w := appendVarintField(nil, 1, id)
+		w = appendBytesField(w, 2, packed(keys...))
+		w = appendBytesField(w, 3, packed(values...))
+		return appendBytesField(w, 8, deltas(refs...))
+	}
+	ways := appendBytesField(nil, 3, way(10, []uint64{1}, []uint64{2}, 1, 2, 3))
+	ways = appendBytesField(ways, 3, way(11, []uint64{1, 3}, []uint64{2, 4}, 4, 1))
+	ways = appendBytesField(ways, 3, way(12, []uint64{5}, []uint64{4}, 1, 2, 4, 1))
+
+	block := appendBytesField(nil, 1, stringTable)
+	block = appendBytesField(block, 2, nodes)
+	block = appendBytesField(block, 2, ways)
+	block = appendVarintField(block, 17, granularity)
+	block = appendVarintField(block, 19, uint64(latOffset))
+	block = appendVarintField(block, 20, uint64(lonOffset))
+	return appendBlob(file, "OSMData", block, true)
+}
+
+func TestReadOSMPBF(t *testing.T) {
+	builder := NewBuilder()
+	if err := builder.ReadOSMPBF(bytes.NewReader(testPBF("OsmSchema-V0.6", "DenseNodes"))); err != nil {
+		t.Fatalf("ReadOSMPBF() error = %v", err)
+	}
+	if len(builder.ways) != 2 {
+		t.Errorf("ways = %d, want 2 (the building is not a road)", len(builder.ways))
+	}
+	if got := builder.nodes[2]; haversine(got, pointB) > 0.01 {
+		t.Errorf("node 2 = %v, want %v", got, pointB)
+	}
+	if got := builder.nodes[4]; haversine(got, pointD) > 0.01 {
+		t.Errorf("node 4 = %v, want %v", got, pointD)
+	}
+
+	graph, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if stats := graph.Stats(); stats.Vertices != 3 || stats.Segments != 2 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+
+	// D-A is one-way south for cars
+	path, err := graph.Route(Car, []LatLng{pointD, pointC})
+	if err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	assertNear(t, "distance", path.Distance, haversine(pointD, pointA)+haversine(pointA, pointC), 1)
+	if _, err := graph.Route(Car, []LatLng{pointC, pointD}); err != ErrNoRoute {
+		t.Errorf("Route(C, D) error = %v, want ErrNoRoute", err)
+	}
+}
+
+func TestReadOSMPBFRejectsUnsupportedFeatures(t *testing.T) {
+	err := NewBuilder().ReadOSMPBF(bytes.NewReader(testPBF("OsmSchema-V0.6", "HistoricalInformation")))
+	if err == nil || !strings.Contains(err.Error(), "HistoricalInformation") {
+		t.Errorf("ReadOSMPBF() error = %v", err)
+	}
+
+	err = NewBuilder().ReadOSMPBF(bytes.NewReader(testPBF()[:40]))
+	if err == nil {
+		t.Error("a truncated file should fail")
+	}
+}
@@ -0,0 +1,212 @@
+package routing
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Profile is a mode of travel with its own road access rules and speeds
+type Profile int
+
+const (
+	Foot Profile = iota
+	Bike
+	Car
+
+	profileCount = 3
+)
+
+// Profiles lists every profile in index order
+var Profiles = []Profile{Foot, Bike, Car}
+
+// profileSpeeds are the default speeds (km/h) by highway type; a missing entry
+// means the profile may not use that highway type
+var profileSpeeds = [profileCount]map[string]float64{
+	Foot: {
+		"primary": 5, "primary_link": 5, "secondary": 5, "secondary_link": 5,
+		"tertiary": 5, "tertiary_link": 5, "unclassified": 5, "residential": 5,
+		"living_street": 5, "service": 5, "track": 4.5, "path": 4.5, "footway": 5,
+		"pedestrian": 5, "steps": 2, "cycleway": 5, "bridleway": 4.5, "road": 5,
+		"trunk": 5, "trunk_link": 5,
+	},
+	Bike: {
+		"primary": 16, "primary_link": 16, "secondary": 16, "secondary_link": 16,
+		"tertiary": 16, "tertiary_link": 16, "unclassified": 15, "residential": 15,
+		"living_street": 10, "service": 12, "track": 10, "path": 10, "cycleway": 18,
+		"road": 15,
+	},
+	Car: {
+		"motorway": 100, "motorway_link": 60, "trunk": 80, "trunk_link": 50,
+		"primary": 60, "primary_link": 40, "secondary": 50, "secondary_link": 35,
+		"tertiary": 40, "tertiary_link": 30, "unclassified": 30, "residential": 30,
+		"living_street": 10, "service": 15, "road": 30,
+	},
+}
+
+// maxSpeeds (km/h) bound every speed a profile can reach; A* divides the
+// straight-line distance by it, so it must not be exceeded
+var maxSpeeds = [profileCount]float64{Foot: 5, Bike: 18, Car: 110}
+
+// typicalSpeeds (km/h) are average speeds including stops and crossings, for
+// estimates made without a road network
+var typicalSpeeds = [profileCount]float64{Foot: 4.5, Bike: 14, Car: 30}
+
+// accessKeys are the OSM tags that grant or deny a profile, most specific first
+var accessKeys = [profileCount][]string{
+	Foot: {"foot", "access"},
+	Bike: {"bicycle", "vehicle", "access"},
+	Car:  {"motorcar", "motor_vehicle", "vehicle", "access"},
+}
+
+// ParseProfile accepts foot/walk/walking, bike/bicycle/cycling and car/drive/driving
+func ParseProfile(name string) (Profile, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "foot", "walk", "walking":
+		return Foot, nil
+	case "bike", "bicycle", "cycling":
+		return Bike, nil
+	case "car", "drive", "driving":
+		return Car, nil
+	}
+	return Foot, fmt.Errorf("unknown routing profile: %s (use foot, bike or car)", name)
+}
+
+// String returns the profile name used in the API
+func (p Profile) String() string {
+	switch p {
+	case Foot:
+		return "foot"
+	case Bike:
+		return "bike"
+	case Car:
+		return "car"
+	}
+	return fmt.Sprintf("profile(%d)", int(p))
+}
+
+// MaxSpeed returns the fastest speed (km/h) the profile travels at
+func (p Profile) MaxSpeed() float64 {
+	return maxSpeeds[p]
+}
+
+// wayAccess is what a way allows for each profile
+type wayAccess struct {
+	forward  [profileCount]bool
+	backward [profileCount]bool
+	speed    [profileCount]float32 // m/s
+}
+
+func (a wayAccess) usable() bool {
+	for p := 0; p < profileCount; p++ {
+		if a.forward[p] || a.backward[p] {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluateWay applies the profile rules to a way's OSM tags
+func evaluateWay(tags map[string]string) wayAccess {
+	var access wayAccess
+
+	highway := tags["highway"]
+	if highway == "" {
+		return access
+	}
+	if tags["area"] == "yes" {
+		return access
+	}
+
+	for _, profile := range Profiles {
+		speed, allowed := profileSpeeds[profile][highway]
+		if granted, explicit := tagAccess(tags, accessKeys[profile]); explicit {
+			if !granted {
+				continue
+			}
+			if !allowed {
+				speed = profileSpeeds[profile]["road"]
+			}
+		} else if !allowed {
+			continue
+		}
+
+		if profile == Car {
+			if limit := parseMaxSpeed(tags["maxspeed"]); limit > 0 {
+				speed = limit
+			}
+		}
+		speed = min(speed, maxSpeeds[profile])
+
+		forward, backward := true, true
+		if profile != Foot {
+			switch onewayDirection(tags, highway, profile) {
+			case 1:
+				backward = false
+			case -1:
+				forward = false
+			}
+		}
+
+		access.forward[profile] = forward
+		access.backward[profile] = backward
+		access.speed[profile] = float32(speed / 3.6)
+	}
+	return access
+}
+
+// tagAccess reads the first access tag present; explicit is false when none is.
+// The generic access tag only denies: access=yes on a motorway does not admit walkers.
+func tagAccess(tags map[string]string, keys []string) (granted, explicit bool) {
+	for _, key := range keys {
+		switch tags[key] {
+		case "":
+			continue
+		case "no", "private", "agricultural", "forestry", "delivery":
+			return false, true
+		default: // yes, permissive, designated, destination, customers ...
+			return true, key != "access"
+		}
+	}
+	return false, false
+}
+
+// onewayDirection returns 1 for one-way along the way, -1 against it and 0 for two-way
+func onewayDirection(tags map[string]string, highway string, profile Profile) int {
+	if profile == Bike {
+		if value := tags["oneway:bicycle"]; value == "no" {
+			return 0
+		}
+	}
+
+	switch tags["oneway"] {
+	case "yes", "true", "1":
+		return 1
+	case "-1", "reverse":
+		return -1
+	case "no", "false", "0":
+		return 0
+	}
+	if highway == "motorway" || highway == "motorway_link" || tags["junction"] == "roundabout" {
+		return 1
+	}
+	return 0
+}
+
+// parseMaxSpeed reads an OSM maxspeed value in km/h; "50 mph" is converted
+func parseMaxSpeed(value string) float64 {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	factor := 1.0
+	if strings.HasSuffix(value, "mph") {
+		factor = 1.609344
+		value = strings.TrimSpace(strings.TrimSuffix(value, "mph"))
+	}
+	speed, err := strconv.ParseFloat(value, 64)
+	if err != nil || speed <= 0 {
+		return 0
+	}
+	return speed * factor
+}
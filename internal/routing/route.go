@@ -0,0 +1,325 @@
+package routing
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrNoRoute is returned when the snapped points are not connected for the profile
+var ErrNoRoute = errors.New("no route between the points")
+
+// SnapError reports a point with no usable road nearby
+type SnapError struct {
+	Index       int // position in the requested points
+	Profile     Profile
+	MaxDistance float64
+}
+
+func (e *SnapError) Error() string {
+	return fmt.Sprintf("point %d is more than %.0f m from a road usable by %s", e.Index, e.MaxDistance, e.Profile)
+}
+
+// Leg is the part of a path between two consecutive requested points
+type Leg struct {
+	Distance float64 `json:"distance"` // meters
+	Duration float64 `json:"duration"` // seconds
+}
+
+// Path is a computed route
+type Path struct {
+	Profile  Profile  `json:"-"`
+	Geometry []LatLng `json:"geometry"` // from the first snapped point to the last
+	Distance float64  `json:"distance"` // meters
+	Duration float64  `json:"duration"` // seconds
+	Legs     []Leg    `json:"legs"`
+	Snaps    []Snap   `json:"snaps"`
+}
+
+// Coordinates returns the geometry as GeoJSON [lng, lat] pairs
+func (p *Path) Coordinates() [][2]float64 {
+	coordinates := make([][2]float64, len(p.Geometry))
+	for i, point := range p.Geometry {
+		coordinates[i] = [2]float64{point.Longitude, point.Latitude}
+	}
+	return coordinates
+}
+
+// Route finds the fastest path for profile through points in order, snapping
+// each to the nearest usable road within DefaultSnapDistance
+func (g *Graph) Route(profile Profile, points []LatLng) (*Path, error) {
+	if len(points) < 2 {
+		return nil, fmt.Errorf("a route needs at least 2 points")
+	}
+	if profile < 0 || profile >= profileCount {
+		return nil, fmt.Errorf("unknown routing profile: %d", profile)
+	}
+
+	path := &Path{Profile: profile}
+	for i, point := range points {
+		snap, ok := g.Snap(point, profile, DefaultSnapDistance)
+		if !ok {
+			return nil, &SnapError{Index: i, Profile: profile, MaxDistance: DefaultSnapDistance}
+		}
+		path.Snaps = append(path.Snaps, *snap)
+	}
+
+	for i := 1; i < len(path.Snaps); i++ {
+		leg, err := g.leg(profile, &path.Snaps[i-1], &path.Snaps[i])
+		if err != nil {
+			return nil, err
+		}
+		path.Legs = append(path.Legs, leg.Leg)
+		path.Distance += leg.Distance
+		path.Duration += leg.Duration
+		for _, point := range leg.geometry {
+			path.Geometry = appendPoint(path.Geometry, point)
+		}
+	}
+	return path, nil
+}
+
+type legResult struct {
+	Leg
+	geometry []LatLng
+}
+
+// label is the best known way to reach a vertex
+type label struct {
+	cost     float64 // seconds
+	distance float64 // meters
+	arc      int32   // arc used to arrive, -1 when reached straight from the start snap
+	parent   int32
+	backward bool // seeds only: reached by going back along the start segment
+	closed   bool
+}
+
+// exit is how the search leaves the graph for the target snap
+type exit struct {
+	vertex  int32 // -1 for a direct path along the shared segment
+	reverse bool
+}
+
+// leg runs A* from one snap to the next. The search starts at the two ends of the
+// start segment and finishes by walking from an end of the target segment to the
+// snapped point, so neither point has to sit on a vertex.
+func (g *Graph) leg(profile Profile, from, to *Snap) (*legResult, error) {
+	maxSpeed := maxSpeeds[profile] / 3.6
+	heuristic := func(v int32) float64 {
+		return haversine(g.vertices[v], to.Point) / maxSpeed
+	}
+
+	start := &g.segments[from.segment]
+	target := &g.segments[to.segment]
+	startSpeed := float64(start.speed[profile])
+	targetSpeed := float64(target.speed[profile])
+
+	bestCost := math.Inf(1)
+	var bestExit exit
+	var bestDistance float64
+
+	// Both points on the same segment: going straight along it may be best
+	if from.segment == to.segment {
+		along := to.offset - from.offset
+		if (along >= 0 && start.forward[profile]) || (along <= 0 && start.backward[profile]) {
+			bestCost = math.Abs(along) / startSpeed
+			bestDistance = math.Abs(along)
+			bestExit = exit{vertex: -1, reverse: along < 0}
+		}
+	}
+
+	labels := newLabelSet()
+	queue := &searchQueue{}
+	seed := func(v int32, distance float64, backward bool) {
+		cost := distance / startSpeed
+		if current := labels.get(v); current != nil && current.cost <= cost {
+			return
+		}
+		labels.set(v, label{cost: cost, distance: distance, arc: -1, parent: -1, backward: backward})
+		heap.Push(queue, searchItem{vertex: v, cost: cost, priority: cost + heuristic(v)})
+	}
+	if start.forward[profile] {
+		seed(start.to, float64(start.length)-from.offset, false)
+	}
+	if start.backward[profile] {
+		seed(start.from, from.offset, true)
+	}
+
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(searchItem)
+		if item.priority >= bestCost {
+			break
+		}
+		current := labels.get(item.vertex)
+		if current.closed || item.cost > current.cost {
+			continue
+		}
+		current.closed = true
+		reached, travelled := current.cost, current.distance
+
+		// Finish along the target segment
+		if item.vertex == target.from && target.forward[profile] {
+			if cost := reached + to.offset/targetSpeed; cost < bestCost {
+				bestCost, bestDistance = cost, travelled+to.offset
+				bestExit = exit{vertex: item.vertex}
+			}
+		}
+		if item.vertex == target.to && target.backward[profile] {
+			remaining := float64(target.length) - to.offset
+			if cost := reached + remaining/targetSpeed; cost < bestCost {
+				bestCost, bestDistance = cost, travelled+remaining
+				bestExit = exit{vertex: item.vertex, reverse: true}
+			}
+		}
+
+		for a := g.firstArc[item.vertex]; a < g.firstArc[item.vertex+1]; a++ {
+			s := &g.segments[g.arcs[a].segment]
+			if !s.allows(profile, g.arcs[a].reverse) {
+				continue
+			}
+			next := s.to
+			if g.arcs[a].reverse {
+				next = s.from
+			}
+			cost := reached + float64(s.length)/float64(s.speed[profile])
+			if known := labels.get(next); known != nil && (known.closed || known.cost <= cost) {
+				continue
+			}
+			labels.set(next, label{
+				cost:     cost,
+				distance: travelled + float64(s.length),
+				arc:      a,
+				parent:   item.vertex,
+			})
+			heap.Push(queue, searchItem{vertex: next, cost: cost, priority: cost + heuristic(next)})
+		}
+	}
+
+	if math.IsInf(bestCost, 1) {
+		return nil, ErrNoRoute
+	}
+
+	result := &legResult{Leg: Leg{Distance: bestDistance, Duration: bestCost}}
+	result.geometry = g.legGeometry(from, to, bestExit, labels)
+	return result, nil
+}
+
+// legGeometry walks the labels back from the exit vertex and stitches the
+// partial start and target segments onto the arcs in between
+func (g *Graph) legGeometry(from, to *Snap, out exit, labels *labelSet) []LatLng {
+	startShape := g.shape(&g.segments[from.segment])
+	targetShape := g.shape(&g.segments[to.segment])
+
+	geometry := []LatLng{from.Point}
+	if out.vertex < 0 {
+		if out.reverse {
+			for i := from.piece; i > to.piece; i-- {
+				geometry = appendPoint(geometry, startShape[i])
+			}
+		} else {
+			for i := from.piece + 1; i <= to.piece; i++ {
+				geometry = appendPoint(geometry, startShape[i])
+			}
+		}
+		return appendPoint(geometry, to.Point)
+	}
+
+	// Arcs from the exit back to the start
+	var arcs []int32
+	v := out.vertex
+	for labels.get(v).arc >= 0 {
+		arcs = append(arcs, labels.get(v).arc)
+		v = labels.get(v).parent
+	}
+
+	// v is the start segment end the search was seeded from
+	if labels.get(v).backward {
+		for i := from.piece; i >= 0; i-- {
+			geometry = appendPoint(geometry, startShape[i])
+		}
+	} else {
+		for i := from.piece + 1; i < len(startShape); i++ {
+			geometry = appendPoint(geometry, startShape[i])
+		}
+	}
+
+	for i := len(arcs) - 1; i >= 0; i-- {
+		a := g.arcs[arcs[i]]
+		shape := g.shape(&g.segments[a.segment])
+		if a.reverse {
+			for j := len(shape) - 1; j >= 0; j-- {
+				geometry = appendPoint(geometry, shape[j])
+			}
+		} else {
+			for _, point := range shape {
+				geometry = appendPoint(geometry, point)
+			}
+		}
+	}
+
+	if out.reverse {
+		for i := len(targetShape) - 1; i > to.piece; i-- {
+			geometry = appendPoint(geometry, targetShape[i])
+		}
+	} else {
+		for i := 0; i <= to.piece; i++ {
+			geometry = appendPoint(geometry, targetShape[i])
+		}
+	}
+	return appendPoint(geometry, to.Point)
+}
+
+func appendPoint(geometry []LatLng, point LatLng) []LatLng {
+	if n := len(geometry); n > 0 && geometry[n-1] == point {
+		return geometry
+	}
+	return append(geometry, point)
+}
+
+// labelSet holds the labels of the vertices a search has reached
+type labelSet struct {
+	index  map[int32]int32
+	labels []label
+}
+
+func newLabelSet() *labelSet {
+	return &labelSet{index: make(map[int32]int32)}
+}
+
+// get returns the label of v, or nil; the pointer is valid until the next set
+func (l *labelSet) get(v int32) *label {
+	if i, ok := l.index[v]; ok {
+		return &l.labels[i]
+	}
+	return nil
+}
+
+func (l *labelSet) set(v int32, value label) {
+	if i, ok := l.index[v]; ok {
+		l.labels[i] = value
+		return
+	}
+	l.index[v] = int32(len(l.labels))
+	l.labels = append(l.labels, value)
+}
+
+type searchItem struct {
+	vertex   int32
+	cost     float64
+	priority float64
+}
+
+// searchQueue is a min-heap on priority
+type searchQueue []searchItem
+
+func (q searchQueue) Len() int            { return len(q) }
+func (q searchQueue) Less(i, j int) bool  { return q[i].priority < q[j].priority }
+func (q searchQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *searchQueue) Push(x interface{}) { *q = append(*q, x.(searchItem)) }
+func (q *searchQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,312 @@
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// testNetwork is a small block in Taipei:
+//
+//	D ---------- E      E-D one-way west for vehicles
+//	|            |
+//	A --- B ---- C      A-C one-way east for vehicles, B is a shape point
+//
+// plus an unconnected footway 1.1 km to the north.
+const testNetwork = `{"type":"FeatureCollection","features":[
+ {"type":"Feature","properties":{"highway":"residential","oneway":"yes"},
+  "geometry":{"type":"LineString","coordinates":[[121.560,25.030],[121.562,25.030],[121.564,25.030]]}},
+ {"type":"Feature","properties":{"highway":"residential"},
+  "geometry":{"type":"LineString","coordinates":[[121.560,25.030],[121.560,25.032]]}},
+ {"type":"Feature","properties":{"highway":"residential","oneway":true},
+  "geometry":{"type":"LineString","coordinates":[[121.564,25.032],[121.560,25.032]]}},
+ {"type":"Feature","properties":{"highway":"residential"},
+  "geometry":{"type":"LineString","coordinates":[[121.564,25.032],[121.564,25.030]]}},
+ {"type":"Feature","properties":{"highway":"footway","name":"河濱步道"},
+  "geometry":{"type":"LineString","coordinates":[[121.560,25.042],[121.564,25.042]]}},
+ {"type":"Feature","properties":{"highway":"motorway"},
+  "geometry":{"type":"Point","coordinates":[121.560,25.050]}}
+]}`
+
+func testGraph(t testing.TB) *Graph {
+	builder := NewBuilder()
+	if err := builder.ReadGeoJSON(strings.NewReader(testNetwork)); err != nil {
+		t.Fatalf("ReadGeoJSON() error = %v", err)
+	}
+	graph, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	return graph
+}
+
+var (
+	pointA = LatLng{Latitude: 25.030, Longitude: 121.560}
+	pointB = LatLng{Latitude: 25.030, Longitude: 121.562}
+	pointC = LatLng{Latitude: 25.030, Longitude: 121.564}
+	pointD = LatLng{Latitude: 25.032, Longitude: 121.560}
+	pointE = LatLng{Latitude: 25.032, Longitude: 121.564}
+)
+
+func assertNear(t *testing.T, name string, got, want, tolerance float64) {
+	t.Helper()
+	if math.Abs(got-want) > tolerance {
+		t.Errorf("%s = %.1f, want %.1f ± %.1f", name, got, want, tolerance)
+	}
+}
+
+func TestBuildContractsShapePoints(t *testing.T) {
+	stats := testGraph(t).Stats()
+	// A, C, D, E and the two footway ends; B stays a shape point
+	if stats.Vertices != 6 || stats.Segments != 5 {
+		t.Errorf("Stats() = %+v, want 6 vertices and 5 segments", stats)
+	}
+}
+
+func TestRouteProfiles(t *testing.T) {
+	graph := testGraph(t)
+	south := haversine(pointA, pointC)
+	loop := haversine(pointC, pointE) + haversine(pointE, pointD) + haversine(pointD, pointA)
+
+	tests := []struct {
+		profile  Profile
+		from, to LatLng
+		distance float64
+		speed    float64 // km/h
+	}{
+		{Car, pointA, pointC, south, 30},
+		{Car, pointC, pointA, loop, 30}, // against A-C: around the block
+		{Foot, pointC, pointA, south, 5},
+		{Bike, pointA, pointC, south, 15},
+		{Bike, pointC, pointD, haversine(pointC, pointE) + haversine(pointE, pointD), 15},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %v->%v", tt.profile, tt.from, tt.to), func(t *testing.T) {
+			path, err := graph.Route(tt.profile, []LatLng{tt.from, tt.to})
+			if err != nil {
+				t.Fatalf("Route() error = %v", err)
+			}
+			assertNear(t, "distance", path.Distance, tt.distance, 1)
+			assertNear(t, "duration", path.Duration, tt.distance/(tt.speed/3.6), 1)
+		})
+	}
+}
+
+func TestRouteBetweenPointsOnSegments(t *testing.T) {
+	graph := testGraph(t)
+	start := LatLng{Latitude: 25.0301, Longitude: 121.561}
+	end := LatLng{Latitude: 25.0301, Longitude: 121.563}
+
+	// Forward along A-C: straight along the shared segment, through B
+	path, err := graph.Route(Car, []LatLng{start, end})
+	if err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	assertNear(t, "distance", path.Distance, haversine(pointA, pointC)/2, 1)
+	if len(path.Geometry) != 3 || path.Geometry[1] != pointB {
+		t.Errorf("geometry = %v, want snapped start, B, snapped end", path.Geometry)
+	}
+	if path.Snaps[0].Distance < 10 || path.Snaps[0].Distance > 12 {
+		t.Errorf("snap distance = %.1f, want about 11 m", path.Snaps[0].Distance)
+	}
+
+	// Backward is against the one-way: walkers go straight back
+	path, err = graph.Route(Foot, []LatLng{end, start})
+	if err != nil {
+		t.Fatalf("Route(Foot) error = %v", err)
+	}
+	assertNear(t, "foot distance", path.Distance, haversine(pointA, pointC)/2, 1)
+
+	// A car from the middle of A-C to the middle of D-E goes on to C, up to E and
+	// west; the geometry follows every turn
+	north := LatLng{Latitude: 25.0319, Longitude: 121.562}
+	path, err = graph.Route(Car, []LatLng{start, north})
+	if err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	want := haversine(LatLng{Latitude: 25.030, Longitude: 121.561}, pointC) + haversine(pointC, pointE) + haversine(pointE, pointD)/2
+	assertNear(t, "distance", path.Distance, want, 2)
+	wantGeometry := []LatLng{{Latitude: 25.030, Longitude: 121.561}, pointB, pointC, pointE, {Latitude: 25.032, Longitude: 121.562}}
+	if len(path.Geometry) != len(wantGeometry) {
+		t.Fatalf("geometry = %v, want %v", path.Geometry, wantGeometry)
+	}
+	for i := range wantGeometry {
+		if haversine(path.Geometry[i], wantGeometry[i]) > 0.5 {
+			t.Errorf("geometry[%d] = %v, want %v", i, path.Geometry[i], wantGeometry[i])
+		}
+	}
+}
+
+func TestRouteViaPoints(t *testing.T) {
+	graph := testGraph(t)
+	path, err := graph.Route(Foot, []LatLng{pointA, pointE, pointC})
+	if err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	if len(path.Legs) != 2 {
+		t.Fatalf("legs = %d, want 2", len(path.Legs))
+	}
+	assertNear(t, "leg 1", path.Legs[0].Distance, haversine(pointA, pointD)+haversine(pointD, pointE), 1)
+	assertNear(t, "leg 2", path.Legs[1].Distance, haversine(pointE, pointC), 1)
+	assertNear(t, "total", path.Distance, path.Legs[0].Distance+path.Legs[1].Distance, 0.01)
+}
+
+func TestRouteSnapping(t *testing.T) {
+	graph := testGraph(t)
+	riverside := LatLng{Latitude: 25.0421, Longitude: 121.562}
+
+	// The footway is over a kilometre from any road a car may use
+	_, err := graph.Route(Car, []LatLng{riverside, pointA})
+	var snapErr *SnapError
+	if !errors.As(err, &snapErr) || snapErr.Index != 0 {
+		t.Errorf("Route(Car) error = %v, want a SnapError for point 0", err)
+	}
+
+	// Walkers reach the footway, but it is not connected to the block
+	if _, err := graph.Route(Foot, []LatLng{riverside, pointA}); !errors.Is(err, ErrNoRoute) {
+		t.Errorf("Route(Foot) error = %v, want ErrNoRoute", err)
+	}
+}
+
+func TestEvaluateWay(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     map[string]string
+		forward  [profileCount]bool
+		backward [profileCount]bool
+		carSpeed float64 // km/h
+	}{
+		{"motorway", map[string]string{"highway": "motorway"}, [3]bool{false, false, true}, [3]bool{}, 100},
+		{"maxspeed", map[string]string{"highway": "primary", "maxspeed": "70"}, [3]bool{true, true, true}, [3]bool{true, true, true}, 70},
+		{"reverse one-way", map[string]string{"highway": "secondary", "oneway": "-1"}, [3]bool{true, false, false}, [3]bool{true, true, true}, 50},
+		{"contraflow cycling", map[string]string{"highway": "residential", "oneway": "yes", "oneway:bicycle": "no"}, [3]bool{true, true, true}, [3]bool{true, true, false}, 30},
+		{"roundabout", map[string]string{"highway": "tertiary", "junction": "roundabout"}, [3]bool{true, true, true}, [3]bool{true, false, false}, 40},
+		{"footway open to bikes", map[string]string{"highway": "footway", "bicycle": "yes"}, [3]bool{true, true, false}, [3]bool{true, true, false}, 0},
+		{"private", map[string]string{"highway": "service", "access": "private", "foot": "yes"}, [3]bool{true, false, false}, [3]bool{true, false, false}, 0},
+		{"access=yes does not open a motorway", map[string]string{"highway": "motorway", "access": "yes"}, [3]bool{false, false, true}, [3]bool{}, 100},
+		{"not a road", map[string]string{"building": "yes"}, [3]bool{}, [3]bool{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			access := evaluateWay(tt.tags)
+			if access.forward != tt.forward || access.backward != tt.backward {
+				t.Errorf("access = %v / %v, want %v / %v", access.forward, access.backward, tt.forward, tt.backward)
+			}
+			assertNear(t, "car speed", float64(access.speed[Car])*3.6, tt.carSpeed, 0.01)
+		})
+	}
+}
+
+func TestParseProfile(t *testing.T) {
+	for name, want := range map[string]Profile{"walking": Foot, "Bicycle": Bike, "drive": Car, " car ": Car} {
+		if got, err := ParseProfile(name); err != nil || got != want {
+			t.Errorf("ParseProfile(%q) = %v, %v", name, got, err)
+		}
+	}
+	if _, err := ParseProfile("boat"); err == nil {
+		t.Error("ParseProfile(boat) should fail")
+	}
+}
+
+func TestIsochrones(t *testing.T) {
+	graph := testGraph(t)
+
+	// Walking at 5 km/h: about 83 m in a minute, 417 m in five
+	isochrones, err := graph.Isochrones(Foot, pointA, []float64{60, 300})
+	if err != nil {
+		t.Fatal(err)
+	}
+	south := LatLng{Latitude: 25.0273, Longitude: 121.560} // 300 m off the network
+	tests := []struct {
+		isochrone int
+		point     LatLng
+		want      bool
+	}{
+		{0, pointA, true},
+		{0, pointB, false},
+		{1, pointB, true},
+		{1, pointC, true},
+		{1, pointE, false},
+		{1, south, false},
+	}
+	for _, tt := range tests {
+		if got := ringContains(isochrones[tt.isochrone].Outline, tt.point); got != tt.want {
+			t.Errorf("isochrone %d contains %v = %v, want %v", tt.isochrone, tt.point, got, tt.want)
+		}
+	}
+	if outline := isochrones[1].Outline; len(outline) != isochroneSectors+1 || outline[0] != outline[len(outline)-1] {
+		t.Errorf("outline has %d points, want a closed ring of %d", len(outline), isochroneSectors+1)
+	}
+
+	if _, err := graph.Isochrones(Foot, pointA, []float64{0}); err == nil {
+		t.Error("a zero travel time should fail")
+	}
+	var snapErr *SnapError
+	if _, err := graph.Isochrones(Car, LatLng{Latitude: 25.0421, Longitude: 121.562}, []float64{60}); !errors.As(err, &snapErr) {
+		t.Errorf("Isochrones() off the car network error = %v, want a SnapError", err)
+	}
+}
+
+func TestEstimateIsochrones(t *testing.T) {
+	isochrones, err := EstimateIsochrones(Foot, pointA, []float64{600})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isochrones[0].Estimated {
+		t.Error("an estimate should be marked as one")
+	}
+	// 10 minutes at 4.5 km/h, over the detour factor
+	for _, point := range isochrones[0].Outline {
+		assertNear(t, "radius", haversine(pointA, point), 4.5/3.6*600/detourFactor, 2)
+	}
+}
+
+// ringContains is the even-odd test on a closed ring
+func ringContains(ring []LatLng, point LatLng) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Latitude > point.Latitude) != (b.Latitude > point.Latitude) &&
+			point.Longitude < (b.Longitude-a.Longitude)*(point.Latitude-a.Latitude)/(b.Latitude-a.Latitude)+a.Longitude {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// gridNetwork is an n x n grid of residential streets about 100 m apart
+func gridNetwork(n int) *Graph {
+	builder := NewBuilder()
+	id := func(row, col int) int64 { return int64(row*n + col + 1) }
+	for row := 0; row < n; row++ {
+		for col := 0; col < n; col++ {
+			builder.SetNode(id(row, col), 25.0+float64(row)*0.0009, 121.5+float64(col)*0.001)
+		}
+	}
+	tags := map[string]string{"highway": "residential"}
+	for i := 0; i < n; i++ {
+		var row, col []int64
+		for j := 0; j < n; j++ {
+			row = append(row, id(i, j))
+			col = append(col, id(j, i))
+		}
+		builder.AddWay(row, tags)
+		builder.AddWay(col, tags)
+	}
+	graph, _ := builder.Build()
+	return graph
+}
+
+func BenchmarkRouteGrid(b *testing.B) {
+	graph := gridNetwork(200)
+	from := LatLng{Latitude: 25.0001, Longitude: 121.5005}
+	to := LatLng{Latitude: 25.17, Longitude: 121.69}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := graph.Route(Car, []LatLng{from, to}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package routing
+
+import (
+	"math"
+)
+
+// indexCellSize is the segment grid cell size in degrees (about 1 km)
+const indexCellSize = 0.01
+
+// DefaultSnapDistance (m) is how far a point may be from the nearest usable road
+const DefaultSnapDistance = 1000.0
+
+// segmentIndex finds segments near a point from the cells their shapes cross
+type segmentIndex struct {
+	cells map[[2]int32][]int32
+}
+
+func newSegmentIndex(g *Graph) *segmentIndex {
+	index := &segmentIndex{cells: make(map[[2]int32][]int32)}
+	for i := range g.segments {
+		shape := g.shape(&g.segments[i])
+		seen := make(map[[2]int32]bool)
+		for j := 1; j < len(shape); j++ {
+			minCell := indexCell(math.Min(shape[j-1].Latitude, shape[j].Latitude), math.Min(shape[j-1].Longitude, shape[j].Longitude))
+			maxCell := indexCell(math.Max(shape[j-1].Latitude, shape[j].Latitude), math.Max(shape[j-1].Longitude, shape[j].Longitude))
+			for y := minCell[0]; y <= maxCell[0]; y++ {
+				for x := minCell[1]; x <= maxCell[1]; x++ {
+					cell := [2]int32{y, x}
+					if !seen[cell] {
+						seen[cell] = true
+						index.cells[cell] = append(index.cells[cell], int32(i))
+					}
+				}
+			}
+		}
+	}
+	return index
+}
+
+func indexCell(latitude, longitude float64) [2]int32 {
+	return [2]int32{int32(math.Floor(latitude / indexCellSize)), int32(math.Floor(longitude / indexCellSize))}
+}
+
+// Snap is a point projected onto the nearest road a profile can use
+type Snap struct {
+	Point    LatLng  `json:"point"`
+	Distance float64 `json:"distance"` // meters from the requested point
+
+	segment int32
+	piece   int     // shape index the point lies after
+	offset  float64 // meters along the segment from its from vertex
+}
+
+// Snap projects point onto the nearest segment usable by profile within maxDistance meters
+func (g *Graph) Snap(point LatLng, profile Profile, maxDistance float64) (*Snap, bool) {
+	// Cells are square in degrees; widen longitude by latitude so the radius is covered
+	latSpan := maxDistance / 111320
+	lngSpan := latSpan / math.Max(math.Cos(point.Latitude*math.Pi/180), 0.01)
+	minCell := indexCell(point.Latitude-latSpan, point.Longitude-lngSpan)
+	maxCell := indexCell(point.Latitude+latSpan, point.Longitude+lngSpan)
+
+	var best *Snap
+	seen := make(map[int32]bool)
+	for y := minCell[0]; y <= maxCell[0]; y++ {
+		for x := minCell[1]; x <= maxCell[1]; x++ {
+			for _, id := range g.index.cells[[2]int32{y, x}] {
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+
+				s := &g.segments[id]
+				if !s.usable(profile) {
+					continue
+				}
+				if snap := g.project(point, id); snap.Distance <= maxDistance && (best == nil || snap.Distance < best.Distance) {
+					best = snap
+				}
+			}
+		}
+	}
+	return best, best != nil
+}
+
+// project finds the closest point on segment id to point
+func (g *Graph) project(point LatLng, id int32) *Snap {
+	shape := g.shape(&g.segments[id])
+	best := &Snap{Distance: math.Inf(1), segment: id}
+	along := 0.0
+	for i := 1; i < len(shape); i++ {
+		a, b := shape[i-1], shape[i]
+		candidate, t := projectOnto(point, a, b)
+		if distance := haversine(point, candidate); distance < best.Distance {
+			best.Point = candidate
+			best.Distance = distance
+			best.piece = i - 1
+			best.offset = along + t*haversine(a, b)
+		}
+		along += haversine(a, b)
+	}
+	return best
+}
+
+// projectOnto returns the closest point to p on the line a-b and its fraction
+// along it, on a local equirectangular plane
+func projectOnto(p, a, b LatLng) (LatLng, float64) {
+	scale := math.Cos(p.Latitude * math.Pi / 180)
+	ax, ay := a.Longitude*scale, a.Latitude
+	bx, by := b.Longitude*scale, b.Latitude
+	px, py := p.Longitude*scale, p.Latitude
+
+	dx, dy := bx-ax, by-ay
+	lengthSquared := dx*dx + dy*dy
+	if lengthSquared == 0 {
+		return a, 0
+	}
+	t := math.Max(0, math.Min(1, ((px-ax)*dx+(py-ay)*dy)/lengthSquared))
+	return LatLng{
+		Latitude:  a.Latitude + t*(b.Latitude-a.Latitude),
+		Longitude: a.Longitude + t*(b.Longitude-a.Longitude),
+	}, t
+}
@@ -0,0 +1,46 @@
+package segment
+
+// baseWords are the command words every segmenter knows; place names come from
+// the gazetteer and the user dictionary
+var baseWords = map[Kind][]string{
+	KindMoveVerb: {
+		"去", "到", "前往", "想去", "帶我去", "帶我到", "導航到", "導航去", "移動到", "走到", "跑到",
+		"飛到", "騎到", "開到", "回到", "出發去", "過去", "傳送到",
+		"go to", "move to", "navigate to", "travel to", "head to", "take me to", "walk to", "drive to", "fly to",
+	},
+	KindAction: {
+		"吃", "喝", "玩", "買", "逛", "看", "拍照", "散步", "吃飯", "喝咖啡", "看夜景", "看日出", "爬山",
+		"逛街", "參觀", "泡溫泉", "住", "休息", "約會",
+		"eat", "drink", "visit", "see", "shop",
+	},
+	KindModifier: {
+		"那個", "這個", "那家", "這家", "那間", "這間", "那邊", "很", "最", "有名", "有名的", "知名", "著名",
+		"傳說中", "的", "附近", "附近的", "最近", "最近的", "好吃", "好吃的", "好玩", "好玩的", "一下",
+		"一家", "一個", "the", "nearest", "famous",
+	},
+	KindTransport: {
+		"騎車", "騎機車", "騎腳踏車", "開車", "搭車", "搭捷運", "坐捷運", "搭高鐵", "坐高鐵", "搭火車",
+		"坐火車", "搭公車", "坐公車", "走路", "步行", "搭飛機", "坐飛機",
+		"by car", "by bike", "on foot",
+	},
+	KindConjunction: {
+		"後", "之後", "以後", "然後", "再", "接著", "和", "跟", "還有", "順便", "then", "and", "after",
+	},
+	KindFunction: {
+		"我", "我們", "想", "要", "想要", "帶", "幫我", "可以", "一起", "i", "want", "me", "please",
+	},
+	KindParticle: {
+		"吧", "啦", "啊", "呀", "喔", "哦", "嗎", "呢", "好嗎", "好不好", "謝謝",
+	},
+}
+
+// BaseDictionary returns a new dictionary with the built-in command words
+func BaseDictionary() *Dictionary {
+	dict := NewDictionary()
+	for kind, words := range baseWords {
+		for _, word := range words {
+			dict.Add(word, kind, 0)
+		}
+	}
+	return dict
+}
@@ -0,0 +1,345 @@
+// Package segment splits Chinese (and mixed Latin) commands into words with a
+// dictionary-driven DAG and maximum-probability route, then picks out place
+// spans, movement verbs, activities and modifiers with their byte offsets.
+package segment
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind classifies a token
+type Kind string
+
+const (
+	KindWord        Kind = "word"        // unknown text
+	KindPlace       Kind = "place"       // gazetteer or user-dictionary place name
+	KindMoveVerb    Kind = "move"        // 去, 前往, go to
+	KindAction      Kind = "action"      // 吃, 逛, 拍照: what to do there
+	KindModifier    Kind = "modifier"    // 那個, 很有名的, 附近
+	KindTransport   Kind = "transport"   // 騎車, 搭高鐵
+	KindConjunction Kind = "conjunction" // 然後, 之後, and
+	KindFunction    Kind = "function"    // 我, 想, 要
+	KindParticle    Kind = "particle"    // 吧, 嗎, 好嗎
+	KindNumber      Kind = "number"
+	KindPunct       Kind = "punct"
+	KindSpace       Kind = "space"
+)
+
+// Token is a segment of the input; Start and End are byte offsets into it
+type Token struct {
+	Text  string `json:"text"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Kind  Kind   `json:"kind"`
+}
+
+// Span is a place phrase; Known is set when it is a single dictionary place
+type Span struct {
+	Text  string `json:"text"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Known bool   `json:"known"`
+}
+
+// Analysis is a segmented command
+type Analysis struct {
+	Tokens    []Token `json:"tokens"`
+	Places    []Span  `json:"places"` // in the order they are mentioned
+	Verbs     []Token `json:"verbs"`
+	Actions   []Token `json:"actions"`
+	Modifiers []Token `json:"modifiers"`
+	Transport []Token `json:"transport"`
+}
+
+type entry struct {
+	kind Kind
+	freq float64
+}
+
+// Dictionary maps words to a kind and frequency
+type Dictionary struct {
+	words  map[string]entry
+	maxLen int // longest word in runes
+	total  float64
+}
+
+// NewDictionary returns an empty dictionary
+func NewDictionary() *Dictionary {
+	return &Dictionary{words: make(map[string]entry)}
+}
+
+// Add inserts or replaces a word. Higher freq makes the word win over
+// alternative splits; freq <= 0 uses a default for the kind.
+func (d *Dictionary) Add(word string, kind Kind, freq float64) {
+	key := fold(strings.TrimSpace(word))
+	if key == "" {
+		return
+	}
+	if freq <= 0 {
+		freq = defaultFreq(kind)
+	}
+	if old, exists := d.words[key]; exists {
+		d.total -= old.freq
+	}
+	d.words[key] = entry{kind: kind, freq: freq}
+	d.total += freq
+	if n := utf8.RuneCountInString(key); n > d.maxLen {
+		d.maxLen = n
+	}
+}
+
+// Merge adds every word of other
+func (d *Dictionary) Merge(other *Dictionary) {
+	for word, e := range other.words {
+		d.Add(word, e.kind, e.freq)
+	}
+}
+
+// Len returns the number of words
+func (d *Dictionary) Len() int {
+	return len(d.words)
+}
+
+// LoadUserDictionary reads lines of "word [freq] [kind]" (kind defaults to place);
+// blank lines and lines starting with # are skipped
+func (d *Dictionary) LoadUserDictionary(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		word, kind, freq := fields[0], KindPlace, 0.0
+		for _, field := range fields[1:] {
+			if value, err := strconv.ParseFloat(field, 64); err == nil {
+				freq = value
+				continue
+			}
+			if !validKind(Kind(field)) {
+				return fmt.Errorf("line %d: unknown kind %q", line, field)
+			}
+			kind = Kind(field)
+		}
+		d.Add(word, kind, freq)
+	}
+	return scanner.Err()
+}
+
+func validKind(kind Kind) bool {
+	switch kind {
+	case KindWord, KindPlace, KindMoveVerb, KindAction, KindModifier, KindTransport,
+		KindConjunction, KindFunction, KindParticle:
+		return true
+	}
+	return false
+}
+
+func defaultFreq(kind Kind) float64 {
+	switch kind {
+	case KindPlace:
+		return 5000 // place names should not be split into common words
+	case KindMoveVerb, KindAction, KindTransport:
+		return 3000
+	}
+	return 1000
+}
+
+// Segmenter segments text against a dictionary; it is safe for concurrent use
+// as long as the dictionary is not modified
+type Segmenter struct {
+	dict *Dictionary
+}
+
+// New returns a segmenter over dict
+func New(dict *Dictionary) *Segmenter {
+	return &Segmenter{dict: dict}
+}
+
+// Segment splits text along the most probable route through the word DAG
+func (s *Segmenter) Segment(text string) []Token {
+	folded := fold(text)
+	runes := []rune(folded)
+	offsets := make([]int, len(runes)+1) // rune index -> byte offset
+	for i, offset := 0, 0; i < len(runes); i++ {
+		offsets[i] = offset
+		offset += utf8.RuneLen(runes[i])
+	}
+	offsets[len(runes)] = len(folded)
+
+	n := len(runes)
+	logTotal := math.Log(math.Max(s.dict.total, 1))
+	unknown := -logTotal - 8 // log probability of an unknown rune or run
+
+	// route[i] is the best log probability of runes[i:] and the end of its first word
+	type step struct {
+		score float64
+		end   int
+		kind  Kind
+	}
+	route := make([]step, n+1)
+
+	for i := n - 1; i >= 0; i-- {
+		// Runs of letters and digits are single units unless a dictionary word starts here
+		end := i + 1
+		kind := KindWord
+		switch r := runes[i]; {
+		case isLatin(r) || unicode.IsDigit(r):
+			for end < n && (isLatin(runes[end]) || unicode.IsDigit(runes[end])) {
+				end++
+			}
+			if isNumber(runes[i:end]) {
+				kind = KindNumber
+			}
+		case unicode.IsSpace(r):
+			for end < n && unicode.IsSpace(runes[end]) {
+				end++
+			}
+			kind = KindSpace
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			kind = KindPunct
+		}
+		best := step{score: unknown + route[end].score, end: end, kind: kind}
+
+		limit := min(n, i+s.dict.maxLen)
+		for j := i + 1; j <= limit; j++ {
+			e, ok := s.dict.words[folded[offsets[i]:offsets[j]]]
+			if !ok {
+				continue
+			}
+			// Latin dictionary words must not cut a word in half
+			if isLatin(runes[j-1]) && j < n && isLatin(runes[j]) {
+				continue
+			}
+			if isLatin(runes[i]) && i > 0 && isLatin(runes[i-1]) {
+				continue
+			}
+			score := math.Log(e.freq) - logTotal + route[j].score
+			if score > best.score {
+				best = step{score: score, end: j, kind: e.kind}
+			}
+		}
+		route[i] = best
+	}
+
+	tokens := make([]Token, 0, n)
+	for i := 0; i < n; {
+		next := route[i]
+		start, end := offsets[i], offsets[next.end]
+		tokens = append(tokens, Token{Text: text[start:end], Start: start, End: end, Kind: next.kind})
+		i = next.end
+	}
+	return tokens
+}
+
+// Analyze segments text and groups the words after each movement verb into a place span.
+// Leading modifiers ("那個很有名的") are skipped; an activity, conjunction, transport
+// word or punctuation ends the span.
+func (s *Segmenter) Analyze(text string) *Analysis {
+	analysis := &Analysis{Tokens: s.Segment(text)}
+
+	collecting := false
+	var span []Token
+	flush := func() {
+		for len(span) > 0 && span[len(span)-1].Kind == KindSpace {
+			span = span[:len(span)-1]
+		}
+		if len(span) > 0 {
+			start, end := span[0].Start, span[len(span)-1].End
+			analysis.Places = append(analysis.Places, Span{
+				Text:  text[start:end],
+				Start: start,
+				End:   end,
+				Known: len(span) == 1 && span[0].Kind == KindPlace,
+			})
+		}
+		span = nil
+	}
+
+	for _, token := range analysis.Tokens {
+		switch token.Kind {
+		case KindMoveVerb:
+			flush()
+			analysis.Verbs = append(analysis.Verbs, token)
+			collecting = true
+		case KindPlace:
+			if collecting {
+				span = append(span, token)
+				continue
+			}
+			// A place named without a verb ("嘉義市好玩嗎") still counts
+			span = []Token{token}
+			flush()
+		case KindAction:
+			flush()
+			analysis.Actions = append(analysis.Actions, token)
+			collecting = false
+		case KindTransport:
+			flush()
+			analysis.Transport = append(analysis.Transport, token)
+			collecting = false
+		case KindModifier, KindFunction:
+			if token.Kind == KindModifier {
+				analysis.Modifiers = append(analysis.Modifiers, token)
+			}
+			if collecting && len(span) > 0 {
+				flush()
+				collecting = false
+			}
+		case KindConjunction, KindParticle, KindPunct:
+			flush()
+			collecting = false
+		case KindSpace:
+			if collecting && len(span) > 0 {
+				span = append(span, token)
+			}
+		default:
+			if collecting {
+				span = append(span, token)
+			}
+		}
+	}
+	flush()
+
+	return analysis
+}
+
+// fold lowercases ASCII and folds 臺 to 台 without changing byte offsets
+func fold(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	for _, r := range text {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			b.WriteRune(r + ('a' - 'A'))
+		case r == '臺':
+			b.WriteRune('台')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func isLatin(r rune) bool {
+	return r < unicode.MaxASCII && unicode.IsLetter(r)
+}
+
+func isNumber(runes []rune) bool {
+	for _, r := range runes {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+package segment
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testSegmenter() *Segmenter {
+	dict := BaseDictionary()
+	for _, place := range []string{"嘉義市", "阿里山", "台北", "台北車站", "日月潭", "Sun Moon Lake"} {
+		dict.Add(place, KindPlace, 0)
+	}
+	return New(dict)
+}
+
+func placeTexts(analysis *Analysis) []string {
+	var texts []string
+	for _, place := range analysis.Places {
+		texts = append(texts, place.Text)
+	}
+	return texts
+}
+
+func TestAnalyzePlaceSpans(t *testing.T) {
+	s := testSegmenter()
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"我想去那個很有名的鼎泰豐", []string{"鼎泰豐"}},
+		{"去嘉義市吃雞肉飯後去阿里山", []string{"嘉義市", "阿里山"}},
+		{"帶我去台北車站好嗎", []string{"台北車站"}},
+		{"騎車去日月潭看夜景", []string{"日月潭"}},
+		{"前往台北市政府", []string{"台北市政府"}},
+		{"臺北好玩嗎", []string{"臺北"}},
+		{"go to Sun Moon Lake then eat", []string{"Sun Moon Lake"}},
+		{"take me to Din Tai Fung please", []string{"Din Tai Fung"}},
+		{"收集寶物", nil},
+	}
+
+	for _, tt := range tests {
+		got := placeTexts(s.Analyze(tt.text))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Analyze(%q) places = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeOffsetsAndRoles(t *testing.T) {
+	text := "去嘉義市吃雞肉飯後去阿里山"
+	analysis := testSegmenter().Analyze(text)
+
+	for _, place := range analysis.Places {
+		if text[place.Start:place.End] != place.Text {
+			t.Errorf("span %q has offsets [%d,%d) = %q", place.Text, place.Start, place.End, text[place.Start:place.End])
+		}
+		if !place.Known {
+			t.Errorf("span %q should be a known place", place.Text)
+		}
+	}
+	if len(analysis.Verbs) != 2 {
+		t.Errorf("expected 2 movement verbs, got %+v", analysis.Verbs)
+	}
+	if len(analysis.Actions) != 1 || analysis.Actions[0].Text != "吃" {
+		t.Errorf("expected action 吃, got %+v", analysis.Actions)
+	}
+
+	// Tokens cover the input without gaps
+	var rebuilt strings.Builder
+	for _, token := range analysis.Tokens {
+		rebuilt.WriteString(token.Text)
+	}
+	if rebuilt.String() != text {
+		t.Errorf("tokens rebuild %q, want %q", rebuilt.String(), text)
+	}
+}
+
+func TestLatinWordsRespectBoundaries(t *testing.T) {
+	dict := NewDictionary()
+	dict.Add("go to", KindMoveVerb, 0)
+	dict.Add("tai", KindPlace, 0)
+
+	tokens := New(dict).Segment("go to Taichung")
+	for _, token := range tokens {
+		if token.Kind == KindPlace {
+			t.Errorf("matched %q inside a longer word", token.Text)
+		}
+	}
+}
+
+func TestLoadUserDictionary(t *testing.T) {
+	dict := NewDictionary()
+	input := "# shops\n鼎泰豐 8000\n阿宗麵線\n逛夜市 action\n"
+	if err := dict.LoadUserDictionary(strings.NewReader(input)); err != nil {
+		t.Fatalf("LoadUserDictionary: %v", err)
+	}
+	if dict.Len() != 3 {
+		t.Fatalf("expected 3 words, got %d", dict.Len())
+	}
+	if e := dict.words["鼎泰豐"]; e.kind != KindPlace || e.freq != 8000 {
+		t.Errorf("鼎泰豐 = %+v", e)
+	}
+	if e := dict.words["逛夜市"]; e.kind != KindAction {
+		t.Errorf("逛夜市 = %+v", e)
+	}
+
+	if err := dict.LoadUserDictionary(strings.NewReader("鼎泰豐 shop\n")); err == nil {
+		t.Error("expected an error for an unknown kind")
+	}
+}
+
+func BenchmarkSegment(b *testing.B) {
+	s := testSegmenter()
+	text := "我想去那個很有名的鼎泰豐，然後去嘉義市吃雞肉飯後騎車去阿里山看日出"
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s.Segment(text)
+	}
+}
+
+func BenchmarkAnalyze(b *testing.B) {
+	s := testSegmenter()
+	text := "我想去那個很有名的鼎泰豐，然後去嘉義市吃雞肉飯後騎車去阿里山看日出"
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s.Analyze(text)
+	}
+}
@@ -0,0 +1,138 @@
+package tiles
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Cache keeps rendered tiles on disk under dir/<layer>/<generation>/z/x/y.mvt.
+// Invalidating a layer moves it to a new generation and deletes the old one, so
+// a tile rendered before a write can never be stored after it.
+type Cache struct {
+	dir string
+
+	mu          sync.Mutex
+	generations map[string]int64
+}
+
+// NewCache uses dir, creating it when needed. Tiles left by an earlier run are
+// deleted in the background, since writes may have happened while it was down.
+func NewCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("tile cache: %v", err)
+	}
+
+	cache := &Cache{dir: dir, generations: make(map[string]int64)}
+	start := time.Now().UnixNano()
+	for _, layer := range LayerNames() {
+		cache.generations[layer] = start
+	}
+	go cache.removeStale()
+	return cache, nil
+}
+
+// Generation is the layer's current generation; pass it to Put
+func (c *Cache) Generation(layer string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generations[layer]
+}
+
+// Get returns a cached tile, or nil
+func (c *Cache) Get(layer string, t Tile) []byte {
+	data, err := os.ReadFile(c.path(layer, c.Generation(layer), t))
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// Put stores a tile rendered during generation; it is dropped when the layer
+// has been invalidated since
+func (c *Cache) Put(layer string, generation int64, t Tile, data []byte) error {
+	if generation != c.Generation(layer) {
+		return nil
+	}
+
+	path := c.path(layer, generation, t)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// Write then rename, so readers never see half a tile
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tile-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// Invalidate drops every cached tile of layer after its table was written. It
+// does nothing on a nil cache, so writers need not check whether caching is on.
+func (c *Cache) Invalidate(layer string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	old, ok := c.generations[layer]
+	if ok {
+		c.generations[layer] = max(old+1, time.Now().UnixNano())
+	}
+	c.mu.Unlock()
+
+	if ok {
+		go c.remove(filepath.Join(c.dir, layer, strconv.FormatInt(old, 10)))
+	}
+}
+
+// RemoveLayer deletes a layer's tiles from a cache directory. It is for other
+// processes, such as the import command, that write the tables a server caches.
+func RemoveLayer(dir, layer string) error {
+	return os.RemoveAll(filepath.Join(dir, layer))
+}
+
+func (c *Cache) path(layer string, generation int64, t Tile) string {
+	return filepath.Join(c.dir, layer, strconv.FormatInt(generation, 10),
+		strconv.Itoa(t.Z), strconv.Itoa(t.X), strconv.Itoa(t.Y)+".mvt")
+}
+
+// removeStale deletes every generation directory but the current ones
+func (c *Cache) removeStale() {
+	for _, layer := range LayerNames() {
+		entries, err := os.ReadDir(filepath.Join(c.dir, layer))
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				log.Printf("Failed to read tile cache: %v", err)
+			}
+			continue
+		}
+		current := strconv.FormatInt(c.Generation(layer), 10)
+		for _, entry := range entries {
+			if entry.Name() != current {
+				c.remove(filepath.Join(c.dir, layer, entry.Name()))
+			}
+		}
+	}
+}
+
+func (c *Cache) remove(path string) {
+	if err := os.RemoveAll(path); err != nil {
+		log.Printf("Failed to remove stale tiles in %s: %v", path, err)
+	}
+}
@@ -0,0 +1,120 @@
+package tiles
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Layer names
+const (
+	LayerLocations       = "locations"
+	LayerHistoricalSites = "historical_sites"
+	LayerItems           = "items"
+)
+
+// maxFeaturesPerTile keeps a low-zoom tile over a dense area small; rows beyond it are dropped
+const maxFeaturesPerTile = 5000
+
+// Layer is a table of points served as one MVT layer
+type Layer struct {
+	Name    string
+	Table   string
+	Where   string // extra filter, e.g. only active rows
+	MinZoom int    // empty tiles are served below this zoom
+	// Attributes lists the columns added at each zoom, on top of id; a column
+	// appears in every tile from its zoom onwards
+	Attributes []Attribute
+}
+
+// Attribute is a column included from MinZoom
+type Attribute struct {
+	Column  string // SQL expression selected from Table
+	Name    string // property name in the tile
+	MinZoom int
+}
+
+var layers = map[string]*Layer{
+	LayerLocations: {
+		Name:    LayerLocations,
+		Table:   "locations",
+		MinZoom: 8,
+		Attributes: []Attribute{
+			{Column: "type", Name: "type", MinZoom: 8},
+			{Column: "name", Name: "name", MinZoom: 12},
+			{Column: "address", Name: "address", MinZoom: 15},
+		},
+	},
+	LayerHistoricalSites: {
+		Name:    LayerHistoricalSites,
+		Table:   "historical_sites",
+		Where:   "is_active = true",
+		MinZoom: 5,
+		Attributes: []Attribute{
+			{Column: "name", Name: "name", MinZoom: 9},
+			{Column: "era", Name: "era", MinZoom: 9},
+			{Column: "address", Name: "address", MinZoom: 14},
+		},
+	},
+	LayerItems: {
+		Name:    LayerItems,
+		Table:   "items",
+		Where:   "is_collected = false",
+		MinZoom: 12,
+		Attributes: []Attribute{
+			{Column: "item_type", Name: "itemType", MinZoom: 12},
+			{Column: "rarity", Name: "rarity", MinZoom: 12},
+			{Column: "name", Name: "name", MinZoom: 15},
+			{Column: "value", Name: "value", MinZoom: 15},
+		},
+	},
+}
+
+// LayerNames lists the served layers
+func LayerNames() []string {
+	return []string{LayerLocations, LayerHistoricalSites, LayerItems}
+}
+
+// LookupLayer returns the layer called name
+func LookupLayer(name string) (*Layer, bool) {
+	layer, ok := layers[name]
+	return layer, ok
+}
+
+// attributes returns the columns a tile at zoom carries
+func (l *Layer) attributes(zoom int) []Attribute {
+	var attributes []Attribute
+	for _, attribute := range l.Attributes {
+		if zoom >= attribute.MinZoom {
+			attributes = append(attributes, attribute)
+		}
+	}
+	return attributes
+}
+
+// query builds the ST_AsMVT statement for a tile. Rows are picked by the
+// buffered tile bounds through the index on the geometry of the generated
+// geog column (see geo.BoundsCondition), then clipped by PostGIS.
+func (l *Layer) query(t Tile) (string, []interface{}) {
+	columns := []string{"id"}
+	for _, attribute := range l.attributes(t.Z) {
+		columns = append(columns, fmt.Sprintf(`%s AS "%s"`, attribute.Column, attribute.Name))
+	}
+
+	where := "geog::geometry && ST_MakeEnvelope(?, ?, ?, ?, 4326)"
+	if l.Where != "" {
+		where = l.Where + " AND " + where
+	}
+
+	sql := fmt.Sprintf(`SELECT ST_AsMVT(tile, '%s', %d, 'geom') FROM (
+	SELECT %s, ST_AsMVTGeom(
+		ST_Transform(ST_SetSRID(ST_MakePoint(longitude, latitude), 4326), 3857),
+		ST_TileEnvelope(?, ?, ?), %d, %d, true) AS geom
+	FROM %s
+	WHERE %s
+	ORDER BY id
+	LIMIT %d
+) AS tile`, l.Name, Extent, strings.Join(columns, ", "), Extent, Buffer, l.Table, where, maxFeaturesPerTile)
+
+	west, south, east, north := t.Bounds()
+	return sql, []interface{}{t.Z, t.X, t.Y, west, south, east, north}
+}
@@ -0,0 +1,79 @@
+package tiles
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// ErrUnknownLayer is returned for a layer name that is not served
+var ErrUnknownLayer = errors.New("unknown tile layer")
+
+// Result is a rendered tile; Data is empty when the tile has no features
+type Result struct {
+	Data   []byte
+	ETag   string
+	Cached bool
+}
+
+// renderer produces the MVT bytes of one tile
+type renderer func(layer *Layer, t Tile) ([]byte, error)
+
+// Service renders tiles, from the cache when it can
+type Service struct {
+	render renderer
+	cache  *Cache // nil when caching is off
+}
+
+// NewService renders tiles with PostGIS on db, keeping them in cache when it is not nil
+func NewService(db *gorm.DB, cache *Cache) *Service {
+	return &Service{render: func(layer *Layer, t Tile) ([]byte, error) {
+		sql, args := layer.query(t)
+		var data []byte
+		if err := db.Raw(sql, args...).Row().Scan(&data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}, cache: cache}
+}
+
+// Tile returns the tile of the named layer
+func (s *Service) Tile(name string, t Tile) (*Result, error) {
+	layer, ok := LookupLayer(name)
+	if !ok {
+		return nil, ErrUnknownLayer
+	}
+	if t.Z < layer.MinZoom {
+		return &Result{ETag: etag(nil)}, nil
+	}
+
+	cache := s.cache
+	var generation int64
+	if cache != nil {
+		if data := cache.Get(name, t); data != nil {
+			return &Result{Data: data, ETag: etag(data), Cached: true}, nil
+		}
+		generation = cache.Generation(name)
+	}
+
+	data, err := s.render(layer, t)
+	if err != nil {
+		return nil, err
+	}
+	if cache != nil {
+		if err := cache.Put(name, generation, t, data); err != nil {
+			log.Printf("Failed to cache tile %s/%s: %v", name, t, err)
+		}
+	}
+	return &Result{Data: data, ETag: etag(data)}, nil
+}
+
+// etag is a strong validator over the tile bytes, so it survives cache invalidation
+// when the data did not change
+func etag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:12]) + `"`
+}
@@ -0,0 +1,79 @@
+// Package tiles serves locations, historical sites and active game items as
+// Mapbox Vector Tiles. Tiles are rendered by PostGIS (ST_AsMVT) and kept in an
+// on-disk cache that is invalidated whenever a layer's table is written.
+package tiles
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MaxZoom is the deepest zoom served; points need no more detail than this
+const MaxZoom = 20
+
+// Extent and Buffer are the MVT tile coordinate space and the margin, in the
+// same units, rendered around it so that symbols at tile edges are not clipped
+const (
+	Extent = 4096
+	Buffer = 64
+)
+
+// Tile addresses one XYZ (Web Mercator, "slippy map") tile
+type Tile struct {
+	Z, X, Y int
+}
+
+// ParseTile reads z, x and y path segments; y may carry a ".mvt" or ".pbf" suffix
+func ParseTile(z, x, y string) (Tile, error) {
+	y = strings.TrimSuffix(strings.TrimSuffix(y, ".mvt"), ".pbf")
+
+	var t Tile
+	var errZ, errX, errY error
+	t.Z, errZ = strconv.Atoi(z)
+	t.X, errX = strconv.Atoi(x)
+	t.Y, errY = strconv.Atoi(y)
+	if errZ != nil || errX != nil || errY != nil {
+		return Tile{}, fmt.Errorf("invalid tile %s/%s/%s", z, x, y)
+	}
+	if t.Z < 0 || t.Z > MaxZoom {
+		return Tile{}, fmt.Errorf("zoom must be between 0 and %d", MaxZoom)
+	}
+	if n := 1 << t.Z; t.X < 0 || t.X >= n || t.Y < 0 || t.Y >= n {
+		return Tile{}, fmt.Errorf("tile %d/%d/%d does not exist", t.Z, t.X, t.Y)
+	}
+	return t, nil
+}
+
+func (t Tile) String() string {
+	return fmt.Sprintf("%d/%d/%d", t.Z, t.X, t.Y)
+}
+
+// Bounds is the tile's WGS84 bounding box grown by the render buffer, used to
+// pick candidate rows by latitude and longitude
+func (t Tile) Bounds() (west, south, east, north float64) {
+	n := float64(int(1) << t.Z)
+	margin := float64(Buffer) / Extent
+
+	west = tileLongitude(float64(t.X)-margin, n)
+	east = tileLongitude(float64(t.X+1)+margin, n)
+	north = tileLatitude(float64(t.Y)-margin, n)
+	south = tileLatitude(float64(t.Y+1)+margin, n)
+	return west, south, east, north
+}
+
+func tileLongitude(x, n float64) float64 {
+	return math.Max(-180, math.Min(180, x/n*360-180))
+}
+
+func tileLatitude(y, n float64) float64 {
+	// Beyond the Mercator limits the buffer covers the pole
+	if y <= 0 {
+		return 90
+	}
+	if y >= n {
+		return -90
+	}
+	return math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180 / math.Pi
+}
@@ -0,0 +1,127 @@
+package tiles
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseTile(t *testing.T) {
+	tile, err := ParseTile("14", "13724", "7014.mvt")
+	if err != nil || tile != (Tile{Z: 14, X: 13724, Y: 7014}) {
+		t.Errorf("ParseTile() = %v, %v", tile, err)
+	}
+
+	for _, bad := range [][3]string{
+		{"a", "0", "0"},
+		{"21", "0", "0"},
+		{"2", "4", "0"},
+		{"2", "0", "-1"},
+	} {
+		if _, err := ParseTile(bad[0], bad[1], bad[2]); err == nil {
+			t.Errorf("ParseTile(%v) should fail", bad)
+		}
+	}
+}
+
+func TestTileBounds(t *testing.T) {
+	// Taipei 101 (25.0340, 121.5645) lies in 14/13724/7014
+	west, south, east, north := Tile{Z: 14, X: 13724, Y: 7014}.Bounds()
+	if !(west < 121.5645 && 121.5645 < east && south < 25.0340 && 25.0340 < north) {
+		t.Errorf("bounds = %v %v %v %v", west, south, east, north)
+	}
+	// One tile is 360/2^14 degrees wide, plus the buffer on both sides
+	want := 360.0 / (1 << 14) * (1 + 2.0*Buffer/Extent)
+	if math.Abs((east-west)-want) > 1e-9 {
+		t.Errorf("width = %v, want %v", east-west, want)
+	}
+
+	west, south, east, north = Tile{}.Bounds()
+	if west != -180 || east != 180 || south != -90 || north != 90 {
+		t.Errorf("world bounds = %v %v %v %v", west, south, east, north)
+	}
+}
+
+func TestLayerAttributesByZoom(t *testing.T) {
+	layer, _ := LookupLayer(LayerItems)
+
+	low, _ := layer.query(Tile{Z: 12})
+	if !strings.Contains(low, `item_type AS "itemType"`) || strings.Contains(low, `AS "name"`) {
+		t.Errorf("zoom 12 query = %s", low)
+	}
+	high, args := layer.query(Tile{Z: 16, X: 54898, Y: 28058})
+	if !strings.Contains(high, `name AS "name"`) || !strings.Contains(high, "is_collected = false AND") {
+		t.Errorf("zoom 16 query = %s", high)
+	}
+	if len(args) != 7 || args[0] != 16 || args[1] != 54898 {
+		t.Errorf("args = %v", args)
+	}
+}
+
+func TestServiceUsesCache(t *testing.T) {
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renders := 0
+	service := &Service{render: func(layer *Layer, tile Tile) ([]byte, error) {
+		renders++
+		return []byte{0x1a, byte(renders)}, nil
+	}, cache: cache}
+	tile := Tile{Z: 14, X: 13724, Y: 7014}
+
+	first, err := service.Tile(LayerLocations, tile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := service.Tile(LayerLocations, tile)
+	if renders != 1 || !second.Cached || second.ETag != first.ETag {
+		t.Errorf("renders = %d, second = %+v, first = %+v", renders, second, first)
+	}
+
+	// A write elsewhere leaves this layer cached; a write to it re-renders
+	cache.Invalidate(LayerItems)
+	service.Tile(LayerLocations, tile)
+	cache.Invalidate(LayerLocations)
+	third, _ := service.Tile(LayerLocations, tile)
+	if renders != 2 || third.Cached || third.ETag == first.ETag {
+		t.Errorf("renders = %d, third = %+v", renders, third)
+	}
+
+	// Below the layer's minimum zoom nothing is rendered
+	empty, _ := service.Tile(LayerItems, Tile{Z: 5})
+	if renders != 2 || len(empty.Data) != 0 {
+		t.Errorf("low zoom tile = %+v", empty)
+	}
+	if _, err := service.Tile("roads", tile); err != ErrUnknownLayer {
+		t.Errorf("unknown layer error = %v", err)
+	}
+
+	// Writers invalidate whether or not caching is on
+	var off *Cache
+	off.Invalidate(LayerItems)
+}
+
+func TestCacheDropsStalePut(t *testing.T) {
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tile := Tile{Z: 10, X: 857, Y: 438}
+
+	// A tile rendered before a write finishes after it
+	generation := cache.Generation(LayerHistoricalSites)
+	cache.Invalidate(LayerHistoricalSites)
+	if err := cache.Put(LayerHistoricalSites, generation, tile, []byte("old")); err != nil {
+		t.Fatal(err)
+	}
+	if data := cache.Get(LayerHistoricalSites, tile); data != nil {
+		t.Errorf("stale tile was cached: %q", data)
+	}
+
+	cache.Put(LayerHistoricalSites, cache.Generation(LayerHistoricalSites), tile, []byte("new"))
+	if data := cache.Get(LayerHistoricalSites, tile); string(data) != "new" {
+		t.Errorf("Get() = %q", data)
+	}
+}
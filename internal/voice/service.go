@@ -4,9 +4,13 @@ import (
 	"encoding/base64"
 	"fmt"
 	"strings"
+
+	"intelligent-spatial-platform/internal/geo"
+	"intelligent-spatial-platform/internal/segment"
 )
 
 type Service struct {
+	segmenter *segment.Segmenter
 }
 
 type VoiceCommand struct {
@@ -16,8 +20,8 @@ type VoiceCommand struct {
 	Text       string                 `json:"text"`       // original text
 }
 
-func NewService() *Service {
-	return &Service{}
+func NewService(resources geo.Resources) *Service {
+	return &Service{segmenter: resources.WithDefaults().Segmenter}
 }
 
 func (s *Service) ProcessAudio(audioData, language string) (string, error) {
@@ -123,12 +127,27 @@ func (s *Service) extractNavigationAction(text string) string {
 func (s *Service) extractNavigationParameters(text string) map[string]interface{} {
 	params := make(map[string]interface{})
 
-	params["destination"] = s.extractDestination(text)
+	analysis := s.segmenter.Analyze(text)
+	params["destination"] = s.extractDestination(text, analysis)
+	if len(analysis.Places) > 0 {
+		params["places"] = analysis.Places
+	}
+	if len(analysis.Actions) > 0 {
+		params["actions"] = analysis.Actions
+	}
+	if len(analysis.Modifiers) > 0 {
+		params["modifiers"] = analysis.Modifiers
+	}
 
 	return params
 }
 
-func (s *Service) extractDestination(text string) string {
+// extractDestination returns the first place span, falling back to the text after a movement verb
+func (s *Service) extractDestination(text string, analysis *segment.Analysis) string {
+	if len(analysis.Places) > 0 {
+		return analysis.Places[0].Text
+	}
+
 	patterns := []string{"前往", "去", "帶我去", "go to", "navigate to"}
 
 	for _, pattern := range patterns {